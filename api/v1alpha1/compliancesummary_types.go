@@ -0,0 +1,66 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicyOffender is a noncompliant policy and the number of its policy templates that are noncompliant.
+type PolicyOffender struct {
+	Name                  string `json:"name"`
+	NonCompliantTemplates int    `json:"nonCompliantTemplates"`
+}
+
+// ComplianceSummaryStatus defines the observed compliance of the policies in the cluster namespace
+type ComplianceSummaryStatus struct {
+	// Total is the number of policies in the cluster namespace.
+	Total int `json:"total"`
+	// Compliant is the number of compliant policies.
+	Compliant int `json:"compliant"`
+	// NonCompliant is the number of noncompliant policies.
+	NonCompliant int `json:"noncompliant"`
+	// Pending is the number of policies without a compliance state yet.
+	Pending int `json:"pending"`
+	// Score is the compliance score of the cluster from 0 to 100. It is the percentage of the policies with a
+	// compliance state that are compliant, weighted by the severity and categories of the policies.
+	Score int `json:"score"`
+	// TopOffenders are the noncompliant policies with the most noncompliant policy templates.
+	TopOffenders []PolicyOffender `json:"topOffenders,omitempty"`
+	// LastUpdated is the time the summary was last computed.
+	LastUpdated metav1.Time `json:"lastUpdated,omitempty"`
+	// Conditions report the health of the addon, such as the HubSyncDegraded condition when the policy statuses fail
+	// to be synced to the Hub.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=compliancesummaries,scope=Namespaced
+//+kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.total"
+//+kubebuilder:printcolumn:name="Compliant",type="integer",JSONPath=".status.compliant"
+//+kubebuilder:printcolumn:name="NonCompliant",type="integer",JSONPath=".status.noncompliant"
+//+kubebuilder:printcolumn:name="Pending",type="integer",JSONPath=".status.pending"
+//+kubebuilder:printcolumn:name="Score",type="integer",JSONPath=".status.score"
+
+// ComplianceSummary is the Schema for the compliancesummaries API. It summarizes the compliance of the policies in the
+// cluster namespace on the managed cluster.
+type ComplianceSummary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status ComplianceSummaryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ComplianceSummaryList contains a list of ComplianceSummary
+type ComplianceSummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ComplianceSummary `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ComplianceSummary{}, &ComplianceSummaryList{})
+}
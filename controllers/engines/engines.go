@@ -0,0 +1,114 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package engines defines the adapters between the policy framework and the policy engines which evaluate the policy
+// templates on the managed cluster, such as Gatekeeper. The engine-specific sync logic lives in the adapter of each
+// engine, in its own package, so that supporting another engine doesn't require changes to the sync controllers.
+package engines
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var log = ctrl.Log.WithName("engines")
+
+// EngineStatus is the status of a template object as reported by its policy engine.
+type EngineStatus struct {
+	// Enforced is true when the policy engine enforces the template object, so that its compliance can be asserted.
+	Enforced bool
+	// Message describes the status for the logs, such as why the template object isn't enforced.
+	Message string
+}
+
+// PolicyEngineAdapter adapts the sync of the policy templates to a policy engine.
+type PolicyEngineAdapter interface {
+	// Name is the name of the policy engine, such as gatekeeper.
+	Name() string
+	// Handles returns true if the template objects of the input kind are evaluated by the policy engine.
+	Handles(gk schema.GroupKind) bool
+	// Discover returns true if the policy engine is installed on the managed cluster.
+	Discover(ctx context.Context, client discovery.DiscoveryInterface) (bool, error)
+	// Translate converts the input template object in place to the object applied on the managed cluster.
+	Translate(obj *unstructured.Unstructured) error
+	// CollectStatus returns the status of the input template object from the policy engine.
+	CollectStatus(obj *unstructured.Unstructured) EngineStatus
+	// Cleanup removes what the policy engine keeps for the input template object after it is deleted.
+	Cleanup(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured) error
+}
+
+// Registry holds the policy engine adapters of the addon.
+type Registry struct {
+	adapters []PolicyEngineAdapter
+}
+
+// NewRegistry returns a Registry of the input policy engine adapters. When several adapters handle the same kind, the
+// first one is used.
+func NewRegistry(adapters ...PolicyEngineAdapter) *Registry {
+	return &Registry{adapters: adapters}
+}
+
+// For returns the policy engine adapter handling the input kind, or nil if there is none. A nil Registry has no
+// adapters.
+func (r *Registry) For(gk schema.GroupKind) PolicyEngineAdapter {
+	if r == nil {
+		return nil
+	}
+
+	for _, adapter := range r.adapters {
+		if adapter.Handles(gk) {
+			return adapter
+		}
+	}
+
+	return nil
+}
+
+// Discover returns the names of the policy engines installed on the managed cluster. An engine whose discovery fails
+// is logged and skipped.
+func (r *Registry) Discover(ctx context.Context, client discovery.DiscoveryInterface) []string {
+	if r == nil {
+		return nil
+	}
+
+	installed := []string{}
+
+	for _, adapter := range r.adapters {
+		found, err := adapter.Discover(ctx, client)
+		if err != nil {
+			log.Error(err, "Failed to discover the policy engine", "engine", adapter.Name())
+
+			continue
+		}
+
+		if found {
+			installed = append(installed, adapter.Name())
+		}
+	}
+
+	return installed
+}
+
+// Translate converts the input template object with the adapter of its kind, if any.
+func (r *Registry) Translate(obj *unstructured.Unstructured) error {
+	adapter := r.For(obj.GroupVersionKind().GroupKind())
+	if adapter == nil {
+		return nil
+	}
+
+	return adapter.Translate(obj)
+}
+
+// Cleanup runs the cleanup of the adapter of the kind of the input deleted template object, if any.
+func (r *Registry) Cleanup(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured) error {
+	adapter := r.For(obj.GroupVersionKind().GroupKind())
+	if adapter == nil {
+		return nil
+	}
+
+	return adapter.Cleanup(ctx, client, obj)
+}
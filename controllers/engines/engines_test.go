@@ -0,0 +1,81 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package engines
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// fakeAdapter is a policy engine adapter of the kinds of a group which labels the objects it translates.
+type fakeAdapter struct {
+	name      string
+	group     string
+	installed bool
+	err       error
+	cleaned   []string
+}
+
+func (a *fakeAdapter) Name() string { return a.name }
+
+func (a *fakeAdapter) Handles(gk schema.GroupKind) bool { return gk.Group == a.group }
+
+func (a *fakeAdapter) Discover(_ context.Context, _ discovery.DiscoveryInterface) (bool, error) {
+	return a.installed, a.err
+}
+
+func (a *fakeAdapter) Translate(obj *unstructured.Unstructured) error {
+	obj.SetLabels(map[string]string{"engine": a.name})
+
+	return nil
+}
+
+func (a *fakeAdapter) CollectStatus(_ *unstructured.Unstructured) EngineStatus {
+	return EngineStatus{Enforced: true}
+}
+
+func (a *fakeAdapter) Cleanup(_ context.Context, _ dynamic.Interface, obj *unstructured.Unstructured) error {
+	a.cleaned = append(a.cleaned, obj.GetName())
+
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	RegisterTestingT(t)
+
+	kyverno := &fakeAdapter{name: "kyverno", group: "kyverno.io", installed: true}
+	falco := &fakeAdapter{name: "falco", group: "falco.org", err: errors.New("discovery failed")}
+	shadowed := &fakeAdapter{name: "shadowed", group: "kyverno.io", installed: true}
+	registry := NewRegistry(kyverno, falco, shadowed)
+
+	Expect(registry.For(schema.GroupKind{Group: "kyverno.io", Kind: "ClusterPolicy"})).To(BeIdenticalTo(kyverno))
+	Expect(registry.For(schema.GroupKind{Group: "policy.open-cluster-management.io", Kind: "ConfigurationPolicy"})).
+		To(BeNil())
+	Expect(registry.Discover(context.TODO(), nil)).To(Equal([]string{"kyverno", "shadowed"}))
+
+	clusterPolicy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kyverno.io/v1", "kind": "ClusterPolicy", "metadata": map[string]interface{}{"name": "require"},
+	}}
+	Expect(registry.Translate(clusterPolicy)).To(Succeed())
+	Expect(clusterPolicy.GetLabels()).To(HaveKeyWithValue("engine", "kyverno"))
+	Expect(registry.Cleanup(context.TODO(), nil, clusterPolicy)).To(Succeed())
+	Expect(kyverno.cleaned).To(Equal([]string{"require"}))
+
+	// The objects of other kinds are left as is
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+	Expect(registry.Translate(configMap)).To(Succeed())
+	Expect(configMap.GetLabels()).To(BeNil())
+
+	// A nil registry has no adapters
+	var nilRegistry *Registry
+	Expect(nilRegistry.For(schema.GroupKind{Group: "kyverno.io", Kind: "ClusterPolicy"})).To(BeNil())
+	Expect(nilRegistry.Translate(clusterPolicy)).To(Succeed())
+	Expect(nilRegistry.Discover(context.TODO(), nil)).To(BeEmpty())
+}
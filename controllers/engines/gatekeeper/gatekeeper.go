@@ -0,0 +1,98 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package gatekeeper is the policy engine adapter of Gatekeeper constraints.
+package gatekeeper
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines"
+)
+
+const (
+	// ConstraintGroup is the API group of the Gatekeeper constraints.
+	ConstraintGroup = "constraints.gatekeeper.sh"
+	// templateGroup is the API group of the Gatekeeper constraint templates, which is served when Gatekeeper is
+	// installed.
+	templateGroup = "templates.gatekeeper.sh"
+)
+
+// blank assignment to verify that Adapter implements engines.PolicyEngineAdapter
+var _ engines.PolicyEngineAdapter = &Adapter{}
+
+// Adapter is the policy engine adapter of the Gatekeeper constraints.
+type Adapter struct{}
+
+// Name returns gatekeeper.
+func (a *Adapter) Name() string {
+	return "gatekeeper"
+}
+
+// Handles returns true for the Gatekeeper constraints.
+func (a *Adapter) Handles(gk schema.GroupKind) bool {
+	return gk.Group == ConstraintGroup
+}
+
+// Discover returns true if the Gatekeeper constraint templates are served on the managed cluster.
+func (a *Adapter) Discover(_ context.Context, client discovery.DiscoveryInterface) (bool, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return false, err
+	}
+
+	for _, group := range groups.Groups {
+		if group.Name == templateGroup {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Translate leaves the constraint as is since Gatekeeper evaluates the constraints as they are in the policy
+// templates.
+func (a *Adapter) Translate(_ *unstructured.Unstructured) error {
+	return nil
+}
+
+// CollectStatus returns whether all the Gatekeeper pods report that they enforce the constraint, so a constraint
+// without a status yet is not enforced. The audit results of a constraint that isn't enforced by all the pods are
+// incomplete.
+func (a *Adapter) CollectStatus(obj *unstructured.Unstructured) engines.EngineStatus {
+	byPod, _, _ := unstructured.NestedSlice(obj.Object, "status", "byPod")
+	if len(byPod) == 0 {
+		return engines.EngineStatus{Message: "no Gatekeeper pod reported the status of the constraint"}
+	}
+
+	enforcedBy := 0
+
+	for _, podStatus := range byPod {
+		podStatusMap, ok := podStatus.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if enforced, _, _ := unstructured.NestedBool(podStatusMap, "enforced"); enforced {
+			enforcedBy++
+		}
+	}
+
+	if enforcedBy != len(byPod) {
+		return engines.EngineStatus{
+			Message: fmt.Sprintf("%d of %d Gatekeeper pods enforce the constraint", enforcedBy, len(byPod)),
+		}
+	}
+
+	return engines.EngineStatus{Enforced: true}
+}
+
+// Cleanup does nothing since Gatekeeper removes the audit results of a constraint along with it.
+func (a *Adapter) Cleanup(_ context.Context, _ dynamic.Interface, _ *unstructured.Unstructured) error {
+	return nil
+}
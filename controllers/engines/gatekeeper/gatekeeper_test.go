@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package gatekeeper
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines"
+)
+
+func TestHandles(t *testing.T) {
+	RegisterTestingT(t)
+
+	adapter := &Adapter{}
+	Expect(adapter.Handles(schema.GroupKind{Group: ConstraintGroup, Kind: "K8sRequiredLabels"})).To(BeTrue())
+	Expect(adapter.Handles(schema.GroupKind{Group: templateGroup, Kind: "ConstraintTemplate"})).To(BeFalse())
+}
+
+func TestDiscover(t *testing.T) {
+	RegisterTestingT(t)
+
+	adapter := &Adapter{}
+	client := fake.NewSimpleClientset()
+
+	installed, err := adapter.Discover(context.TODO(), client.Discovery())
+	Expect(err).ToNot(HaveOccurred())
+	Expect(installed).To(BeFalse())
+
+	client.Resources = []*metav1.APIResourceList{{
+		GroupVersion: templateGroup + "/v1",
+		APIResources: []metav1.APIResource{{Name: "constrainttemplates", Kind: "ConstraintTemplate"}},
+	}}
+
+	installed, err = adapter.Discover(context.TODO(), client.Discovery())
+	Expect(err).ToNot(HaveOccurred())
+	Expect(installed).To(BeTrue())
+}
+
+func TestCollectStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	adapter := &Adapter{}
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": ConstraintGroup + "/v1beta1",
+		"kind":       "K8sRequiredLabels",
+	}}
+	Expect(adapter.CollectStatus(constraint).Enforced).To(BeFalse())
+
+	constraint.Object["status"] = map[string]interface{}{
+		"byPod": []interface{}{
+			map[string]interface{}{"id": "gatekeeper-audit", "enforced": true},
+			map[string]interface{}{"id": "gatekeeper-controller-manager-0", "enforced": false},
+		},
+	}
+	Expect(adapter.CollectStatus(constraint)).To(Equal(
+		engines.EngineStatus{Message: "1 of 2 Gatekeeper pods enforce the constraint"},
+	))
+
+	constraint.Object["status"] = map[string]interface{}{
+		"byPod": []interface{}{
+			map[string]interface{}{"id": "gatekeeper-audit", "enforced": true},
+			map[string]interface{}{"id": "gatekeeper-controller-manager-0", "enforced": true},
+		},
+	}
+	Expect(adapter.CollectStatus(constraint).Enforced).To(BeTrue())
+}
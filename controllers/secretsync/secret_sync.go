@@ -16,6 +16,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
 
 const (
@@ -31,7 +34,7 @@ func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Secret{}).
 		Named(ControllerName).
-		Complete(r)
+		Complete(syncerrors.Reconciler(ControllerName, r))
 }
 
 // blank assignment to verify that ReconcilePolicy implements reconcile.Reconciler
@@ -43,6 +46,8 @@ type SecretReconciler struct {
 	Scheme        *runtime.Scheme
 	// The namespace that the secret should be synced to.
 	TargetNamespace string
+	// NamespaceGuard pauses the reconciles while the cluster namespace is unavailable
+	NamespaceGuard *utils.NamespaceGuard
 }
 
 // WARNING: In production, this should be namespaced to the actual managed cluster namespace.
@@ -52,6 +57,8 @@ type SecretReconciler struct {
 // Reconcile handles updates to the "policy-encryption-key" Secret in the managed cluster namespace on the Hub.
 // The method is responsible for synchronizing the Secret to the managed cluster namespace on the managed cluster.
 func (r *SecretReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	ctx = utils.WithController(ctx, ControllerName)
+
 	reqLogger := log.WithValues(
 		"Request.Namespace", request.Namespace, "Request.Name", request.Name, "TargetNamespace", r.TargetNamespace,
 	)
@@ -63,6 +70,12 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, nil
 	}
 
+	if !r.NamespaceGuard.Available() {
+		reqLogger.Info("The cluster namespace is unavailable, pausing the reconcile")
+
+		return reconcile.Result{RequeueAfter: utils.NamespaceUnavailableRequeue}, nil
+	}
+
 	hubEncryptionSecret := &corev1.Secret{}
 
 	err := r.Get(ctx, request.NamespacedName, hubEncryptionSecret)
@@ -70,7 +83,7 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		if !errors.IsNotFound(err) {
 			log.Error(err, "Failed to get the Secret on the Hub. Requeueing the request.")
 
-			return reconcile.Result{}, err
+			return reconcile.Result{}, syncerrors.FromHub(err)
 		}
 
 		log.Info("The Secret is no longer on the Hub. Deleting the replicated Secret.")
@@ -112,7 +125,7 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 				Name:      request.Name,
 				Namespace: r.TargetNamespace,
 			},
-			Data: hubEncryptionSecret.Data,
+			Data: copySecretData(hubEncryptionSecret.Data),
 		}
 
 		err := r.ManagedClient.Create(ctx, managedEncryptionSecret)
@@ -130,7 +143,7 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 	if !equality.Semantic.DeepEqual(hubEncryptionSecret.Data, managedEncryptionSecret.Data) {
 		log.Info("Updating the replicated secret due to it not matching the source on the Hub")
 
-		managedEncryptionSecret.Data = hubEncryptionSecret.Data
+		managedEncryptionSecret.Data = copySecretData(hubEncryptionSecret.Data)
 
 		err := r.ManagedClient.Update(ctx, managedEncryptionSecret)
 		if err != nil {
@@ -144,3 +157,19 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 
 	return reconcile.Result{}, nil
 }
+
+// copySecretData returns a deep copy of the input Secret data so that the replicated Secret doesn't share the maps
+// and byte slices of the Hub Secret, which may be in the informer cache.
+func copySecretData(data map[string][]byte) map[string][]byte {
+	if data == nil {
+		return nil
+	}
+
+	copied := make(map[string][]byte, len(data))
+
+	for key, value := range data {
+		copied[key] = append([]byte(nil), value...)
+	}
+
+	return copied
+}
@@ -190,3 +190,19 @@ func TestReconcileInvalidSecretName(t *testing.T) {
 	err = managedClient.Get(context.TODO(), request.NamespacedName, managedEncryptionSecret)
 	Expect(errors.IsNotFound(err)).To(BeTrue())
 }
+
+func TestCopySecretData(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	data := getTestSecret().Data
+	copied := copySecretData(data)
+	Expect(copied).To(Equal(data))
+
+	// Modifying the copy doesn't modify the source
+	copied["key"][0]++
+	copied["other"] = []byte("other")
+	Expect(copied["key"]).NotTo(Equal(data["key"]))
+	Expect(data).NotTo(HaveKey("other"))
+
+	Expect(copySecretData(nil)).To(BeNil())
+}
@@ -0,0 +1,49 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"encoding/json"
+
+	"github.com/prometheus/client_golang/prometheus"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	policySizeHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "policy_spec_sync_policy_size_bytes",
+			Help: "The size in bytes of the replicated policies synced from the Hub. Objects larger than ~1.5MiB " +
+				"are rejected by etcd.",
+			// 1KiB to 2MiB
+			Buckets: prometheus.ExponentialBuckets(1024, 2, 12),
+		},
+	)
+	policyTemplateCountHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "policy_spec_sync_policy_templates",
+			Help:    "The number of policy templates in the replicated policies synced from the Hub.",
+			Buckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(policySizeHistogram, policyTemplateCountHistogram)
+}
+
+// observePolicy records the size and policy template count of a replicated policy synced from the Hub.
+func observePolicy(plc *policiesv1.Policy) {
+	policyTemplateCountHistogram.Observe(float64(len(plc.Spec.PolicyTemplates)))
+
+	plcJSON, err := json.Marshal(plc)
+	if err != nil {
+		log.Error(err, "Failed to determine the size of the policy", "name", plc.GetName())
+
+		return
+	}
+
+	policySizeHistogram.Observe(float64(len(plcJSON)))
+}
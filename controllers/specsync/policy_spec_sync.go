@@ -5,19 +5,28 @@ package specsync
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
 	"open-cluster-management.io/governance-policy-propagator/controllers/common"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
 
 const ControllerName string = "policy-spec-sync"
@@ -26,10 +35,24 @@ var log = logf.Log.WithName(ControllerName)
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&policiesv1.Policy{}).
+	forOptions := []builder.ForOption{}
+	if r.MetadataOnly {
+		forOptions = append(forOptions, builder.OnlyMetadata)
+	}
+
+	ctrlBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&policiesv1.Policy{}, forOptions...).
 		Named(ControllerName).
-		Complete(r)
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Concurrency.Workers(r.ConcurrentReconciles)})
+
+	if r.RootPlacements != nil {
+		// The replicated policies are reconciled when the placement of their root policy changes
+		ctrlBuilder = ctrlBuilder.Watches(
+			&source.Channel{Source: r.RootPlacements.Events()}, &handler.EnqueueRequestForObject{},
+		)
+	}
+
+	return ctrlBuilder.Complete(r.Concurrency.Reconciler(syncerrors.Reconciler(ControllerName, r)))
 }
 
 // blank assignment to verify that ReconcilePolicy implements reconcile.Reconciler
@@ -45,6 +68,39 @@ type PolicyReconciler struct {
 	Scheme          *runtime.Scheme
 	// The namespace that the replicated policies should be synced to.
 	TargetNamespace string
+	// NamespaceGuard pauses the reconciles while the cluster namespace is unavailable
+	NamespaceGuard *utils.NamespaceGuard
+	// DeletionGuard delays the deletion of the policy on the managed cluster after the replicated policy disappears
+	// from the Hub. If it is nil, the policy is deleted right away.
+	DeletionGuard *utils.DeletionGuard
+	// HubAPIReader reads from the Hub API server rather than the cache to verify that a replicated policy is deleted
+	// once the grace period of the DeletionGuard elapsed.
+	HubAPIReader client.Reader
+	// MetadataOnly only caches the metadata of the replicated policies on the Hub, which reduces the memory usage with
+	// large cluster namespaces on the Hub, starting with the initial list. Each reconcile then reads the policy from
+	// the Hub API server with the HubAPIReader, which must be set.
+	MetadataOnly bool
+	// Compaction strips the Hub annotations not in its allow-list from the policies on the managed cluster. If it is
+	// nil, all the annotations are synced.
+	Compaction *utils.PolicyCompaction
+	// HistoryCarryOver keeps the compliance history of the deleted policies for the next policy with the same
+	// identifier, such as when a policy is renamed. If it is nil, the compliance history of a new policy starts empty.
+	HistoryCarryOver *utils.HistoryCarryOver
+	// PolicySelector selects the replicated policies on the Hub which are synced. If it is nil, all the policies are
+	// synced.
+	PolicySelector labels.Selector
+	// Concurrency adapts the number of concurrent reconciles to the CPU and memory usage of the container. If it is
+	// nil, up to ConcurrentReconciles policies are reconciled at a time.
+	Concurrency *utils.AdaptiveConcurrency
+	// ConcurrentReconciles is the number of policies reconciled at a time when the Concurrency is nil. The reconciles
+	// of the same policy are never concurrent. It defaults to one.
+	ConcurrentReconciles int
+	// RootPlacements caches the placements of the root policies on the Hub to set annotations naming the placement
+	// bindings and placements which select the cluster on the policies on the managed cluster. If it is nil, the
+	// annotations are not set.
+	RootPlacements *RootPlacementCache
+	// skipped tracks the policies which don't match the PolicySelector so that each is only reported once
+	skipped utils.SkipTracker
 }
 
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=create;delete;get;list;patch;update;watch
@@ -60,21 +116,57 @@ type PolicyReconciler struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	ctx = utils.WithController(ctx, ControllerName)
+
 	reqLogger := log.WithValues(
 		"Request.Namespace", request.Namespace, "Request.Name", request.Name, "TargetNamespace", r.TargetNamespace,
 	)
 	reqLogger.Info("Reconciling Policy...")
 
+	if !r.NamespaceGuard.Available() {
+		reqLogger.Info("The cluster namespace is unavailable, pausing the reconcile")
+
+		return reconcile.Result{RequeueAfter: utils.NamespaceUnavailableRequeue}, nil
+	}
+
 	// Fetch the Policy instance
 	instance := &policiesv1.Policy{}
 
-	err := r.HubClient.Get(ctx, request.NamespacedName, instance)
+	var hubReader client.Reader = r.HubClient
+	if r.MetadataOnly {
+		hubReader = r.HubAPIReader
+	}
+
+	err := hubReader.Get(ctx, request.NamespacedName, instance)
+	if errors.IsNotFound(err) && r.DeletionGuard != nil {
+		if remaining := r.DeletionGuard.Remaining(request.NamespacedName); remaining > 0 {
+			reqLogger.Info("Policy was not found on the Hub, waiting before removing it on the managed cluster",
+				"retryIn", remaining.String())
+
+			return reconcile.Result{RequeueAfter: remaining}, nil
+		}
+
+		// The grace period elapsed, so verify that the policy is still missing with a read from the API server
+		// rather than the cache
+		if r.HubAPIReader != nil {
+			err = r.HubAPIReader.Get(ctx, request.NamespacedName, instance)
+		}
+	}
+
+	if err == nil {
+		r.DeletionGuard.Clear(request.NamespacedName)
+	}
+
 	if err != nil {
 		if errors.IsNotFound(err) {
+			r.DeletionGuard.Clear(request.NamespacedName)
+			r.skipped.Forget(request.NamespacedName)
+			r.RootPlacements.Forget(request.NamespacedName)
+
 			// repliated policy on hub was deleted, remove policy on managed cluster
 			reqLogger.Info("Policy was deleted, removing on managed cluster...")
 
-			err = r.ManagedClient.Delete(ctx, &policiesv1.Policy{
+			managedPlc := &policiesv1.Policy{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       policiesv1.Kind,
 					APIVersion: policiesv1.SchemeGroupVersion.Group,
@@ -83,7 +175,30 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 					Name:      request.Name,
 					Namespace: r.TargetNamespace,
 				},
-			})
+			}
+
+			// The policy is read first so that its deletion is preconditioned on it not changing in the meantime
+			err = r.ManagedClient.Get(ctx, client.ObjectKeyFromObject(managedPlc), managedPlc)
+			if err == nil {
+				// Keep the compliance history in case the policy was renamed
+				if err := r.HistoryCarryOver.Store(ctx, managedPlc); err != nil {
+					reqLogger.Error(err, "Failed to keep the compliance history of the deleted policy")
+				}
+
+				err = utils.DeleteUnchanged(ctx, r.ManagedClient, managedPlc)
+			}
+
+			if goerrors.Is(err, syncerrors.ErrDeleteConflict) {
+				reqLogger.Error(err, "The policy on the managed cluster changed, will reevaluate its deletion")
+
+				r.ManagedRecorder.Event(managedPlc, "Warning", "PolicyDeleteConflict", err.Error())
+
+				if err := utils.ReportDeleteConflict(ctx, r.ManagedClient, managedPlc, err); err != nil {
+					reqLogger.Error(err, "Failed to set the DeleteConflict condition on the policy")
+				}
+
+				return reconcile.Result{}, err
+			}
 
 			if err != nil && !errors.IsNotFound(err) {
 				reqLogger.Error(err, "Failed to remove policy on managed cluster...")
@@ -96,9 +211,73 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		// Error reading the object - requeue the request.
 		reqLogger.Error(err, "Failed to get policy from hub...")
 
-		return reconcile.Result{}, err
+		return reconcile.Result{}, syncerrors.FromHub(err)
 	}
 
+	if !utils.PolicySelected(r.PolicySelector, instance) {
+		reqLogger.Info("Policy doesn't match the policy label selector, skipping it")
+
+		// The event is recorded in the cluster namespace since the policy may not exist on the managed cluster
+		skippedPlc := &policiesv1.Policy{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       policiesv1.Kind,
+				APIVersion: policiesv1.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      request.Name,
+				Namespace: r.TargetNamespace,
+			},
+		}
+
+		if r.skipped.Skip(request.NamespacedName) {
+			r.ManagedRecorder.Event(skippedPlc, "Normal", "PolicySkipped",
+				fmt.Sprintf("Policy %s was not synchronized since it doesn't match the policy label selector %s",
+					instance.GetName(), r.PolicySelector.String()))
+		}
+
+		err = r.ManagedClient.Get(ctx, client.ObjectKeyFromObject(skippedPlc), skippedPlc)
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+
+		if err != nil {
+			reqLogger.Error(err, "Failed to get policy from managed...")
+
+			return reconcile.Result{}, err
+		}
+
+		// The policy on the managed cluster keeps the labels it was synced with, so it was synced by this addon
+		// instance if they match the selector, and otherwise it is left to the addon instance which synced it
+		if !utils.PolicySelected(r.PolicySelector, skippedPlc) {
+			return reconcile.Result{}, nil
+		}
+
+		reqLogger.Info("Policy stopped matching the policy label selector, removing it on managed cluster...")
+
+		err = utils.DeleteUnchanged(ctx, r.ManagedClient, skippedPlc)
+		if goerrors.Is(err, syncerrors.ErrDeleteConflict) {
+			reqLogger.Error(err, "The policy on the managed cluster changed, will reevaluate its deletion")
+
+			r.ManagedRecorder.Event(skippedPlc, "Warning", "PolicyDeleteConflict", err.Error())
+
+			if err := utils.ReportDeleteConflict(ctx, r.ManagedClient, skippedPlc, err); err != nil {
+				reqLogger.Error(err, "Failed to set the DeleteConflict condition on the policy")
+			}
+
+			return reconcile.Result{}, err
+		}
+
+		if err != nil && !errors.IsNotFound(err) {
+			reqLogger.Error(err, "Failed to remove policy on managed cluster...")
+
+			return reconcile.Result{}, err
+		}
+
+		return reconcile.Result{}, nil
+	}
+
+	r.skipped.Forget(request.NamespacedName)
+
 	managedPlc := &policiesv1.Policy{}
 	err = r.ManagedClient.Get(ctx, types.NamespacedName{Namespace: r.TargetNamespace, Name: request.Name}, managedPlc)
 
@@ -107,17 +286,7 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			// not found on managed cluster, create it
 			reqLogger.Info("Policy not found on managed cluster, creating it...")
 
-			managedPlc = instance.DeepCopy()
-			managedPlc.Namespace = r.TargetNamespace
-
-			if managedPlc.Labels["policy.open-cluster-management.io/cluster-namespace"] != "" {
-				managedPlc.Labels["policy.open-cluster-management.io/cluster-namespace"] = r.TargetNamespace
-			}
-
-			managedPlc.SetOwnerReferences(nil)
-			managedPlc.SetResourceVersion("")
-			err = r.ManagedClient.Create(ctx, managedPlc)
-
+			managedPlc, err = utils.CreateManagedPolicy(ctx, r.ManagedClient, instance, r.TargetNamespace, r.Compaction)
 			if err != nil {
 				reqLogger.Error(err, "Failed to create policy on managed...")
 
@@ -127,18 +296,59 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			r.ManagedRecorder.Event(managedPlc, "Normal", "PolicySpecSync",
 				fmt.Sprintf("Policy %s was synchronized to cluster namespace %s", instance.GetName(),
 					r.TargetNamespace))
+
+			observePolicy(instance)
 		} else {
 			reqLogger.Error(err, "Failed to get policy from managed...")
 
 			return reconcile.Result{}, err
 		}
 	}
+	// Refuse to overwrite a policy from another root policy which happens to have the same name, unless its
+	// replacement was requested
+	collision := utils.RootPolicyCollision(instance, managedPlc)
+	if collision != nil && utils.ReplaceCollidingPolicy(managedPlc) {
+		reqLogger.Info("Replacing the colliding policy as requested with the annotation",
+			"annotation", utils.ReplaceCollidingPolicyAnnotation)
+
+		if err := utils.DeleteUnchanged(ctx, r.ManagedClient, managedPlc); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		r.ManagedRecorder.Event(managedPlc, "Normal", "PolicySpecSyncCollisionResolved",
+			fmt.Sprintf("Policy %s was deleted to be replaced by the policy from the root policy %s as requested "+
+				"with the %s annotation", managedPlc.GetName(), instance.GetLabels()[common.RootPolicyLabel],
+				utils.ReplaceCollidingPolicyAnnotation))
+
+		// The replicated policy is created by the next reconcile
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	if err := utils.ReportRootPolicyCollision(ctx, r.ManagedClient, managedPlc, collision); err != nil {
+		reqLogger.Error(err, "Failed to set the RootPolicyCollision condition on the policy")
+	}
+
+	// The policy is no longer being deleted, such as when it reappeared on the Hub
+	if err := utils.ReportDeleteConflict(ctx, r.ManagedClient, managedPlc, nil); err != nil {
+		reqLogger.Error(err, "Failed to remove the DeleteConflict condition from the policy")
+	}
+
+	if collision != nil {
+		reqLogger.Error(collision, "Refusing to sync the policy")
+
+		r.ManagedRecorder.Event(managedPlc, "Warning", "PolicySpecSyncCollision", collision.Error())
+
+		// The collision is checked again since the spec sync doesn't watch the policies on the managed cluster, so
+		// that the policy is synced once the colliding policy is deleted or its replacement is requested
+		return reconcile.Result{RequeueAfter: utils.RootPolicyCollisionRequeue}, nil
+	}
+
 	// found, then compare and update
-	if !common.CompareSpecAndAnnotation(instance, managedPlc) {
+	if !utils.CompareSpecAndAnnotation(instance, managedPlc, r.Compaction) {
 		// update needed
 		reqLogger.Info("Policy mismatch between hub and managed, updating it...")
-		managedPlc.SetAnnotations(instance.GetAnnotations())
-		managedPlc.Spec = instance.Spec
+		utils.SyncFromHub(instance, managedPlc, r.Compaction)
+		utils.SetCorrelationID(instance, managedPlc)
 		err = r.ManagedClient.Update(ctx, managedPlc)
 
 		if err != nil && errors.IsNotFound(err) {
@@ -150,6 +360,15 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		r.ManagedRecorder.Event(managedPlc, "Normal", "PolicySpecSync",
 			fmt.Sprintf("Policy %s was updated in cluster namespace %s", instance.GetName(),
 				r.TargetNamespace))
+
+		observePolicy(instance)
+	}
+
+	// The placement only helps tracing why the cluster received the policy, so it doesn't fail the reconcile
+	if r.RootPlacements != nil {
+		if err := r.syncRootPlacement(ctx, instance, managedPlc); err != nil {
+			reqLogger.Error(err, "Failed to set the root policy placement annotations")
+		}
 	}
 
 	reqLogger.Info("Reconciliation complete.")
@@ -0,0 +1,258 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// DefaultRootPlacementRefresh is the default interval at which the placements of the root policies are read again
+// from the Hub.
+const DefaultRootPlacementRefresh = 5 * time.Minute
+
+// rootPlacementEntry is the cached placement of a root policy.
+type rootPlacementEntry struct {
+	// annotations are the root policy placement annotations for the cluster
+	annotations map[string]string
+	// policies are the replicated policies of the root policy on the Hub
+	policies map[types.NamespacedName]bool
+}
+
+// RootPlacementCache caches the placements of the root policies on the Hub which select the cluster. The root policies
+// are outside of the cluster namespace, so they are read from the Hub API server rather than watched, which would
+// require listing the policies in all the namespaces. Each root policy is read once when a replicated policy first
+// references it, and then again every Interval, and the replicated policies are reconciled when its placement changed.
+type RootPlacementCache struct {
+	// Reader reads the root policies from the Hub API server
+	Reader client.Reader
+	// ClusterNamespace is the namespace of the cluster on the Hub
+	ClusterNamespace string
+	// Interval is the interval at which the root policies are read again. It defaults to DefaultRootPlacementRefresh.
+	Interval time.Duration
+	// roots maps a root policy to its cached placement
+	roots map[types.NamespacedName]*rootPlacementEntry
+	// replicated maps a replicated policy to its root policy
+	replicated map[types.NamespacedName]types.NamespacedName
+	events     chan event.GenericEvent
+	once       sync.Once
+	lock       sync.Mutex
+}
+
+func (c *RootPlacementCache) init() {
+	c.once.Do(func() {
+		c.roots = map[types.NamespacedName]*rootPlacementEntry{}
+		c.replicated = map[types.NamespacedName]types.NamespacedName{}
+		c.events = make(chan event.GenericEvent, 1024)
+	})
+}
+
+// Events returns the channel of reconcile events for the replicated policies whose root policy placement changed.
+func (c *RootPlacementCache) Events() <-chan event.GenericEvent {
+	c.init()
+
+	return c.events
+}
+
+// Start reads the tracked root policies again every Interval until the input context is closed.
+func (c *RootPlacementCache) Start(ctx context.Context) error {
+	c.init()
+
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultRootPlacementRefresh
+	}
+
+	wait.UntilWithContext(ctx, c.refresh, interval)
+
+	return nil
+}
+
+// refresh reads each tracked root policy from the Hub and sends a reconcile event for its replicated policies when
+// its placement changed. A root policy which fails to be read keeps its cached placement until the next refresh.
+func (c *RootPlacementCache) refresh(ctx context.Context) {
+	c.lock.Lock()
+	roots := make([]types.NamespacedName, 0, len(c.roots))
+
+	for root := range c.roots {
+		roots = append(roots, root)
+	}
+
+	c.lock.Unlock()
+
+	for _, root := range roots {
+		annotations, err := c.read(ctx, root)
+		if err != nil {
+			log.Error(err, "Failed to refresh the root policy placement", "rootPolicy", root.String())
+
+			continue
+		}
+
+		c.lock.Lock()
+		entry, ok := c.roots[root]
+		policies := []types.NamespacedName{}
+
+		if ok && !reflect.DeepEqual(entry.annotations, annotations) {
+			entry.annotations = annotations
+
+			for policy := range entry.policies {
+				policies = append(policies, policy)
+			}
+		}
+
+		c.lock.Unlock()
+
+		for _, policy := range policies {
+			c.events <- event.GenericEvent{
+				Object: &policiesv1.Policy{
+					ObjectMeta: metav1.ObjectMeta{Namespace: policy.Namespace, Name: policy.Name},
+				},
+			}
+		}
+	}
+}
+
+// read returns the root policy placement annotations for the cluster read from the Hub API server.
+func (c *RootPlacementCache) read(ctx context.Context, root types.NamespacedName) (map[string]string, error) {
+	rootPlc := &policiesv1.Policy{}
+
+	if err := c.Reader.Get(ctx, root, rootPlc); err != nil {
+		return nil, fmt.Errorf("failed to get the root policy %s from the Hub: %w", root, err)
+	}
+
+	return utils.RootPlacementAnnotations(rootPlc, c.ClusterNamespace), nil
+}
+
+// Annotations returns the root policy placement annotations of the input replicated policy, from the cache or read
+// from the Hub if the root policy isn't cached yet, and tracks the replicated policy so that it is reconciled when
+// the placement changes.
+func (c *RootPlacementCache) Annotations(
+	ctx context.Context, policy types.NamespacedName, root types.NamespacedName,
+) (map[string]string, error) {
+	c.init()
+
+	c.lock.Lock()
+	c.track(policy, root)
+	entry := c.roots[root]
+
+	if entry.annotations != nil {
+		annotations := entry.annotations
+		c.lock.Unlock()
+
+		return annotations, nil
+	}
+
+	c.lock.Unlock()
+
+	annotations, err := c.read(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	// The root policy may have been forgotten meanwhile
+	if entry, ok := c.roots[root]; ok {
+		entry.annotations = annotations
+	}
+
+	c.lock.Unlock()
+
+	return annotations, nil
+}
+
+// track records the root policy of the input replicated policy. It must be called with the lock held.
+func (c *RootPlacementCache) track(policy types.NamespacedName, root types.NamespacedName) {
+	if previous, ok := c.replicated[policy]; ok && previous != root {
+		c.untrack(policy)
+	}
+
+	c.replicated[policy] = root
+
+	if c.roots[root] == nil {
+		c.roots[root] = &rootPlacementEntry{policies: map[types.NamespacedName]bool{}}
+	}
+
+	c.roots[root].policies[policy] = true
+}
+
+// untrack removes the input replicated policy, and its root policy once it has no replicated policy. It must be
+// called with the lock held.
+func (c *RootPlacementCache) untrack(policy types.NamespacedName) {
+	root, ok := c.replicated[policy]
+	if !ok {
+		return
+	}
+
+	delete(c.replicated, policy)
+
+	if entry := c.roots[root]; entry != nil {
+		delete(entry.policies, policy)
+
+		if len(entry.policies) == 0 {
+			delete(c.roots, root)
+		}
+	}
+}
+
+// Forget stops tracking the input replicated policy, such as when it was deleted from the Hub. A nil
+// RootPlacementCache is valid and tracks nothing.
+func (c *RootPlacementCache) Forget(policy types.NamespacedName) {
+	if c == nil {
+		return
+	}
+
+	c.init()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.untrack(policy)
+}
+
+// syncRootPlacement sets the annotations naming the placement bindings and placements of the root policy on the Hub
+// which select the cluster on the input policy on the managed cluster. The placement is read from the RootPlacements
+// cache, which reads the root policies from the Hub API server since they are outside of the cluster namespace cached
+// on the Hub, so the Hub credentials of the addon must be allowed to get them.
+func (r *PolicyReconciler) syncRootPlacement(
+	ctx context.Context, instance *policiesv1.Policy, managedPlc *policiesv1.Policy,
+) error {
+	rootKey, ok := utils.RootPolicyKey(instance)
+	if !ok {
+		r.RootPlacements.Forget(client.ObjectKeyFromObject(instance))
+
+		return nil
+	}
+
+	rootAnnotations, err := r.RootPlacements.Annotations(ctx, client.ObjectKeyFromObject(instance), rootKey)
+	if err != nil {
+		return err
+	}
+
+	annotations := utils.RootPlacementPatch(managedPlc, rootAnnotations)
+	if annotations == nil {
+		return nil
+	}
+
+	// A null value removes the annotation
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.ManagedClient.Patch(ctx, managedPlc, client.RawPatch(types.MergePatchType, patch))
+}
@@ -0,0 +1,91 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	appsv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// countingReader counts the reads from the Hub API server.
+type countingReader struct {
+	client.Reader
+	gets int
+}
+
+func (c *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.gets++
+
+	return c.Reader.Get(ctx, key, obj)
+}
+
+func TestRootPlacementCache(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	rootPlc := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "policies"},
+		Status: policiesv1.PolicyStatus{Placement: []*policiesv1.Placement{{
+			PlacementBinding: "binding",
+			Placement:        "placement",
+			Decisions:        []appsv1.PlacementDecision{{ClusterName: "cluster1", ClusterNamespace: "cluster1"}},
+		}}},
+	}
+	hubClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rootPlc).Build()
+	reader := &countingReader{Reader: hubClient}
+	cache := &RootPlacementCache{Reader: reader, ClusterNamespace: "cluster1"}
+	ctx := context.TODO()
+	root := types.NamespacedName{Namespace: "policies", Name: "policy"}
+	replicated := types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}
+
+	annotations, err := cache.Annotations(ctx, replicated, root)
+	Expect(err).To(BeNil())
+	Expect(annotations).To(Equal(map[string]string{
+		utils.RootPlacementBindingsAnnotation: "binding",
+		utils.RootPlacementsAnnotation:        "Placement/placement",
+	}))
+
+	// The following reconciles don't read the root policy again
+	_, err = cache.Annotations(ctx, replicated, root)
+	Expect(err).To(BeNil())
+	Expect(reader.gets).To(Equal(1))
+
+	// A refresh without changes doesn't reconcile the replicated policy
+	cache.refresh(ctx)
+	Expect(reader.gets).To(Equal(2))
+	Expect(cache.Events()).To(BeEmpty())
+
+	// A placement change is cached and reconciles the replicated policy
+	rootPlc.Status.Placement[0].PlacementBinding = "other-binding"
+	Expect(hubClient.Update(ctx, rootPlc)).To(Succeed())
+
+	cache.refresh(ctx)
+	Expect(cache.Events()).To(HaveLen(1))
+
+	evt := <-cache.Events()
+	Expect(evt.Object.GetNamespace()).To(Equal("cluster1"))
+	Expect(evt.Object.GetName()).To(Equal("policies.policy"))
+
+	annotations, err = cache.Annotations(ctx, replicated, root)
+	Expect(err).To(BeNil())
+	Expect(annotations[utils.RootPlacementBindingsAnnotation]).To(Equal("other-binding"))
+	Expect(reader.gets).To(Equal(3))
+
+	// A forgotten policy is no longer refreshed
+	cache.Forget(replicated)
+	cache.refresh(ctx)
+	Expect(reader.gets).To(Equal(3))
+}
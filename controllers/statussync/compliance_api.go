@@ -0,0 +1,247 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// standardsAnnotation is the policy annotation listing the comma separated standards of the policy.
+	standardsAnnotation = "policy.open-cluster-management.io/standards"
+	// controlsAnnotation is the policy annotation listing the comma separated controls of the policy.
+	controlsAnnotation = "policy.open-cluster-management.io/controls"
+)
+
+// policyCompliance is the compliance of a policy returned by the compliance API.
+type policyCompliance struct {
+	Name              string               `json:"name"`
+	Namespace         string               `json:"namespace"`
+	ComplianceState   string               `json:"compliant"`
+	RemediationAction string               `json:"remediationAction,omitempty"`
+	Categories        []string             `json:"categories,omitempty"`
+	Standards         []string             `json:"standards,omitempty"`
+	Controls          []string             `json:"controls,omitempty"`
+	Templates         []templateCompliance `json:"templates,omitempty"`
+}
+
+// templateCompliance is the compliance of a policy template returned by the compliance API.
+type templateCompliance struct {
+	Name            string `json:"name"`
+	ComplianceState string `json:"compliant"`
+	Message         string `json:"message,omitempty"`
+}
+
+// ComplianceAPI serves a read-only HTTP API returning the compliance of the policies in the cluster namespace from
+// the controller cache, so that local integrations don't need a Kubernetes client or RBAC on the policies. Requests
+// must have the Token as a bearer token. The GET /policies endpoint returns the policies matching the optional state,
+// category, standard, and control query parameters.
+type ComplianceAPI struct {
+	Client      client.Client
+	Namespace   string
+	BindAddress string
+	Token       string
+	// CertFile and KeyFile are the paths of the certificate and key the API is served over TLS with. If they are
+	// empty, the API is served over plain HTTP, which must only be bound to a loopback address so that the token
+	// isn't sent in clear text over the network.
+	CertFile string
+	KeyFile  string
+}
+
+// NeedLeaderElection is false so that every replica of the addon serves the API.
+func (a *ComplianceAPI) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the API until the input context is closed.
+func (a *ComplianceAPI) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/policies", a.servePolicies)
+
+	server := &http.Server{
+		Addr:              a.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		// Don't pass the already closed context or else the clean up won't happen
+		// nolint: contextcheck
+		if err := server.Shutdown(context.TODO()); err != nil {
+			log.Error(err, "Failed to shutdown the compliance API")
+		}
+	}()
+
+	log.Info("Serving the compliance API", "address", a.BindAddress, "tls", a.CertFile != "")
+
+	var err error
+
+	if a.CertFile != "" {
+		err = server.ListenAndServeTLS(a.CertFile, a.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// authorized returns true if the input request has the token of the API as a bearer token.
+func (a *ComplianceAPI) authorized(req *http.Request) bool {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+	return a.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1
+}
+
+// servePolicies responds with the JSON list of the compliance of the policies matching the query parameters.
+func (a *ComplianceAPI) servePolicies(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET requests are supported", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if !a.authorized(req) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+		return
+	}
+
+	policies := &policiesv1.PolicyList{}
+
+	err := a.Client.List(req.Context(), policies, client.InNamespace(a.Namespace))
+	if err != nil {
+		log.Error(err, "Failed to list the policies for the compliance API")
+		http.Error(w, "failed to list the policies", http.StatusInternalServerError)
+
+		return
+	}
+
+	query := req.URL.Query()
+	result := filterCompliance(policies.Items, query.Get("state"), map[string]string{
+		categoriesAnnotation: query.Get("category"),
+		standardsAnnotation:  query.Get("standard"),
+		controlsAnnotation:   query.Get("control"),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Error(err, "Failed to write the compliance API response")
+	}
+}
+
+// annotationList returns the trimmed values of the input comma separated list annotation of the input policy.
+func annotationList(policy *policiesv1.Policy, annotation string) []string {
+	values := []string{}
+
+	for _, value := range strings.Split(policy.GetAnnotations()[annotation], ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// filterCompliance returns the compliance of the input policies, sorted by name, which have the input compliance state
+// and whose list annotations contain the input values. An empty filter matches all the policies, and a state of
+// Pending matches the policies without a compliance state.
+func filterCompliance(
+	policies []policiesv1.Policy, state string, annotationFilters map[string]string,
+) []policyCompliance {
+	result := []policyCompliance{}
+
+	for i := range policies {
+		policy := &policies[i]
+
+		complianceState := string(policy.Status.ComplianceState)
+		if complianceState == "" {
+			complianceState = string(Pending)
+		}
+
+		if state != "" && !strings.EqualFold(state, complianceState) {
+			continue
+		}
+
+		matches := true
+
+		for annotation, filter := range annotationFilters {
+			if filter == "" {
+				continue
+			}
+
+			found := false
+
+			for _, value := range annotationList(policy, annotation) {
+				if strings.EqualFold(value, filter) {
+					found = true
+
+					break
+				}
+			}
+
+			if !found {
+				matches = false
+
+				break
+			}
+		}
+
+		if !matches {
+			continue
+		}
+
+		compliance := policyCompliance{
+			Name:              policy.GetName(),
+			Namespace:         policy.GetNamespace(),
+			ComplianceState:   complianceState,
+			RemediationAction: string(policy.Spec.RemediationAction),
+			Categories:        annotationList(policy, categoriesAnnotation),
+			Standards:         annotationList(policy, standardsAnnotation),
+			Controls:          annotationList(policy, controlsAnnotation),
+		}
+
+		for _, dpt := range policy.Status.Details {
+			if dpt == nil {
+				continue
+			}
+
+			template := templateCompliance{
+				Name:            dpt.TemplateMeta.GetName(),
+				ComplianceState: string(dpt.ComplianceState),
+			}
+
+			if len(dpt.History) != 0 {
+				template.Message = dpt.History[0].Message
+			}
+
+			compliance.Templates = append(compliance.Templates, template)
+		}
+
+		result = append(result, compliance)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
@@ -0,0 +1,178 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	certutil "k8s.io/client-go/util/cert"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestComplianceAPI(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	pci := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "policy-pci",
+			Namespace:   "cluster1",
+			Annotations: map[string]string{categoriesAnnotation: "PCI, CM Configuration Management"},
+		},
+		Spec: policiesv1.PolicySpec{RemediationAction: policiesv1.Inform},
+		Status: policiesv1.PolicyStatus{
+			ComplianceState: policiesv1.NonCompliant,
+			Details: []*policiesv1.DetailsPerTemplate{{
+				TemplateMeta:    metav1.ObjectMeta{Name: "config-policy"},
+				ComplianceState: policiesv1.NonCompliant,
+				History:         []policiesv1.ComplianceHistory{{Message: "NonCompliant; violation"}},
+			}},
+		},
+	}
+	compliant := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-compliant", Namespace: "cluster1"},
+		Status:     policiesv1.PolicyStatus{ComplianceState: policiesv1.Compliant},
+	}
+	pending := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy-pending", Namespace: "cluster1"}}
+	other := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy-other", Namespace: "cluster2"}}
+
+	api := &ComplianceAPI{
+		Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(pci, compliant, pending, other).Build(),
+		Namespace: "cluster1",
+		Token:     "secret",
+	}
+
+	query := func(target string, token string) (int, []policyCompliance) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		recorder := httptest.NewRecorder()
+		api.servePolicies(recorder, req)
+
+		result := []policyCompliance{}
+		if recorder.Code == http.StatusOK {
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &result)).To(Succeed())
+		}
+
+		return recorder.Code, result
+	}
+
+	code, _ := query("/policies", "")
+	Expect(code).To(Equal(http.StatusUnauthorized))
+
+	code, _ = query("/policies", "wrong")
+	Expect(code).To(Equal(http.StatusUnauthorized))
+
+	code, result := query("/policies", "secret")
+	Expect(code).To(Equal(http.StatusOK))
+	Expect(result).To(HaveLen(3))
+	Expect(result[0].Name).To(Equal("policy-compliant"))
+	Expect(result[2].ComplianceState).To(Equal("Pending"))
+
+	_, result = query("/policies?state=noncompliant&category=pci", "secret")
+	Expect(result).To(Equal([]policyCompliance{{
+		Name:              "policy-pci",
+		Namespace:         "cluster1",
+		ComplianceState:   "NonCompliant",
+		RemediationAction: "Inform",
+		Categories:        []string{"PCI", "CM Configuration Management"},
+		Templates: []templateCompliance{
+			{Name: "config-policy", ComplianceState: "NonCompliant", Message: "NonCompliant; violation"},
+		},
+	}}))
+
+	_, result = query("/policies?state=Compliant&category=PCI", "secret")
+	Expect(result).To(BeEmpty())
+
+	_, result = query("/policies?state=Pending", "secret")
+	Expect(result).To(HaveLen(1))
+	Expect(result[0].Name).To(Equal("policy-pending"))
+}
+
+func TestComplianceAPITLS(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey("localhost", []net.IP{net.ParseIP("127.0.0.1")}, nil)
+	Expect(err).ToNot(HaveOccurred())
+
+	certDir := t.TempDir()
+	Expect(os.WriteFile(filepath.Join(certDir, "tls.crt"), certPEM, 0o600)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(certDir, "tls.key"), keyPEM, 0o600)).To(Succeed())
+
+	// Find a free port to bind the API to
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	address := listener.Addr().String()
+	Expect(listener.Close()).To(Succeed())
+
+	api := &ComplianceAPI{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Namespace:   "cluster1",
+		BindAddress: address,
+		Token:       "secret",
+		CertFile:    filepath.Join(certDir, "tls.crt"),
+		KeyFile:     filepath.Join(certDir, "tls.key"),
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	go func() { _ = api.Start(ctx) }()
+
+	roots := x509.NewCertPool()
+	Expect(roots.AppendCertsFromPEM(certPEM)).To(BeTrue())
+
+	httpsClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}},
+	}
+
+	get := func(url string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		req.Header.Set("Authorization", "Bearer secret")
+
+		return httpsClient.Do(req)
+	}
+
+	// The API is served over TLS
+	Eventually(func() int {
+		resp, err := get("https://" + address + "/policies")
+		if err != nil {
+			return 0
+		}
+
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}).Should(Equal(http.StatusOK))
+
+	// A plain HTTP request is rejected
+	resp, err := get("http://" + address + "/policies")
+	Expect(err).ToNot(HaveOccurred())
+
+	defer resp.Body.Close()
+
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+}
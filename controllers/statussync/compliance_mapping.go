@@ -0,0 +1,145 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/yaml"
+
+	"open-cluster-management.io/governance-policy-framework-addon/pkg/compliance"
+)
+
+// ComplianceMappingsKey is the key in the compliance mapping ConfigMap that contains the list of mappings.
+const ComplianceMappingsKey = "mappings"
+
+// ComplianceMapping maps compliance event messages matching Pattern to State. This allows policy engines which use a
+// different vocabulary (e.g. PASS/FAIL) to integrate with the policy framework.
+type ComplianceMapping struct {
+	Pattern string                     `json:"pattern"`
+	State   policiesv1.ComplianceState `json:"state"`
+}
+
+type complianceMatcher struct {
+	regex *regexp.Regexp
+	state policiesv1.ComplianceState
+}
+
+// ComplianceMessageParser determines the compliance state of compliance event messages. The mappings in the
+// configured ConfigMap are consulted in order before the built-in "Compliant" prefix check. The ConfigMap is watched so
+// that changes take effect without a restart.
+type ComplianceMessageParser struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+	matchers  []complianceMatcher
+	lock      sync.RWMutex
+}
+
+// Start watches the compliance mapping ConfigMap until the input context is closed.
+func (p *ComplianceMessageParser) Start(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		p.Client,
+		0,
+		informers.WithNamespace(p.Namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", p.Name).String()
+		}),
+	)
+
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if configMap, ok := obj.(*corev1.ConfigMap); ok {
+				p.load(configMap)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if configMap, ok := newObj.(*corev1.ConfigMap); ok {
+				p.load(configMap)
+			}
+		},
+		DeleteFunc: func(_ interface{}) {
+			log.Info("The compliance mapping ConfigMap was deleted", "namespace", p.Namespace, "name", p.Name)
+
+			p.lock.Lock()
+			p.matchers = nil
+			p.lock.Unlock()
+		},
+	})
+
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+
+	return nil
+}
+
+// load replaces the compliance mappings with the content of the input ConfigMap. Invalid mappings are logged and
+// skipped.
+func (p *ComplianceMessageParser) load(configMap *corev1.ConfigMap) {
+	mappings := []ComplianceMapping{}
+
+	err := yaml.Unmarshal([]byte(configMap.Data[ComplianceMappingsKey]), &mappings)
+	if err != nil {
+		log.Error(err, "Failed to parse the compliance mapping ConfigMap, ignoring it",
+			"namespace", p.Namespace, "name", p.Name)
+
+		mappings = nil
+	}
+
+	matchers := make([]complianceMatcher, 0, len(mappings))
+
+	for _, mapping := range mappings {
+		if mapping.State != policiesv1.Compliant && mapping.State != policiesv1.NonCompliant {
+			log.Info("Skipping the compliance mapping with an invalid state", "pattern", mapping.Pattern,
+				"state", mapping.State)
+
+			continue
+		}
+
+		regex, err := regexp.Compile(mapping.Pattern)
+		if err != nil {
+			log.Error(err, "Skipping the compliance mapping with an invalid pattern", "pattern", mapping.Pattern)
+
+			continue
+		}
+
+		matchers = append(matchers, complianceMatcher{regex: regex, state: mapping.State})
+	}
+
+	log.Info("Loaded the compliance mappings", "namespace", p.Namespace, "name", p.Name, "count", len(matchers))
+
+	p.lock.Lock()
+	p.matchers = matchers
+	p.lock.Unlock()
+}
+
+// ComplianceState returns the compliance state of the input compliance event message. The configured mappings are
+// checked first, and if none match, the message is Compliant if it starts with "Compliant" and NonCompliant
+// otherwise.
+func (p *ComplianceMessageParser) ComplianceState(message string) policiesv1.ComplianceState {
+	message = strings.TrimSpace(strings.TrimPrefix(message, "(combined from similar events):"))
+
+	if p != nil {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+
+		for _, matcher := range p.matchers {
+			if matcher.regex.MatchString(message) {
+				return matcher.state
+			}
+		}
+	}
+
+	return compliance.MessageState(message)
+}
@@ -0,0 +1,48 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestComplianceStateBuiltIn(t *testing.T) {
+	RegisterTestingT(t)
+
+	var parser *ComplianceMessageParser
+
+	Expect(parser.ComplianceState("Compliant; notification - all good")).To(Equal(policiesv1.Compliant))
+	Expect(parser.ComplianceState("(combined from similar events): compliant")).To(Equal(policiesv1.Compliant))
+	Expect(parser.ComplianceState("NonCompliant; violation")).To(Equal(policiesv1.NonCompliant))
+	Expect(parser.ComplianceState("PASS: all checks passed")).To(Equal(policiesv1.NonCompliant))
+}
+
+func TestComplianceStateMappings(t *testing.T) {
+	RegisterTestingT(t)
+
+	parser := &ComplianceMessageParser{}
+	parser.load(&corev1.ConfigMap{
+		Data: map[string]string{
+			ComplianceMappingsKey: `
+- pattern: "^PASS"
+  state: Compliant
+- pattern: "^FAIL"
+  state: NonCompliant
+- pattern: "^WARN"
+  state: Unknown
+- pattern: "^(invalid"
+  state: Compliant
+`,
+		},
+	})
+
+	Expect(parser.matchers).To(HaveLen(2))
+	Expect(parser.ComplianceState("PASS: all checks passed")).To(Equal(policiesv1.Compliant))
+	Expect(parser.ComplianceState("FAIL: 2 checks failed")).To(Equal(policiesv1.NonCompliant))
+	Expect(parser.ComplianceState("WARN: compliant")).To(Equal(policiesv1.NonCompliant))
+	Expect(parser.ComplianceState("Compliant; notification")).To(Equal(policiesv1.Compliant))
+}
@@ -0,0 +1,136 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+var (
+	policyComplianceGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_compliance_state",
+			Help: "The compliance state of a policy on the managed cluster: 0 when Compliant, 1 when NonCompliant, " +
+				"2 when Pending, and -1 when it has no compliance state yet.",
+		},
+		[]string{"policy", "policy_namespace"},
+	)
+	policyLastTransitionGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_compliance_last_transition_timestamp_seconds",
+			Help: "The Unix time of the latest change of the compliance state of a policy template of a policy.",
+		},
+		[]string{"policy", "policy_namespace"},
+	)
+	policyTemplateErrorsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_template_errors",
+			Help: "The number of policy templates of a policy whose latest compliance event is a template error.",
+		},
+		[]string{"policy", "policy_namespace"},
+	)
+	statusSyncLatencyHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "policy_status_sync_latency_seconds",
+			Help: "The time between a compliance event on the managed cluster and the write of the policy status " +
+				"recording it to the Hub.",
+			// 100 milliseconds to about 7 minutes
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 13),
+		},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(
+		policyComplianceGauge, policyLastTransitionGauge, policyTemplateErrorsGauge, statusSyncLatencyHistogram,
+	)
+}
+
+// complianceStateValue returns the value of the input compliance state in the policy_compliance_state metric.
+func complianceStateValue(state policiesv1.ComplianceState) float64 {
+	switch state {
+	case policiesv1.Compliant:
+		return 0
+	case policiesv1.NonCompliant:
+		return 1
+	case Pending:
+		return 2
+	default:
+		return -1
+	}
+}
+
+// recordComplianceMetrics records the compliance state, the latest compliance transition, and the number of template
+// errors of the input policy from its input status.
+func recordComplianceMetrics(policy types.NamespacedName, status *policiesv1.PolicyStatus) {
+	var lastTransition time.Time
+
+	templateErrors := 0
+
+	for _, dpt := range status.Details {
+		transition := utils.TemplateTimestamp(dpt, utils.LastTransitionTimeAnnotation)
+		if transition.After(lastTransition) {
+			lastTransition = transition
+		}
+
+		if len(dpt.History) != 0 && strings.Contains(dpt.History[0].Message, "template-error;") {
+			templateErrors++
+		}
+	}
+
+	policyComplianceGauge.WithLabelValues(policy.Name, policy.Namespace).Set(
+		complianceStateValue(status.ComplianceState),
+	)
+	policyTemplateErrorsGauge.WithLabelValues(policy.Name, policy.Namespace).Set(float64(templateErrors))
+
+	if lastTransition.IsZero() {
+		policyLastTransitionGauge.DeleteLabelValues(policy.Name, policy.Namespace)
+	} else {
+		policyLastTransitionGauge.WithLabelValues(policy.Name, policy.Namespace).Set(float64(lastTransition.Unix()))
+	}
+}
+
+// forgetComplianceMetrics removes the compliance metrics of the input policy, such as when it is deleted.
+func forgetComplianceMetrics(policy types.NamespacedName) {
+	policyComplianceGauge.DeleteLabelValues(policy.Name, policy.Namespace)
+	policyLastTransitionGauge.DeleteLabelValues(policy.Name, policy.Namespace)
+	policyTemplateErrorsGauge.DeleteLabelValues(policy.Name, policy.Namespace)
+}
+
+// statusSyncLatencies returns the time since the compliance events of the input written policy template details which
+// were not in the input previous details of the policy on the Hub, so each compliance event is only counted once.
+func statusSyncLatencies(previous, written []*policiesv1.DetailsPerTemplate, now time.Time) []time.Duration {
+	known := historyEventNames(previous)
+	latencies := []time.Duration{}
+
+	for _, dpt := range written {
+		for _, entry := range dpt.History {
+			if known[dpt.TemplateMeta.Name][entry.EventName] || entry.LastTimestamp.IsZero() {
+				continue
+			}
+
+			if latency := now.Sub(entry.LastTimestamp.Time); latency >= 0 {
+				latencies = append(latencies, latency)
+			}
+		}
+	}
+
+	return latencies
+}
+
+// observeStatusSyncLatency records the status sync latency of the new compliance events of the input written policy
+// template details in the policy_status_sync_latency_seconds metric.
+func observeStatusSyncLatency(previous, written []*policiesv1.DetailsPerTemplate, now time.Time) {
+	for _, latency := range statusSyncLatencies(previous, written, now) {
+		statusSyncLatencyHistogram.Observe(latency.Seconds())
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestComplianceMetrics(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := types.NamespacedName{Namespace: "cluster-ns", Name: "metrics-policy"}
+	status := &policiesv1.PolicyStatus{
+		ComplianceState: policiesv1.NonCompliant,
+		Details: []*policiesv1.DetailsPerTemplate{
+			{
+				TemplateMeta: metav1.ObjectMeta{
+					Name:        "config-policy",
+					Annotations: map[string]string{utils.LastTransitionTimeAnnotation: "2024-01-01T10:00:00Z"},
+				},
+				History: []policiesv1.ComplianceHistory{{Message: "NonCompliant; violation"}},
+			},
+			{
+				TemplateMeta: metav1.ObjectMeta{
+					Name:        "other-policy",
+					Annotations: map[string]string{utils.LastTransitionTimeAnnotation: "2024-01-01T12:00:00Z"},
+				},
+				History: []policiesv1.ComplianceHistory{{Message: "template-error; invalid"}},
+			},
+		},
+	}
+
+	recordComplianceMetrics(policy, status)
+
+	Expect(testutil.ToFloat64(policyComplianceGauge.WithLabelValues(policy.Name, policy.Namespace))).To(Equal(1.0))
+	Expect(testutil.ToFloat64(policyTemplateErrorsGauge.WithLabelValues(policy.Name, policy.Namespace))).To(Equal(1.0))
+	Expect(testutil.ToFloat64(policyLastTransitionGauge.WithLabelValues(policy.Name, policy.Namespace))).To(
+		Equal(float64(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).Unix())),
+	)
+
+	status.ComplianceState = Pending
+	recordComplianceMetrics(policy, status)
+	Expect(testutil.ToFloat64(policyComplianceGauge.WithLabelValues(policy.Name, policy.Namespace))).To(Equal(2.0))
+
+	forgetComplianceMetrics(policy)
+	Expect(testutil.CollectAndCount(policyComplianceGauge)).To(BeZero())
+	Expect(testutil.CollectAndCount(policyTemplateErrorsGauge)).To(BeZero())
+	Expect(testutil.CollectAndCount(policyLastTransitionGauge)).To(BeZero())
+}
+
+func TestStatusSyncLatencies(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now()
+	previous := []*policiesv1.DetailsPerTemplate{{
+		TemplateMeta: metav1.ObjectMeta{Name: "config-policy"},
+		History: []policiesv1.ComplianceHistory{
+			{EventName: "event.1", LastTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+		},
+	}}
+	written := []*policiesv1.DetailsPerTemplate{{
+		TemplateMeta: metav1.ObjectMeta{Name: "config-policy"},
+		History: []policiesv1.ComplianceHistory{
+			{EventName: "event.2", LastTimestamp: metav1.NewTime(now.Add(-2 * time.Second))},
+			{EventName: "event.1", LastTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+		},
+	}}
+
+	// Only the new compliance event is counted
+	Expect(statusSyncLatencies(previous, written, now)).To(Equal([]time.Duration{2 * time.Second}))
+	Expect(statusSyncLatencies(written, written, now)).To(BeEmpty())
+}
@@ -0,0 +1,211 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// notificationQueueSize is the number of compliance notifications waiting to be sent, beyond which the new
+// notifications are dropped.
+const notificationQueueSize = 1000
+
+// ComplianceNotificationTargetAnnotation can be set on a policy on the Hub to route the notifications of its compliance
+// transitions to another target than the global notification URL. The target is either an http or https URL whose host
+// is allowed, or a channel identifier, such as a chat channel, passed to the global notification URL.
+const ComplianceNotificationTargetAnnotation = "policy.open-cluster-management.io/compliance-notification-target"
+
+var (
+	// ErrInvalidNotificationURL is returned when a compliance notification URL is not an http or https URL.
+	ErrInvalidNotificationURL = errors.New("the compliance notification URL must be an http or https URL")
+	// ErrNotificationHostNotAllowed is returned when the notification target of a policy is a URL whose host is not
+	// allowed.
+	ErrNotificationHostNotAllowed = errors.New("the host of the compliance notification target is not allowed")
+	// ErrNotificationQueueFull is returned when a compliance notification is dropped since too many notifications are
+	// waiting to be sent.
+	ErrNotificationQueueFull = errors.New("too many compliance notifications are waiting to be sent")
+)
+
+// ComplianceNotification is the document sent in a POST request when the compliance of a policy changes.
+type ComplianceNotification struct {
+	Cluster       string                     `json:"cluster"`
+	Policy        string                     `json:"policy"`
+	PreviousState policiesv1.ComplianceState `json:"previousState,omitempty"`
+	State         policiesv1.ComplianceState `json:"state"`
+	// Channel is the channel identifier set on the policy, for the global notification URL to route the notification.
+	Channel   string `json:"channel,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// notification is a compliance notification waiting to be sent.
+type notification struct {
+	url    string
+	policy string
+	body   []byte
+}
+
+// ComplianceNotifier sends a notification when the compliance state of a policy changes. The notifications are sent to
+// the global URL unless the policy on the Hub sets a URL in the ComplianceNotificationTargetAnnotation annotation.
+// They are sent in order from a single goroutine so that the reconciles don't wait for the notification targets, and
+// it must be started with Start, such as by adding it to the manager. Since the transitions are only observed once, a
+// failed notification is not retried.
+type ComplianceNotifier struct {
+	// ClusterName is the name of the managed cluster on the Hub, which is set in the notifications.
+	ClusterName string
+	// URL receives the notifications of the policies without a target URL. If it is empty, only the policies with a
+	// target URL are notified.
+	URL string
+	// AllowedHosts are the hosts of the target URLs the policies may set. Since the policies are authored on the Hub,
+	// this prevents them from directing requests from the managed cluster to arbitrary hosts.
+	AllowedHosts []string
+	client       *http.Client
+	queue        chan notification
+}
+
+// NewComplianceNotifier returns a ComplianceNotifier which sends the notifications to the input global URL, which may
+// be empty, and to the target URLs of the policies on the input allowed hosts.
+func NewComplianceNotifier(globalURL string, clusterName string, allowedHosts []string) (*ComplianceNotifier, error) {
+	if globalURL != "" {
+		if _, err := notificationURL(globalURL); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ComplianceNotifier{
+		ClusterName:  clusterName,
+		URL:          globalURL,
+		AllowedHosts: allowedHosts,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// A redirect would send the notification to a host which may not be allowed, so the redirect response
+			// is returned and fails the notification instead
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		queue: make(chan notification, notificationQueueSize),
+	}, nil
+}
+
+// notificationURL parses the input notification URL and checks that it is an http or https URL.
+func notificationURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotificationURL, err)
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidNotificationURL, rawURL)
+	}
+
+	return parsed, nil
+}
+
+// target returns the URL to send the notifications of the input policy on the Hub to, and the channel identifier to
+// set in them. The URL is empty if the policy has no target URL and there is no global URL.
+func (n *ComplianceNotifier) target(hubPlc *policiesv1.Policy) (string, string, error) {
+	target := strings.TrimSpace(hubPlc.GetAnnotations()[ComplianceNotificationTargetAnnotation])
+	if target == "" {
+		return n.URL, "", nil
+	}
+
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		return n.URL, target, nil
+	}
+
+	parsed, err := notificationURL(target)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, host := range n.AllowedHosts {
+		// An allowed IPv6 address may be set with or without the brackets of its URL form
+		if strings.EqualFold(host, parsed.Host) || strings.EqualFold(strings.Trim(host, "[]"), parsed.Hostname()) {
+			return target, "", nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s", ErrNotificationHostNotAllowed, parsed.Host)
+}
+
+// Notify queues a notification of the compliance transition of the input policy on the Hub from the input previous
+// state to the input state, without waiting for it to be sent. Nothing is sent if the state didn't change or is empty.
+// A nil ComplianceNotifier does nothing.
+func (n *ComplianceNotifier) Notify(
+	_ context.Context, hubPlc *policiesv1.Policy, previous, current policiesv1.ComplianceState,
+) error {
+	if n == nil || current == "" || previous == current {
+		return nil
+	}
+
+	targetURL, channel, err := n.target(hubPlc)
+	if err != nil || targetURL == "" {
+		return err
+	}
+
+	body, err := json.Marshal(ComplianceNotification{
+		Cluster:       n.ClusterName,
+		Policy:        hubPlc.GetName(),
+		PreviousState: previous,
+		State:         current,
+		Channel:       channel,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case n.queue <- notification{url: targetURL, policy: hubPlc.GetName(), body: body}:
+		return nil
+	default:
+		return ErrNotificationQueueFull
+	}
+}
+
+// Start sends the queued notifications until the input context is closed.
+func (n *ComplianceNotifier) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case note := <-n.queue:
+			if err := n.send(ctx, note); err != nil {
+				log.Error(err, "Failed to send the compliance notification", "policy", note.policy)
+			}
+		}
+	}
+}
+
+// send sends the input notification.
+func (n *ComplianceNotifier) send(ctx context.Context, note notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, note.url, bytes.NewReader(note.body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the compliance notification target returned the status %s", resp.Status)
+	}
+
+	return nil
+}
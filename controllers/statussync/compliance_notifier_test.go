@@ -0,0 +1,115 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestComplianceNotifier(t *testing.T) {
+	RegisterTestingT(t)
+
+	received := map[string][]ComplianceNotification{}
+	lock := sync.Mutex{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/global", http.StatusTemporaryRedirect)
+
+			return
+		}
+
+		notification := ComplianceNotification{}
+		Expect(json.NewDecoder(r.Body).Decode(&notification)).To(Succeed())
+
+		lock.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], notification)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	receivedAt := func(path string) func() []ComplianceNotification {
+		return func() []ComplianceNotification {
+			lock.Lock()
+			defer lock.Unlock()
+
+			return append([]ComplianceNotification{}, received[path]...)
+		}
+	}
+
+	serverURL, err := url.Parse(server.URL)
+	Expect(err).ToNot(HaveOccurred())
+
+	_, err = NewComplianceNotifier("ftp://example.com", "cluster1", nil)
+	Expect(errors.Is(err, ErrInvalidNotificationURL)).To(BeTrue())
+
+	notifier, err := NewComplianceNotifier(server.URL+"/global", "cluster1", []string{serverURL.Host})
+	Expect(err).ToNot(HaveOccurred())
+
+	hubPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "default.policy", Namespace: "cluster1"}}
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	defer cancel()
+
+	// Unchanged and empty states are not notified
+	Expect(notifier.Notify(ctx, hubPlc, policiesv1.Compliant, policiesv1.Compliant)).To(Succeed())
+	Expect(notifier.Notify(ctx, hubPlc, policiesv1.Compliant, "")).To(Succeed())
+	Expect(notifier.queue).To(BeEmpty())
+
+	// The notifications are queued until the notifier is started
+	Expect(notifier.Notify(ctx, hubPlc, policiesv1.Compliant, policiesv1.NonCompliant)).To(Succeed())
+
+	hubPlc.SetAnnotations(map[string]string{ComplianceNotificationTargetAnnotation: "#team-a"})
+	Expect(notifier.Notify(ctx, hubPlc, policiesv1.NonCompliant, policiesv1.Compliant)).To(Succeed())
+	Expect(notifier.queue).To(HaveLen(2))
+
+	go func() { _ = notifier.Start(ctx) }()
+
+	Eventually(receivedAt("/global")).Should(HaveLen(2))
+
+	globalNotifications := receivedAt("/global")()
+	Expect(globalNotifications[0].Cluster).To(Equal("cluster1"))
+	Expect(globalNotifications[0].Policy).To(Equal("default.policy"))
+	Expect(globalNotifications[0].State).To(Equal(policiesv1.NonCompliant))
+	Expect(globalNotifications[0].Channel).To(BeEmpty())
+	Expect(globalNotifications[1].Channel).To(Equal("#team-a"))
+
+	hubPlc.SetAnnotations(map[string]string{ComplianceNotificationTargetAnnotation: server.URL + "/team-b"})
+	Expect(notifier.Notify(ctx, hubPlc, policiesv1.Compliant, policiesv1.NonCompliant)).To(Succeed())
+	Eventually(receivedAt("/team-b")).Should(HaveLen(1))
+
+	// A redirect isn't followed, since it could lead to a host which is not allowed
+	err = notifier.send(ctx, notification{url: server.URL + "/redirect", policy: "default.policy", body: []byte("{}")})
+	Expect(err).To(HaveOccurred())
+	Expect(receivedAt("/global")()).To(HaveLen(2))
+
+	// A target URL on another host is refused
+	hubPlc.SetAnnotations(map[string]string{ComplianceNotificationTargetAnnotation: "https://example.com/hook"})
+	err = notifier.Notify(ctx, hubPlc, policiesv1.NonCompliant, policiesv1.Compliant)
+	Expect(errors.Is(err, ErrNotificationHostNotAllowed)).To(BeTrue())
+
+	// An IPv6 host is allowed with or without brackets
+	for _, allowed := range []string{"[fd00::1]", "fd00::1"} {
+		ipv6Notifier, err := NewComplianceNotifier("", "cluster1", []string{allowed})
+		Expect(err).ToNot(HaveOccurred())
+
+		hubPlc.SetAnnotations(map[string]string{ComplianceNotificationTargetAnnotation: "https://[fd00::1]:8443/hook"})
+		target, _, err := ipv6Notifier.target(hubPlc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target).To(Equal("https://[fd00::1]:8443/hook"))
+	}
+
+	var nilNotifier *ComplianceNotifier
+
+	Expect(nilNotifier.Notify(ctx, hubPlc, policiesv1.Compliant, policiesv1.NonCompliant)).To(Succeed())
+}
@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// categoriesAnnotation is the policy annotation listing the comma separated categories of the policy.
+const categoriesAnnotation = "policy.open-cluster-management.io/categories"
+
+// severityWeights are the weights of the policy template severities in the compliance score. Policy templates without
+// a known severity are weighted as medium.
+var severityWeights = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+var complianceScoreGauge = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "policy_cluster_compliance_score",
+		Help: "The compliance score of the cluster from 0 to 100, weighted by the severity and categories of the " +
+			"policies. This is only reported when the compliance summary is enabled.",
+	},
+)
+
+// policySeverityWeight returns the weight of the highest severity of the policy templates of the input policy.
+func policySeverityWeight(policy *policiesv1.Policy) int {
+	weight := 0
+
+	for _, policyT := range policy.Spec.PolicyTemplates {
+		if policyT == nil {
+			continue
+		}
+
+		template := struct {
+			Spec struct {
+				Severity string `json:"severity"`
+			} `json:"spec"`
+		}{}
+
+		// A template that can't be decoded is treated as having no severity
+		_ = json.Unmarshal(policyT.ObjectDefinition.Raw, &template)
+
+		templateWeight, ok := severityWeights[strings.ToLower(template.Spec.Severity)]
+		if !ok {
+			templateWeight = severityWeights["medium"]
+		}
+
+		if templateWeight > weight {
+			weight = templateWeight
+		}
+	}
+
+	if weight == 0 {
+		return severityWeights["medium"]
+	}
+
+	return weight
+}
+
+// policyCategoryWeight returns the highest weight of the categories of the input policy in the input category weights.
+// Categories without a weight have a weight of 1.
+func policyCategoryWeight(policy *policiesv1.Policy, categoryWeights map[string]int) int {
+	weight := 0
+
+	for _, category := range strings.Split(policy.GetAnnotations()[categoriesAnnotation], ",") {
+		categoryWeight, ok := categoryWeights[strings.TrimSpace(category)]
+		if !ok {
+			categoryWeight = 1
+		}
+
+		if categoryWeight > weight {
+			weight = categoryWeight
+		}
+	}
+
+	return weight
+}
+
+// complianceScore returns the percentage of the input policies with a compliance state that are compliant, weighted
+// by the severity and categories of the policies. If no policy has a compliance state yet, the score is 100.
+func complianceScore(policies []policiesv1.Policy, categoryWeights map[string]int) int {
+	compliantWeight := 0
+	totalWeight := 0
+
+	for i := range policies {
+		state := policies[i].Status.ComplianceState
+		if state != policiesv1.Compliant && state != policiesv1.NonCompliant {
+			continue
+		}
+
+		weight := policySeverityWeight(&policies[i]) * policyCategoryWeight(&policies[i], categoryWeights)
+		totalWeight += weight
+
+		if state == policiesv1.Compliant {
+			compliantWeight += weight
+		}
+	}
+
+	if totalWeight == 0 {
+		return 100
+	}
+
+	// Round to the nearest integer
+	return (100*compliantWeight + totalWeight/2) / totalWeight
+}
@@ -0,0 +1,51 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func policyWithSeverity(
+	state policiesv1.ComplianceState, categories string, severities ...string,
+) policiesv1.Policy {
+	policy := policyWithCompliance("policy", state)
+
+	if categories != "" {
+		policy.Annotations = map[string]string{categoriesAnnotation: categories}
+	}
+
+	for _, severity := range severities {
+		policy.Spec.PolicyTemplates = append(policy.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{
+			ObjectDefinition: runtime.RawExtension{
+				Raw: []byte(`{"kind":"ConfigurationPolicy","spec":{"severity":"` + severity + `"}}`),
+			},
+		})
+	}
+
+	return policy
+}
+
+func TestComplianceScore(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(complianceScore(nil, nil)).To(Equal(100))
+
+	policies := []policiesv1.Policy{
+		// Weight 4 from the highest severity
+		policyWithSeverity(policiesv1.Compliant, "", "low", "critical"),
+		// Weight 2 from the default severity
+		policyWithSeverity(policiesv1.NonCompliant, "", "unknown"),
+		// Pending policies aren't part of the score
+		policyWithSeverity("", "", "critical"),
+	}
+	Expect(complianceScore(policies, nil)).To(Equal(67))
+
+	// Weight 2 * 3 from the category weight
+	policies[1].Annotations = map[string]string{categoriesAnnotation: "CM Configuration Management, SI System"}
+	Expect(complianceScore(policies, map[string]int{"CM Configuration Management": 3})).To(Equal(40))
+}
@@ -0,0 +1,201 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	policyv1alpha1 "open-cluster-management.io/governance-policy-framework-addon/api/v1alpha1"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+	"open-cluster-management.io/governance-policy-framework-addon/pkg/compliance"
+)
+
+const (
+	// ComplianceSummaryName is the name of the ComplianceSummary in the cluster namespace on the managed cluster.
+	ComplianceSummaryName = compliance.SummaryName
+	// complianceSummaryDebounce is how long to wait after a policy status change before updating the summary so that
+	// bursts of status changes result in a single update.
+	complianceSummaryDebounce = 5 * time.Second
+	// maxTopOffenders is the maximum number of policies listed in the top offenders of the summary.
+	maxTopOffenders = 5
+)
+
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=compliancesummaries,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=compliancesummaries/status,verbs=get;update;patch
+
+// ComplianceSummarizer maintains the ComplianceSummary in the cluster namespace on the managed cluster. Updates are
+// debounced so that dashboards and operators can watch a single small object instead of all the policies.
+type ComplianceSummarizer struct {
+	Client    client.Client
+	Namespace string
+	// CategoryWeights are the weights of the policy categories in the compliance score. Categories without a weight
+	// have a weight of 1.
+	CategoryWeights map[string]int
+	// HubSync determines the HubSyncDegraded condition of the summary. If it is nil, the summary has no conditions.
+	HubSync *utils.HubSyncMonitor
+	trigger chan struct{}
+	once    sync.Once
+}
+
+func (s *ComplianceSummarizer) init() {
+	s.once.Do(func() {
+		s.trigger = make(chan struct{}, 1)
+
+		// The score is only registered when it's maintained so that a disabled summary doesn't report a score of 0.
+		// An error means it's already registered.
+		_ = metrics.Registry.Register(complianceScoreGauge)
+	})
+}
+
+// Trigger requests an update of the ComplianceSummary. It doesn't block, and a nil ComplianceSummarizer does nothing.
+func (s *ComplianceSummarizer) Trigger() {
+	if s == nil {
+		return
+	}
+
+	s.init()
+
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+		// An update is already pending
+	}
+}
+
+// Start updates the ComplianceSummary after each trigger until the input context is closed. It always performs an
+// initial update.
+func (s *ComplianceSummarizer) Start(ctx context.Context) error {
+	s.init()
+	s.Trigger()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.trigger:
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(complianceSummaryDebounce):
+		}
+
+		err := s.update(ctx)
+		if err != nil {
+			log.Error(err, "Failed to update the compliance summary, will retry",
+				"namespace", s.Namespace, "name", ComplianceSummaryName)
+
+			s.Trigger()
+		}
+	}
+}
+
+// update computes the summary of the policies in the cluster namespace and creates or updates the ComplianceSummary if
+// the summary changed.
+func (s *ComplianceSummarizer) update(ctx context.Context) error {
+	policies := &policiesv1.PolicyList{}
+
+	err := s.Client.List(ctx, policies, client.InNamespace(s.Namespace))
+	if err != nil {
+		return err
+	}
+
+	summaryStatus := summarizeCompliance(policies.Items, s.CategoryWeights)
+	complianceScoreGauge.Set(float64(summaryStatus.Score))
+
+	summary := &policyv1alpha1.ComplianceSummary{}
+
+	err = s.Client.Get(ctx, types.NamespacedName{Namespace: s.Namespace, Name: ComplianceSummaryName}, summary)
+	if errors.IsNotFound(err) {
+		summary = &policyv1alpha1.ComplianceSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: ComplianceSummaryName},
+		}
+
+		err = s.Client.Create(ctx, summary)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if s.HubSync != nil {
+		// Start from the current conditions so that their transition times are kept
+		summaryStatus.Conditions = append([]metav1.Condition{}, summary.Status.Conditions...)
+		meta.SetStatusCondition(&summaryStatus.Conditions, s.HubSync.Condition(time.Now()))
+	}
+
+	// Ignore the timestamp when comparing since it's always different
+	summaryStatus.LastUpdated = summary.Status.LastUpdated
+	if equality.Semantic.DeepEqual(summary.Status, summaryStatus) {
+		return nil
+	}
+
+	summaryStatus.LastUpdated = metav1.Now()
+	summary.Status = summaryStatus
+
+	return s.Client.Status().Update(ctx, summary)
+}
+
+// summarizeCompliance returns the compliance summary of the input policies. A policy without a Compliant or
+// NonCompliant state is counted as pending. The input category weights are used for the compliance score.
+func summarizeCompliance(
+	policies []policiesv1.Policy, categoryWeights map[string]int,
+) policyv1alpha1.ComplianceSummaryStatus {
+	summary := policyv1alpha1.ComplianceSummaryStatus{
+		Total: len(policies),
+		Score: complianceScore(policies, categoryWeights),
+	}
+	offenders := []policyv1alpha1.PolicyOffender{}
+
+	for i := range policies {
+		switch policies[i].Status.ComplianceState {
+		case policiesv1.Compliant:
+			summary.Compliant++
+		case policiesv1.NonCompliant:
+			summary.NonCompliant++
+
+			offender := policyv1alpha1.PolicyOffender{Name: policies[i].Name}
+
+			for _, dpt := range policies[i].Status.Details {
+				if dpt != nil && dpt.ComplianceState == policiesv1.NonCompliant && !isInformational(dpt) {
+					offender.NonCompliantTemplates++
+				}
+			}
+
+			offenders = append(offenders, offender)
+		default:
+			summary.Pending++
+		}
+	}
+
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].NonCompliantTemplates != offenders[j].NonCompliantTemplates {
+			return offenders[i].NonCompliantTemplates > offenders[j].NonCompliantTemplates
+		}
+
+		return offenders[i].Name < offenders[j].Name
+	})
+
+	if len(offenders) > maxTopOffenders {
+		offenders = offenders[:maxTopOffenders]
+	}
+
+	if len(offenders) > 0 {
+		summary.TopOffenders = offenders
+	}
+
+	return summary
+}
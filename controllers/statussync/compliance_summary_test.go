@@ -0,0 +1,101 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyv1alpha1 "open-cluster-management.io/governance-policy-framework-addon/api/v1alpha1"
+)
+
+func policyWithCompliance(name string, state policiesv1.ComplianceState, templateStates ...policiesv1.ComplianceState,
+) policiesv1.Policy {
+	policy := policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed"},
+		Status:     policiesv1.PolicyStatus{ComplianceState: state},
+	}
+
+	for _, templateState := range templateStates {
+		policy.Status.Details = append(policy.Status.Details, &policiesv1.DetailsPerTemplate{
+			ComplianceState: templateState,
+		})
+	}
+
+	return policy
+}
+
+func TestSummarizeCompliance(t *testing.T) {
+	RegisterTestingT(t)
+
+	policies := []policiesv1.Policy{
+		policyWithCompliance("compliant", policiesv1.Compliant),
+		policyWithCompliance("pending", ""),
+		policyWithCompliance("nc-one", policiesv1.NonCompliant, policiesv1.NonCompliant, policiesv1.Compliant),
+		policyWithCompliance("nc-two", policiesv1.NonCompliant, policiesv1.NonCompliant, policiesv1.NonCompliant),
+	}
+
+	for i := 0; i < maxTopOffenders; i++ {
+		policies = append(policies, policyWithCompliance(
+			fmt.Sprintf("nc-extra-%d", i), policiesv1.NonCompliant, policiesv1.NonCompliant,
+		))
+	}
+
+	summary := summarizeCompliance(policies, nil)
+	Expect(summary.Total).To(Equal(len(policies)))
+	Expect(summary.Compliant).To(Equal(1))
+	Expect(summary.Pending).To(Equal(1))
+	Expect(summary.NonCompliant).To(Equal(2 + maxTopOffenders))
+	Expect(summary.TopOffenders).To(HaveLen(maxTopOffenders))
+	Expect(summary.TopOffenders[0]).To(Equal(policyv1alpha1.PolicyOffender{Name: "nc-two", NonCompliantTemplates: 2}))
+	Expect(summary.TopOffenders[1].Name).To(Equal("nc-extra-0"))
+}
+
+func TestComplianceSummarizerUpdate(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+	Expect(policyv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	compliant := policyWithCompliance("compliant", policiesv1.Compliant)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&compliant).Build()
+	summarizer := &ComplianceSummarizer{Client: fakeClient, Namespace: "managed"}
+
+	Expect(summarizer.update(context.TODO())).To(Succeed())
+
+	summary := &policyv1alpha1.ComplianceSummary{}
+	key := types.NamespacedName{Namespace: "managed", Name: ComplianceSummaryName}
+	Expect(fakeClient.Get(context.TODO(), key, summary)).To(Succeed())
+	Expect(summary.Status.Total).To(Equal(1))
+	Expect(summary.Status.Compliant).To(Equal(1))
+	Expect(summary.Status.LastUpdated.IsZero()).To(BeFalse())
+
+	// The summary is left alone when nothing changed
+	resourceVersion := summary.ResourceVersion
+	Expect(summarizer.update(context.TODO())).To(Succeed())
+	Expect(fakeClient.Get(context.TODO(), key, summary)).To(Succeed())
+	Expect(summary.ResourceVersion).To(Equal(resourceVersion))
+}
+
+func TestComplianceSummarizerTrigger(t *testing.T) {
+	RegisterTestingT(t)
+
+	var nilSummarizer *ComplianceSummarizer
+	Expect(nilSummarizer.Trigger).ToNot(Panic())
+
+	summarizer := &ComplianceSummarizer{}
+
+	// Triggers are coalesced while an update is pending
+	summarizer.Trigger()
+	summarizer.Trigger()
+	Expect(summarizer.trigger).To(HaveLen(1))
+}
@@ -0,0 +1,250 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ComplianceTimelineName is the name of the ConfigMap in the cluster namespace on the managed cluster with the
+	// compliance timeline of the policies.
+	ComplianceTimelineName = "policy-compliance-timeline"
+	// complianceTimelineHours is how many hourly buckets are kept for each policy.
+	complianceTimelineHours = 24
+	// maxBucketStates is the maximum number of compliance states recorded in an hourly bucket. Once it is reached, the
+	// last state is replaced so that the bucket still ends with the current state.
+	maxBucketStates = 8
+)
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update
+
+// ComplianceTimeline maintains a rolling timeline of the compliance states of the policies in the cluster namespace
+// over the last 24 hours, in a ConfigMap in the cluster namespace on the managed cluster. This answers questions such
+// as when a policy became noncompliant on the managed cluster itself, without an external monitoring system. The
+// ConfigMap has a key per policy with a JSON object mapping the start of each hour to the compliance states observed
+// during that hour, in order, where C is Compliant, N is NonCompliant, and P is Pending. Updates are debounced like the
+// ComplianceSummary updates, and the timeline is also updated every hour.
+type ComplianceTimeline struct {
+	Client    client.Client
+	Namespace string
+	trigger   chan struct{}
+	once      sync.Once
+}
+
+func (t *ComplianceTimeline) init() {
+	t.once.Do(func() {
+		t.trigger = make(chan struct{}, 1)
+	})
+}
+
+// Trigger requests an update of the timeline. It doesn't block, and a nil ComplianceTimeline does nothing.
+func (t *ComplianceTimeline) Trigger() {
+	if t == nil {
+		return
+	}
+
+	t.init()
+
+	select {
+	case t.trigger <- struct{}{}:
+	default:
+		// An update is already pending
+	}
+}
+
+// Start updates the timeline after each trigger and every hour until the input context is closed. It always performs
+// an initial update.
+func (t *ComplianceTimeline) Start(ctx context.Context) error {
+	t.init()
+	t.Trigger()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.trigger:
+		case <-ticker.C:
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(complianceSummaryDebounce):
+		}
+
+		err := t.update(ctx, time.Now())
+		if err != nil {
+			log.Error(err, "Failed to update the compliance timeline, will retry",
+				"namespace", t.Namespace, "name", ComplianceTimelineName)
+
+			t.Trigger()
+		}
+	}
+}
+
+// update records the current compliance state of the policies in the cluster namespace in the timeline ConfigMap and
+// creates or updates it if the timeline changed.
+func (t *ComplianceTimeline) update(ctx context.Context, now time.Time) error {
+	policies := &policiesv1.PolicyList{}
+
+	err := t.Client.List(ctx, policies, client.InNamespace(t.Namespace))
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{}
+
+	err = t.Client.Get(ctx, types.NamespacedName{Namespace: t.Namespace, Name: ComplianceTimelineName}, configMap)
+	if errors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: t.Namespace, Name: ComplianceTimelineName},
+		}
+
+		err = t.Client.Create(ctx, configMap)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]string, len(policies.Items))
+
+	for i := range policies.Items {
+		name := policies.Items[i].GetName()
+
+		timeline := parseTimeline(configMap.Data[name])
+		timeline.record(timelineState(policies.Items[i].Status.ComplianceState), now)
+
+		data[name], err = timeline.marshal()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The timelines of the deleted policies are removed with them
+	if len(data) == 0 {
+		data = nil
+	}
+
+	if equality.Semantic.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+
+	configMap.Data = data
+
+	return t.Client.Update(ctx, configMap)
+}
+
+// timelineState returns the letter of the input compliance state in the timeline.
+func timelineState(state policiesv1.ComplianceState) byte {
+	switch state {
+	case policiesv1.Compliant:
+		return 'C'
+	case policiesv1.NonCompliant:
+		return 'N'
+	default:
+		return 'P'
+	}
+}
+
+// complianceTimeline maps the start of each hour to the compliance states observed during that hour.
+type complianceTimeline map[time.Time]string
+
+// parseTimeline parses the input timeline of a policy from the ConfigMap. An invalid timeline is discarded.
+func parseTimeline(value string) complianceTimeline {
+	raw := map[string]string{}
+	timeline := complianceTimeline{}
+
+	if value == "" || json.Unmarshal([]byte(value), &raw) != nil {
+		return timeline
+	}
+
+	for hour, states := range raw {
+		parsed, err := time.Parse(time.RFC3339, hour)
+		if err != nil || states == "" {
+			continue
+		}
+
+		timeline[parsed.UTC()] = states
+	}
+
+	return timeline
+}
+
+// record adds the input compliance state to the bucket of the current hour. The hours since the last bucket are filled
+// with the state the last bucket ended with, and the buckets older than 24 hours are removed.
+func (c complianceTimeline) record(state byte, now time.Time) {
+	current := now.UTC().Truncate(time.Hour)
+	oldest := current.Add(-(complianceTimelineHours - 1) * time.Hour)
+
+	var last time.Time
+
+	for hour := range c {
+		if hour.Before(oldest) || hour.After(current) {
+			delete(c, hour)
+
+			continue
+		}
+
+		if hour.After(last) {
+			last = hour
+		}
+	}
+
+	if !last.IsZero() {
+		carried := c[last][len(c[last])-1:]
+
+		for hour := last.Add(time.Hour); hour.Before(current); hour = hour.Add(time.Hour) {
+			c[hour] = carried
+		}
+
+		if last.Before(current) {
+			c[current] = carried
+		}
+	}
+
+	states := c[current]
+
+	switch {
+	case states == "":
+		states = string(state)
+	case states[len(states)-1] == state:
+	case len(states) >= maxBucketStates:
+		states = states[:len(states)-1] + string(state)
+	default:
+		states += string(state)
+	}
+
+	c[current] = states
+}
+
+// marshal returns the JSON representation of the timeline stored in the ConfigMap. The JSON object keys are sorted,
+// which is chronological for the RFC 3339 timestamps in UTC.
+func (c complianceTimeline) marshal() (string, error) {
+	raw := make(map[string]string, len(c))
+	for hour, states := range c {
+		raw[hour.Format(time.RFC3339)] = states
+	}
+
+	value, err := json.Marshal(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
@@ -0,0 +1,81 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestComplianceTimelineRecord(t *testing.T) {
+	RegisterTestingT(t)
+
+	start := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	timeline := parseTimeline("")
+
+	timeline.record('C', start)
+	timeline.record('C', start.Add(10*time.Minute))
+	timeline.record('N', start.Add(40*time.Minute))
+	Expect(timeline.marshal()).To(Equal(`{"2024-05-01T09:00:00Z":"C","2024-05-01T10:00:00Z":"CN"}`))
+
+	// The hours without an update carry the last state over
+	timeline.record('C', start.Add(3*time.Hour))
+	Expect(timeline.marshal()).To(Equal(`{"2024-05-01T09:00:00Z":"C","2024-05-01T10:00:00Z":"CN",` +
+		`"2024-05-01T11:00:00Z":"N","2024-05-01T12:00:00Z":"NC"}`))
+
+	// A flapping hour keeps its first states and ends with the current state
+	for i := 0; i < 10; i++ {
+		timeline.record('N', start.Add(3*time.Hour))
+		timeline.record('C', start.Add(3*time.Hour))
+	}
+
+	value, err := timeline.marshal()
+	Expect(err).ToNot(HaveOccurred())
+
+	timeline = parseTimeline(value)
+	Expect(timeline[time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)]).To(Equal("NCNCNCNC"))
+
+	// The buckets older than 24 hours are removed
+	timeline.record('P', start.Add(26*time.Hour))
+	Expect(timeline).To(HaveLen(24))
+	Expect(timeline).ToNot(HaveKey(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+	Expect(timeline[time.Date(2024, 5, 2, 11, 0, 0, 0, time.UTC)]).To(Equal("CP"))
+
+	Expect(parseTimeline("not json")).To(BeEmpty())
+}
+
+func TestComplianceTimelineUpdate(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster-ns"},
+		Status:     policiesv1.PolicyStatus{ComplianceState: policiesv1.NonCompliant},
+	}
+	staleTimeline := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ComplianceTimelineName, Namespace: "cluster-ns"},
+		Data:       map[string]string{"deleted-policy": `{"2024-05-01T09:00:00Z":"C"}`},
+	}
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy, staleTimeline).Build()
+
+	timeline := &ComplianceTimeline{Client: c, Namespace: "cluster-ns"}
+	Expect(timeline.update(context.TODO(), time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC))).To(Succeed())
+
+	configMap := &corev1.ConfigMap{}
+	Expect(c.Get(
+		context.TODO(), types.NamespacedName{Namespace: "cluster-ns", Name: ComplianceTimelineName}, configMap,
+	)).To(Succeed())
+	Expect(configMap.Data).To(Equal(map[string]string{"policy": `{"2024-05-01T09:00:00Z":"N"}`}))
+}
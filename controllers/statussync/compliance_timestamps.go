@@ -0,0 +1,93 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"time"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	// LastCompliantAnnotation is set on the template metadata in the policy status to the time of the latest Compliant
+	// evaluation of the policy template.
+	LastCompliantAnnotation = "policy.open-cluster-management.io/last-compliant-timestamp"
+	// LastNonCompliantAnnotation is set on the template metadata in the policy status to the time of the latest
+	// NonCompliant evaluation of the policy template.
+	LastNonCompliantAnnotation = "policy.open-cluster-management.io/last-noncompliant-timestamp"
+)
+
+// setTemplateTimestamp sets the input annotation of the template metadata of the input policy template details to the
+// input time, or removes it if the time is zero.
+func setTemplateTimestamp(dpt *policiesv1.DetailsPerTemplate, annotation string, timestamp time.Time) {
+	if timestamp.IsZero() {
+		removeTemplateAnnotation(dpt, annotation)
+
+		return
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[annotation] = timestamp.UTC().Format(time.RFC3339)
+}
+
+// applyComplianceTimestamps records on the template metadata of the input policy template details when their
+// compliance state last changed and when they were last Compliant and NonCompliant, so that the time spent in
+// violation can be computed without parsing the history. Since the history is pruned, the recorded timestamps are
+// kept when the history no longer has the entries they were computed from.
+func (r *PolicyReconciler) applyComplianceTimestamps(details []*policiesv1.DetailsPerTemplate) {
+	for _, dpt := range details {
+		if len(dpt.History) == 0 {
+			continue
+		}
+
+		lastCompliant := utils.TemplateTimestamp(dpt, LastCompliantAnnotation)
+		lastNonCompliant := utils.TemplateTimestamp(dpt, LastNonCompliantAnnotation)
+
+		// The history is sorted from the newest to the oldest entry
+		currentState := r.MessageParser.ComplianceState(dpt.History[0].Message)
+		transition := time.Time{}
+		transitionInHistory := false
+
+		for _, entry := range dpt.History {
+			state := r.MessageParser.ComplianceState(entry.Message)
+
+			switch state {
+			case policiesv1.Compliant:
+				if entry.LastTimestamp.Time.After(lastCompliant) {
+					lastCompliant = entry.LastTimestamp.Time
+				}
+			case policiesv1.NonCompliant:
+				if entry.LastTimestamp.Time.After(lastNonCompliant) {
+					lastNonCompliant = entry.LastTimestamp.Time
+				}
+			}
+
+			if transitionInHistory {
+				continue
+			}
+
+			if state != currentState {
+				transitionInHistory = true
+
+				continue
+			}
+
+			transition = entry.LastTimestamp.Time
+		}
+
+		// When all the entries have the current compliance state, the transition may be older than the history
+		if recorded := utils.TemplateTimestamp(dpt, utils.LastTransitionTimeAnnotation); !transitionInHistory &&
+			!recorded.IsZero() && recorded.Before(transition) {
+			transition = recorded
+		}
+
+		setTemplateTimestamp(dpt, utils.LastTransitionTimeAnnotation, transition)
+		setTemplateTimestamp(dpt, LastCompliantAnnotation, lastCompliant)
+		setTemplateTimestamp(dpt, LastNonCompliantAnnotation, lastNonCompliant)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestApplyComplianceTimestamps(t *testing.T) {
+	RegisterTestingT(t)
+
+	r := &PolicyReconciler{}
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	format := func(at time.Time) string { return at.Format(time.RFC3339) }
+
+	violated := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{Name: "violated"},
+		History: []policiesv1.ComplianceHistory{
+			evaluation(now, "NonCompliant; violation"),
+			evaluation(now.Add(-time.Hour), "NonCompliant; violation"),
+			evaluation(now.Add(-2*time.Hour), "Compliant; notification"),
+			evaluation(now.Add(-3*time.Hour), "NonCompliant; violation"),
+		},
+	}
+	// The history was pruned to the entries of the current compliance state
+	pruned := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{
+			Name: "pruned",
+			Annotations: map[string]string{
+				utils.LastTransitionTimeAnnotation: format(now.Add(-48 * time.Hour)),
+				LastNonCompliantAnnotation:         format(now.Add(-49 * time.Hour)),
+			},
+		},
+		History: []policiesv1.ComplianceHistory{
+			evaluation(now, "Compliant; notification"),
+			evaluation(now.Add(-time.Hour), "Compliant; notification"),
+		},
+	}
+	unevaluated := &policiesv1.DetailsPerTemplate{TemplateMeta: metav1.ObjectMeta{Name: "unevaluated"}}
+
+	r.applyComplianceTimestamps([]*policiesv1.DetailsPerTemplate{violated, pruned, unevaluated})
+
+	Expect(violated.TemplateMeta.Annotations).To(Equal(map[string]string{
+		utils.LastTransitionTimeAnnotation: format(now.Add(-time.Hour)),
+		LastCompliantAnnotation:            format(now.Add(-2 * time.Hour)),
+		LastNonCompliantAnnotation:         format(now),
+	}))
+	Expect(pruned.TemplateMeta.Annotations).To(Equal(map[string]string{
+		utils.LastTransitionTimeAnnotation: format(now.Add(-48 * time.Hour)),
+		LastCompliantAnnotation:            format(now),
+		LastNonCompliantAnnotation:         format(now.Add(-49 * time.Hour)),
+	}))
+	Expect(unevaluated.TemplateMeta.Annotations).To(BeNil())
+
+	// A new compliance state moves the transition time
+	pruned.History = append([]policiesv1.ComplianceHistory{
+		evaluation(now.Add(time.Hour), "NonCompliant; violation"),
+	}, pruned.History...)
+
+	r.applyComplianceTimestamps([]*policiesv1.DetailsPerTemplate{pruned})
+
+	Expect(pruned.TemplateMeta.Annotations).To(Equal(map[string]string{
+		utils.LastTransitionTimeAnnotation: format(now.Add(time.Hour)),
+		LastCompliantAnnotation:            format(now),
+		LastNonCompliantAnnotation:         format(now.Add(time.Hour)),
+	}))
+}
@@ -0,0 +1,78 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// HistoryCorrelationAnnotation is set on the template metadata in the policy status to the JSON object mapping the
+// event names of the compliance history entries to the correlation ID of the change of the policy on the Hub in effect
+// when they were recorded. The entries recorded before the policy had a correlation ID are not mapped.
+const HistoryCorrelationAnnotation = "policy.open-cluster-management.io/history-correlation-ids"
+
+// historyEventNames returns the event names of the compliance history entries of the input policy template details,
+// by policy template name.
+func historyEventNames(details []*policiesv1.DetailsPerTemplate) map[string]map[string]bool {
+	eventNames := make(map[string]map[string]bool, len(details))
+
+	for _, dpt := range details {
+		names := make(map[string]bool, len(dpt.History))
+		for _, entry := range dpt.History {
+			names[entry.EventName] = true
+		}
+
+		eventNames[dpt.TemplateMeta.Name] = names
+	}
+
+	return eventNames
+}
+
+// applyHistoryCorrelation sets the HistoryCorrelationAnnotation annotation on the template metadata of the input
+// policy template details. The entries not among the input known event names were just recorded, so they are mapped
+// to the input correlation ID, and the other entries keep their mapping. The mappings of the entries no longer in the
+// history are dropped.
+func applyHistoryCorrelation(
+	details []*policiesv1.DetailsPerTemplate, known map[string]map[string]bool, correlationID string,
+) {
+	for _, dpt := range details {
+		previous := map[string]string{}
+
+		if value, ok := dpt.TemplateMeta.Annotations[HistoryCorrelationAnnotation]; ok {
+			if err := json.Unmarshal([]byte(value), &previous); err != nil {
+				log.V(2).Info("Ignoring the invalid history correlation IDs", "PolicyTemplate", dpt.TemplateMeta.Name)
+			}
+		}
+
+		ids := map[string]string{}
+
+		for _, entry := range dpt.History {
+			switch {
+			case previous[entry.EventName] != "":
+				ids[entry.EventName] = previous[entry.EventName]
+			case correlationID != "" && !known[dpt.TemplateMeta.Name][entry.EventName]:
+				ids[entry.EventName] = correlationID
+			}
+		}
+
+		if len(ids) == 0 {
+			removeTemplateAnnotation(dpt, HistoryCorrelationAnnotation)
+
+			continue
+		}
+
+		// A map is marshaled with sorted keys, so the annotation is stable
+		value, err := json.Marshal(ids)
+		if err != nil {
+			continue
+		}
+
+		if dpt.TemplateMeta.Annotations == nil {
+			dpt.TemplateMeta.Annotations = map[string]string{}
+		}
+
+		dpt.TemplateMeta.Annotations[HistoryCorrelationAnnotation] = string(value)
+	}
+}
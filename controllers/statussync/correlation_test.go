@@ -0,0 +1,42 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestApplyHistoryCorrelation(t *testing.T) {
+	RegisterTestingT(t)
+
+	dpt := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{Name: "config-policy"},
+		History:      []policiesv1.ComplianceHistory{{EventName: "event.1"}, {EventName: "event.2"}},
+	}
+
+	// The entries already in the status before the policy had a correlation ID are not mapped
+	known := historyEventNames([]*policiesv1.DetailsPerTemplate{dpt})
+
+	dpt.History = append([]policiesv1.ComplianceHistory{{EventName: "event.3"}}, dpt.History...)
+
+	applyHistoryCorrelation([]*policiesv1.DetailsPerTemplate{dpt}, known, "first")
+	Expect(dpt.TemplateMeta.Annotations[HistoryCorrelationAnnotation]).To(Equal(`{"event.3":"first"}`))
+
+	// The mapped entries keep their correlation ID and the dropped entries are removed
+	known = historyEventNames([]*policiesv1.DetailsPerTemplate{dpt})
+	dpt.History = []policiesv1.ComplianceHistory{{EventName: "event.4"}, {EventName: "event.3"}}
+
+	applyHistoryCorrelation([]*policiesv1.DetailsPerTemplate{dpt}, known, "second")
+	Expect(dpt.TemplateMeta.Annotations[HistoryCorrelationAnnotation]).To(
+		Equal(`{"event.3":"first","event.4":"second"}`),
+	)
+
+	dpt.History = []policiesv1.ComplianceHistory{{EventName: "event.1"}}
+
+	applyHistoryCorrelation([]*policiesv1.DetailsPerTemplate{dpt}, known, "second")
+	Expect(dpt.TemplateMeta.Annotations).To(BeNil())
+}
@@ -0,0 +1,100 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// EngineStatusAnnotation is the annotation through which a policy template object publishes its engine status, such
+// as counts or links, when it can't set the status.engineStatus field. It is also set on the template metadata in the
+// policy status to the engine status of the template object, since the policy status has no field for it.
+const EngineStatusAnnotation = "policy.open-cluster-management.io/engine-status"
+
+// maxEngineStatusSize is the maximum size of the JSON engine status of a template object, so that the policy status
+// stays small.
+const maxEngineStatusSize = 2048
+
+// engineStatus returns the engine status published by the input template object as compact JSON, from its
+// status.engineStatus field or else from its EngineStatusAnnotation annotation. It returns an empty string if the
+// object doesn't publish an engine status, and an error if it is not a JSON object or is too large.
+func engineStatus(obj *unstructured.Unstructured) (string, error) {
+	var value []byte
+
+	if status, found, err := unstructured.NestedFieldNoCopy(obj.Object, "status", "engineStatus"); err == nil && found {
+		if _, ok := status.(map[string]interface{}); !ok {
+			return "", fmt.Errorf("the status.engineStatus field is not an object")
+		}
+
+		value, err = json.Marshal(status)
+		if err != nil {
+			return "", err
+		}
+	} else if annotation, ok := obj.GetAnnotations()[EngineStatusAnnotation]; ok {
+		parsed := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(annotation), &parsed); err != nil {
+			return "", fmt.Errorf("the %s annotation is not a JSON object: %w", EngineStatusAnnotation, err)
+		}
+
+		// The annotation is copied verbatim
+		value = []byte(annotation)
+	}
+
+	if len(value) > maxEngineStatusSize {
+		return "", fmt.Errorf("the engine status is larger than %d bytes", maxEngineStatusSize)
+	}
+
+	return string(value), nil
+}
+
+// applyEngineStatus sets the EngineStatusAnnotation annotation on the template metadata of the input policy template
+// details to the engine status published by its template object on the managed cluster, or removes it if there is
+// none. The previous engine status is kept if the template object can't be read.
+func (r *PolicyReconciler) applyEngineStatus(
+	ctx context.Context, gvk *schema.GroupVersionKind, namespace string, dpt *policiesv1.DetailsPerTemplate,
+) {
+	templateObj := &unstructured.Unstructured{}
+	templateObj.SetGroupVersionKind(*gvk)
+
+	tNamespace := r.TemplatePlacements.TemplateNamespace(gvk.GroupKind(), namespace)
+
+	err := r.templateReader().Get(
+		ctx, types.NamespacedName{Namespace: tNamespace, Name: dpt.TemplateMeta.Name}, templateObj,
+	)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			removeTemplateAnnotation(dpt, EngineStatusAnnotation)
+		} else {
+			log.V(1).Info("Failed to get the template object for its engine status", "kind", gvk.Kind,
+				"name", dpt.TemplateMeta.Name, "error", err.Error())
+		}
+
+		return
+	}
+
+	value, err := engineStatus(templateObj)
+	if err != nil {
+		log.Info("Ignoring the invalid engine status of the template object", "kind", gvk.Kind,
+			"name", dpt.TemplateMeta.Name, "reason", err.Error())
+	}
+
+	if value == "" {
+		removeTemplateAnnotation(dpt, EngineStatusAnnotation)
+
+		return
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[EngineStatusAnnotation] = value
+}
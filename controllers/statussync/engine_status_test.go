@@ -0,0 +1,85 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func engineStatusObject(name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("policy.open-cluster-management.io/v1")
+	obj.SetKind("ConfigurationPolicy")
+	obj.SetNamespace("cluster-ns")
+	obj.SetName(name)
+
+	return obj
+}
+
+func TestEngineStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	obj := engineStatusObject("config")
+	Expect(engineStatus(obj)).To(BeEmpty())
+
+	// The annotation is copied verbatim
+	obj.SetAnnotations(map[string]string{EngineStatusAnnotation: `{"violations": 3}`})
+	Expect(engineStatus(obj)).To(Equal(`{"violations": 3}`))
+
+	// The status field takes precedence
+	Expect(unstructured.SetNestedMap(obj.Object, map[string]interface{}{
+		"violations": int64(2), "url": "https://example.com",
+	}, "status", "engineStatus")).To(Succeed())
+	Expect(engineStatus(obj)).To(Equal(`{"url":"https://example.com","violations":2}`))
+
+	Expect(unstructured.SetNestedField(obj.Object, "text", "status", "engineStatus")).To(Succeed())
+	_, err := engineStatus(obj)
+	Expect(err).To(HaveOccurred())
+
+	unstructured.RemoveNestedField(obj.Object, "status")
+	obj.SetAnnotations(map[string]string{EngineStatusAnnotation: "[1, 2]"})
+	_, err = engineStatus(obj)
+	Expect(err).To(HaveOccurred())
+
+	obj.SetAnnotations(map[string]string{
+		EngineStatusAnnotation: `{"message": "` + strings.Repeat("x", maxEngineStatusSize) + `"}`,
+	})
+	_, err = engineStatus(obj)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestApplyEngineStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	obj := engineStatusObject("config")
+	obj.SetAnnotations(map[string]string{EngineStatusAnnotation: `{"violations":3}`})
+
+	// The template object is read from the API server rather than the cache of the ManagedClient
+	r := &PolicyReconciler{
+		ManagedClient: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build(),
+		ManagedAPIReader: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).
+			WithRuntimeObjects(obj).Build(),
+	}
+	gvk := &schema.GroupVersionKind{
+		Group: "policy.open-cluster-management.io", Version: "v1", Kind: "ConfigurationPolicy",
+	}
+
+	dpt := &policiesv1.DetailsPerTemplate{TemplateMeta: metav1.ObjectMeta{Name: "config"}}
+	r.applyEngineStatus(context.TODO(), gvk, "cluster-ns", dpt)
+	Expect(dpt.TemplateMeta.Annotations).To(HaveKeyWithValue(EngineStatusAnnotation, `{"violations":3}`))
+
+	// The engine status is removed with the template object
+	dpt.TemplateMeta.Name = "deleted"
+	r.applyEngineStatus(context.TODO(), gvk, "cluster-ns", dpt)
+	Expect(dpt.TemplateMeta.Annotations).ToNot(HaveKey(EngineStatusAnnotation))
+}
@@ -4,51 +4,58 @@
 package statussync
 
 import (
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
 
 var policiesv1APIVersion = policiesv1.SchemeGroupVersion.Group + "/" + policiesv1.SchemeGroupVersion.Version
 
-var eventPredicateFuncs = predicate.Funcs{
-	UpdateFunc: func(e event.UpdateEvent) bool {
-		eventObjNew, eventObjNewOK := e.ObjectNew.(*corev1.Event)
-		if !eventObjNewOK {
-			return false
-		}
-		// eventObjOld := e.ObjectOld.(*corev1.Event)
-		if eventObjNew.InvolvedObject.Kind == policiesv1.Kind &&
-			eventObjNew.InvolvedObject.APIVersion == policiesv1APIVersion {
-			return true
-		}
+// EventCacheSelector returns the selector of the events to cache on the managed cluster. Only the events involving a
+// policy are cached so that the events of other workloads in the cluster namespace don't use memory. The reason of the
+// events can't be filtered by prefix in the API server, so the events that aren't compliance events are filtered out
+// by the event predicate instead.
+func EventCacheSelector() cache.ObjectSelector {
+	return cache.ObjectSelector{
+		Field: fields.SelectorFromSet(fields.Set{
+			"involvedObject.kind":       policiesv1.Kind,
+			"involvedObject.apiVersion": policiesv1APIVersion,
+		}),
+	}
+}
 
+// isComplianceEvent returns true if the input object is a compliance event on a policy.
+func isComplianceEvent(obj client.Object) bool {
+	eventObj, ok := obj.(*corev1.Event)
+	if !ok {
 		return false
+	}
+
+	// Match the case insensitivity of the reason parsing in the reconcile
+	return eventObj.InvolvedObject.Kind == policiesv1.Kind &&
+		eventObj.InvolvedObject.APIVersion == policiesv1APIVersion &&
+		strings.HasPrefix(
+			strings.ToLower(eventObj.Reason), strings.TrimSpace(utils.ComplianceEventReasonPrefix),
+		)
+}
+
+var eventPredicateFuncs = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return isComplianceEvent(e.ObjectNew)
 	},
 	CreateFunc: func(e event.CreateEvent) bool {
-		eventObj, eventObjOk := e.Object.(*corev1.Event)
-		if !eventObjOk {
-			return false
-		}
-		if eventObj.InvolvedObject.Kind == policiesv1.Kind &&
-			eventObj.InvolvedObject.APIVersion == policiesv1APIVersion {
-			return true
-		}
-
-		return false
+		return isComplianceEvent(e.Object)
 	},
 	GenericFunc: func(e event.GenericEvent) bool {
-		eventObj, eventObjOk := e.Object.(*corev1.Event)
-		if !eventObjOk {
-			return false
-		}
-		if eventObj.InvolvedObject.Kind == policiesv1.Kind &&
-			eventObj.InvolvedObject.APIVersion == policiesv1APIVersion {
-			return true
-		}
-
-		return false
+		return isComplianceEvent(e.Object)
 	},
 	DeleteFunc: func(e event.DeleteEvent) bool {
 		return false
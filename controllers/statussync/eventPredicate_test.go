@@ -0,0 +1,44 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestEventPredicate(t *testing.T) {
+	RegisterTestingT(t)
+
+	complianceEvent := &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: policiesv1.Kind, APIVersion: policiesv1APIVersion},
+		Reason:         "policy: managed/case1-config-policy",
+	}
+	Expect(eventPredicateFuncs.Create(event.CreateEvent{Object: complianceEvent})).To(BeTrue())
+
+	// The reason is parsed case insensitively
+	complianceEvent.Reason = "Policy: managed/case1-config-policy"
+	Expect(eventPredicateFuncs.Generic(event.GenericEvent{Object: complianceEvent})).To(BeTrue())
+
+	syncEvent := complianceEvent.DeepCopy()
+	syncEvent.Reason = "PolicyStatusSync"
+	Expect(eventPredicateFuncs.Update(event.UpdateEvent{ObjectOld: syncEvent, ObjectNew: syncEvent})).To(BeFalse())
+
+	podEvent := complianceEvent.DeepCopy()
+	podEvent.InvolvedObject = corev1.ObjectReference{Kind: "Pod", APIVersion: "v1"}
+	Expect(eventPredicateFuncs.Create(event.CreateEvent{Object: podEvent})).To(BeFalse())
+
+	Expect(EventCacheSelector().Field.Matches(fields.Set{
+		"involvedObject.kind":       policiesv1.Kind,
+		"involvedObject.apiVersion": policiesv1APIVersion,
+	})).To(BeTrue())
+	Expect(EventCacheSelector().Field.Matches(fields.Set{
+		"involvedObject.kind":       "Pod",
+		"involvedObject.apiVersion": "v1",
+	})).To(BeFalse())
+}
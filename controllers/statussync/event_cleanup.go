@@ -0,0 +1,43 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deletePolicyEvents deletes the events involving the input deleted policy on the managed cluster, such as its
+// compliance events, so that they don't linger until they expire and aren't mistaken for the compliance events of a
+// future policy with the same name. It returns the number of deleted events.
+func (r *PolicyReconciler) deletePolicyEvents(ctx context.Context, policy types.NamespacedName) (int, error) {
+	eventList := &corev1.EventList{}
+
+	err := r.ManagedClient.List(ctx, eventList, client.InNamespace(policy.Namespace))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+
+	for i := range eventList.Items {
+		involvedObject := eventList.Items[i].InvolvedObject
+		if involvedObject.Kind != policiesv1.Kind || involvedObject.Name != policy.Name {
+			continue
+		}
+
+		err := r.ManagedClient.Delete(ctx, &eventList.Items[i])
+		if err != nil && !errors.IsNotFound(err) {
+			return deleted, err
+		}
+
+		deleted++
+	}
+
+	return deleted, nil
+}
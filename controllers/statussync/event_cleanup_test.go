@@ -0,0 +1,51 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func cleanupEvent(name string, kind string, involvedName string) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "cluster-ns"},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: involvedName, Namespace: "cluster-ns"},
+	}
+}
+
+func TestDeletePolicyEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	r := &PolicyReconciler{ManagedClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		cleanupEvent("policy.1", policiesv1.Kind, "policy"),
+		cleanupEvent("policy.2", policiesv1.Kind, "policy"),
+		cleanupEvent("other-policy.1", policiesv1.Kind, "other-policy"),
+		cleanupEvent("pod.1", "Pod", "policy"),
+	).Build()}
+
+	deleted, err := r.deletePolicyEvents(context.TODO(), types.NamespacedName{Namespace: "cluster-ns", Name: "policy"})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(deleted).To(Equal(2))
+
+	remaining := &corev1.EventList{}
+	Expect(r.ManagedClient.List(context.TODO(), remaining)).To(Succeed())
+
+	names := []string{}
+	for _, event := range remaining.Items {
+		names = append(names, event.Name)
+	}
+
+	Expect(names).To(ConsistOf("other-policy.1", "pod.1"))
+}
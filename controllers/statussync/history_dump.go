@@ -0,0 +1,108 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DumpHistoryAnnotation is set to "true" on a replicated policy on the Hub to request the compliance history of
+	// the policy to be written to a ConfigMap in the cluster namespace on the Hub. The annotation is removed once the
+	// history is written.
+	DumpHistoryAnnotation = "policy.open-cluster-management.io/dump-history"
+	// HistoryDumpKey is the key in the history dump ConfigMap that contains the compliance history.
+	HistoryDumpKey = "history"
+	// historyDumpPolicyLabel is set on the history dump ConfigMap to the name of the policy.
+	historyDumpPolicyLabel = "policy.open-cluster-management.io/policy"
+)
+
+// templateHistory is the compliance history of a policy template in the history dump ConfigMap.
+type templateHistory struct {
+	Template string                         `json:"template"`
+	History  []policiesv1.ComplianceHistory `json:"history"`
+}
+
+// historyDumpRequested returns true if the input Hub policy has the DumpHistoryAnnotation set to "true".
+func historyDumpRequested(hubPlc *policiesv1.Policy) bool {
+	return strings.EqualFold(hubPlc.GetAnnotations()[DumpHistoryAnnotation], "true")
+}
+
+// historyDumpName returns the name of the history dump ConfigMap of the input policy.
+func historyDumpName(policyName string) string {
+	return policyName + "-history"
+}
+
+// dumpHistory writes the input compliance history of the input Hub policy, which is not pruned unlike the history in
+// the policy status, to a ConfigMap owned by the policy in the cluster namespace on the Hub. The history is only as
+// complete as its sources: the persisted history store when it is enabled, otherwise the pruned history in the policy
+// status and the compliance events which were not garbage collected yet. The DumpHistoryAnnotation is then removed from
+// the Hub policy.
+func (r *PolicyReconciler) dumpHistory(
+	ctx context.Context, hubPlc *policiesv1.Policy, history []templateHistory,
+) error {
+	historyYAML, err := yaml.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to encode the compliance history: %w", err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      historyDumpName(hubPlc.GetName()),
+			Namespace: hubPlc.GetNamespace(),
+			Labels:    map[string]string{historyDumpPolicyLabel: hubPlc.GetName()},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(hubPlc, policiesv1.GroupVersion.WithKind(policiesv1.Kind)),
+			},
+		},
+		Data: map[string]string{HistoryDumpKey: string(historyYAML)},
+	}
+
+	existing := &corev1.ConfigMap{}
+
+	err = r.HubClient.Get(ctx, client.ObjectKeyFromObject(configMap), existing)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get the history dump ConfigMap: %w", err)
+		}
+
+		if err := r.HubClient.Create(ctx, configMap); err != nil {
+			return fmt.Errorf("failed to create the history dump ConfigMap: %w", err)
+		}
+	} else {
+		existing.Labels = configMap.Labels
+		existing.OwnerReferences = configMap.OwnerReferences
+		existing.Data = configMap.Data
+
+		if err := r.HubClient.Update(ctx, existing); err != nil {
+			return fmt.Errorf("failed to update the history dump ConfigMap: %w", err)
+		}
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{DumpHistoryAnnotation: nil},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.HubClient.Patch(ctx, hubPlc, client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return fmt.Errorf("failed to remove the %s annotation: %w", DumpHistoryAnnotation, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDumpHistory(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	hubPlc := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "policies.policy",
+			Namespace:   "managed",
+			Annotations: map[string]string{DumpHistoryAnnotation: "true", "other": "annotation"},
+		},
+	}
+	Expect(historyDumpRequested(hubPlc)).To(BeTrue())
+
+	hubClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubPlc).Build()
+	r := &PolicyReconciler{HubClient: hubClient}
+
+	history := []templateHistory{{
+		Template: "template",
+		History:  []policiesv1.ComplianceHistory{{EventName: "event", Message: "Compliant; no violations"}},
+	}}
+
+	// Write the dump twice to cover updating an existing ConfigMap
+	for i := 0; i < 2; i++ {
+		Expect(r.dumpHistory(context.TODO(), hubPlc, history)).To(Succeed())
+	}
+
+	configMap := &corev1.ConfigMap{}
+	Expect(hubClient.Get(
+		context.TODO(), types.NamespacedName{Namespace: "managed", Name: "policies.policy-history"}, configMap,
+	)).To(Succeed())
+	Expect(configMap.OwnerReferences).To(HaveLen(1))
+	Expect(configMap.OwnerReferences[0].Name).To(Equal("policies.policy"))
+
+	dumped := []templateHistory{}
+	Expect(yaml.Unmarshal([]byte(configMap.Data[HistoryDumpKey]), &dumped)).To(Succeed())
+	Expect(dumped).To(HaveLen(1))
+	Expect(dumped[0].Template).To(Equal("template"))
+	Expect(dumped[0].History[0].Message).To(Equal("Compliant; no violations"))
+
+	updatedPlc := &policiesv1.Policy{}
+	Expect(hubClient.Get(context.TODO(), client.ObjectKeyFromObject(hubPlc), updatedPlc)).To(Succeed())
+	Expect(updatedPlc.Annotations).ToNot(HaveKey(DumpHistoryAnnotation))
+	Expect(updatedPlc.Annotations).To(HaveKeyWithValue("other", "annotation"))
+	Expect(historyDumpRequested(updatedPlc)).To(BeFalse())
+}
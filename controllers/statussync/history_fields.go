@@ -0,0 +1,106 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// HistoryFieldsAnnotation is set on the template metadata in the policy status to the JSON object mapping the event
+// names of the compliance history entries to their structured fields: the kind and severity of the policy template and
+// the generation of the policy which it was evaluated for. The fields come from the annotations of the compliance
+// events, and otherwise from the policy template in the policy when the entry was recorded.
+const HistoryFieldsAnnotation = "policy.open-cluster-management.io/history-fields"
+
+// complianceEventFields returns the structured fields set in the annotations of the input compliance events involving
+// the input policy, by event name. The events without fields are skipped.
+func complianceEventFields(policyName string, events []corev1.Event) map[string]utils.ComplianceEventFields {
+	fields := map[string]utils.ComplianceEventFields{}
+
+	for _, event := range events {
+		if complianceEventTemplate(policyName, event) == "" {
+			continue
+		}
+
+		eventFields := utils.ComplianceEventFieldsFromAnnotations(event.GetAnnotations())
+		if !eventFields.IsZero() {
+			fields[event.GetName()] = eventFields
+		}
+	}
+
+	return fields
+}
+
+// applyHistoryFields sets the HistoryFieldsAnnotation annotation on the template metadata of the input policy template
+// details of the input policy. The entries which already have fields keep them. The fields of the other entries come
+// from their input event fields, completed by the kind and severity of their policy template in the policy. The
+// entries not among the input known event names were just recorded, so they also default to the generation of the
+// policy. The fields of the entries no longer in the history are dropped.
+func applyHistoryFields(
+	instance *policiesv1.Policy,
+	details []*policiesv1.DetailsPerTemplate,
+	known map[string]map[string]bool,
+	eventFields map[string]utils.ComplianceEventFields,
+) {
+	templateFields := make(map[string]utils.ComplianceEventFields, len(instance.Spec.PolicyTemplates))
+
+	for _, policyT := range instance.Spec.PolicyTemplates {
+		if tName, fields := utils.TemplateEventFields(instance, policyT); tName != "" {
+			templateFields[tName] = fields
+		}
+	}
+
+	for _, dpt := range details {
+		previous := map[string]utils.ComplianceEventFields{}
+
+		if value, ok := dpt.TemplateMeta.Annotations[HistoryFieldsAnnotation]; ok {
+			if err := json.Unmarshal([]byte(value), &previous); err != nil {
+				log.V(2).Info("Ignoring the invalid history fields", "PolicyTemplate", dpt.TemplateMeta.Name)
+			}
+		}
+
+		fallback := templateFields[dpt.TemplateMeta.Name]
+		fields := map[string]utils.ComplianceEventFields{}
+
+		for _, entry := range dpt.History {
+			if previousFields, ok := previous[entry.EventName]; ok {
+				fields[entry.EventName] = previousFields
+
+				continue
+			}
+
+			entryFallback := fallback
+			// The policy generation of an entry recorded before the fields were set is unknown
+			if known[dpt.TemplateMeta.Name][entry.EventName] {
+				entryFallback.PolicyGeneration = 0
+			}
+
+			if entryFields := eventFields[entry.EventName].Or(entryFallback); !entryFields.IsZero() {
+				fields[entry.EventName] = entryFields
+			}
+		}
+
+		if len(fields) == 0 {
+			removeTemplateAnnotation(dpt, HistoryFieldsAnnotation)
+
+			continue
+		}
+
+		// A map is marshaled with sorted keys, so the annotation is stable
+		value, err := json.Marshal(fields)
+		if err != nil {
+			continue
+		}
+
+		if dpt.TemplateMeta.Annotations == nil {
+			dpt.TemplateMeta.Annotations = map[string]string{}
+		}
+
+		dpt.TemplateMeta.Annotations[HistoryFieldsAnnotation] = string(value)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestApplyHistoryFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	instance := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Generation: 3},
+		Spec: policiesv1.PolicySpec{
+			PolicyTemplates: []*policiesv1.PolicyTemplate{{ObjectDefinition: runtime.RawExtension{
+				Raw: []byte(`{"kind":"ConfigurationPolicy","metadata":{"name":"config-policy"},` +
+					`"spec":{"severity":"High"}}`),
+			}}},
+		},
+	}
+
+	annotated := corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "event.3",
+			Annotations: utils.ComplianceEventFields{
+				Kind: "ConfigurationPolicy", Severity: "critical", PolicyGeneration: 2,
+			}.Annotations(),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: policiesv1.Kind, APIVersion: policiesv1APIVersion, Name: "policy",
+		},
+		Reason: "policy: cluster/config-policy",
+	}
+	plain := annotated
+	plain.Name = "event.4"
+	plain.Annotations = nil
+
+	eventFields := complianceEventFields("policy", []corev1.Event{annotated, plain})
+	Expect(eventFields).To(HaveLen(1))
+	Expect(eventFields["event.3"].Severity).To(Equal("critical"))
+
+	dpt := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{Name: "config-policy"},
+		History:      []policiesv1.ComplianceHistory{{EventName: "event.1"}},
+	}
+
+	// The entries already in the status don't default to the current generation of the policy
+	known := historyEventNames([]*policiesv1.DetailsPerTemplate{dpt})
+	dpt.History = append(
+		[]policiesv1.ComplianceHistory{{EventName: "event.4"}, {EventName: "event.3"}}, dpt.History...,
+	)
+
+	applyHistoryFields(instance, []*policiesv1.DetailsPerTemplate{dpt}, known, eventFields)
+	Expect(dpt.TemplateMeta.Annotations[HistoryFieldsAnnotation]).To(Equal(
+		`{"event.1":{"kind":"ConfigurationPolicy","severity":"high"},` +
+			`"event.3":{"kind":"ConfigurationPolicy","severity":"critical","policyGeneration":2},` +
+			`"event.4":{"kind":"ConfigurationPolicy","severity":"high","policyGeneration":3}}`,
+	))
+
+	// The entries keep their fields and the dropped entries are removed
+	instance.Generation = 4
+	known = historyEventNames([]*policiesv1.DetailsPerTemplate{dpt})
+	dpt.History = []policiesv1.ComplianceHistory{{EventName: "event.4"}}
+
+	applyHistoryFields(instance, []*policiesv1.DetailsPerTemplate{dpt}, known, nil)
+	Expect(dpt.TemplateMeta.Annotations[HistoryFieldsAnnotation]).To(Equal(
+		`{"event.4":{"kind":"ConfigurationPolicy","severity":"high","policyGeneration":3}}`,
+	))
+
+	dpt.History = nil
+
+	applyHistoryFields(instance, []*policiesv1.DetailsPerTemplate{dpt}, known, nil)
+	Expect(dpt.TemplateMeta.Annotations).To(BeNil())
+}
@@ -0,0 +1,40 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/pkg/compliance"
+)
+
+// internHistory replaces the message of each compliance history entry of the input policy template details with a
+// reference to the newest entry with the same message when the reference is shorter, so that the long messages of a
+// flapping policy are only stored once per policy template. The compliance state prefix of the messages is kept, so
+// that the compliance state of each entry can still be determined on the Hub. The messages are restored with
+// compliance.ExpandHistory.
+func internHistory(details []*policiesv1.DetailsPerTemplate) {
+	for _, dpt := range details {
+		if dpt == nil {
+			continue
+		}
+
+		// The index of the newest entry with each message. The history is sorted from the newest entry.
+		first := map[string]int{}
+
+		for i := range dpt.History {
+			message := dpt.History[i].Message
+
+			index, ok := first[message]
+			if !ok {
+				first[message] = i
+
+				continue
+			}
+
+			if reference := compliance.InternedMessage(message, index); len(reference) < len(message) {
+				dpt.History[i].Message = reference
+			}
+		}
+	}
+}
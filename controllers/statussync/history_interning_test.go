@@ -0,0 +1,50 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/pkg/compliance"
+)
+
+func TestInternHistory(t *testing.T) {
+	RegisterTestingT(t)
+
+	nonCompliant := "NonCompliant; violation - " + strings.Repeat("the object is missing, ", 5)
+	compliant := "Compliant; notification - " + strings.Repeat("the object exists as specified, ", 5)
+	messages := []string{nonCompliant, compliant, nonCompliant, compliant, nonCompliant, "NonCompliant; short"}
+
+	history := make([]policiesv1.ComplianceHistory, 0, len(messages))
+	for _, message := range messages {
+		history = append(history, policiesv1.ComplianceHistory{Message: message})
+	}
+
+	details := []*policiesv1.DetailsPerTemplate{{TemplateMeta: metav1.ObjectMeta{Name: "config-policy"}}}
+	details[0].History = history
+
+	internHistory(details)
+
+	Expect(details[0].History[0].Message).To(Equal(nonCompliant))
+	Expect(details[0].History[1].Message).To(Equal(compliant))
+	Expect(details[0].History[2].Message).To(Equal("NonCompliant; (same message as history entry 0)"))
+	Expect(details[0].History[3].Message).To(Equal("Compliant; (same message as history entry 1)"))
+	Expect(details[0].History[4].Message).To(Equal("NonCompliant; (same message as history entry 0)"))
+	Expect(details[0].History[5].Message).To(Equal("NonCompliant; short"))
+
+	compliance.ExpandHistory(details[0].History)
+
+	for i, message := range messages {
+		Expect(details[0].History[i].Message).To(Equal(message))
+	}
+
+	// A reference which doesn't point to a newer entry is left as is
+	invalid := []policiesv1.ComplianceHistory{{Message: "NonCompliant; (same message as history entry 3)"}}
+	compliance.ExpandHistory(invalid)
+	Expect(invalid[0].Message).To(Equal("NonCompliant; (same message as history entry 3)"))
+}
@@ -0,0 +1,46 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"strconv"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+const (
+	// HistoryLimitAnnotation can be set on a policy on the Hub to the number of compliance history entries kept for
+	// each of its policy templates, overriding the limit of the controller.
+	HistoryLimitAnnotation = "policy.open-cluster-management.io/compliance-history-limit"
+	// maxHistoryLimit caps the history limit set on a policy so that a policy can't grow its status unbounded.
+	maxHistoryLimit = 100
+)
+
+// templateHistoryLimit returns the number of compliance history entries kept for each policy template of the input
+// policy on the Hub, from its HistoryLimitAnnotation annotation or else from the controller limit. An invalid
+// annotation is ignored.
+func (r *PolicyReconciler) templateHistoryLimit(hubPlc *policiesv1.Policy) int {
+	limit := r.HistoryLimit
+	if limit <= 0 {
+		limit = historyLimit
+	}
+
+	value, ok := hubPlc.GetAnnotations()[HistoryLimitAnnotation]
+	if !ok {
+		return limit
+	}
+
+	policyLimit, err := strconv.Atoi(value)
+	if err != nil || policyLimit <= 0 {
+		log.Info("Ignoring the invalid compliance history limit annotation", "policy", hubPlc.GetName(),
+			"value", value)
+
+		return limit
+	}
+
+	if policyLimit > maxHistoryLimit {
+		return maxHistoryLimit
+	}
+
+	return policyLimit
+}
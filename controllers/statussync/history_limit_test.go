@@ -0,0 +1,28 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestTemplateHistoryLimit(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "default.policy"}}
+
+	r := &PolicyReconciler{}
+	Expect(r.templateHistoryLimit(hubPlc)).To(Equal(historyLimit))
+
+	r.HistoryLimit = 25
+	Expect(r.templateHistoryLimit(hubPlc)).To(Equal(25))
+
+	for value, expected := range map[string]int{"5": 5, "1000": maxHistoryLimit, "0": 25, "many": 25} {
+		hubPlc.SetAnnotations(map[string]string{HistoryLimitAnnotation: value})
+		Expect(r.templateHistoryLimit(hubPlc)).To(Equal(expected), "annotation value %s", value)
+	}
+}
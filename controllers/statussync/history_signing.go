@@ -0,0 +1,186 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
+)
+
+const (
+	// HistorySignaturesAnnotation is set on the template metadata in the policy status to the JSON list of the HMAC
+	// signatures of the compliance history entries of the policy template, in the same order as the history.
+	HistorySignaturesAnnotation = "policy.open-cluster-management.io/history-signatures"
+	// historySigningLabel is the HKDF info deriving the signing key from the input key, so that the policy encryption
+	// key isn't used directly for another purpose.
+	historySigningLabel = "policy.open-cluster-management.io/history-signatures"
+	// encryptionKeyTTL is how long the policy encryption key is used before it is read again.
+	encryptionKeyTTL = time.Minute
+)
+
+// ErrHistorySignature is returned when a compliance history entry doesn't match its signature.
+var ErrHistorySignature = errors.New("the compliance history doesn't match its signatures")
+
+// HistorySigner signs the compliance history entries of the policy templates with an HMAC so that auditors on the Hub
+// can verify that the status history wasn't altered in transit or by a compromised intermediate.
+type HistorySigner struct {
+	// Key is a dedicated signing key. If it is empty, the policy encryption key synced from the Hub to the cluster
+	// namespace is used.
+	Key []byte
+	// Client reads the policy encryption key Secret in the Namespace when there is no dedicated key.
+	Client    kubernetes.Interface
+	Namespace string
+	cachedKey []byte
+	expiry    time.Time
+	lock      sync.Mutex
+}
+
+// key returns the signing key, reading the policy encryption key again once it expires.
+func (s *HistorySigner) key(ctx context.Context) ([]byte, error) {
+	if len(s.Key) != 0 {
+		return s.Key, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.cachedKey != nil && time.Now().Before(s.expiry) {
+		return s.cachedKey, nil
+	}
+
+	secret, err := s.Client.CoreV1().Secrets(s.Namespace).Get(ctx, secretsync.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the policy encryption key: %w", err)
+	}
+
+	key := secret.Data["key"]
+	if len(key) == 0 {
+		return nil, fmt.Errorf("the %s Secret doesn't have a key", secretsync.SecretName)
+	}
+
+	s.cachedKey = key
+	s.expiry = time.Now().Add(encryptionKeyTTL)
+
+	return key, nil
+}
+
+// signingKey derives the HMAC key of the history signatures from the input key with HKDF-SHA256.
+func signingKey(key []byte) ([]byte, error) {
+	derived := make([]byte, sha256.Size)
+
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(historySigningLabel)), derived); err != nil {
+		return nil, fmt.Errorf("failed to derive the history signing key: %w", err)
+	}
+
+	return derived, nil
+}
+
+// historySignatures returns the base64 encoded HMAC-SHA256 signatures of the input compliance history entries of the
+// input policy template with the input derived signing key. The signatures are chained: each one also covers the
+// number of entries and the signature of the previous entry, so that an entry can't be removed or moved along with
+// its signature without the following signatures no longer matching.
+func historySignatures(key []byte, templateName string, history []policiesv1.ComplianceHistory) []string {
+	signatures := make([]string, 0, len(history))
+	previous := ""
+
+	for _, entry := range history {
+		mac := hmac.New(sha256.New, key)
+		// The fields are separated by a newline, which can't be in the template or event names
+		_, _ = fmt.Fprintf(mac, "%s\n%d\n%s\n%s\n%s\n%s", previous, len(history), templateName, entry.EventName,
+			entry.LastTimestamp.UTC().Format(time.RFC3339), entry.Message)
+
+		previous = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		signatures = append(signatures, previous)
+	}
+
+	return signatures
+}
+
+// sign sets the HistorySignaturesAnnotation annotation on the template metadata of the input policy template details
+// to the signatures of their compliance history entries. A nil HistorySigner does nothing.
+func (s *HistorySigner) sign(ctx context.Context, details []*policiesv1.DetailsPerTemplate) error {
+	if s == nil {
+		return nil
+	}
+
+	key, err := s.key(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err = signingKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, dpt := range details {
+		if dpt == nil {
+			continue
+		}
+
+		if len(dpt.History) == 0 {
+			removeTemplateAnnotation(dpt, HistorySignaturesAnnotation)
+
+			continue
+		}
+
+		value, err := json.Marshal(historySignatures(key, dpt.TemplateMeta.Name, dpt.History))
+		if err != nil {
+			return err
+		}
+
+		if dpt.TemplateMeta.Annotations == nil {
+			dpt.TemplateMeta.Annotations = map[string]string{}
+		}
+
+		dpt.TemplateMeta.Annotations[HistorySignaturesAnnotation] = string(value)
+	}
+
+	return nil
+}
+
+// VerifyHistorySignatures verifies the compliance history of the input policy template details against the signatures
+// in its HistorySignaturesAnnotation annotation with the input key, which is the key the history was signed with
+// before the signing key is derived from it. It returns an ErrHistorySignature error if any entry isn't signed or
+// doesn't match its signature, such as when an entry was altered, removed, or moved.
+func VerifyHistorySignatures(key []byte, dpt *policiesv1.DetailsPerTemplate) error {
+	signatures := []string{}
+
+	if value, ok := dpt.TemplateMeta.Annotations[HistorySignaturesAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &signatures); err != nil {
+			return fmt.Errorf("%w: the signatures are invalid: %s", ErrHistorySignature, err)
+		}
+	}
+
+	if len(signatures) != len(dpt.History) {
+		return fmt.Errorf("%w: %d entries have %d signatures", ErrHistorySignature, len(dpt.History), len(signatures))
+	}
+
+	key, err := signingKey(key)
+	if err != nil {
+		return err
+	}
+
+	for i, expected := range historySignatures(key, dpt.TemplateMeta.Name, dpt.History) {
+		if !hmac.Equal([]byte(expected), []byte(signatures[i])) {
+			return fmt.Errorf("%w: the entry %d (%s) was altered", ErrHistorySignature, i, dpt.History[i].EventName)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
+)
+
+func signedDetails() []*policiesv1.DetailsPerTemplate {
+	return []*policiesv1.DetailsPerTemplate{{
+		TemplateMeta: metav1.ObjectMeta{Name: "config-policy"},
+		History: []policiesv1.ComplianceHistory{
+			{
+				LastTimestamp: metav1.NewTime(time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)),
+				Message:       "NonCompliant; violation",
+				EventName:     "policy.16eb1b2e1c0c3d2a",
+			},
+			{
+				LastTimestamp: metav1.NewTime(time.Date(2022, 5, 1, 9, 0, 0, 0, time.UTC)),
+				Message:       "Compliant; notification",
+				EventName:     "policy.16eb1b2e1c0c3d29",
+			},
+		},
+	}}
+}
+
+func TestHistorySigner(t *testing.T) {
+	RegisterTestingT(t)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretsync.SecretName, Namespace: "cluster1"},
+		Data:       map[string][]byte{"key": []byte("encryption-key")},
+	}
+	signer := &HistorySigner{Client: fake.NewSimpleClientset(secret), Namespace: "cluster1"}
+
+	var nilSigner *HistorySigner
+	Expect(nilSigner.sign(context.TODO(), signedDetails())).To(Succeed())
+
+	details := signedDetails()
+	Expect(signer.sign(context.TODO(), details)).To(Succeed())
+	Expect(details[0].TemplateMeta.Annotations).To(HaveKey(HistorySignaturesAnnotation))
+	Expect(VerifyHistorySignatures([]byte("encryption-key"), details[0])).To(Succeed())
+
+	// Another key doesn't verify the signatures
+	err := VerifyHistorySignatures([]byte("another-key"), details[0])
+	Expect(errors.Is(err, ErrHistorySignature)).To(BeTrue())
+
+	// An altered entry doesn't match its signature
+	details[0].History[1].Message = "Compliant; altered"
+	err = VerifyHistorySignatures([]byte("encryption-key"), details[0])
+	Expect(err).To(MatchError(ContainSubstring("the entry 1 (policy.16eb1b2e1c0c3d29) was altered")))
+
+	// A removed entry doesn't match the number of signatures
+	details[0].History = details[0].History[:1]
+	err = VerifyHistorySignatures([]byte("encryption-key"), details[0])
+	Expect(errors.Is(err, ErrHistorySignature)).To(BeTrue())
+
+	// An entry removed along with its signature breaks the chain of signatures
+	details = signedDetails()
+	Expect(signer.sign(context.TODO(), details)).To(Succeed())
+
+	signatures := []string{}
+	Expect(json.Unmarshal([]byte(details[0].TemplateMeta.Annotations[HistorySignaturesAnnotation]), &signatures)).
+		To(Succeed())
+
+	removed := details[0].DeepCopy()
+	removed.History = removed.History[1:]
+	removed.TemplateMeta.Annotations[HistorySignaturesAnnotation] = `["` + signatures[1] + `"]`
+	err = VerifyHistorySignatures([]byte("encryption-key"), removed)
+	Expect(errors.Is(err, ErrHistorySignature)).To(BeTrue())
+
+	// Neither do entries moved along with their signatures
+	moved := details[0].DeepCopy()
+	moved.History[0], moved.History[1] = moved.History[1], moved.History[0]
+	moved.TemplateMeta.Annotations[HistorySignaturesAnnotation] = `["` + signatures[1] + `","` + signatures[0] + `"]`
+	err = VerifyHistorySignatures([]byte("encryption-key"), moved)
+	Expect(errors.Is(err, ErrHistorySignature)).To(BeTrue())
+
+	// The encryption key isn't used directly as the HMAC key
+	Expect(historySignatures([]byte("encryption-key"), "config-policy", details[0].History)).ToNot(Equal(signatures))
+
+	// A dedicated key is used instead of the policy encryption key
+	dedicated := &HistorySigner{Key: []byte("dedicated-key")}
+	details = signedDetails()
+	Expect(dedicated.sign(context.TODO(), details)).To(Succeed())
+	Expect(VerifyHistorySignatures([]byte("dedicated-key"), details[0])).To(Succeed())
+
+	// The encryption key is required when there is no dedicated key
+	missing := &HistorySigner{Client: fake.NewSimpleClientset(), Namespace: "cluster1"}
+	Expect(missing.sign(context.TODO(), signedDetails())).NotTo(Succeed())
+}
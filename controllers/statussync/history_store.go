@@ -0,0 +1,215 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultHistoryStoreLimit is the number of compliance history entries kept for each policy template in the history
+// store when no limit is set.
+const defaultHistoryStoreLimit = 100
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;delete
+
+// HistoryStore persists the compliance history of the policies in a ConfigMap per policy in the cluster namespace on
+// the managed cluster. Since the compliance events are garbage collected after an hour by default, this keeps more
+// history than the pruned policy status, and the history is restored on a policy without a status, such as when the
+// policy is recreated on the managed cluster. The ConfigMap of a policy is deleted once the policy is deleted from both
+// the Hub and the managed cluster.
+type HistoryStore struct {
+	Client client.Client
+	// Limit is the number of compliance history entries kept for each policy template. If it is zero, 100 entries are
+	// kept.
+	Limit int
+}
+
+// historyStoreName returns the name of the history store ConfigMap of the input policy.
+func historyStoreName(policyName string) string {
+	return policyName + "-compliance-history"
+}
+
+// load returns the history store ConfigMap of the input policy and its compliance history, or nil if there is none.
+func (s *HistoryStore) load(
+	ctx context.Context, policy types.NamespacedName,
+) (*corev1.ConfigMap, []templateHistory, error) {
+	configMap := &corev1.ConfigMap{}
+
+	err := s.Client.Get(
+		ctx, types.NamespacedName{Namespace: policy.Namespace, Name: historyStoreName(policy.Name)}, configMap,
+	)
+	if errors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the history store ConfigMap: %w", err)
+	}
+
+	history := []templateHistory{}
+
+	if err := json.Unmarshal([]byte(configMap.Data[HistoryDumpKey]), &history); err != nil {
+		return configMap, nil, fmt.Errorf("failed to decode the stored compliance history: %w", err)
+	}
+
+	return configMap, history, nil
+}
+
+// Restore sets the stored compliance history on the status of the input policy and returns true if it did. The
+// history is only restored on a policy without a compliance history. A nil HistoryStore does nothing.
+func (s *HistoryStore) Restore(ctx context.Context, instance *policiesv1.Policy) (bool, error) {
+	if s == nil || len(instance.Status.Details) != 0 {
+		return false, nil
+	}
+
+	_, history, err := s.load(ctx, client.ObjectKeyFromObject(instance))
+	if err != nil || len(history) == 0 {
+		return false, err
+	}
+
+	for _, tHistory := range history {
+		instance.Status.Details = append(instance.Status.Details, &policiesv1.DetailsPerTemplate{
+			TemplateMeta: metav1.ObjectMeta{Name: tHistory.Template},
+			History:      tHistory.History,
+		})
+	}
+
+	return true, nil
+}
+
+// Save merges the input compliance history of the policy templates of the input policy, sorted from the newest entry,
+// into its stored history, writes it if it changed, and returns the merged history. The stored history of the policy
+// templates no longer in the input history is dropped. A nil HistoryStore returns the input history.
+func (s *HistoryStore) Save(
+	ctx context.Context, instance *policiesv1.Policy, history []templateHistory,
+) ([]templateHistory, error) {
+	if s == nil {
+		return history, nil
+	}
+
+	configMap, stored, err := s.load(ctx, client.ObjectKeyFromObject(instance))
+	if err != nil && configMap == nil {
+		return nil, err
+	}
+
+	storedByTemplate := map[string][]policiesv1.ComplianceHistory{}
+	for _, tHistory := range stored {
+		storedByTemplate[tHistory.Template] = tHistory.History
+	}
+
+	merged := make([]templateHistory, 0, len(history))
+	for _, tHistory := range history {
+		merged = append(merged, templateHistory{
+			Template: tHistory.Template,
+			History:  s.mergeHistory(tHistory.History, storedByTemplate[tHistory.Template]),
+		})
+	}
+
+	content, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the compliance history: %w", err)
+	}
+
+	if configMap == nil {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      historyStoreName(instance.GetName()),
+				Namespace: instance.GetNamespace(),
+				Labels:    map[string]string{historyDumpPolicyLabel: instance.GetName()},
+			},
+			Data: map[string]string{HistoryDumpKey: string(content)},
+		}
+
+		if err := s.Client.Create(ctx, configMap); err != nil {
+			return nil, fmt.Errorf("failed to create the history store ConfigMap: %w", err)
+		}
+
+		return merged, nil
+	}
+
+	if configMap.Data[HistoryDumpKey] == string(content) {
+		return merged, nil
+	}
+
+	configMap.Data = map[string]string{HistoryDumpKey: string(content)}
+
+	if err := s.Client.Update(ctx, configMap); err != nil {
+		return nil, fmt.Errorf("failed to update the history store ConfigMap: %w", err)
+	}
+
+	return merged, nil
+}
+
+// mergeHistory returns the union of the input current and stored compliance history entries, sorted from the newest
+// entry and limited to the store limit. The entries are identified by their timestamp and event name like in the
+// policy status, and the consecutive entries of the same event with the same message are collapsed into the newest
+// one, since the current history isn't deduplicated like the policy status.
+func (s *HistoryStore) mergeHistory(
+	current []policiesv1.ComplianceHistory, stored []policiesv1.ComplianceHistory,
+) []policiesv1.ComplianceHistory {
+	type entryKey struct {
+		timestamp int64
+		eventName string
+	}
+
+	seen := map[entryKey]bool{}
+	merged := make([]policiesv1.ComplianceHistory, 0, len(current)+len(stored))
+
+	for _, entries := range [][]policiesv1.ComplianceHistory{current, stored} {
+		for _, entry := range entries {
+			key := entryKey{timestamp: entry.LastTimestamp.Unix(), eventName: entry.EventName}
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			merged = append(merged, entry)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].LastTimestamp.Time.After(merged[j].LastTimestamp.Time)
+	})
+
+	merged = collapseHistory(merged)
+
+	limit := s.Limit
+	if limit <= 0 {
+		limit = defaultHistoryStoreLimit
+	}
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+
+	return merged
+}
+
+// Delete deletes the history store ConfigMap of the input policy. A nil HistoryStore does nothing.
+func (s *HistoryStore) Delete(ctx context.Context, policy types.NamespacedName) error {
+	if s == nil {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: policy.Namespace, Name: historyStoreName(policy.Name)},
+	}
+
+	err := s.Client.Delete(ctx, configMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the history store ConfigMap: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func storedEntry(hour int, state string) policiesv1.ComplianceHistory {
+	return policiesv1.ComplianceHistory{
+		LastTimestamp: metav1.NewTime(time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)),
+		Message:       state + "; evaluated",
+		EventName:     fmt.Sprintf("policy.%s%d", state, hour),
+	}
+}
+
+func TestHistoryStore(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	store := &HistoryStore{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Limit: 3}
+	ctx := context.TODO()
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster-ns"}}
+
+	// Nothing is restored before the history is stored
+	Expect(store.Restore(ctx, plc)).To(BeFalse())
+
+	_, err := store.Save(ctx, plc, []templateHistory{
+		{Template: "config-policy", History: []policiesv1.ComplianceHistory{storedEntry(2, "C"), storedEntry(1, "N")}},
+	})
+	Expect(err).To(BeNil())
+
+	// The stored entries are kept once their events expired, up to the limit, and the merged history is returned
+	saved, err := store.Save(ctx, plc, []templateHistory{
+		{Template: "config-policy", History: []policiesv1.ComplianceHistory{storedEntry(4, "N"), storedEntry(3, "C")}},
+	})
+	Expect(err).To(BeNil())
+	Expect(saved).To(HaveLen(1))
+	Expect(saved[0].History).To(HaveLen(3))
+
+	Expect(store.Restore(ctx, plc)).To(BeTrue())
+	Expect(plc.Status.Details).To(HaveLen(1))
+	Expect(plc.Status.Details[0].TemplateMeta.Name).To(Equal("config-policy"))
+
+	hours := []int{}
+	for _, entry := range plc.Status.Details[0].History {
+		hours = append(hours, entry.LastTimestamp.UTC().Hour())
+	}
+
+	Expect(hours).To(Equal([]int{4, 3, 2}))
+
+	// The history isn't restored on a policy with a compliance history
+	Expect(store.Restore(ctx, plc)).To(BeFalse())
+
+	Expect(store.Delete(ctx, types.NamespacedName{Namespace: "cluster-ns", Name: "policy"})).To(Succeed())
+	Expect(store.Delete(ctx, types.NamespacedName{Namespace: "cluster-ns", Name: "policy"})).To(Succeed())
+
+	plc.Status.Details = nil
+	Expect(store.Restore(ctx, plc)).To(BeFalse())
+
+	var nilStore *HistoryStore
+
+	saved, err = nilStore.Save(ctx, plc, []templateHistory{{Template: "config-policy"}})
+	Expect(err).To(BeNil())
+	Expect(saved).To(Equal([]templateHistory{{Template: "config-policy"}}))
+}
+
+func TestHistoryStoreRecurringEvent(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	store := &HistoryStore{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	ctx := context.TODO()
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster-ns"}}
+
+	_, err := store.Save(ctx, plc, []templateHistory{
+		{Template: "config-policy", History: []policiesv1.ComplianceHistory{storedEntry(2, "N"), storedEntry(1, "C")}},
+	})
+	Expect(err).To(BeNil())
+
+	// The event recurred with a new timestamp, which is a single entry in the stored history
+	recurred := storedEntry(3, "N")
+	recurred.EventName = storedEntry(2, "N").EventName
+
+	_, err = store.Save(ctx, plc, []templateHistory{
+		{Template: "config-policy", History: []policiesv1.ComplianceHistory{recurred, storedEntry(1, "C")}},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(store.Restore(ctx, plc)).To(BeTrue())
+
+	hours := []int{}
+	for _, entry := range plc.Status.Details[0].History {
+		hours = append(hours, entry.LastTimestamp.UTC().Hour())
+	}
+
+	Expect(hours).To(Equal([]int{3, 1}))
+}
@@ -0,0 +1,53 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"time"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// reportHubSync records the outcome of syncing the status of the input policy to the Hub, where a nil error is a
+// success, and sets or removes the HubSyncDegraded condition on the policy on the managed cluster accordingly. Since
+// the condition is only informational, failing to set it is logged instead of failing the reconcile.
+func (r *PolicyReconciler) reportHubSync(ctx context.Context, instance *policiesv1.Policy, syncErr error) {
+	if r.HubSync == nil {
+		return
+	}
+
+	key := client.ObjectKeyFromObject(instance)
+
+	if syncErr != nil {
+		r.HubSync.RecordFailure(key, syncErr, time.Now())
+	} else {
+		r.HubSync.RecordSuccess(key)
+	}
+
+	condition := r.HubSync.PolicyCondition(key, time.Now())
+	_, wasDegraded := instance.GetAnnotations()[utils.HubSyncDegradedAnnotation]
+
+	patched := instance.DeepCopy()
+
+	changed, err := utils.SetHubSyncDegraded(patched, condition)
+	if err == nil && changed {
+		err = r.ManagedClient.Patch(ctx, patched, client.MergeFrom(instance))
+	}
+
+	if err != nil {
+		log.Error(err, "Failed to set the HubSyncDegraded condition on the policy",
+			"namespace", key.Namespace, "name", key.Name)
+
+		return
+	}
+
+	if condition != nil && !wasDegraded {
+		r.ManagedRecorder.Event(instance, "Warning", utils.HubSyncDegradedCondition,
+			"The policy status failed to be synced to the Hub since "+
+				condition.LastTransitionTime.Format(time.RFC3339)+": "+condition.Message)
+	}
+}
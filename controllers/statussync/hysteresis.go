@@ -0,0 +1,116 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// HysteresisAnnotation is set on the template metadata in the policy status while a change in the compliance state
+// of the policy template is held back by the compliance hysteresis.
+const HysteresisAnnotation = "policy.open-cluster-management.io/compliance-hysteresis"
+
+// ComplianceHysteresis suppresses flapping compliance states by requiring Count consecutive evaluations with the new
+// compliance state within Window before the reported compliance state of a policy template changes. The raw
+// evaluations are still visible in the compliance history. A nil ComplianceHysteresis or a Count of 1 or less
+// disables the hysteresis.
+type ComplianceHysteresis struct {
+	Count  int
+	Window time.Duration
+}
+
+// pendingTransition is the content of the HysteresisAnnotation annotation.
+type pendingTransition struct {
+	State policiesv1.ComplianceState `json:"state"`
+	Count int                        `json:"count"`
+	Since time.Time                  `json:"since"`
+	Last  time.Time                  `json:"last"`
+}
+
+// apply returns the compliance state to report for the policy template given the compliance state of its latest
+// evaluation, and records the pending transition on the template metadata if the reported state is held back.
+func (h *ComplianceHysteresis) apply(
+	dpt *policiesv1.DetailsPerTemplate, latest policiesv1.ComplianceHistory, newState policiesv1.ComplianceState,
+) policiesv1.ComplianceState {
+	reported := dpt.ComplianceState
+
+	// Only transitions between asserted compliance states are held back, and template errors are always reported
+	// right away since they require action.
+	if h == nil || h.Count <= 1 || newState == reported ||
+		(reported != policiesv1.Compliant && reported != policiesv1.NonCompliant) ||
+		strings.Contains(latest.Message, "template-error;") {
+		setPendingTransition(dpt, nil)
+
+		return newState
+	}
+
+	evaluatedAt := latest.LastTimestamp.Time
+	pending := getPendingTransition(dpt)
+
+	switch {
+	case pending != nil && pending.State == newState && evaluatedAt.Equal(pending.Last):
+		// The same evaluation, nothing to record
+	case pending != nil && pending.State == newState && evaluatedAt.After(pending.Last) &&
+		evaluatedAt.Sub(pending.Since) <= h.Window:
+		pending.Count++
+		pending.Last = evaluatedAt
+	default:
+		pending = &pendingTransition{State: newState, Count: 1, Since: evaluatedAt, Last: evaluatedAt}
+	}
+
+	if pending.Count >= h.Count {
+		setPendingTransition(dpt, nil)
+
+		return newState
+	}
+
+	setPendingTransition(dpt, pending)
+
+	return reported
+}
+
+// getPendingTransition returns the pending transition recorded on the template metadata or nil if there isn't one.
+func getPendingTransition(dpt *policiesv1.DetailsPerTemplate) *pendingTransition {
+	value, ok := dpt.TemplateMeta.Annotations[HysteresisAnnotation]
+	if !ok {
+		return nil
+	}
+
+	pending := &pendingTransition{}
+
+	err := json.Unmarshal([]byte(value), pending)
+	if err != nil {
+		log.Info("Ignoring the invalid compliance hysteresis annotation", "PolicyTemplate", dpt.TemplateMeta.Name,
+			"value", value)
+
+		return nil
+	}
+
+	return pending
+}
+
+// setPendingTransition records the input pending transition on the template metadata, or removes it if it is nil.
+func setPendingTransition(dpt *policiesv1.DetailsPerTemplate, pending *pendingTransition) {
+	if pending == nil {
+		removeTemplateAnnotation(dpt, HysteresisAnnotation)
+
+		return
+	}
+
+	value, err := json.Marshal(pending)
+	if err != nil {
+		log.Error(err, "Failed to record the pending compliance transition", "PolicyTemplate", dpt.TemplateMeta.Name)
+
+		return
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[HysteresisAnnotation] = string(value)
+}
@@ -0,0 +1,72 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func evaluation(at time.Time, message string) policiesv1.ComplianceHistory {
+	return policiesv1.ComplianceHistory{LastTimestamp: metav1.NewTime(at), Message: message}
+}
+
+func TestComplianceHysteresis(t *testing.T) {
+	RegisterTestingT(t)
+
+	hysteresis := &ComplianceHysteresis{Count: 3, Window: time.Minute}
+	dpt := &policiesv1.DetailsPerTemplate{ComplianceState: policiesv1.Compliant}
+	start := time.Now().Truncate(time.Second)
+
+	nc := func(offset time.Duration) policiesv1.ComplianceState {
+		return hysteresis.apply(dpt, evaluation(start.Add(offset), "NonCompliant; violation"), policiesv1.NonCompliant)
+	}
+
+	Expect(nc(0)).To(Equal(policiesv1.Compliant))
+	Expect(dpt.TemplateMeta.Annotations).To(HaveKey(HysteresisAnnotation))
+	// Reconciling the same evaluation again doesn't count
+	Expect(nc(0)).To(Equal(policiesv1.Compliant))
+	Expect(nc(10 * time.Second)).To(Equal(policiesv1.Compliant))
+	Expect(getPendingTransition(dpt).Count).To(Equal(2))
+
+	// A Compliant evaluation in between resets the count
+	state := hysteresis.apply(dpt, evaluation(start.Add(20*time.Second), "Compliant"), policiesv1.Compliant)
+	Expect(state).To(Equal(policiesv1.Compliant))
+	Expect(dpt.TemplateMeta.Annotations).To(BeNil())
+
+	Expect(nc(30 * time.Second)).To(Equal(policiesv1.Compliant))
+	Expect(nc(40 * time.Second)).To(Equal(policiesv1.Compliant))
+	// The window since the first evaluation is exceeded, so counting restarts
+	Expect(nc(2 * time.Minute)).To(Equal(policiesv1.Compliant))
+	Expect(getPendingTransition(dpt).Count).To(Equal(1))
+
+	Expect(nc(2*time.Minute + time.Second)).To(Equal(policiesv1.Compliant))
+	Expect(nc(2*time.Minute + 2*time.Second)).To(Equal(policiesv1.NonCompliant))
+	Expect(dpt.TemplateMeta.Annotations).To(BeNil())
+}
+
+func TestComplianceHysteresisBypass(t *testing.T) {
+	RegisterTestingT(t)
+
+	hysteresis := &ComplianceHysteresis{Count: 3, Window: time.Minute}
+	now := time.Now()
+
+	// The first compliance state is reported right away
+	dpt := &policiesv1.DetailsPerTemplate{}
+	Expect(hysteresis.apply(dpt, evaluation(now, "NonCompliant"), policiesv1.NonCompliant)).To(
+		Equal(policiesv1.NonCompliant))
+
+	// Template errors are reported right away
+	dpt = &policiesv1.DetailsPerTemplate{ComplianceState: policiesv1.Compliant}
+	Expect(hysteresis.apply(dpt, evaluation(now, "NonCompliant; template-error; oops"), policiesv1.NonCompliant)).To(
+		Equal(policiesv1.NonCompliant))
+
+	// A nil hysteresis is disabled
+	var disabled *ComplianceHysteresis
+	Expect(disabled.apply(dpt, evaluation(now, "NonCompliant"), policiesv1.NonCompliant)).To(
+		Equal(policiesv1.NonCompliant))
+}
@@ -0,0 +1,36 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// InformationalAnnotation can be set to "true" on a policy template for report-only checks. Its compliance is still
+// recorded in the policy status, but it doesn't count toward the overall compliance state of the policy nor the
+// remediation SLA. The annotation is copied to the template metadata in the policy status.
+const InformationalAnnotation = "policy.open-cluster-management.io/informational"
+
+// markInformational sets the InformationalAnnotation annotation on the template metadata of the input policy template
+// details if the input policy template object is informational, and removes it otherwise.
+func markInformational(tObject metav1.Object, dpt *policiesv1.DetailsPerTemplate) {
+	if !strings.EqualFold(tObject.GetAnnotations()[InformationalAnnotation], "true") {
+		removeTemplateAnnotation(dpt, InformationalAnnotation)
+
+		return
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[InformationalAnnotation] = "true"
+}
+
+// isInformational returns true if the input policy template details are marked as informational.
+func isInformational(dpt *policiesv1.DetailsPerTemplate) bool {
+	return dpt.TemplateMeta.Annotations[InformationalAnnotation] == "true"
+}
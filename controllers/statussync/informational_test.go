@@ -0,0 +1,51 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestInformationalTemplates(t *testing.T) {
+	RegisterTestingT(t)
+
+	tObject := &unstructured.Unstructured{}
+	tObject.SetAnnotations(map[string]string{InformationalAnnotation: "True"})
+
+	informational := &policiesv1.DetailsPerTemplate{
+		TemplateMeta:    metav1.ObjectMeta{Name: "report-only"},
+		ComplianceState: policiesv1.NonCompliant,
+		History: []policiesv1.ComplianceHistory{
+			{Message: "NonCompliant; violation", LastTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+		},
+	}
+	markInformational(tObject, informational)
+	Expect(informational.TemplateMeta.Annotations).To(HaveKeyWithValue(InformationalAnnotation, "true"))
+
+	enforced := &policiesv1.DetailsPerTemplate{
+		TemplateMeta:    metav1.ObjectMeta{Name: "enforced"},
+		ComplianceState: policiesv1.Compliant,
+	}
+
+	// The informational policy template doesn't make the policy NonCompliant
+	details := []*policiesv1.DetailsPerTemplate{informational, enforced}
+	Expect(policyComplianceState(details)).To(Equal(policiesv1.Compliant))
+
+	// Nor does it breach the remediation SLA
+	r := &PolicyReconciler{}
+	r.applyComplianceTimestamps(details)
+
+	breached, _ := r.applyRemediationSLA(time.Minute, details, time.Now())
+	Expect(breached).To(BeEmpty())
+
+	// Removing the annotation from the policy template counts it again
+	markInformational(&unstructured.Unstructured{}, informational)
+	Expect(informational.TemplateMeta.Annotations).ToNot(HaveKey(InformationalAnnotation))
+	Expect(policyComplianceState(details)).To(Equal(policiesv1.NonCompliant))
+}
@@ -0,0 +1,109 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MessageNormalizer rewrites the compliance event messages of policy template kinds whose policy engine emits
+// multi-line or JSON formatted messages, so that the compliance messages in the policy status stay on a single line.
+type MessageNormalizer struct {
+	// Kinds are the policy template kinds whose messages have their whitespace collapsed. The "*" kind matches all the
+	// kinds.
+	Kinds map[string]bool
+	// JSONKeys are the keys extracted from the JSON object in the messages of each policy template kind. The extracted
+	// values replace the JSON object in the message. The whitespace of these kinds is also collapsed.
+	JSONKeys map[string][]string
+}
+
+// ParseMessageNormalizer returns the MessageNormalizer for the input kinds and JSON keys, such as from the command
+// line. Each JSON keys entry is in the format of <kind>=<key>[,<key>...].
+func ParseMessageNormalizer(kinds []string, jsonKeys []string) (*MessageNormalizer, error) {
+	if len(kinds) == 0 && len(jsonKeys) == 0 {
+		return nil, nil
+	}
+
+	normalizer := &MessageNormalizer{Kinds: map[string]bool{}, JSONKeys: map[string][]string{}}
+
+	for _, kind := range kinds {
+		normalizer.Kinds[kind] = true
+	}
+
+	for _, entry := range jsonKeys {
+		kind, keys, found := strings.Cut(entry, "=")
+		if !found || kind == "" || keys == "" {
+			return nil, fmt.Errorf("the JSON keys entry %q is not in the format of <kind>=<key>[,<key>...]", entry)
+		}
+
+		normalizer.JSONKeys[kind] = append(normalizer.JSONKeys[kind], strings.Split(keys, ",")...)
+	}
+
+	return normalizer, nil
+}
+
+// Normalize returns the normalized compliance message for a policy template of the input kind. The message is
+// returned as is if the kind isn't normalized. A nil MessageNormalizer returns the message as is.
+func (n *MessageNormalizer) Normalize(kind string, message string) string {
+	if n == nil {
+		return message
+	}
+
+	jsonKeys := n.JSONKeys[kind]
+
+	if len(jsonKeys) == 0 && !n.Kinds[kind] && !n.Kinds["*"] {
+		return message
+	}
+
+	if len(jsonKeys) != 0 {
+		message = extractJSONKeys(message, jsonKeys)
+	}
+
+	return strings.Join(strings.Fields(message), " ")
+}
+
+// extractJSONKeys replaces the JSON object at the end of the input message, such as after the "NonCompliant; "
+// prefix, with the values of the input keys. The message is returned as is if it doesn't end with a JSON object or if
+// none of the keys are in it.
+func extractJSONKeys(message string, keys []string) string {
+	start := strings.Index(message, "{")
+	if start == -1 {
+		return message
+	}
+
+	object := map[string]interface{}{}
+
+	if err := json.Unmarshal([]byte(message[start:]), &object); err != nil {
+		return message
+	}
+
+	values := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		value, ok := object[key]
+		if !ok {
+			continue
+		}
+
+		if stringValue, ok := value.(string); ok {
+			values = append(values, key+": "+stringValue)
+
+			continue
+		}
+
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+
+		values = append(values, key+": "+string(encodedValue))
+	}
+
+	if len(values) == 0 {
+		return message
+	}
+
+	return message[:start] + strings.Join(values, ", ")
+}
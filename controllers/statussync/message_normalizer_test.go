@@ -0,0 +1,60 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMessageNormalizer(t *testing.T) {
+	RegisterTestingT(t)
+
+	normalizer, err := ParseMessageNormalizer(
+		[]string{"ConfigurationPolicy"}, []string{"K8sRequiredLabels=msg,violations"},
+	)
+	Expect(err).ToNot(HaveOccurred())
+
+	multiLine := "NonCompliant; violation -\n  namespaces [default]   not found\n"
+
+	Expect(normalizer.Normalize("ConfigurationPolicy", multiLine)).To(
+		Equal("NonCompliant; violation - namespaces [default] not found"),
+	)
+	// Kinds which aren't normalized are left as is
+	Expect(normalizer.Normalize("CertificatePolicy", multiLine)).To(Equal(multiLine))
+
+	jsonMessage := "NonCompliant; {\n  \"msg\": \"you must provide labels\",\n  \"violations\": [\"ns-a\", \"ns-b\"]," +
+		"\n  \"details\": {}\n}"
+
+	Expect(normalizer.Normalize("K8sRequiredLabels", jsonMessage)).To(
+		Equal(`NonCompliant; msg: you must provide labels, violations: ["ns-a","ns-b"]`),
+	)
+
+	// A message without the keys only has its whitespace collapsed
+	Expect(normalizer.Normalize("K8sRequiredLabels", "NonCompliant; {\n\"other\": 1}")).To(
+		Equal(`NonCompliant; { "other": 1}`),
+	)
+}
+
+func TestMessageNormalizerAllKinds(t *testing.T) {
+	RegisterTestingT(t)
+
+	normalizer, err := ParseMessageNormalizer([]string{"*"}, nil)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(normalizer.Normalize("CertificatePolicy", "Compliant;\n\tno violations")).To(
+		Equal("Compliant; no violations"),
+	)
+}
+
+func TestParseMessageNormalizer(t *testing.T) {
+	RegisterTestingT(t)
+
+	normalizer, err := ParseMessageNormalizer(nil, nil)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(normalizer).To(BeNil())
+	Expect(normalizer.Normalize("ConfigurationPolicy", "Compliant;\n")).To(Equal("Compliant;\n"))
+
+	_, err = ParseMessageNormalizer(nil, []string{"K8sRequiredLabels"})
+	Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,141 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+const (
+	// NodeScopedLabel can be set to "true" on a policy on the Hub whose policy templates are evaluated on each node,
+	// such as by a node agent, so that the compliance of its policy templates is also reported per node.
+	NodeScopedLabel = "policy.open-cluster-management.io/node-scoped"
+	// NodeComplianceAnnotation is set on the template metadata in the status of a node-scoped policy to the JSON
+	// compliance of the policy template on each node, keyed by node name.
+	NodeComplianceAnnotation = "policy.open-cluster-management.io/node-compliance"
+)
+
+// nodeCompliance is the compliance of a policy template on a node, from the latest compliance event of the node.
+type nodeCompliance struct {
+	ComplianceState policiesv1.ComplianceState `json:"compliant"`
+	LastTimestamp   metav1.Time                `json:"lastTimestamp"`
+}
+
+// isNodeScoped returns true if the input policy is labeled as node-scoped.
+func isNodeScoped(plc *policiesv1.Policy) bool {
+	return strings.EqualFold(plc.GetLabels()[NodeScopedLabel], "true")
+}
+
+// eventNodeName returns the name of the node the input event was generated on, from its source host or otherwise its
+// related object if it is a node, or an empty string if it has none.
+func eventNodeName(event corev1.Event) string {
+	if event.Source.Host != "" {
+		return event.Source.Host
+	}
+
+	if event.Related != nil && event.Related.Kind == "Node" {
+		return event.Related.Name
+	}
+
+	return ""
+}
+
+// nodeComplianceEvents returns the latest compliance event of each node from the input events involving the input
+// policy, keyed by policy template name and node name. The events without a node and the events from untrusted
+// sources are ignored.
+func (r *PolicyReconciler) nodeComplianceEvents(
+	policyName string, events []corev1.Event,
+) map[string]map[string]policiesv1.ComplianceHistory {
+	eventsByTemplate := map[string]map[string]policiesv1.ComplianceHistory{}
+
+	for _, event := range events {
+		templateName := complianceEventTemplate(policyName, event)
+		nodeName := eventNodeName(event)
+
+		if templateName == "" || nodeName == "" ||
+			!r.TrustedEventSources.Trusted(event.Source, event.ReportingController) {
+			continue
+		}
+
+		timestamp := r.ClockSkew.ToHubTime(event.LastTimestamp)
+
+		if eventsByTemplate[templateName] == nil {
+			eventsByTemplate[templateName] = map[string]policiesv1.ComplianceHistory{}
+		}
+
+		if latest, ok := eventsByTemplate[templateName][nodeName]; ok && !timestamp.After(latest.LastTimestamp.Time) {
+			continue
+		}
+
+		eventsByTemplate[templateName][nodeName] = policiesv1.ComplianceHistory{
+			LastTimestamp: timestamp,
+			Message:       event.Message,
+			EventName:     event.GetName(),
+		}
+	}
+
+	return eventsByTemplate
+}
+
+// applyNodeCompliance merges the input latest compliance events by node into the NodeComplianceAnnotation annotation
+// of the input policy template details. Since the compliance events expire, the nodes which no longer have events keep
+// their last compliance until it is older than the oldest entry of the compliance history of the policy template, so
+// that the nodes removed from the cluster are eventually dropped. The annotation is removed when no node is left.
+func (r *PolicyReconciler) applyNodeCompliance(
+	dpt *policiesv1.DetailsPerTemplate, events map[string]policiesv1.ComplianceHistory,
+) {
+	nodes := map[string]nodeCompliance{}
+
+	if value, ok := dpt.TemplateMeta.Annotations[NodeComplianceAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &nodes); err != nil {
+			log.Info("Ignoring the invalid node compliance annotation", "PolicyTemplate", dpt.TemplateMeta.Name)
+		}
+	}
+
+	for nodeName, event := range events {
+		if current, ok := nodes[nodeName]; ok && current.LastTimestamp.After(event.LastTimestamp.Time) {
+			continue
+		}
+
+		nodes[nodeName] = nodeCompliance{
+			ComplianceState: r.MessageParser.ComplianceState(event.Message),
+			LastTimestamp:   event.LastTimestamp,
+		}
+	}
+
+	if len(dpt.History) == 0 {
+		nodes = nil
+	} else {
+		horizon := dpt.History[len(dpt.History)-1].LastTimestamp.Time
+
+		for nodeName, node := range nodes {
+			if node.LastTimestamp.Time.Before(horizon) {
+				delete(nodes, nodeName)
+			}
+		}
+	}
+
+	if len(nodes) == 0 {
+		removeTemplateAnnotation(dpt, NodeComplianceAnnotation)
+
+		return
+	}
+
+	value, err := json.Marshal(nodes)
+	if err != nil {
+		log.Error(err, "Failed to encode the node compliance", "PolicyTemplate", dpt.TemplateMeta.Name)
+
+		return
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[NodeComplianceAnnotation] = string(value)
+}
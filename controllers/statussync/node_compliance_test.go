@@ -0,0 +1,91 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func nodeEvent(name string, node string, message string, timestamp time.Time) corev1.Event {
+	return corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: policiesv1.Kind, APIVersion: policiesv1APIVersion, Name: "policy",
+		},
+		Reason:        "policy: cluster/node-check",
+		Message:       message,
+		Source:        corev1.EventSource{Host: node},
+		LastTimestamp: metav1.NewTime(timestamp),
+	}
+}
+
+func TestNodeCompliance(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now().Truncate(time.Second)
+	r := &PolicyReconciler{}
+
+	related := nodeEvent("event-4", "", "Compliant; ok", now)
+	related.Related = &corev1.ObjectReference{Kind: "Node", Name: "node3"}
+
+	events := r.nodeComplianceEvents("policy", []corev1.Event{
+		nodeEvent("event-1", "node1", "Compliant; ok", now.Add(-2*time.Minute)),
+		nodeEvent("event-2", "node1", "NonCompliant; violation", now.Add(-time.Minute)),
+		nodeEvent("event-3", "node2", "Compliant; ok", now.Add(-time.Minute)),
+		nodeEvent("event-5", "", "Compliant; ok", now),
+		related,
+	})
+	Expect(events).To(HaveKey("node-check"))
+	Expect(events["node-check"]).To(HaveLen(3))
+	Expect(events["node-check"]["node1"].EventName).To(Equal("event-2"))
+
+	dpt := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{Name: "node-check"},
+		History: []policiesv1.ComplianceHistory{
+			{Message: "Compliant; ok", LastTimestamp: metav1.NewTime(now)},
+			{Message: "NonCompliant; violation", LastTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+		},
+	}
+
+	r.applyNodeCompliance(dpt, events["node-check"])
+
+	nodes := map[string]nodeCompliance{}
+	Expect(json.Unmarshal([]byte(dpt.TemplateMeta.Annotations[NodeComplianceAnnotation]), &nodes)).To(Succeed())
+	Expect(nodes).To(HaveLen(3))
+	Expect(nodes["node1"].ComplianceState).To(Equal(policiesv1.NonCompliant))
+	Expect(nodes["node2"].ComplianceState).To(Equal(policiesv1.Compliant))
+	Expect(nodes["node3"].ComplianceState).To(Equal(policiesv1.Compliant))
+
+	// The nodes without events keep their compliance until it is older than the compliance history
+	dpt.History = dpt.History[:1]
+	r.applyNodeCompliance(dpt, map[string]policiesv1.ComplianceHistory{
+		"node1": {Message: "Compliant; ok", LastTimestamp: metav1.NewTime(now)},
+	})
+
+	nodes = map[string]nodeCompliance{}
+	Expect(json.Unmarshal([]byte(dpt.TemplateMeta.Annotations[NodeComplianceAnnotation]), &nodes)).To(Succeed())
+	Expect(nodes).To(HaveLen(2))
+	Expect(nodes["node1"].ComplianceState).To(Equal(policiesv1.Compliant))
+	Expect(nodes).To(HaveKey("node3"))
+
+	dpt.History = nil
+	r.applyNodeCompliance(dpt, nil)
+	Expect(dpt.TemplateMeta.Annotations).NotTo(HaveKey(NodeComplianceAnnotation))
+}
+
+func TestIsNodeScoped(t *testing.T) {
+	RegisterTestingT(t)
+
+	plc := &policiesv1.Policy{}
+	Expect(isNodeScoped(plc)).To(BeFalse())
+
+	plc.SetLabels(map[string]string{NodeScopedLabel: "True"})
+	Expect(isNodeScoped(plc)).To(BeTrue())
+}
@@ -5,45 +5,88 @@ package statussync
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
-	"os"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
-	"open-cluster-management.io/governance-policy-propagator/controllers/common"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+	"open-cluster-management.io/governance-policy-framework-addon/pkg/compliance"
 )
 
 const ControllerName string = "policy-status-sync"
 
 var log = ctrl.Log.WithName(ControllerName)
 
+// complianceReasonRegex matches the reason of compliance events, such as "policy: calamari/policy-grc-example", with
+// the namespace and the policy template name as submatches.
+var complianceReasonRegex = regexp.MustCompile(`(?i)^policy:\s*([A-Za-z0-9.-]+)\s*\/([A-Za-z0-9.-]+)`)
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	err := mgr.GetFieldIndexer().IndexField(
+		context.TODO(), &policiesv1.Policy{}, templateObjectIndex, indexTemplateObjects,
+	)
+	if err != nil {
+		return err
+	}
+
+	ctrlBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&policiesv1.Policy{}).
 		Watches(
 			&source.Kind{Type: &corev1.Event{}},
 			handler.EnqueueRequestsFromMapFunc(eventMapper),
 			builder.WithPredicates(eventPredicateFuncs),
 		).
+		Watches(
+			&source.Kind{Type: &policiesv1.Policy{}},
+			handler.EnqueueRequestsFromMapFunc(r.conflictMapper),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Named(ControllerName).
-		Complete(r)
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Concurrency.Workers(r.ConcurrentReconciles)})
+
+	for _, writer := range []*StatusWriter{r.ManagedStatusWriter, r.HubStatusWriter} {
+		if writer != nil {
+			// Reconcile the policies again once the status they didn't wait for is written, to report the outcome
+			ctrlBuilder = ctrlBuilder.Watches(
+				&source.Channel{Source: writer.Events()}, &handler.EnqueueRequestForObject{},
+			)
+		}
+	}
+
+	if r.TemplateWatcher != nil {
+		// Reconcile the policies with a template object pending readiness when the template object changes
+		ctrlBuilder = ctrlBuilder.Watches(
+			&source.Channel{Source: r.TemplateWatcher.Events(ControllerName, nil)}, &handler.EnqueueRequestForObject{},
+		)
+	}
+
+	return ctrlBuilder.Complete(r.Concurrency.Reconciler(syncerrors.Reconciler(ControllerName, r)))
 }
 
 // blank assignment to verify that ReconcilePolicy implements reconcile.Reconciler
@@ -53,18 +96,124 @@ var _ reconcile.Reconciler = &PolicyReconciler{}
 type PolicyReconciler struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	HubClient             client.Client
-	ManagedClient         client.Client
+	HubClient     client.Client
+	ManagedClient client.Client
+	// ManagedAPIReader reads the template objects from the managed cluster API server rather than the cache, since
+	// reading an arbitrary kind with the cached ManagedClient would start a cluster-wide informer for it. If it is nil,
+	// the ManagedClient is used.
+	ManagedAPIReader client.Reader
+	// Heartbeat records the last successful status write to the Hub
+	Heartbeat             *utils.Heartbeat
 	HubRecorder           record.EventRecorder
 	ManagedRecorder       record.EventRecorder
 	Scheme                *runtime.Scheme
 	ClusterNamespaceOnHub string
+	// MessageParser determines the compliance state from compliance event messages. If it is nil, only the built-in
+	// "Compliant" prefix check is used.
+	MessageParser *ComplianceMessageParser
+	// MessageNormalizer rewrites the multi-line or JSON formatted compliance messages of the configured policy
+	// template kinds on a single line. If it is nil, the compliance messages are used as is.
+	MessageNormalizer *MessageNormalizer
+	// ReadinessGates causes the first Compliant state of a policy template to be held as Pending until the template
+	// object on the managed cluster is ready.
+	ReadinessGates bool
+	// Engines are the policy engine adapters which determine if the template objects of the kinds they evaluate are
+	// ready. If it is nil, the readiness of all the template objects is determined from their status conditions.
+	Engines *engines.Registry
+	// TemplatePlacements determines the namespace of the template objects checked by the readiness gates. If it is
+	// nil, the template objects are in the namespace of the policy.
+	TemplatePlacements *utils.TemplatePlacements
+	// ComplianceSummarizer is triggered on every reconcile to update the ComplianceSummary. If it is nil, no
+	// ComplianceSummary is maintained.
+	ComplianceSummarizer *ComplianceSummarizer
+	// ComplianceTimeline is triggered on every reconcile to update the compliance timeline ConfigMap. If it is nil, no
+	// compliance timeline is maintained.
+	ComplianceTimeline *ComplianceTimeline
+	// SearchExporter exports the compliance of the policies for the OCM search indexer. If it is nil, nothing is
+	// exported.
+	SearchExporter *SearchExporter
+	// TrustedEventSources are the event sources whose compliance events are used for the policy status. Compliance
+	// events from other sources are ignored. This is a filter rather than a security boundary since the event source
+	// is set by the creator of the event. If it is empty, all event sources are trusted.
+	TrustedEventSources utils.EventSourceTrust
+	// Hysteresis holds back changes in the compliance state of policy templates until they are consistently
+	// observed. If it is nil, compliance state changes are reported right away.
+	Hysteresis *ComplianceHysteresis
+	// NamespaceGuard pauses the reconciles while the cluster namespace is unavailable
+	NamespaceGuard *utils.NamespaceGuard
+	// DeletionGuard delays the deletion of the policy on the managed cluster after the replicated policy disappears
+	// from the Hub. If it is nil, the policy is deleted right away.
+	DeletionGuard *utils.DeletionGuard
+	// Compaction strips the Hub annotations not in its allow-list from the policies on the managed cluster. If it is
+	// nil, all the annotations are synced.
+	Compaction *utils.PolicyCompaction
+	// HistoryCarryOver keeps the compliance history of the deleted policies for the next policy with the same
+	// identifier, such as when a policy is renamed. If it is nil, the compliance history of a new policy starts empty.
+	HistoryCarryOver *utils.HistoryCarryOver
+	// TemplateWatcher watches the template objects pending readiness so that the policy is reconciled as soon as they
+	// are ready. If it is nil, the readiness is checked periodically.
+	TemplateWatcher *utils.DynamicWatcher
+	// StatusAuditor stamps the policies on the Hub with the addon instance which wrote their status. If it is nil, the
+	// policies are not stamped.
+	StatusAuditor *StatusAuditor
+	// HistorySigner signs the compliance history entries in the policy status so that their integrity can be verified
+	// on the Hub. If it is nil, the compliance history isn't signed.
+	HistorySigner *HistorySigner
+	// PolicySelector selects the replicated policies on the Hub whose status is synced. If it is nil, the status of
+	// all the policies is synced.
+	PolicySelector labels.Selector
+	// Concurrency adapts the number of concurrent reconciles to the CPU and memory usage of the container. If it is
+	// nil, up to ConcurrentReconciles policies are reconciled at a time.
+	Concurrency *utils.AdaptiveConcurrency
+	// ConcurrentReconciles is the number of policies reconciled at a time when the Concurrency is nil. The reconciles
+	// of the same policy are never concurrent. It defaults to one.
+	ConcurrentReconciles int
+	// ManagedStatusWriter and HubStatusWriter write the policy statuses to the managed cluster and the Hub in order
+	// from a single goroutine each. If they are nil, each reconcile writes the policy statuses itself.
+	ManagedStatusWriter *StatusWriter
+	HubStatusWriter     *StatusWriter
+	// ClockSkew shifts the timestamps of the compliance events to the Hub clock when the clocks of the Hub and the
+	// managed cluster are skewed. If it is nil, the timestamps are used as is.
+	ClockSkew *utils.ClockSkew
+	// DeleteEventsOnDeletion deletes the events involving a policy on the managed cluster once the policy is deleted
+	// from both the Hub and the managed cluster. If it is false, the events are kept until they expire.
+	DeleteEventsOnDeletion bool
+	// EngineStatus copies the engine status published by the template objects onto the template metadata in the
+	// policy status. Since this reads each template object on every reconcile, it is disabled by default.
+	EngineStatus bool
+	// SelfManagedHub determines when the replicated policy on the Hub and the policy on the managed cluster are the
+	// same object, whose status is then only written once. The zero value is utils.SelfManagedHubAuto.
+	SelfManagedHub utils.SelfManagedHubMode
+	// InternHistoryMessages replaces the messages of the compliance history entries repeating the message of a newer
+	// entry of the same policy template with a reference to it, which shrinks the status of flapping policies.
+	InternHistoryMessages bool
+	// HistoryStore persists the compliance history of the policies beyond the retention of the compliance events. If
+	// it is nil, the compliance history is only kept in the policy status.
+	HistoryStore *HistoryStore
+	// HistoryLimit is the number of compliance history entries kept for each policy template, unless a policy
+	// overrides it with the HistoryLimitAnnotation annotation. If it is zero, 10 entries are kept.
+	HistoryLimit int
+	// MessageSizeLimit truncates the compliance messages longer than this many bytes before they are merged in the
+	// compliance history. If it is zero, the messages aren't truncated.
+	MessageSizeLimit int
+	// StatusSizeBudget removes the oldest compliance history entries until the policy template details in the policy
+	// status fit in this many bytes, so that the status update isn't rejected for exceeding the etcd object size
+	// limit. If it is zero, the status size isn't limited.
+	StatusSizeBudget int
+	// ComplianceNotifier notifies the compliance transitions of the policies. If it is nil, no notifications are sent.
+	ComplianceNotifier *ComplianceNotifier
+	// HubSync reports the HubSyncDegraded condition on the policies whose status fails to be synced to the Hub for
+	// longer than its threshold. If it is nil, the failures are only logged.
+	HubSync *utils.HubSyncMonitor
+	// resync tracks the trigger-update annotation of the policies to force the status writes when it changes
+	resync utils.ResyncTracker
 }
 
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
 // This is required for the status lease for the addon framework
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
 
@@ -74,11 +223,23 @@ type PolicyReconciler struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	ctx = utils.WithController(ctx, ControllerName)
+
 	reqLogger := log.WithValues(
 		"Request.Namespace", request.Namespace, "Request.Name", request.Name, "HubNamespace", r.ClusterNamespaceOnHub,
 	)
 	reqLogger.Info("Reconciling the policy")
 
+	if !r.NamespaceGuard.Available() {
+		reqLogger.Info("The cluster namespace is unavailable, pausing the reconcile")
+
+		return reconcile.Result{RequeueAfter: utils.NamespaceUnavailableRequeue}, nil
+	}
+
+	// The update is debounced, so it reflects the outcome of this reconcile
+	r.ComplianceSummarizer.Trigger()
+	r.ComplianceTimeline.Trigger()
+
 	// Fetch the Policy instance
 	instance := &policiesv1.Policy{}
 
@@ -97,6 +258,35 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 					// confirmed deleted on hub, doing nothing
 					reqLogger.Info("Policy was deleted, no status to update")
 
+					r.TemplateWatcher.Track(ControllerName, request.NamespacedName, nil)
+					r.resync.Forget(request.NamespacedName)
+					r.HubSync.RecordSuccess(request.NamespacedName)
+					recordSLABreaches(request.NamespacedName, nil)
+					forgetComplianceMetrics(request.NamespacedName)
+
+					if r.DeleteEventsOnDeletion {
+						deleted, err := r.deletePolicyEvents(ctx, request.NamespacedName)
+						if err != nil {
+							reqLogger.Error(err, "Failed to delete the events of the deleted policy, will retry")
+
+							return reconcile.Result{}, err
+						}
+
+						reqLogger.Info("Deleted the events of the deleted policy", "count", deleted)
+					}
+
+					if err := r.HistoryStore.Delete(ctx, request.NamespacedName); err != nil {
+						reqLogger.Error(err, "Failed to delete the stored compliance history, will retry")
+
+						return reconcile.Result{}, err
+					}
+
+					if err := r.SearchExporter.Delete(ctx, request.NamespacedName); err != nil {
+						reqLogger.Error(err, "Failed to export the deletion of the policy for search")
+
+						return reconcile.Result{RequeueAfter: searchExportRetryInterval}, nil
+					}
+
 					return reconcile.Result{}, nil
 				}
 				// other error, requeue
@@ -105,18 +295,18 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 				return reconcile.Result{}, err
 			}
 
-			// still exist on hub, recover policy on managed
-			managedInstance := hubInstance.DeepCopy()
-			managedInstance.Namespace = request.Namespace
+			if !utils.PolicySelected(r.PolicySelector, hubInstance) {
+				reqLogger.Info("Policy doesn't match the policy label selector, not recovering it")
 
-			if managedInstance.Labels["policy.open-cluster-management.io/cluster-namespace"] != "" {
-				managedInstance.Labels["policy.open-cluster-management.io/cluster-namespace"] = request.Namespace
+				return reconcile.Result{}, nil
 			}
 
-			managedInstance.SetOwnerReferences(nil)
-			managedInstance.SetResourceVersion("")
+			// still exist on hub, recover policy on managed
+			reqLogger.Info("Policy still exists on the hub, recovering it on the managed cluster")
+
+			_, err = utils.CreateManagedPolicy(ctx, r.ManagedClient, hubInstance, request.Namespace, r.Compaction)
 
-			return reconcile.Result{}, r.ManagedClient.Create(ctx, managedInstance)
+			return reconcile.Result{}, err
 		}
 		// Error reading the object - requeue the request.
 		reqLogger.Error(err, "Error reading the policy object, will requeue the request")
@@ -126,17 +316,46 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 	// get hub policy
 	hubPlc := &policiesv1.Policy{}
 	err = r.HubClient.Get(ctx, types.NamespacedName{Namespace: r.ClusterNamespaceOnHub, Name: request.Name}, hubPlc)
+	if err == nil {
+		r.DeletionGuard.Clear(request.NamespacedName)
+	}
 
 	if err != nil {
 		// hub policy not found, it has been deleted
 		if errors.IsNotFound(err) {
+			if remaining := r.DeletionGuard.Remaining(request.NamespacedName); remaining > 0 {
+				reqLogger.Info("Hub policy not found, waiting before deleting the managed policy",
+					"retryIn", remaining.String())
+
+				return reconcile.Result{RequeueAfter: remaining}, nil
+			}
+
 			reqLogger.Info("Hub policy not found, it has been deleted")
-			// try to delete local one
-			err = r.ManagedClient.Delete(ctx, instance)
+			// Keep the compliance history in case the policy was renamed
+			if err := r.HistoryCarryOver.Store(ctx, instance); err != nil {
+				reqLogger.Error(err, "Failed to keep the compliance history of the deleted policy")
+			}
+
+			// try to delete local one, unless it changed since it was read
+			err = utils.DeleteUnchanged(ctx, r.ManagedClient, instance)
+			if goerrors.Is(err, syncerrors.ErrDeleteConflict) {
+				reqLogger.Error(err, "The managed policy changed, will reevaluate its deletion")
+
+				r.ManagedRecorder.Event(instance, "Warning", "PolicyDeleteConflict", err.Error())
+
+				if err := utils.ReportDeleteConflict(ctx, r.ManagedClient, instance, err); err != nil {
+					reqLogger.Error(err, "Failed to set the DeleteConflict condition on the policy")
+				}
+
+				return reconcile.Result{}, err
+			}
+
 			if err == nil || errors.IsNotFound(err) {
 				// no err or err is not found means local policy has been deleted
 				reqLogger.Info("Managed policy was deleted")
 
+				r.DeletionGuard.Clear(request.NamespacedName)
+
 				return reconcile.Result{}, nil
 			}
 			// otherwise requeue to delete again
@@ -147,17 +366,62 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 
 		reqLogger.Error(err, "Failed to get policy on hub")
 
-		return reconcile.Result{}, err
+		r.reportHubSync(ctx, instance, err)
+
+		return reconcile.Result{}, syncerrors.FromHub(err)
+	}
+	// Another addon instance may sync the policies which don't match the policy label selector
+	if !utils.PolicySelected(r.PolicySelector, hubPlc) {
+		reqLogger.Info("Hub policy doesn't match the policy label selector, not syncing its status")
+
+		return reconcile.Result{}, nil
+	}
+
+	// Don't sync the status of a policy from another root policy which happens to have the same name. The condition
+	// is removed once the collision is resolved, such as when the spec sync replaced the policy.
+	collision := utils.RootPolicyCollision(hubPlc, instance)
+	if err := utils.ReportRootPolicyCollision(ctx, r.ManagedClient, instance, collision); err != nil {
+		reqLogger.Error(err, "Failed to set the RootPolicyCollision condition on the policy")
+	}
+
+	// The policy is no longer being deleted, such as when it reappeared on the Hub
+	if err := utils.ReportDeleteConflict(ctx, r.ManagedClient, instance, nil); err != nil {
+		reqLogger.Error(err, "Failed to remove the DeleteConflict condition from the policy")
+	}
+
+	if collision != nil {
+		reqLogger.Error(collision, "Refusing to sync the policy status")
+
+		r.HubRecorder.Event(hubPlc, "Warning", "PolicyStatusSyncCollision", collision.Error())
+
+		return reconcile.Result{}, nil
 	}
-	// found, ensure managed plc matches hub plc
-	if !common.CompareSpecAndAnnotation(instance, hubPlc) {
+
+	// A trigger-update annotation set only on the managed policy is removed below, so it is checked first
+	forceResync := r.resync.Requested(instance)
+	if forceResync {
+		reqLogger.Info("A resync of the policy was requested with the trigger-update annotation")
+
+		r.ManagedRecorder.Event(instance, "Normal", "PolicyResync",
+			fmt.Sprintf("Policy %s status is resynced as requested with the %s annotation", instance.GetName(),
+				utils.TriggerUpdateAnnotation))
+	}
+
+	// On a self-managed Hub, the status is only written to the managed policy since it is also the Hub policy
+	selfManaged := r.SelfManagedHub.SamePolicy(hubPlc, instance)
+
+	// found, ensure managed plc matches hub plc. If they are the same object, they only differ when one of the caches
+	// is stale, so updating it would cause conflicts.
+	if !utils.SameObject(hubPlc, instance) && !utils.CompareSpecAndAnnotation(hubPlc, instance, r.Compaction) {
 		// plc mismatch, update to latest
-		instance.SetAnnotations(hubPlc.GetAnnotations())
-		instance.Spec = hubPlc.Spec
-		// update and stop here
+		utils.SyncFromHub(hubPlc, instance, r.Compaction)
 		reqLogger.Info("Found mismatch with hub and managed policies, updating")
 
-		return reconcile.Result{}, r.ManagedClient.Update(ctx, instance)
+		err = r.ManagedClient.Update(ctx, instance)
+		// update and stop here, unless a resync was requested since the next reconcile won't know about it
+		if err != nil || !forceResync {
+			return reconcile.Result{}, err
+		}
 	}
 
 	// plc matches hub plc, then get events
@@ -171,37 +435,52 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, err
 	}
 	// filter events to current policy instance and build map
-	eventForPolicyMap := make(map[string]*[]policiesv1.ComplianceHistory)
-	// panic if regexp invalid
-	rgx := regexp.MustCompile(`(?i)^policy:\s*([A-Za-z0-9.-]+)\s*\/([A-Za-z0-9.-]+)`)
-	for _, event := range eventList.Items {
-		// sample event.Reason -- reason: 'policy: calamari/policy-grc-rbactest-example'
-		reason := rgx.FindString(event.Reason)
-		if event.InvolvedObject.Kind == policiesv1.Kind && event.InvolvedObject.APIVersion == policiesv1APIVersion &&
-			event.InvolvedObject.Name == instance.GetName() && reason != "" {
-			templateName := rgx.FindStringSubmatch(event.Reason)[2]
-			eventHistory := policiesv1.ComplianceHistory{
-				LastTimestamp: event.LastTimestamp,
-				Message:       strings.TrimSpace(strings.TrimPrefix(event.Message, "(combined from similar events):")),
-				EventName:     event.GetName(),
-			}
+	eventForPolicyMap := r.complianceEventsByTemplate(instance.GetName(), eventList.Items)
 
-			if eventForPolicyMap[templateName] == nil {
-				eventForPolicyMap[templateName] = &[]policiesv1.ComplianceHistory{}
-			}
+	// The compliance of the node-scoped policies is also reported per node
+	var nodeEventsByTemplate map[string]map[string]policiesv1.ComplianceHistory
 
-			templateEvents := append(*eventForPolicyMap[templateName], eventHistory)
-			eventForPolicyMap[templateName] = &templateEvents
-		}
+	nodeScoped := isNodeScoped(instance)
+	if nodeScoped {
+		nodeEventsByTemplate = r.nodeComplianceEvents(instance.GetName(), eventList.Items)
 	}
 
 	oldStatus := *instance.Status.DeepCopy()
 	newStatus := policiesv1.PolicyStatus{}
 
+	// Continue the compliance history of a deleted policy with the same identifier, such as a renamed policy
+	if restored, err := r.HistoryCarryOver.Restore(ctx, instance); err != nil {
+		reqLogger.Error(err, "Failed to restore the compliance history of a deleted policy with the same identifier")
+	} else if restored {
+		reqLogger.Info("Restored the compliance history of a deleted policy with the same identifier")
+	}
+
+	// Rebuild the compliance history from the history store when the policy status was lost, such as when the
+	// policy was recreated after its compliance events expired
+	if restored, err := r.HistoryStore.Restore(ctx, instance); err != nil {
+		reqLogger.Error(err, "Failed to restore the compliance history from the history store")
+	} else if restored {
+		reqLogger.Info("Restored the compliance history from the history store")
+	}
+
+	// The compliance history entries not yet in the status are related to the current change of the policy
+	knownEvents := historyEventNames(instance.Status.Details)
+
 	reqLogger.Info("Updating status for policy templates")
 
+	var requeueAfter time.Duration
+
+	// The resources of the template objects pending readiness
+	pendingResources := map[schema.GroupVersionResource]bool{}
+	// The compliance history of each policy template which isn't pruned, only recorded when a history dump is
+	// requested or the history is persisted
+	var fullHistory []templateHistory
+
+	dumpHistory := historyDumpRequested(hubPlc)
+	limit := r.templateHistoryLimit(hubPlc)
+
 	for _, policyT := range instance.Spec.PolicyTemplates {
-		object, _, err := unstructured.UnstructuredJSONScheme.Decode(policyT.ObjectDefinition.Raw, nil, nil)
+		object, gvk, err := unstructured.UnstructuredJSONScheme.Decode(policyT.ObjectDefinition.Raw, nil, nil)
 		if err != nil {
 			// failed to decode PolicyTemplate, skipping it
 			reqLogger.Error(err, "Failed to decode policy template, skipping it")
@@ -236,64 +515,55 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			}
 		}
 
-		history := []policiesv1.ComplianceHistory{}
-		if eventForPolicyMap[tName] != nil {
-			history = *eventForPolicyMap[tName]
+		markInformational(object.(metav1.Object), existingDpt)
+
+		if r.EngineStatus {
+			r.applyEngineStatus(ctx, gvk, instance.GetNamespace(), existingDpt)
 		}
 
-		for _, ech := range existingDpt.History {
-			exists := false
+		history, complianceState := r.mergeTemplateHistory(
+			existingDpt, eventForPolicyMap[tName], gvk.Kind, limit,
+		)
 
-			for _, ch := range history {
-				if ch.LastTimestamp.Time.Equal(ech.LastTimestamp.Time) && ch.EventName == ech.EventName {
-					// do nothing
-					exists = true
+		if nodeScoped {
+			r.applyNodeCompliance(existingDpt, nodeEventsByTemplate[tName])
+		} else {
+			removeTemplateAnnotation(existingDpt, NodeComplianceAnnotation)
+		}
 
-					break
-				}
-			}
-			// doesn't exists, append to history
-			if !exists {
-				history = append(history, ech)
-			}
+		if dumpHistory || r.HistoryStore != nil {
+			fullHistory = append(fullHistory, templateHistory{Template: tName, History: history})
 		}
-		// sort by lasttimestamp
-		sort.Slice(history, func(i, j int) bool {
-			return history[i].LastTimestamp.Time.After(history[j].LastTimestamp.Time)
-		})
-		// remove duplicates
-		newHistory := []policiesv1.ComplianceHistory{}
 
-		for historyIndex := 0; historyIndex < len(history); historyIndex++ {
-			newHistory = append(newHistory, history[historyIndex])
+		// set compliancy at different level
+		if len(existingDpt.History) > 0 {
+			// Only the first Compliant state is gated since the template object was previously ready if the
+			// compliance was already asserted. Gatekeeper constraints are always gated since their compliance is only
+			// meaningful while all the Gatekeeper pods enforce them.
+			if r.ReadinessGates && r.readinessGated(gvk, existingDpt, complianceState) {
+				tNamespace := r.TemplatePlacements.TemplateNamespace(gvk.GroupKind(), instance.GetNamespace())
+
+				ready, err := r.templateObjectReady(ctx, gvk, tName, tNamespace)
+				if err != nil {
+					reqLogger.Error(err, "Failed to determine if the policy template object is ready",
+						"PolicyTemplate", tName)
+				}
+
+				if !ready {
+					reqLogger.Info("The policy template object is not ready, setting the compliance to Pending",
+						"PolicyTemplate", tName)
 
-			for j := historyIndex; j < len(history); j++ {
-				if history[historyIndex].EventName == history[j].EventName &&
-					history[historyIndex].Message == history[j].Message {
-					// same event, filter it
-				} else {
-					historyIndex = j - 1
+					complianceState = Pending
+					requeueAfter = readinessRequeueInterval
 
-					break
+					mapping, err := r.ManagedClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+					if err == nil {
+						pendingResources[mapping.Resource] = true
+					}
 				}
 			}
-		}
-		// shorten it to first 10
-		size := 10
-		if len(newHistory) < 10 {
-			size = len(newHistory)
-		}
-
-		existingDpt.History = newHistory[0:size]
 
-		// set compliancy at different level
-		if len(existingDpt.History) > 0 {
-			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(
-				strings.TrimPrefix(existingDpt.History[0].Message, "(combined from similar events):"))), "compliant") {
-				existingDpt.ComplianceState = policiesv1.Compliant
-			} else {
-				existingDpt.ComplianceState = policiesv1.NonCompliant
-			}
+			existingDpt.ComplianceState = complianceState
 		}
 
 		// append existingDpt to status
@@ -302,66 +572,388 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		reqLogger.Info("Status update complete", "PolicyTemplate", tName)
 	}
 
-	instance.Status = newStatus
-	// one violation found in status of one template, set overall compliancy to NonCompliant
-	isCompliant := true
+	r.TemplateWatcher.Track(ControllerName, request.NamespacedName, pendingResources)
 
-	for _, dpt := range newStatus.Details {
-		if dpt.ComplianceState == "NonCompliant" {
-			instance.Status.ComplianceState = policiesv1.NonCompliant
-			isCompliant = false
+	// The stored history is dumped when the history is persisted, since it is kept beyond the compliance events
+	if stored, err := r.HistoryStore.Save(ctx, instance, fullHistory); err != nil {
+		reqLogger.Error(err, "Failed to persist the compliance history in the history store")
+	} else {
+		fullHistory = stored
+	}
 
-			break
-		} else if dpt.ComplianceState == "" {
-			isCompliant = false
+	r.applyComplianceTimestamps(newStatus.Details)
+	applyTemplateDrift(instance, newStatus.Details)
+	applyTemplateChecksums(instance, newStatus.Details)
+
+	conflicting, err := r.conflictingPolicies(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to list the policies to find the policy template conflicts, will requeue")
+
+		return reconcile.Result{}, err
+	}
+
+	conflicts := templateConflicts(instance, conflicting)
+
+	for _, tName := range applyTemplateConflicts(newStatus.Details, conflicts) {
+		message := fmt.Sprintf("The policy template %s manages the same object as a policy template of %s",
+			tName, strings.Join(conflicts[tName], ", "))
+
+		reqLogger.Info("Found a policy template conflict", "PolicyTemplate", tName, "policies", conflicts[tName])
+
+		r.HubRecorder.Event(hubPlc, "Warning", "PolicyTemplateConflict", message)
+	}
+
+	slaBreaches, untilNextBreach := r.applyRemediationSLA(remediationSLA(hubPlc), newStatus.Details, time.Now())
+	if untilNextBreach > 0 && (requeueAfter == 0 || untilNextBreach < requeueAfter) {
+		requeueAfter = untilNextBreach
+	}
+
+	recordSLABreaches(request.NamespacedName, newStatus.Details)
+
+	for _, tName := range slaBreaches {
+		message := fmt.Sprintf("The policy template %s stayed NonCompliant longer than the remediation SLA of %s",
+			tName, hubPlc.GetAnnotations()[RemediationSLAAnnotation])
+
+		reqLogger.Info("The remediation SLA is breached", "PolicyTemplate", tName)
+
+		r.ManagedRecorder.Event(instance, "Warning", "SLABreached", message)
+
+		if !selfManaged {
+			r.HubRecorder.Event(hubPlc, "Warning", "SLABreached", message)
 		}
 	}
-	// set to compliant only when all templates are compliant
-	if isCompliant {
-		instance.Status.ComplianceState = policiesv1.Compliant
+
+	// The history is interned before it is signed since the signatures are verified against the status on the Hub
+	if r.InternHistoryMessages {
+		internHistory(newStatus.Details)
+	}
+
+	eventFields := complianceEventFields(instance.GetName(), eventList.Items)
+	annotateHistory := func() {
+		applyHistoryCorrelation(newStatus.Details, knownEvents, utils.CorrelationID(instance))
+		applyHistoryFields(instance, newStatus.Details, knownEvents, eventFields)
+
+		if err := r.HistorySigner.sign(ctx, newStatus.Details); err != nil {
+			reqLogger.Error(err, "Failed to sign the compliance history")
+		}
 	}
 
+	if removed := fitToBudget(newStatus.Details, r.StatusSizeBudget, annotateHistory); removed != 0 {
+		reqLogger.Info("Removed the oldest compliance history entries to fit the policy status in the size budget",
+			"removed", removed, "budget", r.StatusSizeBudget)
+	}
+
+	instance.Status = newStatus
+	instance.Status.ComplianceState = policyComplianceState(newStatus.Details)
+
+	recordComplianceMetrics(request.NamespacedName, &instance.Status)
+
 	// all done, update status on managed and hub
 	// instance.Status.Details = nil
-	if !equality.Semantic.DeepEqual(newStatus.Details, oldStatus.Details) ||
+	if forceResync || !equality.Semantic.DeepEqual(newStatus.Details, oldStatus.Details) ||
 		instance.Status.ComplianceState != oldStatus.ComplianceState {
 		reqLogger.Info("status mismatch on managed, update it")
 
-		err = r.ManagedClient.Status().Update(ctx, instance)
+		err = r.ManagedStatusWriter.Write(ctx, r.ManagedClient, instance, instance.Status)
 
-		if err != nil {
+		switch {
+		case goerrors.Is(err, ErrStatusHeld):
+			// The policy is reconciled again once the status writer writes its latest status
+			reqLogger.Info("The policy status on managed is held until the status write interval elapses")
+		case err != nil:
 			reqLogger.Error(err, "Failed to get update policy status on managed")
 
 			return reconcile.Result{}, err
+		default:
+			// The managed policy is also the Hub policy
+			if selfManaged {
+				r.Heartbeat.RecordStatusWrite()
+				observeStatusSyncLatency(oldStatus.Details, instance.Status.Details, time.Now())
+			}
+
+			r.ManagedRecorder.Event(instance, "Normal", "PolicyStatusSync",
+				fmt.Sprintf("Policy %s status was updated in cluster namespace %s", instance.GetName(),
+					instance.GetNamespace()))
 		}
 
-		r.ManagedRecorder.Event(instance, "Normal", "PolicyStatusSync",
-			fmt.Sprintf("Policy %s status was updated in cluster namespace %s", instance.GetName(),
-				instance.GetNamespace()))
+		err = r.ComplianceNotifier.Notify(ctx, hubPlc, oldStatus.ComplianceState, instance.Status.ComplianceState)
+		if err != nil {
+			reqLogger.Error(err, "Failed to queue the compliance notification")
+		}
 	} else {
 		reqLogger.Info("status match on managed, nothing to update")
 	}
 
-	if os.Getenv("ON_MULTICLUSTERHUB") != "true" && !equality.Semantic.DeepEqual(hubPlc.Status, instance.Status) {
+	// Set when the Hub can't be reached, in which case the Hub status writer buffers the status until it can
+	var hubBufferedErr error
+
+	// Set when the Hub status writer holds the status, whose outcome is reported once the policy is reconciled again
+	hubHeld := false
+
+	// The Hub policy may come from a stale cache, so its status is written anyway when a resync is requested
+	if selfManaged {
+		reqLogger.Info("The hub policy is the managed policy, nothing to update on the hub")
+	} else if forceResync || !equality.Semantic.DeepEqual(hubPlc.Status, instance.Status) {
 		reqLogger.Info("status not in sync, update the hub")
 
-		hubPlc.Status = instance.Status
-		err = r.HubClient.Status().Update(ctx, hubPlc)
+		previousHubDetails := hubPlc.Status.Details
 
-		if err != nil {
+		err = r.HubStatusWriter.Write(ctx, r.HubClient, hubPlc, instance.Status)
+
+		switch {
+		case goerrors.Is(err, ErrStatusBuffered):
+			reqLogger.Info("The Hub can't be reached, the policy status is buffered until it can")
+
+			hubBufferedErr = err
+		case goerrors.Is(err, ErrStatusHeld):
+			reqLogger.Info("The policy status on the hub is held until the status write interval elapses")
+
+			hubHeld = true
+		case err != nil:
 			reqLogger.Error(err, "Failed to get update policy status on hub")
 
-			return reconcile.Result{}, err
-		}
+			r.reportHubSync(ctx, instance, err)
+
+			return reconcile.Result{}, syncerrors.FromHub(err)
+		default:
+			r.Heartbeat.RecordStatusWrite()
+			observeStatusSyncLatency(previousHubDetails, instance.Status.Details, time.Now())
 
-		r.HubRecorder.Event(hubPlc, "Normal", "PolicyStatusSync",
-			fmt.Sprintf("Policy %s status was updated in cluster namespace %s", hubPlc.GetName(),
-				hubPlc.GetNamespace()))
+			if err := r.StatusAuditor.stamp(ctx, r.HubClient, hubPlc); err != nil {
+				reqLogger.Error(err, "Failed to record the status write in the policy annotations on the hub")
+			}
+
+			r.HubRecorder.Event(hubPlc, "Normal", "PolicyStatusSync",
+				fmt.Sprintf("Policy %s status was updated in cluster namespace %s", hubPlc.GetName(),
+					hubPlc.GetNamespace()))
+		}
 	} else {
 		reqLogger.Info("status match on hub, nothing to update")
 	}
 
+	// A buffered status still counts as a failure to sync with the Hub until it is written
+	if !hubHeld {
+		r.reportHubSync(ctx, instance, hubBufferedErr)
+	}
+
+	if dumpHistory && hubBufferedErr == nil {
+		reqLogger.Info("Writing the complete compliance history to a ConfigMap on the hub")
+
+		if err := r.dumpHistory(ctx, hubPlc, fullHistory); err != nil {
+			reqLogger.Error(err, "Failed to write the complete compliance history on the hub")
+
+			return reconcile.Result{}, err
+		}
+	}
+
+	if err := r.SearchExporter.Export(ctx, instance); err != nil {
+		reqLogger.Error(err, "Failed to export the policy compliance for search, will retry")
+
+		if requeueAfter == 0 || requeueAfter > searchExportRetryInterval {
+			requeueAfter = searchExportRetryInterval
+		}
+	}
+
 	reqLogger.Info("Reconciling complete")
 
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// complianceEventsByTemplate returns the compliance history entries of the input events involving the input policy,
+// keyed by policy template name. The events from untrusted sources are ignored.
+func (r *PolicyReconciler) complianceEventsByTemplate(
+	policyName string, events []corev1.Event,
+) map[string][]policiesv1.ComplianceHistory {
+	eventsByTemplate := map[string][]policiesv1.ComplianceHistory{}
+
+	for _, event := range events {
+		templateName := complianceEventTemplate(policyName, event)
+		if templateName == "" {
+			continue
+		}
+
+		if !r.TrustedEventSources.Trusted(event.Source, event.ReportingController) {
+			log.Info("Ignoring the compliance event from an untrusted source", "policy", policyName,
+				"event", event.GetName(), "source", event.Source.Component)
+
+			continue
+		}
+
+		eventsByTemplate[templateName] = append(eventsByTemplate[templateName], policiesv1.ComplianceHistory{
+			LastTimestamp: r.ClockSkew.ToHubTime(event.LastTimestamp),
+			Message:       strings.TrimSpace(strings.TrimPrefix(event.Message, "(combined from similar events):")),
+			EventName:     event.GetName(),
+		})
+	}
+
+	return eventsByTemplate
+}
+
+// complianceEventTemplate returns the name of the policy template of the input event if it is a compliance event
+// involving the input policy, and an empty string otherwise.
+func complianceEventTemplate(policyName string, event corev1.Event) string {
+	// sample event.Reason -- reason: 'policy: calamari/policy-grc-rbactest-example'
+	match := complianceReasonRegex.FindStringSubmatch(event.Reason)
+	if event.InvolvedObject.Kind != policiesv1.Kind || event.InvolvedObject.APIVersion != policiesv1APIVersion ||
+		event.InvolvedObject.Name != policyName || match == nil {
+		return ""
+	}
+
+	return match[2]
+}
+
+// mergeTemplateHistory merges the input compliance events of a policy template of the input kind into the history of
+// its existing details, which is pruned to the last input limit of distinct entries. The complete merged history,
+// sorted from the newest entry, and the compliance state of the latest entry are returned. The compliance state is
+// empty if there is no history.
+func (r *PolicyReconciler) mergeTemplateHistory(
+	existingDpt *policiesv1.DetailsPerTemplate, events []policiesv1.ComplianceHistory, kind string, limit int,
+) ([]policiesv1.ComplianceHistory, policiesv1.ComplianceState) {
+	history := make([]policiesv1.ComplianceHistory, 0, len(events)+len(existingDpt.History))
+
+	// The messages interned in the previous status are restored so that the entries are compared on their messages
+	compliance.ExpandHistory(existingDpt.History)
+
+	for _, event := range events {
+		event.Message = truncateMessage(r.MessageNormalizer.Normalize(kind, event.Message), r.MessageSizeLimit)
+		history = append(history, event)
+	}
+
+	for _, ech := range existingDpt.History {
+		exists := false
+
+		for _, ch := range history {
+			if ch.LastTimestamp.Time.Equal(ech.LastTimestamp.Time) && ch.EventName == ech.EventName {
+				// do nothing
+				exists = true
+
+				break
+			}
+		}
+		// doesn't exists, append to history
+		if !exists {
+			history = append(history, ech)
+		}
+	}
+	// sort by lasttimestamp
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].LastTimestamp.Time.After(history[j].LastTimestamp.Time)
+	})
+	existingDpt.History = pruneHistory(collapseHistory(history), limit, r.MessageParser.ComplianceState)
+
+	if len(existingDpt.History) == 0 {
+		return history, ""
+	}
+
+	complianceState := r.MessageParser.ComplianceState(existingDpt.History[0].Message)
+
+	return history, r.Hysteresis.apply(existingDpt, existingDpt.History[0], complianceState)
+}
+
+// collapseHistory returns the input compliance history, sorted from the newest entry, with each run of consecutive
+// entries of the same event with the same message collapsed into its newest entry.
+func collapseHistory(history []policiesv1.ComplianceHistory) []policiesv1.ComplianceHistory {
+	newHistory := []policiesv1.ComplianceHistory{}
+
+	for historyIndex := 0; historyIndex < len(history); historyIndex++ {
+		newHistory = append(newHistory, history[historyIndex])
+
+		for j := historyIndex; j < len(history); j++ {
+			if history[historyIndex].EventName == history[j].EventName &&
+				history[historyIndex].Message == history[j].Message {
+				// same event, filter it
+			} else {
+				historyIndex = j - 1
+
+				break
+			}
+		}
+	}
+
+	return newHistory
+}
+
+// policyComplianceState returns the overall compliance state of a policy with the input policy template details. It is
+// NonCompliant if any policy template is NonCompliant, Compliant if all of them are Compliant, and empty otherwise. The
+// informational policy templates are ignored.
+func policyComplianceState(details []*policiesv1.DetailsPerTemplate) policiesv1.ComplianceState {
+	isCompliant := true
+
+	for _, dpt := range details {
+		if isInformational(dpt) {
+			continue
+		}
+
+		if dpt.ComplianceState == policiesv1.NonCompliant {
+			return policiesv1.NonCompliant
+		} else if dpt.ComplianceState != policiesv1.Compliant {
+			isCompliant = false
+		}
+	}
+
+	// set to compliant only when all templates are compliant
+	if isCompliant {
+		return policiesv1.Compliant
+	}
+
+	return ""
+}
+
+// historyLimit is the default number of compliance history entries kept for each policy template in the policy status.
+const historyLimit = 10
+
+// pruneHistory returns the first limit entries of the input history, sorted from the newest entry, while guaranteeing
+// that the newest entry of each compliance state in the history is kept. This way, a storm of NonCompliant entries
+// doesn't drop the context of when the policy template was last Compliant. The oldest entries of the compliance states
+// with several kept entries make room for them.
+func pruneHistory(
+	history []policiesv1.ComplianceHistory,
+	limit int,
+	stateOf func(message string) policiesv1.ComplianceState,
+) []policiesv1.ComplianceHistory {
+	if len(history) <= limit {
+		return history
+	}
+
+	states := make([]policiesv1.ComplianceState, len(history))
+	keptCount := map[policiesv1.ComplianceState]int{}
+	kept := make([]bool, len(history))
+
+	for i := range history {
+		states[i] = stateOf(history[i].Message)
+
+		if i < limit {
+			kept[i] = true
+			keptCount[states[i]]++
+		}
+	}
+
+	for i := limit; i < len(history); i++ {
+		if keptCount[states[i]] != 0 {
+			continue
+		}
+
+		// Make room by dropping the oldest kept entry of a compliance state with several kept entries
+		for j := i - 1; j >= 0; j-- {
+			if kept[j] && keptCount[states[j]] > 1 {
+				kept[j] = false
+				keptCount[states[j]]--
+
+				break
+			}
+		}
+
+		kept[i] = true
+		keptCount[states[i]]++
+	}
+
+	pruned := make([]policiesv1.ComplianceHistory, 0, limit)
+
+	for i := range history {
+		if kept[i] {
+			pruned = append(pruned, history[i])
+		}
+	}
+
+	return pruned
 }
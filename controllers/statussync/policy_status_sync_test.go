@@ -0,0 +1,89 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// historyOf returns a history sorted from the newest entry with the input messages.
+func historyOf(messages ...string) []policiesv1.ComplianceHistory {
+	now := time.Now()
+	history := make([]policiesv1.ComplianceHistory, 0, len(messages))
+
+	for i, message := range messages {
+		history = append(history, policiesv1.ComplianceHistory{
+			EventName:     fmt.Sprintf("event-%d", i),
+			Message:       message,
+			LastTimestamp: metav1.NewTime(now.Add(-time.Duration(i) * time.Minute)),
+		})
+	}
+
+	return history
+}
+
+func TestPruneHistoryNonCompliantStorm(t *testing.T) {
+	RegisterTestingT(t)
+
+	messages := []string{}
+	for i := 0; i < 15; i++ {
+		messages = append(messages, fmt.Sprintf("NonCompliant; violation %d", i))
+	}
+
+	messages = append(messages, "Compliant; no violations", "NonCompliant; violation 15")
+	history := historyOf(messages...)
+
+	var parser *ComplianceMessageParser
+
+	pruned := pruneHistory(history, historyLimit, parser.ComplianceState)
+	Expect(pruned).To(HaveLen(historyLimit))
+
+	// The newest entries are kept, except for the oldest one which makes room for the last Compliant entry
+	Expect(pruned[:historyLimit-1]).To(Equal(history[:historyLimit-1]))
+	Expect(pruned[historyLimit-1].Message).To(Equal("Compliant; no violations"))
+}
+
+func TestPruneHistoryCompliantStorm(t *testing.T) {
+	RegisterTestingT(t)
+
+	messages := []string{}
+	for i := 0; i < 20; i++ {
+		messages = append(messages, "Compliant; no violations")
+	}
+
+	messages = append(messages, "NonCompliant; violation")
+	history := historyOf(messages...)
+
+	var parser *ComplianceMessageParser
+
+	pruned := pruneHistory(history, historyLimit, parser.ComplianceState)
+	Expect(pruned).To(HaveLen(historyLimit))
+	Expect(pruned[historyLimit-1].Message).To(Equal("NonCompliant; violation"))
+
+	// The entries remain sorted from the newest entry
+	for i := 1; i < len(pruned); i++ {
+		Expect(pruned[i].LastTimestamp.Before(&pruned[i-1].LastTimestamp)).To(BeTrue())
+	}
+}
+
+func TestPruneHistoryNoPruning(t *testing.T) {
+	RegisterTestingT(t)
+
+	var parser *ComplianceMessageParser
+
+	// All the compliance states are already in the newest entries
+	history := historyOf("NonCompliant; violation", "Compliant; no violations", "NonCompliant; violation",
+		"NonCompliant; violation", "NonCompliant; violation", "NonCompliant; violation", "NonCompliant; violation",
+		"NonCompliant; violation", "NonCompliant; violation", "NonCompliant; violation", "Compliant; no violations")
+
+	Expect(pruneHistory(history, historyLimit, parser.ComplianceState)).To(Equal(history[:historyLimit]))
+
+	short := historyOf("Compliant; no violations")
+	Expect(pruneHistory(short, historyLimit, parser.ComplianceState)).To(Equal(short))
+}
@@ -0,0 +1,131 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Pending is the compliance state of a policy template that has not yet been evaluated in a way that allows its
+	// compliance to be asserted.
+	Pending policiesv1.ComplianceState = "Pending"
+	// readinessRequeueInterval is how long to wait before checking again if a template object is ready.
+	readinessRequeueInterval = 10 * time.Second
+)
+
+// readinessConditionTypes are the condition types that indicate whether an object is ready. If any of these are
+// present on the template object, they must all have a status of True for the object to be considered ready.
+var readinessConditionTypes = map[string]bool{
+	"Ready":       true,
+	"Established": true,
+	"Available":   true,
+}
+
+// readinessGated returns true if the input compliance state of a policy template must be held as Pending until its
+// template object is ready. The kinds evaluated by a policy engine adapter, such as the Gatekeeper constraints, are
+// gated until the engine enforces them, for both the Compliant and NonCompliant states, since the results of an object
+// that isn't enforced are incomplete. Other kinds only have their first Compliant state gated. Template errors are
+// never gated since they require action.
+func (r *PolicyReconciler) readinessGated(
+	gvk *schema.GroupVersionKind, dpt *policiesv1.DetailsPerTemplate, complianceState policiesv1.ComplianceState,
+) bool {
+	if len(dpt.History) != 0 && strings.Contains(dpt.History[0].Message, "template-error;") {
+		return false
+	}
+
+	if r.Engines.For(gvk.GroupKind()) != nil {
+		return complianceState == policiesv1.Compliant || complianceState == policiesv1.NonCompliant
+	}
+
+	return complianceState == policiesv1.Compliant && dpt.ComplianceState != policiesv1.Compliant &&
+		dpt.ComplianceState != policiesv1.NonCompliant
+}
+
+// templateReader returns the reader of the template objects on the managed cluster.
+func (r *PolicyReconciler) templateReader() client.Reader {
+	if r.ManagedAPIReader != nil {
+		return r.ManagedAPIReader
+	}
+
+	return r.ManagedClient
+}
+
+// templateObjectReady determines if the template object on the managed cluster has reached a ready state. Objects
+// that don't report any readiness information are considered ready as soon as they exist.
+func (r *PolicyReconciler) templateObjectReady(
+	ctx context.Context, gvk *schema.GroupVersionKind, name string, namespace string,
+) (bool, error) {
+	templateObj := &unstructured.Unstructured{}
+	templateObj.SetGroupVersionKind(*gvk)
+
+	err := r.templateReader().Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, templateObj)
+	if err != nil {
+		return false, err
+	}
+
+	return r.isObjectReady(templateObj), nil
+}
+
+// isObjectReady determines if the object is ready based on its status. The kinds evaluated by a policy engine adapter
+// are ready when the engine enforces them. Objects with a per-pod status are ready when all the pods report that the
+// object is enforced. Other objects are ready when all of their readiness conditions are True.
+func (r *PolicyReconciler) isObjectReady(obj *unstructured.Unstructured) bool {
+	if adapter := r.Engines.For(obj.GroupVersionKind().GroupKind()); adapter != nil {
+		status := adapter.CollectStatus(obj)
+		if !status.Enforced {
+			log.V(2).Info("The policy engine doesn't enforce the template object", "engine", adapter.Name(),
+				"kind", obj.GetKind(), "name", obj.GetName(), "reason", status.Message)
+		}
+
+		return status.Enforced
+	}
+
+	byPod, found, _ := unstructured.NestedSlice(obj.Object, "status", "byPod")
+	if found {
+		if len(byPod) == 0 {
+			return false
+		}
+
+		for _, podStatus := range byPod {
+			podStatusMap, ok := podStatus.(map[string]interface{})
+			if !ok {
+				return false
+			}
+
+			if enforced, _, _ := unstructured.NestedBool(podStatusMap, "enforced"); !enforced {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		conditionType, _, _ := unstructured.NestedString(conditionMap, "type")
+		if !readinessConditionTypes[conditionType] {
+			continue
+		}
+
+		if status, _, _ := unstructured.NestedString(conditionMap, "status"); status != "True" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,77 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines/gatekeeper"
+)
+
+func TestReadinessGated(t *testing.T) {
+	t.Parallel()
+	RegisterTestingT(t)
+
+	r := &PolicyReconciler{Engines: engines.NewRegistry(&gatekeeper.Adapter{})}
+	constraint := &schema.GroupVersionKind{
+		Group: gatekeeper.ConstraintGroup, Version: "v1beta1", Kind: "K8sRequiredLabels",
+	}
+	configPolicy := &schema.GroupVersionKind{
+		Group: "policy.open-cluster-management.io", Version: "v1", Kind: "ConfigurationPolicy",
+	}
+	asserted := &policiesv1.DetailsPerTemplate{
+		ComplianceState: policiesv1.Compliant,
+		History:         []policiesv1.ComplianceHistory{{Message: "Compliant; notification - all good"}},
+	}
+	templateError := &policiesv1.DetailsPerTemplate{
+		History: []policiesv1.ComplianceHistory{{Message: "NonCompliant; template-error; bad template"}},
+	}
+
+	Expect(r.readinessGated(configPolicy, &policiesv1.DetailsPerTemplate{}, policiesv1.Compliant)).To(BeTrue())
+	Expect(r.readinessGated(configPolicy, &policiesv1.DetailsPerTemplate{}, policiesv1.NonCompliant)).To(BeFalse())
+	Expect(r.readinessGated(configPolicy, asserted, policiesv1.Compliant)).To(BeFalse())
+	Expect(r.readinessGated(constraint, &policiesv1.DetailsPerTemplate{}, policiesv1.NonCompliant)).To(BeTrue())
+	Expect(r.readinessGated(constraint, asserted, policiesv1.Compliant)).To(BeTrue())
+	Expect(r.readinessGated(constraint, templateError, policiesv1.NonCompliant)).To(BeFalse())
+
+	// Without the Gatekeeper adapter, the constraints are gated like the other kinds
+	r.Engines = nil
+	Expect(r.readinessGated(constraint, asserted, policiesv1.Compliant)).To(BeFalse())
+}
+
+func TestIsObjectReadyGatekeeper(t *testing.T) {
+	t.Parallel()
+	RegisterTestingT(t)
+
+	r := &PolicyReconciler{Engines: engines.NewRegistry(&gatekeeper.Adapter{})}
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gatekeeper.ConstraintGroup + "/v1beta1",
+		"kind":       "K8sRequiredLabels",
+	}}
+	Expect(r.isObjectReady(constraint)).To(BeFalse())
+
+	constraint.Object["status"] = map[string]interface{}{
+		"byPod": []interface{}{
+			map[string]interface{}{"id": "gatekeeper-audit", "enforced": true},
+			map[string]interface{}{"id": "gatekeeper-controller-manager-0", "enforced": false},
+		},
+	}
+	Expect(r.isObjectReady(constraint)).To(BeFalse())
+
+	constraint.Object["status"] = map[string]interface{}{
+		"byPod": []interface{}{
+			map[string]interface{}{"id": "gatekeeper-audit", "enforced": true},
+			map[string]interface{}{"id": "gatekeeper-controller-manager-0", "enforced": true},
+		},
+	}
+	Expect(r.isObjectReady(constraint)).To(BeTrue())
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+	Expect(r.isObjectReady(configMap)).To(BeTrue())
+}
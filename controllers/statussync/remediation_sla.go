@@ -0,0 +1,139 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	// RemediationSLAAnnotation is set on a policy on the Hub to the maximum duration (e.g. 24h) that its policy
+	// templates may stay NonCompliant before the remediation SLA is breached.
+	RemediationSLAAnnotation = "policy.open-cluster-management.io/remediation-sla"
+	// SLABreachedAnnotation is set on the template metadata in the policy status while the policy template breaches the
+	// remediation SLA of the policy, to the time since which it is NonCompliant.
+	SLABreachedAnnotation = "policy.open-cluster-management.io/remediation-sla-breached"
+)
+
+var slaBreachGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "policy_remediation_sla_breaches",
+		Help: "The number of policy templates of a policy which stayed NonCompliant longer than the remediation SLA " +
+			"set by the " + RemediationSLAAnnotation + " annotation.",
+	},
+	[]string{"policy", "policy_namespace"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(slaBreachGauge)
+}
+
+// remediationSLA returns the remediation SLA of the input Hub policy, or zero if it doesn't have a valid one.
+func remediationSLA(hubPlc *policiesv1.Policy) time.Duration {
+	value, ok := hubPlc.GetAnnotations()[RemediationSLAAnnotation]
+	if !ok {
+		return 0
+	}
+
+	sla, err := time.ParseDuration(value)
+	if err != nil || sla <= 0 {
+		log.Info("Ignoring the invalid remediation SLA annotation", "policy", hubPlc.GetName(), "value", value)
+
+		return 0
+	}
+
+	return sla
+}
+
+// nonCompliantSince returns the time since which the input NonCompliant policy template is NonCompliant, as recorded
+// in the utils.LastTransitionTimeAnnotation annotation of its template metadata by applyComplianceTimestamps, which
+// must be called first. Unlike the history, which is pruned and collapses the recurring events, the recorded time
+// covers the whole time spent NonCompliant. The zero time is returned if it isn't recorded.
+func nonCompliantSince(dpt *policiesv1.DetailsPerTemplate) time.Time {
+	return utils.TemplateTimestamp(dpt, utils.LastTransitionTimeAnnotation)
+}
+
+// applyRemediationSLA records the breaches of the input remediation SLA on the template metadata of the input policy
+// template details, whose compliance timestamps must already be applied. The names of the policy templates which
+// newly breach the SLA are returned along with the duration until the next policy template breaches it, which is zero
+// if none will without a new evaluation.
+func (r *PolicyReconciler) applyRemediationSLA(
+	sla time.Duration, details []*policiesv1.DetailsPerTemplate, now time.Time,
+) (breached []string, untilNextBreach time.Duration) {
+	for _, dpt := range details {
+		var since time.Time
+
+		if sla > 0 && dpt.ComplianceState == policiesv1.NonCompliant && !isInformational(dpt) {
+			since = nonCompliantSince(dpt)
+		}
+
+		if since.IsZero() {
+			removeTemplateAnnotation(dpt, SLABreachedAnnotation)
+
+			continue
+		}
+
+		if untilBreach := since.Add(sla).Sub(now); untilBreach > 0 {
+			removeTemplateAnnotation(dpt, SLABreachedAnnotation)
+
+			if untilNextBreach == 0 || untilBreach < untilNextBreach {
+				untilNextBreach = untilBreach
+			}
+
+			continue
+		}
+
+		if _, ok := dpt.TemplateMeta.Annotations[SLABreachedAnnotation]; !ok {
+			breached = append(breached, dpt.TemplateMeta.Name)
+		}
+
+		if dpt.TemplateMeta.Annotations == nil {
+			dpt.TemplateMeta.Annotations = map[string]string{}
+		}
+
+		dpt.TemplateMeta.Annotations[SLABreachedAnnotation] = since.UTC().Format(time.RFC3339)
+	}
+
+	return breached, untilNextBreach
+}
+
+// recordSLABreaches sets the remediation SLA breach metric of the input policy from its policy template details.
+func recordSLABreaches(policy types.NamespacedName, details []*policiesv1.DetailsPerTemplate) {
+	count := 0
+
+	for _, dpt := range details {
+		if _, ok := dpt.TemplateMeta.Annotations[SLABreachedAnnotation]; ok {
+			count++
+		}
+	}
+
+	if count == 0 {
+		slaBreachGauge.DeleteLabelValues(policy.Name, policy.Namespace)
+
+		return
+	}
+
+	slaBreachGauge.WithLabelValues(policy.Name, policy.Namespace).Set(float64(count))
+}
+
+// removeTemplateAnnotation removes the input annotation from the template metadata of the input policy template
+// details.
+func removeTemplateAnnotation(dpt *policiesv1.DetailsPerTemplate, annotation string) {
+	if _, ok := dpt.TemplateMeta.Annotations[annotation]; !ok {
+		return
+	}
+
+	delete(dpt.TemplateMeta.Annotations, annotation)
+
+	if len(dpt.TemplateMeta.Annotations) == 0 {
+		dpt.TemplateMeta.Annotations = nil
+	}
+}
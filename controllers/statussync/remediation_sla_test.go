@@ -0,0 +1,107 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestRemediationSLA(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{}
+	Expect(remediationSLA(hubPlc)).To(BeZero())
+
+	hubPlc.SetAnnotations(map[string]string{RemediationSLAAnnotation: "24h"})
+	Expect(remediationSLA(hubPlc)).To(Equal(24 * time.Hour))
+
+	hubPlc.SetAnnotations(map[string]string{RemediationSLAAnnotation: "tomorrow"})
+	Expect(remediationSLA(hubPlc)).To(BeZero())
+}
+
+func TestApplyRemediationSLA(t *testing.T) {
+	RegisterTestingT(t)
+
+	r := &PolicyReconciler{}
+	now := time.Now().Truncate(time.Second)
+
+	breaching := &policiesv1.DetailsPerTemplate{
+		TemplateMeta:    metav1.ObjectMeta{Name: "breaching"},
+		ComplianceState: policiesv1.NonCompliant,
+		History: []policiesv1.ComplianceHistory{
+			evaluation(now.Add(-time.Minute), "NonCompliant; violation"),
+			evaluation(now.Add(-2*time.Hour), "NonCompliant; violation"),
+			evaluation(now.Add(-3*time.Hour), "Compliant; notification"),
+		},
+	}
+	pending := &policiesv1.DetailsPerTemplate{
+		TemplateMeta:    metav1.ObjectMeta{Name: "pending"},
+		ComplianceState: policiesv1.NonCompliant,
+		History: []policiesv1.ComplianceHistory{
+			evaluation(now.Add(-30*time.Minute), "NonCompliant; violation"),
+		},
+	}
+	// The history was pruned to the latest evaluation, but the transition time is recorded
+	pruned := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{
+			Name: "pruned",
+			Annotations: map[string]string{
+				utils.LastTransitionTimeAnnotation: now.Add(-5 * time.Hour).UTC().Format(time.RFC3339),
+			},
+		},
+		ComplianceState: policiesv1.NonCompliant,
+		History: []policiesv1.ComplianceHistory{
+			evaluation(now.Add(-time.Minute), "NonCompliant; violation"),
+		},
+	}
+	compliant := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{
+			Name:        "compliant",
+			Annotations: map[string]string{SLABreachedAnnotation: now.Add(-5 * time.Hour).Format(time.RFC3339)},
+		},
+		ComplianceState: policiesv1.Compliant,
+		History:         []policiesv1.ComplianceHistory{evaluation(now, "Compliant; notification")},
+	}
+	details := []*policiesv1.DetailsPerTemplate{breaching, pending, pruned, compliant}
+
+	r.applyComplianceTimestamps(details)
+
+	breached, untilNextBreach := r.applyRemediationSLA(time.Hour, details, now)
+	Expect(breached).To(Equal([]string{"breaching", "pruned"}))
+	Expect(untilNextBreach).To(Equal(30 * time.Minute))
+	Expect(breaching.TemplateMeta.Annotations).To(HaveKeyWithValue(
+		SLABreachedAnnotation, now.Add(-2*time.Hour).UTC().Format(time.RFC3339),
+	))
+	Expect(pruned.TemplateMeta.Annotations).To(HaveKeyWithValue(
+		SLABreachedAnnotation, now.Add(-5*time.Hour).UTC().Format(time.RFC3339),
+	))
+	Expect(pending.TemplateMeta.Annotations).ToNot(HaveKey(SLABreachedAnnotation))
+	Expect(compliant.TemplateMeta.Annotations).ToNot(HaveKey(SLABreachedAnnotation))
+
+	policy := types.NamespacedName{Namespace: "managed", Name: "policy"}
+
+	recordSLABreaches(policy, details)
+	Expect(testutil.ToFloat64(slaBreachGauge.WithLabelValues("policy", "managed"))).To(Equal(2.0))
+
+	// An ongoing breach is only reported once
+	breached, _ = r.applyRemediationSLA(time.Hour, details, now.Add(time.Minute))
+	Expect(breached).To(BeEmpty())
+
+	// Without an SLA, the breaches are cleared
+	breached, untilNextBreach = r.applyRemediationSLA(0, details, now)
+	Expect(breached).To(BeEmpty())
+	Expect(untilNextBreach).To(BeZero())
+	Expect(breaching.TemplateMeta.Annotations).ToNot(HaveKey(SLABreachedAnnotation))
+
+	recordSLABreaches(policy, details)
+	Expect(testutil.CollectAndCount(slaBreachGauge)).To(BeZero())
+}
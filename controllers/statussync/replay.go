@@ -0,0 +1,64 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// ReplayStatus returns the status of the input policy after merging the input events with the same logic as the
+// reconcile, but without a cluster. This reproduces status anomalies offline from a dump of the policy and the events
+// in the cluster namespace. The readiness gates are not applied since they need the template objects.
+func (r *PolicyReconciler) ReplayStatus(
+	policy *policiesv1.Policy, events []corev1.Event,
+) (policiesv1.PolicyStatus, error) {
+	policy = policy.DeepCopy()
+	eventsByTemplate := r.complianceEventsByTemplate(policy.GetName(), events)
+	status := policiesv1.PolicyStatus{}
+	limit := r.templateHistoryLimit(policy)
+
+	for tIndex, policyT := range policy.Spec.PolicyTemplates {
+		object, gvk, err := unstructured.UnstructuredJSONScheme.Decode(policyT.ObjectDefinition.Raw, nil, nil)
+		if err != nil {
+			return status, fmt.Errorf("failed to decode the policy template at index %d: %w", tIndex, err)
+		}
+
+		tName := object.(metav1.Object).GetName()
+		existingDpt := &policiesv1.DetailsPerTemplate{
+			TemplateMeta: metav1.ObjectMeta{Name: tName},
+			History:      []policiesv1.ComplianceHistory{},
+		}
+
+		for _, dpt := range policy.Status.Details {
+			if dpt.TemplateMeta.Name == tName {
+				existingDpt = dpt
+
+				break
+			}
+		}
+
+		markInformational(object.(metav1.Object), existingDpt)
+
+		_, complianceState := r.mergeTemplateHistory(existingDpt, eventsByTemplate[tName], gvk.Kind, limit)
+		if len(existingDpt.History) > 0 {
+			existingDpt.ComplianceState = complianceState
+		}
+
+		status.Details = append(status.Details, existingDpt)
+	}
+
+	if r.InternHistoryMessages {
+		internHistory(status.Details)
+	}
+
+	trimToBudget(status.Details, r.StatusSizeBudget)
+
+	status.ComplianceState = policyComplianceState(status.Details)
+
+	return status, nil
+}
@@ -0,0 +1,78 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func replayEvent(name string, template string, message string, lastTimestamp time.Time) corev1.Event {
+	return corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed"},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: policiesv1.Kind, APIVersion: policiesv1APIVersion, Name: "policy", Namespace: "managed",
+		},
+		Reason:        "policy: managed/" + template,
+		Message:       message,
+		LastTimestamp: metav1.NewTime(lastTimestamp),
+	}
+}
+
+func TestReplayStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"},
+		Spec: policiesv1.PolicySpec{
+			PolicyTemplates: []*policiesv1.PolicyTemplate{
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(
+					`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+						`"metadata":{"name":"template-a"}}`,
+				)}},
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(
+					`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+						`"metadata":{"name":"template-b"}}`,
+				)}},
+			},
+		},
+	}
+
+	now := time.Now().Truncate(time.Second)
+	events := []corev1.Event{
+		replayEvent("event-1", "template-a", "NonCompliant; violation", now.Add(-time.Minute)),
+		replayEvent("event-2", "template-a", "Compliant; no violations", now),
+		replayEvent("event-3", "template-b", "(combined from similar events): NonCompliant; violation", now),
+		// Events of other policies are ignored
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "event-4", Namespace: "managed"},
+			InvolvedObject: corev1.ObjectReference{
+				Kind: policiesv1.Kind, APIVersion: policiesv1APIVersion, Name: "other",
+			},
+			Reason:        "policy: managed/template-a",
+			Message:       "NonCompliant; violation",
+			LastTimestamp: metav1.NewTime(now.Add(time.Minute)),
+		},
+	}
+
+	r := &PolicyReconciler{}
+
+	status, err := r.ReplayStatus(policy, events)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+	Expect(status.Details).To(HaveLen(2))
+	Expect(status.Details[0].ComplianceState).To(Equal(policiesv1.Compliant))
+	Expect(status.Details[0].History).To(HaveLen(2))
+	Expect(status.Details[0].History[0].EventName).To(Equal("event-2"))
+	Expect(status.Details[1].ComplianceState).To(Equal(policiesv1.NonCompliant))
+	Expect(status.Details[1].History[0].Message).To(Equal("NonCompliant; violation"))
+
+	// The input policy is not modified
+	Expect(policy.Status.Details).To(BeEmpty())
+}
@@ -0,0 +1,266 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// searchExportRetryInterval is the interval after which a policy is reconciled again when its export failed.
+const searchExportRetryInterval = time.Minute
+
+// ErrInvalidSearchExportEndpoint is returned when the search export endpoint is not an http, https, or file URL.
+var ErrInvalidSearchExportEndpoint = errors.New("the search export endpoint must be an http, https, or file URL")
+
+// SearchResource is a resource in the schema consumed by the OCM search indexer.
+type SearchResource struct {
+	Kind           string                 `json:"kind"`
+	UID            string                 `json:"uid"`
+	ResourceString string                 `json:"resourceString"`
+	Properties     map[string]interface{} `json:"properties"`
+}
+
+// SearchDeletion identifies a resource deleted from the OCM search index.
+type SearchDeletion struct {
+	UID string `json:"uid"`
+}
+
+// SearchSyncEvent is the document sent to the OCM search indexer with the resources to add, update, or delete.
+type SearchSyncEvent struct {
+	AddResources    []SearchResource `json:"addResources,omitempty"`
+	UpdateResources []SearchResource `json:"updateResources,omitempty"`
+	DeleteResources []SearchDeletion `json:"deleteResources,omitempty"`
+}
+
+// searchSink delivers the search documents of a policy.
+type searchSink interface {
+	send(ctx context.Context, policy types.NamespacedName, event *SearchSyncEvent) error
+}
+
+// SearchExporter exports the compliance of the policies on the managed cluster in the schema consumed by the OCM search
+// indexer, so that the search indexing doesn't require a separate collector to derive the same data. Only the changes
+// are exported, and the export failures are retried on the next reconcile of the policy.
+type SearchExporter struct {
+	// ClusterName is the name of the managed cluster on the Hub, which prefixes the UIDs of the resources.
+	ClusterName string
+	sink        searchSink
+	// exported maps each exported policy to its last exported resource
+	exported map[types.NamespacedName]SearchResource
+	lock     sync.Mutex
+}
+
+// NewSearchExporter returns a SearchExporter for the input endpoint. An http or https endpoint receives each document
+// in a POST request, and a file endpoint is a directory in which each policy has a document.
+func NewSearchExporter(endpoint string, clusterName string) (*SearchExporter, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSearchExportEndpoint, err)
+	}
+
+	exporter := &SearchExporter{ClusterName: clusterName, exported: map[types.NamespacedName]SearchResource{}}
+
+	switch endpointURL.Scheme {
+	case "http", "https":
+		exporter.sink = &httpSearchSink{url: endpoint, client: &http.Client{Timeout: 30 * time.Second}}
+	case "file":
+		exporter.sink = &fileSearchSink{directory: endpointURL.Path}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSearchExportEndpoint, endpoint)
+	}
+
+	return exporter, nil
+}
+
+// searchResource returns the search resource of the input policy, with the properties set by the OCM search collector
+// for policies.
+func (e *SearchExporter) searchResource(plc *policiesv1.Policy) SearchResource {
+	properties := map[string]interface{}{
+		"kind":              policiesv1.Kind,
+		"apigroup":          policiesv1.GroupVersion.Group,
+		"apiversion":        policiesv1.GroupVersion.Version,
+		"name":              plc.GetName(),
+		"namespace":         plc.GetNamespace(),
+		"cluster":           e.ClusterName,
+		"created":           plc.GetCreationTimestamp().UTC().Format(time.RFC3339),
+		"compliant":         string(plc.Status.ComplianceState),
+		"remediationAction": string(plc.Spec.RemediationAction),
+		"disabled":          plc.Spec.Disabled,
+	}
+
+	if len(plc.GetLabels()) != 0 {
+		properties["label"] = plc.GetLabels()
+	}
+
+	return SearchResource{
+		Kind:           policiesv1.Kind,
+		UID:            e.ClusterName + "/" + string(plc.GetUID()),
+		ResourceString: "policies",
+		Properties:     properties,
+	}
+}
+
+// Export exports the compliance of the input policy if it changed since it was last exported. A nil SearchExporter
+// does nothing.
+func (e *SearchExporter) Export(ctx context.Context, plc *policiesv1.Policy) error {
+	if e == nil {
+		return nil
+	}
+
+	policy := types.NamespacedName{Namespace: plc.GetNamespace(), Name: plc.GetName()}
+	resource := e.searchResource(plc)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	event := &SearchSyncEvent{}
+
+	if previous, ok := e.exported[policy]; !ok {
+		event.AddResources = []SearchResource{resource}
+	} else if previous.UID != resource.UID {
+		// The policy was recreated
+		event.DeleteResources = []SearchDeletion{{UID: previous.UID}}
+		event.AddResources = []SearchResource{resource}
+	} else if !searchResourceChanged(previous, resource) {
+		return nil
+	} else {
+		event.UpdateResources = []SearchResource{resource}
+	}
+
+	if err := e.sink.send(ctx, policy, event); err != nil {
+		return err
+	}
+
+	e.exported[policy] = resource
+
+	return nil
+}
+
+// Delete exports the deletion of the input policy if it was exported. A nil SearchExporter does nothing.
+func (e *SearchExporter) Delete(ctx context.Context, policy types.NamespacedName) error {
+	if e == nil {
+		return nil
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	previous, ok := e.exported[policy]
+	if !ok {
+		return nil
+	}
+
+	event := &SearchSyncEvent{DeleteResources: []SearchDeletion{{UID: previous.UID}}}
+
+	if err := e.sink.send(ctx, policy, event); err != nil {
+		return err
+	}
+
+	delete(e.exported, policy)
+
+	return nil
+}
+
+// searchResourceChanged returns true if the properties of the input resources differ, as compared through their JSON.
+func searchResourceChanged(previous, current SearchResource) bool {
+	previousJSON, err := json.Marshal(previous)
+	if err != nil {
+		return true
+	}
+
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return true
+	}
+
+	return !bytes.Equal(previousJSON, currentJSON)
+}
+
+// httpSearchSink sends each document in a POST request to the URL.
+type httpSearchSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSearchSink) send(ctx context.Context, _ types.NamespacedName, event *SearchSyncEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the search export endpoint returned the status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// fileSearchSink writes the last document of each policy to a file named after the policy in the directory. The file
+// is removed when the policy is deleted.
+type fileSearchSink struct {
+	directory string
+}
+
+func (s *fileSearchSink) send(_ context.Context, policy types.NamespacedName, event *SearchSyncEvent) error {
+	path := filepath.Join(s.directory, policy.Namespace+"."+policy.Name+".json")
+
+	if len(event.AddResources) == 0 && len(event.UpdateResources) == 0 {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	body, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that readers never see a partial document
+	tmpFile, err := os.CreateTemp(s.directory, "."+strings.TrimSuffix(filepath.Base(path), ".json")+"-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmpFile.Write(body)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
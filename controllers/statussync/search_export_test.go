@@ -0,0 +1,96 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func searchTestPolicy() *policiesv1.Policy {
+	return &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "default.policy", Namespace: "managed", UID: "1234"},
+		Spec:       policiesv1.PolicySpec{RemediationAction: policiesv1.Inform},
+		Status:     policiesv1.PolicyStatus{ComplianceState: policiesv1.NonCompliant},
+	}
+}
+
+func TestSearchExporterHTTP(t *testing.T) {
+	RegisterTestingT(t)
+
+	events := []SearchSyncEvent{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := SearchSyncEvent{}
+		Expect(json.NewDecoder(r.Body).Decode(&event)).To(Succeed())
+
+		events = append(events, event)
+	}))
+	defer server.Close()
+
+	exporter, err := NewSearchExporter(server.URL, "cluster1")
+	Expect(err).To(BeNil())
+
+	plc := searchTestPolicy()
+	Expect(exporter.Export(context.TODO(), plc)).To(Succeed())
+	// Unchanged policies are not exported again
+	Expect(exporter.Export(context.TODO(), plc)).To(Succeed())
+
+	plc.Status.ComplianceState = policiesv1.Compliant
+	Expect(exporter.Export(context.TODO(), plc)).To(Succeed())
+	Expect(exporter.Delete(context.TODO(), types.NamespacedName{Namespace: "managed", Name: "default.policy"})).
+		To(Succeed())
+
+	Expect(events).To(HaveLen(3))
+	Expect(events[0].AddResources).To(HaveLen(1))
+	Expect(events[0].AddResources[0].UID).To(Equal("cluster1/1234"))
+	Expect(events[0].AddResources[0].Properties).To(HaveKeyWithValue("compliant", "NonCompliant"))
+	Expect(events[0].AddResources[0].Properties).To(HaveKeyWithValue("cluster", "cluster1"))
+	Expect(events[1].UpdateResources[0].Properties).To(HaveKeyWithValue("compliant", "Compliant"))
+	Expect(events[2].DeleteResources).To(Equal([]SearchDeletion{{UID: "cluster1/1234"}}))
+}
+
+func TestSearchExporterFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir := t.TempDir()
+
+	exporter, err := NewSearchExporter("file://"+dir, "cluster1")
+	Expect(err).To(BeNil())
+
+	Expect(exporter.Export(context.TODO(), searchTestPolicy())).To(Succeed())
+
+	path := filepath.Join(dir, "managed.default.policy.json")
+	content, err := os.ReadFile(path)
+	Expect(err).To(BeNil())
+
+	event := SearchSyncEvent{}
+	Expect(json.Unmarshal(content, &event)).To(Succeed())
+	Expect(event.AddResources[0].Properties).To(HaveKeyWithValue("name", "default.policy"))
+
+	Expect(exporter.Delete(context.TODO(), types.NamespacedName{Namespace: "managed", Name: "default.policy"})).
+		To(Succeed())
+
+	_, err = os.Stat(path)
+	Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func TestNewSearchExporterInvalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewSearchExporter("ftp://example.com", "cluster1")
+	Expect(errors.Is(err, ErrInvalidSearchExportEndpoint)).To(BeTrue())
+
+	var nilExporter *SearchExporter
+	Expect(nilExporter.Export(context.TODO(), searchTestPolicy())).To(Succeed())
+}
@@ -0,0 +1,132 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"k8s.io/client-go/util/retry"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// statusWriterLimit is the maximum number of addon instances recorded in the StatusWriterAnnotation annotation. The
+// instances with the fewest status writes are dropped first, other than the current writer.
+const statusWriterLimit = 5
+
+// statusWriterRecord is the content of the StatusWriterAnnotation annotation. Sequences is a version vector with the
+// number of status writes of each addon instance, so another instance writing the status in between is detected.
+type statusWriterRecord struct {
+	Writer    string           `json:"writer"`
+	Sequences map[string]int64 `json:"sequences"`
+}
+
+// StatusAuditor stamps the replicated policies on the Hub with the addon instance which wrote their status, so that
+// the status writes of the addon can be told apart from those of other actors, and concurrent writers in a highly
+// available deployment are detected. A nil StatusAuditor does nothing.
+type StatusAuditor struct {
+	// InstanceID identifies the addon instance, such as the name of its pod.
+	InstanceID string
+}
+
+// stamp records a status write of the addon instance in the StatusWriterAnnotation annotation of the input Hub policy.
+// The annotation is patched with an optimistic lock, so a concurrent writer causes a conflict and the patch is retried
+// on the latest policy.
+func (a *StatusAuditor) stamp(ctx context.Context, hubClient client.Client, hubPlc *policiesv1.Policy) error {
+	if a == nil {
+		return nil
+	}
+
+	attempt := 0
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		attempt++
+
+		if attempt > 1 {
+			if err := hubClient.Get(ctx, client.ObjectKeyFromObject(hubPlc), hubPlc); err != nil {
+				return err
+			}
+		}
+
+		record := getStatusWriterRecord(hubPlc)
+
+		if record.Writer != "" && record.Writer != a.InstanceID {
+			log.Info("The policy status on the Hub was last written by another addon instance",
+				"namespace", hubPlc.GetNamespace(), "name", hubPlc.GetName(), "previousWriter", record.Writer,
+				"previousSequence", record.Sequences[record.Writer], "instance", a.InstanceID)
+		}
+
+		record.Writer = a.InstanceID
+		record.Sequences[a.InstanceID]++
+		pruneStatusWriters(record)
+
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		patch := client.MergeFromWithOptions(hubPlc.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+		annotations := hubPlc.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[utils.StatusWriterAnnotation] = string(value)
+		hubPlc.SetAnnotations(annotations)
+
+		return hubClient.Patch(ctx, hubPlc, patch)
+	})
+}
+
+// getStatusWriterRecord returns the status writer record of the input Hub policy. An invalid or missing annotation
+// results in an empty record.
+func getStatusWriterRecord(hubPlc *policiesv1.Policy) *statusWriterRecord {
+	record := &statusWriterRecord{}
+
+	if value, ok := hubPlc.GetAnnotations()[utils.StatusWriterAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), record); err != nil {
+			log.Info("Ignoring the invalid status writer annotation", "name", hubPlc.GetName(), "value", value)
+
+			record = &statusWriterRecord{}
+		}
+	}
+
+	if record.Sequences == nil {
+		record.Sequences = map[string]int64{}
+	}
+
+	return record
+}
+
+// pruneStatusWriters drops the instances with the fewest status writes, other than the current writer, until at most
+// statusWriterLimit instances are recorded.
+func pruneStatusWriters(record *statusWriterRecord) {
+	if len(record.Sequences) <= statusWriterLimit {
+		return
+	}
+
+	others := make([]string, 0, len(record.Sequences))
+
+	for instance := range record.Sequences {
+		if instance != record.Writer {
+			others = append(others, instance)
+		}
+	}
+
+	sort.Slice(others, func(i, j int) bool {
+		if record.Sequences[others[i]] != record.Sequences[others[j]] {
+			return record.Sequences[others[i]] < record.Sequences[others[j]]
+		}
+
+		return others[i] < others[j]
+	})
+
+	for _, instance := range others[:len(record.Sequences)-statusWriterLimit] {
+		delete(record.Sequences, instance)
+	}
+}
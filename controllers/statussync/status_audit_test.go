@@ -0,0 +1,68 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestStatusAuditorStamp(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster1"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).Build()
+
+	var nilAuditor *StatusAuditor
+	Expect(nilAuditor.stamp(context.TODO(), c, policy)).To(Succeed())
+
+	hubPlc := &policiesv1.Policy{}
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), hubPlc)).To(Succeed())
+
+	// The stale copy is patched after the policy was changed by another actor
+	stale := hubPlc.DeepCopy()
+	hubPlc.Labels = map[string]string{"updated": "true"}
+	Expect(c.Update(context.TODO(), hubPlc)).To(Succeed())
+
+	podA := &StatusAuditor{InstanceID: "addon-a"}
+	Expect(podA.stamp(context.TODO(), c, stale)).To(Succeed())
+	Expect(podA.stamp(context.TODO(), c, stale)).To(Succeed())
+
+	podB := &StatusAuditor{InstanceID: "addon-b"}
+	Expect(podB.stamp(context.TODO(), c, stale)).To(Succeed())
+
+	updated := &policiesv1.Policy{}
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), updated)).To(Succeed())
+	Expect(updated.Labels).To(HaveKeyWithValue("updated", "true"))
+	Expect(updated.Annotations).To(HaveKeyWithValue(
+		utils.StatusWriterAnnotation, `{"writer":"addon-b","sequences":{"addon-a":2,"addon-b":1}}`,
+	))
+}
+
+func TestPruneStatusWriters(t *testing.T) {
+	RegisterTestingT(t)
+
+	record := &statusWriterRecord{
+		Writer: "addon-f",
+		Sequences: map[string]int64{
+			"addon-a": 9, "addon-b": 1, "addon-c": 7, "addon-d": 2, "addon-e": 5, "addon-f": 1,
+		},
+	}
+
+	pruneStatusWriters(record)
+	Expect(record.Sequences).To(Equal(map[string]int64{
+		"addon-a": 9, "addon-c": 7, "addon-d": 2, "addon-e": 5, "addon-f": 1,
+	}))
+}
@@ -0,0 +1,103 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// truncatedSuffix is appended to the compliance messages truncated to the message size limit.
+const truncatedSuffix = " [truncated]"
+
+// truncateMessage returns the input compliance message truncated to the input limit in bytes, including the
+// truncatedSuffix suffix, without splitting a multi-byte character. The compliance state prefix of the message is kept
+// since it is at its start. A limit of zero or less doesn't truncate the message.
+func truncateMessage(message string, limit int) string {
+	if limit <= 0 || len(message) <= limit {
+		return message
+	}
+
+	end := limit - len(truncatedSuffix)
+	if end < 0 {
+		end = 0
+	}
+
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+
+	return message[:end] + truncatedSuffix
+}
+
+// trimToBudget removes the oldest compliance history entries of the input policy template details until their JSON
+// size is within the input budget in bytes, always from the policy template with the most entries, so that a policy
+// template with huge messages doesn't push the policy status over the etcd object size limit. The newest entry of each
+// policy template is always kept. The number of removed entries is returned. A budget of zero or less removes nothing.
+// The details are serialized once, and the size of each removed entry is then subtracted from their size.
+func trimToBudget(details []*policiesv1.DetailsPerTemplate, budget int) int {
+	if budget <= 0 {
+		return 0
+	}
+
+	size := statusSize(details)
+	removed := 0
+
+	for size > budget {
+		var largest *policiesv1.DetailsPerTemplate
+
+		for _, dpt := range details {
+			if dpt != nil && len(dpt.History) > 1 && (largest == nil || len(dpt.History) > len(largest.History)) {
+				largest = dpt
+			}
+		}
+
+		if largest == nil {
+			break
+		}
+
+		// The entry is removed along with the comma separating it from the previous entry
+		size -= historyEntrySize(largest.History[len(largest.History)-1]) + 1
+		largest.History = largest.History[:len(largest.History)-1]
+		removed++
+	}
+
+	return removed
+}
+
+// fitToBudget sets the annotations of the compliance history entries on the input policy template details with the
+// input annotate function and removes the oldest entries like trimToBudget, so that the annotations are also counted
+// against the input budget. Since the annotations only describe the entries in the history, they are set again once
+// entries are removed, which can only make the details smaller. The number of removed entries is returned.
+func fitToBudget(details []*policiesv1.DetailsPerTemplate, budget int, annotate func()) int {
+	annotate()
+
+	removed := trimToBudget(details, budget)
+	if removed != 0 {
+		annotate()
+	}
+
+	return removed
+}
+
+// historyEntrySize returns the size of the input compliance history entry when serialized to JSON.
+func historyEntrySize(entry policiesv1.ComplianceHistory) int {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return 0
+	}
+
+	return len(content)
+}
+
+// statusSize returns the size of the input policy template details when serialized to JSON.
+func statusSize(details []*policiesv1.DetailsPerTemplate) int {
+	content, err := json.Marshal(details)
+	if err != nil {
+		return 0
+	}
+
+	return len(content)
+}
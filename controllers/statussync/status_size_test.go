@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestTruncateMessage(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(truncateMessage("NonCompliant; violation", 0)).To(Equal("NonCompliant; violation"))
+	Expect(truncateMessage("NonCompliant; violation", 100)).To(Equal("NonCompliant; violation"))
+
+	truncated := truncateMessage("NonCompliant; "+strings.Repeat("x", 100), 40)
+	Expect(truncated).To(HaveLen(40))
+	Expect(truncated).To(HavePrefix("NonCompliant; "))
+	Expect(truncated).To(HaveSuffix(truncatedSuffix))
+
+	// A multi-byte character isn't split
+	truncated = truncateMessage("NonCompliant; é"+strings.Repeat("x", 100), 15+len(truncatedSuffix))
+	Expect(truncated).To(Equal("NonCompliant; " + truncatedSuffix))
+}
+
+func TestTrimToBudget(t *testing.T) {
+	RegisterTestingT(t)
+
+	history := func(count int, message string) []policiesv1.ComplianceHistory {
+		entries := make([]policiesv1.ComplianceHistory, 0, count)
+		for i := 0; i < count; i++ {
+			entries = append(entries, policiesv1.ComplianceHistory{Message: message})
+		}
+
+		return entries
+	}
+
+	details := []*policiesv1.DetailsPerTemplate{
+		{TemplateMeta: metav1.ObjectMeta{Name: "large"}, History: history(10, strings.Repeat("x", 1000))},
+		{TemplateMeta: metav1.ObjectMeta{Name: "small"}, History: history(3, "Compliant; ok")},
+	}
+
+	Expect(trimToBudget(details, 0)).To(Equal(0))
+
+	removed := trimToBudget(details, 5000)
+	Expect(removed).To(Equal(6))
+	Expect(statusSize(details)).To(BeNumerically("<=", 5000))
+	Expect(details[0].History).To(HaveLen(4))
+	Expect(details[1].History).To(HaveLen(3))
+
+	// The size of the removed entries is exactly subtracted from the size of the details
+	size := statusSize(details)
+	Expect(trimToBudget(details, size)).To(Equal(0))
+	Expect(trimToBudget(details, size-1)).To(Equal(1))
+	Expect(statusSize(details)).To(Equal(size - historyEntrySize(details[0].History[0]) - 1))
+
+	// The newest entry of each policy template is always kept
+	Expect(trimToBudget(details, 10)).To(Equal(4))
+	Expect(details[0].History).To(HaveLen(1))
+	Expect(details[1].History).To(HaveLen(1))
+}
+
+func TestFitToBudget(t *testing.T) {
+	RegisterTestingT(t)
+
+	details := []*policiesv1.DetailsPerTemplate{{TemplateMeta: metav1.ObjectMeta{Name: "config-policy"}}}
+	for i := 0; i < 10; i++ {
+		details[0].History = append(details[0].History, policiesv1.ComplianceHistory{
+			EventName: fmt.Sprintf("policy.%d", i), Message: "NonCompliant; " + strings.Repeat("x", 100),
+		})
+	}
+
+	signer := &HistorySigner{Key: []byte("key")}
+	annotate := func() {
+		Expect(signer.sign(context.TODO(), details)).To(Succeed())
+	}
+
+	// The history alone fits in the budget, but not with the signatures of its entries
+	budget := statusSize(details)
+
+	removed := fitToBudget(details, budget, annotate)
+	Expect(removed).To(BeNumerically(">", 0))
+	Expect(statusSize(details)).To(BeNumerically("<=", budget))
+
+	// The annotations were set again for the remaining entries
+	Expect(details[0].History).To(HaveLen(10 - removed))
+	Expect(VerifyHistorySignatures([]byte("key"), details[0])).To(Succeed())
+}
@@ -0,0 +1,216 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	goerrors "errors"
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// statusVerifierSettleTime is how long to wait before checking a divergent policy again, so that a status write in
+// progress isn't reported as a divergence.
+const statusVerifierSettleTime = 5 * time.Second
+
+var (
+	statusVerificationCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "policy_status_verifications_total",
+			Help: "The number of policies whose status on the Hub was compared with their status on the managed " +
+				"cluster by the status verifier.",
+		},
+	)
+	statusDivergenceCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_status_divergences_total",
+			Help: "The number of policies whose status on the Hub differed from their status on the managed cluster " +
+				"when checked by the status verifier, by whether the status on the Hub was repaired.",
+		},
+		[]string{"repaired"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(statusVerificationCounter, statusDivergenceCounter)
+}
+
+// StatusVerifier periodically compares the status of a sample of the policies on the Hub with their status on the
+// managed cluster, to catch the cases where the two copies silently drift apart after a missed status write. The
+// divergences are logged and counted, and optionally repaired by writing the status of the managed cluster to the
+// Hub since it is the source of the status.
+type StatusVerifier struct {
+	HubClient             client.Client
+	ManagedClient         client.Client
+	ClusterNamespace      string
+	ClusterNamespaceOnHub string
+	Interval              time.Duration
+	// SampleSize is the maximum number of policies checked on each interval.
+	SampleSize int
+	// Repair enables writing the status of the managed cluster to the Hub when they diverge.
+	Repair bool
+	// HubStatusWriter writes the repaired statuses in order with the status writes of the Status Sync controller. If
+	// it is nil, the repaired statuses are written right away.
+	HubStatusWriter *StatusWriter
+	settle          time.Duration
+}
+
+// Start verifies a sample of the policies on every interval until the input context is closed.
+func (v *StatusVerifier) Start(ctx context.Context) error {
+	ticker := time.NewTicker(v.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := v.verify(ctx); err != nil {
+			log.Error(err, "Failed to verify the policy statuses on the hub")
+		}
+	}
+}
+
+// verify compares the status of a random sample of the policies in the cluster namespace with their status on the Hub.
+func (v *StatusVerifier) verify(ctx context.Context) error {
+	policies := &policiesv1.PolicyList{}
+
+	err := v.ManagedClient.List(ctx, policies, client.InNamespace(v.ClusterNamespace))
+	if err != nil {
+		return err
+	}
+
+	items := policies.Items
+
+	rand.Shuffle(len(items), func(i, j int) {
+		items[i], items[j] = items[j], items[i]
+	})
+
+	if v.SampleSize > 0 && len(items) > v.SampleSize {
+		items = items[:v.SampleSize]
+	}
+
+	for i := range items {
+		diverged, err := v.diverged(ctx, items[i].GetName())
+		if err != nil {
+			return err
+		}
+
+		statusVerificationCounter.Inc()
+
+		if !diverged {
+			continue
+		}
+
+		settle := v.settle
+		if settle == 0 {
+			settle = statusVerifierSettleTime
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(settle):
+		}
+
+		// Check again in case a status write was in progress
+		if err := v.verifyPolicy(ctx, items[i].GetName()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diverged returns true if the status of the input policy on the managed cluster differs from its status on the Hub.
+// A policy which is missing on either cluster isn't a divergence since it's handled by the sync controllers.
+func (v *StatusVerifier) diverged(ctx context.Context, name string) (bool, error) {
+	managedPlc, hubPlc, err := v.getPolicies(ctx, name)
+	if err != nil || managedPlc == nil || hubPlc == nil {
+		return false, err
+	}
+
+	return !equality.Semantic.DeepEqual(managedPlc.Status, hubPlc.Status), nil
+}
+
+// verifyPolicy logs and counts a divergence of the status of the input policy, and repairs it if enabled.
+func (v *StatusVerifier) verifyPolicy(ctx context.Context, name string) error {
+	managedPlc, hubPlc, err := v.getPolicies(ctx, name)
+	if err != nil || managedPlc == nil || hubPlc == nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(managedPlc.Status, hubPlc.Status) {
+		return nil
+	}
+
+	log.Info("The policy status on the hub diverged from the managed cluster", "policy", name,
+		"managedCompliance", managedPlc.Status.ComplianceState, "hubCompliance", hubPlc.Status.ComplianceState,
+		"managedTemplates", len(managedPlc.Status.Details), "hubTemplates", len(hubPlc.Status.Details),
+		"repair", v.Repair)
+
+	if !v.Repair {
+		statusDivergenceCounter.WithLabelValues("false").Inc()
+
+		return nil
+	}
+
+	// A held status is written once the interval of the policy elapses
+	err = v.HubStatusWriter.Write(ctx, v.HubClient, hubPlc, managedPlc.Status)
+	if err != nil && !goerrors.Is(err, ErrStatusHeld) {
+		statusDivergenceCounter.WithLabelValues("false").Inc()
+
+		return err
+	}
+
+	statusDivergenceCounter.WithLabelValues("true").Inc()
+
+	return nil
+}
+
+// getPolicies returns the input policy on the managed cluster and on the Hub. A policy which is not found is nil.
+func (v *StatusVerifier) getPolicies(
+	ctx context.Context, name string,
+) (*policiesv1.Policy, *policiesv1.Policy, error) {
+	managedPlc := &policiesv1.Policy{}
+
+	err := v.ManagedClient.Get(ctx, types.NamespacedName{Namespace: v.ClusterNamespace, Name: name}, managedPlc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	hubPlc := &policiesv1.Policy{}
+
+	err = v.HubClient.Get(ctx, types.NamespacedName{Namespace: v.ClusterNamespaceOnHub, Name: name}, hubPlc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	// The policy can't diverge from itself on a self-managed Hub
+	if utils.SameObject(hubPlc, managedPlc) {
+		return nil, nil, nil
+	}
+
+	return managedPlc, hubPlc, nil
+}
@@ -0,0 +1,80 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+func TestStatusVerifier(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	policy := func(namespace, name string, state policiesv1.ComplianceState) *policiesv1.Policy {
+		return &policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status:     policiesv1.PolicyStatus{ComplianceState: state},
+		}
+	}
+
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		policy("cluster1", "policy-in-sync", policiesv1.Compliant),
+		policy("cluster1", "policy-drifted", policiesv1.NonCompliant),
+		policy("cluster1", "policy-not-on-hub", policiesv1.NonCompliant),
+	).Build()
+	hubClient := testutils.NewApplyClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		policy("cluster1-hub", "policy-in-sync", policiesv1.Compliant),
+		policy("cluster1-hub", "policy-drifted", policiesv1.Compliant),
+	).Build())
+
+	verifier := &StatusVerifier{
+		HubClient:             hubClient,
+		ManagedClient:         managedClient,
+		ClusterNamespace:      "cluster1",
+		ClusterNamespaceOnHub: "cluster1-hub",
+		settle:                time.Millisecond,
+	}
+
+	verifications := testutil.ToFloat64(statusVerificationCounter)
+	unrepaired := testutil.ToFloat64(statusDivergenceCounter.WithLabelValues("false"))
+	repaired := testutil.ToFloat64(statusDivergenceCounter.WithLabelValues("true"))
+
+	Expect(verifier.verify(context.TODO())).To(Succeed())
+	Expect(testutil.ToFloat64(statusVerificationCounter)).To(Equal(verifications + 3))
+	Expect(testutil.ToFloat64(statusDivergenceCounter.WithLabelValues("false"))).To(Equal(unrepaired + 1))
+
+	hubPlc := &policiesv1.Policy{}
+	key := types.NamespacedName{Namespace: "cluster1-hub", Name: "policy-drifted"}
+	Expect(hubClient.Get(context.TODO(), key, hubPlc)).To(Succeed())
+	Expect(hubPlc.Status.ComplianceState).To(Equal(policiesv1.Compliant))
+
+	// The sample size limits the number of policies checked
+	verifier.SampleSize = 1
+	verifications = testutil.ToFloat64(statusVerificationCounter)
+
+	Expect(verifier.verify(context.TODO())).To(Succeed())
+	Expect(testutil.ToFloat64(statusVerificationCounter)).To(Equal(verifications + 1))
+
+	// The status of the managed cluster is written to the Hub when repairing
+	verifier.SampleSize = 0
+	verifier.Repair = true
+
+	Expect(verifier.verify(context.TODO())).To(Succeed())
+	Expect(testutil.ToFloat64(statusDivergenceCounter.WithLabelValues("true"))).To(Equal(repaired + 1))
+	Expect(hubClient.Get(context.TODO(), key, hubPlc)).To(Succeed())
+	Expect(hubPlc.Status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+}
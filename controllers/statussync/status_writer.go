@@ -0,0 +1,488 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// defaultOutageRetryInterval is how often the oldest buffered status is retried while the cluster can't be reached
+// when no interval is set.
+const defaultOutageRetryInterval = 10 * time.Second
+
+// ErrStatusBuffered is returned by StatusWriter.Write when the status is buffered since the cluster can't be reached.
+// The status is written once the cluster can be reached again, unless a newer status of the policy replaces it, and the
+// policy is then sent on the Events channel.
+var ErrStatusBuffered = errors.New("the policy status is buffered until the cluster can be reached")
+
+// ErrStatusHeld is returned by StatusWriter.Write when the status is held until the interval since the previous status
+// write of the policy elapses. The policy is sent on the Events channel once its latest status is written.
+var ErrStatusHeld = errors.New("the policy status is held until the status write interval of the policy elapses")
+
+var (
+	statusWriteQueueGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_framework_status_write_queue_depth",
+			Help: "The number of policies waiting for their status to be written to the target cluster (hub or " +
+				"managed).",
+		},
+		[]string{"target"},
+	)
+	statusWriteCoalescedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_framework_status_writes_coalesced_total",
+			Help: "The number of policy statuses to write to the target cluster (hub or managed) which replaced a " +
+				"status of the same policy still waiting to be written.",
+		},
+		[]string{"target"},
+	)
+	statusOutageBufferGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_framework_status_outage_buffer_depth",
+			Help: "The number of policy statuses buffered while the target cluster (hub or managed) can't be " +
+				"reached.",
+		},
+		[]string{"target"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(statusWriteQueueGauge, statusWriteCoalescedCounter, statusOutageBufferGauge)
+}
+
+// statusWrite is a queued status write of a policy, along with the channels of the reconciles waiting for it.
+type statusWrite struct {
+	key     types.NamespacedName
+	policy  *policiesv1.Policy
+	status  policiesv1.PolicyStatus
+	waiters []chan statusWriteResult
+	// requeue is set when a reconcile didn't wait for the status write, so that the policy is reconciled again once it
+	// is done
+	requeue bool
+}
+
+// statusWriteResult is the outcome of a status write. On success, policy is the updated policy.
+type statusWriteResult struct {
+	policy *policiesv1.Policy
+	err    error
+}
+
+// StatusWriter writes the policy statuses to a cluster from a single goroutine, in the order they were queued. Since
+// the concurrent reconciles no longer write the same policy at the same time, they don't cause conflicts with each
+// other, and the status writes to the cluster are rate limited in a single place. A status queued for a policy whose
+// previous status is still waiting to be written replaces it, so that only the latest status is written. It must be
+// started with Start, such as by adding it to the manager.
+type StatusWriter struct {
+	Client client.Client
+	// Target names the cluster in the logs and metrics, such as hub or managed.
+	Target string
+	// WritesPerSecond limits the rate of the status writes. If it is zero, the rate isn't limited.
+	WritesPerSecond float64
+	// Interval is the shortest time between two status writes of the same policy. A status queued within the interval
+	// of the previous write of the policy is held until the interval elapses, and the statuses of the policy queued
+	// meanwhile are coalesced into a single write. The reconciles don't wait for the held statuses. If it is zero, the
+	// statuses are written right away.
+	Interval time.Duration
+	// BufferOnOutage buffers the statuses while the cluster can't be reached instead of failing their writes, and
+	// writes them in order once it can be reached again. Only the latest status of each policy is kept.
+	BufferOnOutage bool
+	// OutageRetryInterval is how often the oldest buffered status is retried while the cluster can't be reached. If it
+	// is zero, it is retried every 10 seconds.
+	OutageRetryInterval time.Duration
+	// RequeueNamespace is the namespace of the policies sent on the Events channel, when the reconciled policies are
+	// in another namespace than the written policies. If it is empty, the namespace of the written policy is used.
+	RequeueNamespace string
+	queue            []*statusWrite
+	pending          map[types.NamespacedName]*statusWrite
+	// lastWrites are the times of the last status writes of the policies within the interval
+	lastWrites map[types.NamespacedName]time.Time
+	lastPrune  time.Time
+	// offline is set while the cluster can't be reached, during which the statuses are buffered
+	offline bool
+	// wake is signaled when a status write is queued
+	wake chan struct{}
+	// events receives the policies whose status write no reconcile waited for once it is done
+	events chan event.GenericEvent
+	lock   sync.Mutex
+}
+
+// init initializes the queue of the writer if needed. The lock must be held.
+func (w *StatusWriter) init() {
+	if w.pending == nil {
+		w.pending = map[types.NamespacedName]*statusWrite{}
+		w.lastWrites = map[types.NamespacedName]time.Time{}
+		w.wake = make(chan struct{}, 1)
+	}
+}
+
+// due returns when the status of the input policy may be written next. The lock must be held.
+func (w *StatusWriter) due(key types.NamespacedName) time.Time {
+	if w.Interval <= 0 {
+		return time.Time{}
+	}
+
+	lastWrite, ok := w.lastWrites[key]
+	if !ok {
+		return time.Time{}
+	}
+
+	return lastWrite.Add(w.Interval)
+}
+
+// recordWrite records that the status of the input policy was written at the input time, and forgets the writes
+// older than the interval, which no longer hold any status.
+func (w *StatusWriter) recordWrite(key types.NamespacedName, now time.Time) {
+	if w.Interval <= 0 {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.init()
+	w.lastWrites[key] = now
+
+	if now.Sub(w.lastPrune) < w.Interval {
+		return
+	}
+
+	w.lastPrune = now
+
+	for written, lastWrite := range w.lastWrites {
+		if now.Sub(lastWrite) >= w.Interval {
+			delete(w.lastWrites, written)
+		}
+	}
+}
+
+// Events returns the channel of the policies whose status write no reconcile waited for, such as a held status, once
+// the write is done, so that the policies are reconciled again to report its outcome.
+func (w *StatusWriter) Events() <-chan event.GenericEvent {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.events == nil {
+		w.events = make(chan event.GenericEvent, 1024)
+	}
+
+	return w.events
+}
+
+// requeue sends the input policy on the Events channel, if it is used, unless the input context is closed.
+func (w *StatusWriter) requeue(ctx context.Context, key types.NamespacedName) {
+	w.lock.Lock()
+	events := w.events
+	w.lock.Unlock()
+
+	if events == nil {
+		return
+	}
+
+	if w.RequeueNamespace != "" {
+		key.Namespace = w.RequeueNamespace
+	}
+
+	select {
+	case <-ctx.Done():
+	case events <- event.GenericEvent{
+		Object: &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}},
+	}:
+	}
+}
+
+// Write queues the input status of the input policy and waits until it is written or the input context is closed. On
+// success, the input policy reflects the updated policy. A status held by the interval isn't waited for, and
+// ErrStatusHeld is returned right away instead. A nil StatusWriter writes the status right away with the input client.
+func (w *StatusWriter) Write(
+	ctx context.Context, c client.Client, policy *policiesv1.Policy, status policiesv1.PolicyStatus,
+) error {
+	if w == nil {
+		return utils.ApplyPolicyStatus(ctx, c, policy, status)
+	}
+
+	done := make(chan statusWriteResult, 1)
+	key := client.ObjectKeyFromObject(policy)
+
+	w.lock.Lock()
+	w.init()
+
+	// While the cluster can't be reached, the status is buffered without waiting for it to be written
+	buffered := w.offline
+	// A held status isn't waited for either, so that the reconcile doesn't tie up a worker for the interval
+	held := !buffered && w.due(key).After(time.Now())
+
+	write, ok := w.pending[key]
+	if ok {
+		write.policy = policy.DeepCopy()
+		write.status = *status.DeepCopy()
+
+		statusWriteCoalescedCounter.WithLabelValues(w.Target).Inc()
+	} else {
+		write = &statusWrite{key: key, policy: policy.DeepCopy(), status: *status.DeepCopy()}
+
+		w.pending[key] = write
+		w.queue = append(w.queue, write)
+
+		statusWriteQueueGauge.WithLabelValues(w.Target).Set(float64(len(w.queue)))
+	}
+
+	switch {
+	case buffered:
+		write.requeue = true
+
+		statusOutageBufferGauge.WithLabelValues(w.Target).Set(float64(len(w.queue)))
+	case held:
+		write.requeue = true
+	default:
+		write.waiters = append(write.waiters, done)
+	}
+
+	w.lock.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+
+	if buffered {
+		return ErrStatusBuffered
+	}
+
+	if held {
+		return ErrStatusHeld
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-done:
+		if result.err != nil {
+			return result.err
+		}
+
+		result.policy.DeepCopyInto(policy)
+
+		return nil
+	}
+}
+
+// next returns the oldest queued status write which is due, waiting for one if needed. It returns nil when the input
+// context is closed.
+func (w *StatusWriter) next(ctx context.Context) *statusWrite {
+	for {
+		w.lock.Lock()
+		w.init()
+
+		now := time.Now()
+
+		// How long until the first held status write is due, or zero if none is held
+		var wait time.Duration
+
+		for i, write := range w.queue {
+			due := w.due(write.key)
+			if due.After(now) {
+				if wait == 0 || due.Sub(now) < wait {
+					wait = due.Sub(now)
+				}
+
+				continue
+			}
+
+			if i == 0 {
+				w.queue = w.queue[1:]
+			} else {
+				w.queue = append(w.queue[:i], w.queue[i+1:]...)
+			}
+
+			delete(w.pending, write.key)
+			statusWriteQueueGauge.WithLabelValues(w.Target).Set(float64(len(w.queue)))
+
+			w.lock.Unlock()
+
+			return write
+		}
+
+		wake := w.wake
+
+		w.lock.Unlock()
+
+		var (
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-wake:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// buffer puts back the input status write, which failed since the cluster can't be reached, at the front of the queue
+// unless a newer status of the policy was queued meanwhile, and answers the reconciles waiting for the queued status
+// writes that their status is buffered. Since these reconciles no longer wait for the outcome of the writes, the
+// policies are sent on the Events channel once their status write is done. The next status writes are buffered until
+// one succeeds.
+func (w *StatusWriter) buffer(write *statusWrite, cause error) {
+	w.lock.Lock()
+
+	if !w.offline {
+		log.Info("The cluster can't be reached, buffering the policy statuses until it can", "target", w.Target,
+			"error", cause.Error())
+
+		w.offline = true
+	}
+
+	waiters := write.waiters
+	write.waiters = nil
+	write.requeue = write.requeue || len(waiters) != 0
+
+	if newer, ok := w.pending[write.key]; ok {
+		newer.requeue = newer.requeue || write.requeue
+	} else {
+		w.pending[write.key] = write
+		w.queue = append([]*statusWrite{write}, w.queue...)
+	}
+
+	for _, queued := range w.queue {
+		if len(queued.waiters) != 0 {
+			queued.requeue = true
+		}
+
+		waiters = append(waiters, queued.waiters...)
+		queued.waiters = nil
+	}
+
+	statusWriteQueueGauge.WithLabelValues(w.Target).Set(float64(len(w.queue)))
+	statusOutageBufferGauge.WithLabelValues(w.Target).Set(float64(len(w.queue)))
+
+	w.lock.Unlock()
+
+	for _, waiter := range waiters {
+		waiter <- statusWriteResult{err: fmt.Errorf("%w: %v", ErrStatusBuffered, cause)}
+	}
+}
+
+// reconnected records that a status was written, which ends the buffering of the statuses if the cluster couldn't be
+// reached. The buffered statuses are then written in order.
+func (w *StatusWriter) reconnected() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.offline {
+		return
+	}
+
+	log.Info("The cluster can be reached again, writing the buffered policy statuses", "target", w.Target,
+		"buffered", len(w.queue))
+
+	w.offline = false
+
+	statusOutageBufferGauge.WithLabelValues(w.Target).Set(0)
+}
+
+// Start writes the queued statuses until the input context is closed.
+func (w *StatusWriter) Start(ctx context.Context) error {
+	var limiter *rate.Limiter
+
+	if w.WritesPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(w.WritesPerSecond), 1)
+	}
+
+	for {
+		write := w.next(ctx)
+		if write == nil {
+			return nil
+		}
+
+		var err error
+
+		if limiter != nil {
+			err = limiter.Wait(ctx)
+		}
+
+		if err == nil {
+			err = utils.ApplyPolicyStatus(ctx, w.Client, write.policy, write.status)
+		}
+
+		if err == nil {
+			w.recordWrite(write.key, time.Now())
+			w.reconnected()
+		}
+
+		if err != nil && w.BufferOnOutage && errors.Is(syncerrors.FromHub(err), syncerrors.ErrHubUnavailable) {
+			w.buffer(write, err)
+
+			retryInterval := w.OutageRetryInterval
+			if retryInterval <= 0 {
+				retryInterval = defaultOutageRetryInterval
+			}
+
+			timer := time.NewTimer(retryInterval)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return nil
+			case <-timer.C:
+			}
+
+			continue
+		}
+
+		if err != nil {
+			log.V(1).Info("Failed to write the policy status", "target", w.Target,
+				"namespace", write.key.Namespace, "name", write.key.Name, "error", err.Error())
+		}
+
+		for i, waiter := range write.waiters {
+			result := statusWriteResult{err: err}
+
+			// Each waiting reconcile gets its own copy of the updated policy
+			if err == nil {
+				result.policy = write.policy
+				if i != len(write.waiters)-1 {
+					result.policy = write.policy.DeepCopy()
+				}
+			}
+
+			waiter <- result
+		}
+
+		if write.requeue {
+			w.requeue(ctx, write.key)
+		}
+	}
+}
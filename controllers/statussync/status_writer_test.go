@@ -0,0 +1,249 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+func TestStatusWriter(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"}}
+	c := testutils.NewApplyClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).Build())
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+
+	// A nil writer writes the status right away
+	var nilWriter *StatusWriter
+
+	status := policiesv1.PolicyStatus{ComplianceState: policiesv1.NonCompliant}
+	Expect(nilWriter.Write(context.TODO(), c, policy, status)).To(Succeed())
+	Expect(policy.Status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+
+	writtenVersion := policy.ResourceVersion
+	writer := &StatusWriter{Client: c, Target: "managed"}
+
+	// The statuses queued for a policy before the writer starts are coalesced into the latest one
+	results := make(chan error, 2)
+	policies := []*policiesv1.Policy{policy.DeepCopy(), policy.DeepCopy()}
+
+	for i, state := range []policiesv1.ComplianceState{policiesv1.Compliant, policiesv1.NonCompliant} {
+		go func(i int, state policiesv1.ComplianceState) {
+			status := policiesv1.PolicyStatus{ComplianceState: state}
+			results <- writer.Write(context.TODO(), nil, policies[i], status)
+		}(i, state)
+
+		Eventually(func() int {
+			writer.lock.Lock()
+			defer writer.lock.Unlock()
+
+			if write, ok := writer.pending[client.ObjectKeyFromObject(policy)]; ok {
+				return len(write.waiters)
+			}
+
+			return 0
+		}).Should(Equal(i + 1))
+	}
+
+	writer.lock.Lock()
+	Expect(writer.queue).To(HaveLen(1))
+	writer.lock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	go func() { _ = writer.Start(ctx) }()
+
+	Expect(<-results).To(Succeed())
+	Expect(<-results).To(Succeed())
+
+	for _, written := range policies {
+		Expect(written.Status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+		Expect(written.ResourceVersion).ToNot(Equal(writtenVersion))
+	}
+
+	// Both reconciles get their own copy of the updated policy
+	Expect(policies[0]).ToNot(BeIdenticalTo(policies[1]))
+	Expect(policies[0].ResourceVersion).To(Equal(policies[1].ResourceVersion))
+
+	// A reconcile stops waiting when its context is closed
+	cancel()
+
+	waitCtx, waitCancel := context.WithCancel(context.TODO())
+	waitCancel()
+
+	err := (&StatusWriter{Client: c}).Write(waitCtx, nil, policy, status)
+	Expect(err).To(MatchError(context.Canceled))
+}
+
+func TestStatusWriterInterval(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"}}
+	other := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "managed"}}
+	c := testutils.NewApplyClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy, other).Build())
+
+	interval := 500 * time.Millisecond
+	writer := &StatusWriter{Client: c, Target: "hub", Interval: interval, RequeueNamespace: "cluster"}
+	events := writer.Events()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	go func() { _ = writer.Start(ctx) }()
+
+	// The first status of a policy is written right away
+	status := policiesv1.PolicyStatus{ComplianceState: policiesv1.Compliant}
+	firstWrite := time.Now()
+
+	Expect(writer.Write(ctx, nil, policy.DeepCopy(), status)).To(Succeed())
+
+	// The next statuses of the policy are held until the interval elapses without waiting for them, and without
+	// holding the other policies
+	for _, state := range []policiesv1.ComplianceState{policiesv1.Compliant, policiesv1.NonCompliant} {
+		status := policiesv1.PolicyStatus{ComplianceState: state}
+		Expect(writer.Write(ctx, nil, policy.DeepCopy(), status)).To(MatchError(ErrStatusHeld))
+	}
+
+	Expect(writer.Write(ctx, nil, other.DeepCopy(), status)).To(Succeed())
+	Expect(time.Since(firstWrite)).To(BeNumerically("<", interval))
+	Expect(events).To(BeEmpty())
+
+	// The policy is sent for a reconcile once its latest status is written
+	requeued := <-events
+	Expect(time.Since(firstWrite)).To(BeNumerically(">=", interval))
+	Expect(requeued.Object.GetNamespace()).To(Equal("cluster"))
+	Expect(requeued.Object.GetName()).To(Equal("policy"))
+
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+	Expect(policy.Status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+	Expect(events).To(BeEmpty())
+}
+
+// unreachableClient fails the status writes with a connection error while it is down, and records the names of the
+// policies whose status was written.
+type unreachableClient struct {
+	testutils.ApplyClient
+	down    bool
+	written []string
+	lock    sync.Mutex
+}
+
+func (c *unreachableClient) Status() client.StatusWriter {
+	return unreachableStatusWriter{StatusWriter: c.ApplyClient.Status(), client: c}
+}
+
+func (c *unreachableClient) setDown(down bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.down = down
+}
+
+func (c *unreachableClient) writtenPolicies() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string{}, c.written...)
+}
+
+type unreachableStatusWriter struct {
+	client.StatusWriter
+	client *unreachableClient
+}
+
+func (w unreachableStatusWriter) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	w.client.lock.Lock()
+	defer w.client.lock.Unlock()
+
+	if w.client.down {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+
+	w.client.written = append(w.client.written, obj.GetName())
+
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func TestStatusWriterOutageBuffer(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"}}
+	other := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "managed"}}
+	c := &unreachableClient{
+		ApplyClient: testutils.NewApplyClient(
+			fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy, other).Build(),
+		),
+		down: true,
+	}
+
+	writer := &StatusWriter{
+		Client: c, Target: "hub", BufferOnOutage: true, OutageRetryInterval: 100 * time.Millisecond,
+	}
+	events := writer.Events()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	go func() { _ = writer.Start(ctx) }()
+
+	// The first failed write reports that the status is buffered, and so do the next ones right away
+	status := policiesv1.PolicyStatus{ComplianceState: policiesv1.NonCompliant}
+	Expect(writer.Write(ctx, nil, policy.DeepCopy(), status)).To(MatchError(ErrStatusBuffered))
+	Expect(writer.Write(ctx, nil, other.DeepCopy(), status)).To(MatchError(ErrStatusBuffered))
+
+	// Only the latest buffered status of a policy is written
+	status = policiesv1.PolicyStatus{ComplianceState: policiesv1.Compliant}
+	Expect(writer.Write(ctx, nil, policy.DeepCopy(), status)).To(MatchError(ErrStatusBuffered))
+
+	Consistently(c.writtenPolicies, 300*time.Millisecond).Should(BeEmpty())
+
+	// The buffered statuses are written in order once the cluster can be reached
+	c.setDown(false)
+
+	Eventually(c.writtenPolicies).Should(Equal([]string{"policy", "other"}))
+
+	// The policies are reconciled again to report the outcome of their buffered status write
+	for _, name := range []string{"policy", "other"} {
+		requeued := <-events
+		Expect(requeued.Object.GetNamespace()).To(Equal("managed"))
+		Expect(requeued.Object.GetName()).To(Equal(name))
+	}
+
+	Expect(events).To(BeEmpty())
+
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+	Expect(policy.Status.ComplianceState).To(Equal(policiesv1.Compliant))
+
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(other), other)).To(Succeed())
+	Expect(other.Status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+
+	// The statuses are no longer buffered
+	Expect(writer.Write(ctx, nil, policy.DeepCopy(), status)).To(Succeed())
+	Expect(events).To(BeEmpty())
+}
@@ -0,0 +1,43 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// applyTemplateChecksums sets the utils.TemplateChecksumAnnotation annotation on the template metadata of the input
+// policy template details to the checksum of the policy template recorded in the template inventory of the input
+// policy, so that the checksums are also available on the Hub. The annotation is removed while the object doesn't
+// match its policy template.
+func applyTemplateChecksums(instance *policiesv1.Policy, details []*policiesv1.DetailsPerTemplate) {
+	checksums := map[string]string{}
+
+	inventory, err := utils.PolicyInventory(instance)
+	if err != nil {
+		log.Error(err, "Failed to parse the template inventory annotation", "policy", instance.GetName())
+	}
+
+	for _, entry := range inventory {
+		if entry.Checksum != "" {
+			checksums[entry.Name] = entry.Checksum
+		}
+	}
+
+	for _, dpt := range details {
+		checksum, ok := checksums[dpt.TemplateMeta.Name]
+		if !ok {
+			removeTemplateAnnotation(dpt, utils.TemplateChecksumAnnotation)
+
+			continue
+		}
+
+		if dpt.TemplateMeta.Annotations == nil {
+			dpt.TemplateMeta.Annotations = map[string]string{}
+		}
+
+		dpt.TemplateMeta.Annotations[utils.TemplateChecksumAnnotation] = checksum
+	}
+}
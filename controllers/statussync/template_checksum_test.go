@@ -0,0 +1,39 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestApplyTemplateChecksums(t *testing.T) {
+	RegisterTestingT(t)
+
+	value, err := utils.InventoryAnnotationValue([]utils.InventoryEntry{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "synced", Checksum: "092089be71085e149802566466fdc609"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "pending", Pending: "The update is held"},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	instance := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.TemplateInventoryAnnotation: value}},
+	}
+	synced := &policiesv1.DetailsPerTemplate{TemplateMeta: metav1.ObjectMeta{Name: "synced"}}
+	pending := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{
+			Name: "pending", Annotations: map[string]string{utils.TemplateChecksumAnnotation: "previous"},
+		},
+	}
+
+	applyTemplateChecksums(instance, []*policiesv1.DetailsPerTemplate{synced, pending})
+	Expect(synced.TemplateMeta.Annotations).To(
+		HaveKeyWithValue(utils.TemplateChecksumAnnotation, "092089be71085e149802566466fdc609"),
+	)
+	Expect(pending.TemplateMeta.Annotations).To(BeNil())
+}
@@ -0,0 +1,199 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TemplateConflictAnnotation is set on the template metadata in the policy status to the comma-separated names of the
+// other policies with a policy template of the same kind and name, since they would manage the same object. The
+// Template Sync controller refuses to apply the policy template of a policy whose object is owned by another policy.
+const TemplateConflictAnnotation = "policy.open-cluster-management.io/template-conflict"
+
+// templateObjectIndex is the field index of the cached policies by the objects managed by their policy templates, so
+// that the policies with conflicting policy templates are found without listing all the policies in the namespace.
+const templateObjectIndex = "policyTemplateObjects"
+
+// templateKey identifies the object managed by a policy template. The version is ignored since it doesn't change the
+// object.
+type templateKey struct {
+	groupKind schema.GroupKind
+	name      string
+}
+
+// String returns the value of the templateObjectIndex field index for the object.
+func (k templateKey) String() string {
+	return k.groupKind.String() + "/" + k.name
+}
+
+// indexTemplateObjects returns the values of the templateObjectIndex field index for the input policy.
+func indexTemplateObjects(obj client.Object) []string {
+	plc, ok := obj.(*policiesv1.Policy)
+	if !ok {
+		return nil
+	}
+
+	keys := policyTemplateKeys(plc)
+	values := make([]string, 0, len(keys))
+
+	for key := range keys {
+		values = append(values, key.String())
+	}
+
+	return values
+}
+
+// policyTemplateKeys returns the names of the policy templates of the input policy by the object they manage.
+func policyTemplateKeys(plc *policiesv1.Policy) map[templateKey]string {
+	keys := make(map[templateKey]string, len(plc.Spec.PolicyTemplates))
+
+	for _, policyT := range plc.Spec.PolicyTemplates {
+		if policyT == nil {
+			continue
+		}
+
+		tObject := &unstructured.Unstructured{}
+		if err := tObject.UnmarshalJSON(policyT.ObjectDefinition.Raw); err != nil {
+			continue
+		}
+
+		key := templateKey{groupKind: tObject.GroupVersionKind().GroupKind(), name: tObject.GetName()}
+		keys[key] = tObject.GetName()
+	}
+
+	return keys
+}
+
+// templateConflicts returns the sorted names of the other input policies with a policy template managing the same
+// object as a policy template of the input policy, by policy template name.
+func templateConflicts(plc *policiesv1.Policy, policies []policiesv1.Policy) map[string][]string {
+	conflicts := map[string][]string{}
+	keys := policyTemplateKeys(plc)
+
+	for i := range policies {
+		if policies[i].GetName() == plc.GetName() {
+			continue
+		}
+
+		for key := range policyTemplateKeys(&policies[i]) {
+			if tName, ok := keys[key]; ok {
+				conflicts[tName] = append(conflicts[tName], policies[i].GetName())
+			}
+		}
+	}
+
+	for tName := range conflicts {
+		sort.Strings(conflicts[tName])
+	}
+
+	return conflicts
+}
+
+// applyTemplateConflicts sets the TemplateConflictAnnotation annotation on the template metadata of the input policy
+// template details with the input conflicts, and removes it from the others. The names of the policy templates whose
+// conflicts changed are returned.
+func applyTemplateConflicts(details []*policiesv1.DetailsPerTemplate, conflicts map[string][]string) []string {
+	changed := []string{}
+
+	for _, dpt := range details {
+		policies, ok := conflicts[dpt.TemplateMeta.Name]
+		if !ok {
+			removeTemplateAnnotation(dpt, TemplateConflictAnnotation)
+
+			continue
+		}
+
+		value := strings.Join(policies, ",")
+		if dpt.TemplateMeta.Annotations[TemplateConflictAnnotation] == value {
+			continue
+		}
+
+		if dpt.TemplateMeta.Annotations == nil {
+			dpt.TemplateMeta.Annotations = map[string]string{}
+		}
+
+		dpt.TemplateMeta.Annotations[TemplateConflictAnnotation] = value
+
+		changed = append(changed, dpt.TemplateMeta.Name)
+	}
+
+	return changed
+}
+
+// conflictingPolicies returns the other policies in the namespace of the input policy with a policy template managing
+// the same object as one of its policy templates. Only the policies matching a policy template of the input policy in
+// the templateObjectIndex field index are listed, rather than all the policies in the namespace.
+func (r *PolicyReconciler) conflictingPolicies(
+	ctx context.Context, plc *policiesv1.Policy,
+) ([]policiesv1.Policy, error) {
+	keys := policyTemplateKeys(plc)
+	found := map[string]bool{}
+	conflicting := []policiesv1.Policy{}
+
+	for key := range keys {
+		policies := &policiesv1.PolicyList{}
+
+		err := r.ManagedClient.List(
+			ctx,
+			policies,
+			client.InNamespace(plc.GetNamespace()),
+			client.MatchingFields{templateObjectIndex: key.String()},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range policies.Items {
+			name := policies.Items[i].GetName()
+			if name == plc.GetName() || found[name] {
+				continue
+			}
+
+			// The index is checked again in case the client doesn't support field indexes
+			if _, ok := policyTemplateKeys(&policies.Items[i])[key]; !ok {
+				continue
+			}
+
+			found[name] = true
+
+			conflicting = append(conflicting, policies.Items[i])
+		}
+	}
+
+	return conflicting, nil
+}
+
+// conflictMapper returns the reconcile requests of the other policies in the namespace of the input policy with a
+// policy template managing the same object as one of its policy templates, so that their conflicts are updated when
+// the input policy changes or is deleted.
+func (r *PolicyReconciler) conflictMapper(obj client.Object) []reconcile.Request {
+	//nolint:forcetypeassert
+	plc := obj.(*policiesv1.Policy)
+
+	policies, err := r.conflictingPolicies(context.TODO(), plc)
+	if err != nil {
+		log.Error(err, "Failed to list the policies to find the policy template conflicts", "policy", plc.GetName())
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(policies))
+
+	for i := range policies {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: plc.GetNamespace(), Name: policies[i].GetName(),
+		}})
+	}
+
+	return requests
+}
@@ -0,0 +1,74 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// conflictTestPolicy returns a policy with a policy template of each of the input kind/name pairs.
+func conflictTestPolicy(name string, templates ...string) *policiesv1.Policy {
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed"}}
+
+	for i := 0; i < len(templates); i += 2 {
+		plc.Spec.PolicyTemplates = append(plc.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{
+			ObjectDefinition: runtime.RawExtension{Raw: []byte(fmt.Sprintf(
+				`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"%s","metadata":{"name":"%s"}}`,
+				templates[i], templates[i+1],
+			))},
+		})
+	}
+
+	return plc
+}
+
+func TestTemplateConflicts(t *testing.T) {
+	RegisterTestingT(t)
+
+	first := conflictTestPolicy("first", "ConfigurationPolicy", "shared", "ConfigurationPolicy", "own")
+	second := conflictTestPolicy("second", "ConfigurationPolicy", "shared")
+	// The same name with another kind is another object
+	other := conflictTestPolicy("other", "CertificatePolicy", "shared")
+	policies := []policiesv1.Policy{*first, *second, *other}
+
+	conflicts := templateConflicts(first, policies)
+	Expect(conflicts).To(Equal(map[string][]string{"shared": {"second"}}))
+	Expect(templateConflicts(other, policies)).To(BeEmpty())
+	Expect(indexTemplateObjects(second)).To(Equal([]string{
+		"ConfigurationPolicy.policy.open-cluster-management.io/shared",
+	}))
+
+	shared := &policiesv1.DetailsPerTemplate{TemplateMeta: metav1.ObjectMeta{Name: "shared"}}
+	own := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{
+			Name: "own", Annotations: map[string]string{TemplateConflictAnnotation: "removed"},
+		},
+	}
+	details := []*policiesv1.DetailsPerTemplate{shared, own}
+
+	Expect(applyTemplateConflicts(details, conflicts)).To(Equal([]string{"shared"}))
+	Expect(shared.TemplateMeta.Annotations).To(HaveKeyWithValue(TemplateConflictAnnotation, "second"))
+	Expect(own.TemplateMeta.Annotations).To(BeNil())
+
+	// An unchanged conflict isn't reported again
+	Expect(applyTemplateConflicts(details, conflicts)).To(BeEmpty())
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	r := &PolicyReconciler{
+		ManagedClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(first, second, other).Build(),
+	}
+	Expect(r.conflictMapper(second)).To(Equal([]reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "managed", Name: "first"}},
+	}))
+}
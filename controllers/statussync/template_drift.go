@@ -0,0 +1,45 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// TemplateDriftAnnotation is set on the template metadata in the policy status while the object of a create-only
+// policy template differs from it, to the drift reason recorded in the template inventory by the template sync.
+const TemplateDriftAnnotation = "policy.open-cluster-management.io/template-drift"
+
+// applyTemplateDrift reports the drift of the create-only policy templates recorded in the template inventory of the
+// input policy on the template metadata of the input policy template details.
+func applyTemplateDrift(instance *policiesv1.Policy, details []*policiesv1.DetailsPerTemplate) {
+	drifted := map[string]string{}
+
+	inventory, err := utils.PolicyInventory(instance)
+	if err != nil {
+		log.Error(err, "Failed to parse the template inventory annotation", "policy", instance.GetName())
+	}
+
+	for _, entry := range inventory {
+		if entry.Drifted != "" {
+			drifted[entry.Name] = entry.Drifted
+		}
+	}
+
+	for _, dpt := range details {
+		reason, ok := drifted[dpt.TemplateMeta.Name]
+		if !ok {
+			removeTemplateAnnotation(dpt, TemplateDriftAnnotation)
+
+			continue
+		}
+
+		if dpt.TemplateMeta.Annotations == nil {
+			dpt.TemplateMeta.Annotations = map[string]string{}
+		}
+
+		dpt.TemplateMeta.Annotations[TemplateDriftAnnotation] = reason
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestApplyTemplateDrift(t *testing.T) {
+	RegisterTestingT(t)
+
+	value, err := utils.InventoryAnnotationValue([]utils.InventoryEntry{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "bootstrap", Drifted: "The object differs"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "managed"},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	instance := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.TemplateInventoryAnnotation: value}},
+	}
+	bootstrap := &policiesv1.DetailsPerTemplate{TemplateMeta: metav1.ObjectMeta{Name: "bootstrap"}}
+	managed := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{
+			Name: "managed", Annotations: map[string]string{TemplateDriftAnnotation: "The object differs"},
+		},
+	}
+
+	applyTemplateDrift(instance, []*policiesv1.DetailsPerTemplate{bootstrap, managed})
+	Expect(bootstrap.TemplateMeta.Annotations).To(HaveKeyWithValue(TemplateDriftAnnotation, "The object differs"))
+	Expect(managed.TemplateMeta.Annotations).To(BeNil())
+}
@@ -0,0 +1,203 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package syncerrors defines the errors shared by the controllers of the addon. Each error is classified with a reason
+// code, used in the template error events and the error metrics, and whether it is permanent, in which case the
+// reconcile is not retried until the policy changes.
+package syncerrors
+
+import (
+	"errors"
+	"net"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	// ErrTemplateDecode is returned when a policy template is not a valid object definition.
+	ErrTemplateDecode = errors.New("the policy template could not be decoded")
+	// ErrTemplateMissingName is returned when the object definition of a policy template does not have a name.
+	ErrTemplateMissingName = errors.New("the policy template does not have a name")
+	// ErrOCIArtifact is returned when the OCI artifact of a policy template can't be resolved.
+	ErrOCIArtifact = errors.New("the OCI artifact of the policy template could not be resolved")
+	// ErrConfigMapRef is returned when the Hub ConfigMap referenced by a policy template can't be resolved.
+	ErrConfigMapRef = errors.New("the ConfigMap reference of the policy template could not be resolved")
+	// ErrMappingNotFound is returned when the kind of a policy template is not served on the managed cluster.
+	ErrMappingNotFound = errors.New("the kind of the policy template is not served on the managed cluster")
+	// ErrHubTemplatesUnsupported is returned when a policy template of a kind other than ConfigurationPolicy uses Hub
+	// templates.
+	ErrHubTemplatesUnsupported = errors.New("the kind of the policy template does not support Hub templates")
+	// ErrTemplateUnmarshal is returned when a policy template can't be unmarshaled to an object.
+	ErrTemplateUnmarshal = errors.New("the policy template could not be unmarshaled")
+	// ErrVerificationFailed is returned when a policy with enforce mode policy templates fails its verification.
+	ErrVerificationFailed = errors.New("the policy could not be verified")
+	// ErrClusterIdentity is returned when the cluster identity variables can't be substituted in a policy template.
+	ErrClusterIdentity = errors.New("the cluster identity could not be substituted in the policy template")
+	// ErrExecHookRejected is returned when the exec hook of the kind of a policy template rejects it.
+	ErrExecHookRejected = errors.New("the policy template was rejected by its exec hook")
+	// ErrExecHook is returned when the exec hook of the kind of a policy template can't be run or returns an invalid
+	// object.
+	ErrExecHook = errors.New("the exec hook of the policy template failed")
+	// ErrEngineTranslate is returned when the policy engine adapter of the kind of a policy template can't translate
+	// it.
+	ErrEngineTranslate = errors.New("the policy template could not be translated for its policy engine")
+	// ErrTemplateGet is returned when the object of a policy template can't be retrieved.
+	ErrTemplateGet = errors.New("the policy template object could not be retrieved")
+	// ErrTemplateCreate is returned when the object of a policy template can't be created.
+	ErrTemplateCreate = errors.New("the policy template object could not be created")
+	// ErrTemplateUpdate is returned when the object of a policy template can't be updated.
+	ErrTemplateUpdate = errors.New("the policy template object could not be updated")
+	// ErrTemplateUnknownFields is returned when the object of a policy template is rejected in strict mode since it
+	// has fields unknown to the schema of its kind.
+	ErrTemplateUnknownFields = errors.New("the policy template has fields unknown to the schema of its kind")
+	// ErrTemplateNameConflict is returned when the object of a policy template belongs to another policy.
+	ErrTemplateNameConflict = errors.New("the policy template object belongs to another policy")
+	// ErrApplyTimeout is returned when a create, update, or delete request of a policy template object doesn't
+	// complete within its apply timeout, such as when an admission webhook on the managed cluster hangs.
+	ErrApplyTimeout = errors.New("the request to apply the policy template object timed out")
+	// ErrRootPolicyCollision is returned when a replicated policy on the Hub and the policy with the same name on the
+	// managed cluster belong to different root policies.
+	ErrRootPolicyCollision = errors.New(
+		"the replicated policy name collides with a policy from another root policy",
+	)
+	// ErrHubUnavailable is returned when a request to the Hub fails because the Hub can't be reached.
+	ErrHubUnavailable = errors.New("the Hub is unavailable")
+	// ErrDeleteConflict is returned when an object is not deleted since it was replaced or modified by another actor
+	// after it was read.
+	ErrDeleteConflict = errors.New("the object changed since it was read so it was not deleted")
+)
+
+// ReasonUnknown is the reason code of the errors which are not classified.
+const ReasonUnknown = "Unknown"
+
+// classifications are checked in order, so an error wrapping several classified errors, such as an ErrTemplateCreate
+// caused by an ErrApplyTimeout, gets the reason of the first one.
+var classifications = []struct {
+	err       error
+	reason    string
+	permanent bool
+}{
+	{ErrApplyTimeout, "ApplyTimeout", false},
+	{ErrHubUnavailable, "HubUnavailable", false},
+	// The deletion is reevaluated against the current object when retried
+	{ErrDeleteConflict, "DeleteConflict", false},
+	{ErrTemplateDecode, "DecodeError", true},
+	{ErrTemplateMissingName, "MissingName", true},
+	{ErrOCIArtifact, "OCIArtifactError", false},
+	{ErrConfigMapRef, "ConfigMapRefError", false},
+	{ErrMappingNotFound, "MappingNotFound", false},
+	{ErrHubTemplatesUnsupported, "HubTemplatesUnsupported", true},
+	{ErrTemplateUnmarshal, "UnmarshalError", true},
+	{ErrVerificationFailed, "VerificationFailed", true},
+	// The cluster claims may be added later, so this is retried
+	{ErrClusterIdentity, "ClusterIdentityError", false},
+	{ErrExecHookRejected, "ExecHookRejected", true},
+	{ErrExecHook, "ExecHookError", false},
+	{ErrEngineTranslate, "EngineTranslateError", true},
+	{ErrTemplateGet, "GetError", false},
+	{ErrTemplateCreate, "CreateError", false},
+	{ErrTemplateUpdate, "UpdateError", false},
+	{ErrTemplateUnknownFields, "UnknownFields", true},
+	// The other policy may be deleted, so the conflict is retried
+	{ErrTemplateNameConflict, "NameConflict", false},
+	{ErrRootPolicyCollision, "RootPolicyCollision", true},
+}
+
+// Error is a classified error with a message for the users and an optional underlying cause.
+type Error struct {
+	kind    error
+	message string
+	cause   error
+}
+
+// New returns an error of the input classified kind with the input message.
+func New(kind error, message string) error {
+	return &Error{kind: kind, message: message}
+}
+
+// WithCause returns an error of the input classified kind with the input message, caused by the input error.
+func WithCause(kind error, message string, cause error) error {
+	return &Error{kind: kind, message: message, cause: cause}
+}
+
+// Wrap returns the input error classified as the input kind, or nil if the input error is nil.
+func Wrap(kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{kind: kind, message: err.Error(), cause: err}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+// Unwrap returns the underlying cause so that it can still be inspected, such as with the Kubernetes API error
+// helpers.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is returns true if the input error is the classified kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.kind
+}
+
+// Reason returns the reason code of the input error, or an empty string if the error is nil.
+func Reason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	for _, classification := range classifications {
+		if errors.Is(err, classification.err) {
+			return classification.reason
+		}
+	}
+
+	return ReasonUnknown
+}
+
+// Permanent returns true if retrying the reconcile can't resolve the input error, since it is caused by the content of
+// the policy. These reconciles are retried when the policy changes instead.
+func Permanent(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, classification := range classifications {
+		if errors.Is(err, classification.err) {
+			return classification.permanent
+		}
+	}
+
+	return false
+}
+
+// Prefer returns the error to report for a reconcile which encountered the current error and then the input error.
+// The input error is preferred unless it is permanent and the current error isn't, so that the reconcile is still
+// retried.
+func Prefer(current error, err error) error {
+	if current != nil && !Permanent(current) && Permanent(err) {
+		return current
+	}
+
+	return err
+}
+
+// FromHub classifies the input error of a request to the Hub as ErrHubUnavailable if the Hub couldn't be reached.
+// Other errors are returned as is.
+func FromHub(err error) error {
+	if err == nil || errors.Is(err, ErrHubUnavailable) {
+		return err
+	}
+
+	var netErr net.Error
+
+	if errors.As(err, &netErr) || k8serrors.IsServiceUnavailable(err) || k8serrors.IsServerTimeout(err) ||
+		k8serrors.IsTimeout(err) {
+		return Wrap(ErrHubUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,83 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package syncerrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestClassification(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(Reason(nil)).To(BeEmpty())
+	Expect(Permanent(nil)).To(BeFalse())
+	Expect(Reason(errors.New("unclassified"))).To(Equal(ReasonUnknown))
+
+	decodeErr := New(ErrTemplateDecode, "Failed to decode policy template with err: bad")
+	Expect(decodeErr.Error()).To(Equal("Failed to decode policy template with err: bad"))
+	Expect(Reason(decodeErr)).To(Equal("DecodeError"))
+	Expect(Permanent(decodeErr)).To(BeTrue())
+
+	// The cause can still be inspected and the most specific reason is used
+	notFound := k8serrors.NewNotFound(schema.GroupResource{Resource: "configurationpolicies"}, "template")
+	getErr := WithCause(ErrTemplateGet, "Failed to get the object in the policy template", notFound)
+	Expect(k8serrors.IsNotFound(getErr)).To(BeTrue())
+	Expect(Reason(getErr)).To(Equal("GetError"))
+	Expect(Permanent(getErr)).To(BeFalse())
+
+	timeoutErr := Wrap(ErrTemplateCreate, fmt.Errorf("%w after 1m", ErrApplyTimeout))
+	Expect(Reason(timeoutErr)).To(Equal("ApplyTimeout"))
+	Expect(Wrap(ErrTemplateCreate, nil)).To(BeNil())
+
+	// A transient error is kept over a later permanent error so that the reconcile is retried
+	mappingErr := New(ErrMappingNotFound, "Mapping not found")
+	Expect(Prefer(nil, decodeErr)).To(Equal(decodeErr))
+	Expect(Prefer(mappingErr, decodeErr)).To(Equal(mappingErr))
+	Expect(Prefer(decodeErr, mappingErr)).To(Equal(mappingErr))
+}
+
+func TestFromHub(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(FromHub(nil)).To(BeNil())
+
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	Expect(Reason(FromHub(connErr))).To(Equal("HubUnavailable"))
+	Expect(Reason(FromHub(k8serrors.NewServiceUnavailable("down")))).To(Equal("HubUnavailable"))
+
+	forbidden := k8serrors.NewForbidden(schema.GroupResource{Resource: "policies"}, "policy", errors.New("denied"))
+	Expect(FromHub(forbidden)).To(Equal(forbidden))
+}
+
+type erroringReconciler struct {
+	err error
+}
+
+func (r *erroringReconciler) Reconcile(context.Context, reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, r.err
+}
+
+func TestReconciler(t *testing.T) {
+	RegisterTestingT(t)
+
+	transientErr := New(ErrMappingNotFound, "Mapping not found")
+
+	_, err := Reconciler("test", &erroringReconciler{err: transientErr}).Reconcile(
+		context.TODO(), reconcile.Request{},
+	)
+	Expect(err).To(Equal(transientErr))
+
+	_, err = Reconciler("test", &erroringReconciler{err: New(ErrTemplateDecode, "bad")}).Reconcile(
+		context.TODO(), reconcile.Request{},
+	)
+	Expect(err).To(BeNil())
+}
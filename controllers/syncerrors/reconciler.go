@@ -0,0 +1,54 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package syncerrors
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var syncErrorCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "policy_framework_sync_errors_total",
+		Help: "The number of reconciles of the addon controllers which failed, by controller and error reason.",
+	},
+	[]string{"controller", "reason"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(syncErrorCounter)
+}
+
+// classifiedReconciler records the errors of the wrapped reconciler by reason and doesn't retry the reconciles which
+// failed with a permanent error.
+type classifiedReconciler struct {
+	controller string
+	reconciler reconcile.Reconciler
+}
+
+// Reconciler wraps the input reconciler of the input controller so that its errors are counted by reason in the
+// policy_framework_sync_errors_total metric, and the reconciles which failed with a permanent error are not retried.
+func Reconciler(controller string, reconciler reconcile.Reconciler) reconcile.Reconciler {
+	return &classifiedReconciler{controller: controller, reconciler: reconciler}
+}
+
+func (c *classifiedReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	result, err := c.reconciler.Reconcile(ctx, request)
+	if err == nil {
+		return result, nil
+	}
+
+	syncErrorCounter.WithLabelValues(c.controller, Reason(err)).Inc()
+
+	// The permanent errors are already reported by the reconcilers, and the policy is reconciled again when it
+	// changes
+	if Permanent(err) {
+		return result, nil
+	}
+
+	return result, err
+}
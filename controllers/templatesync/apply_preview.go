@@ -0,0 +1,172 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxPreviewPaths is the maximum number of changed fields listed in the preview event of an update.
+const maxPreviewPaths = 10
+
+// previewKey identifies a policy template of a policy.
+type previewKey struct {
+	policy types.NamespacedName
+	tName  string
+}
+
+// pendingPreview is an update of a policy template object which was previewed but not applied yet.
+type pendingPreview struct {
+	checksum string
+	since    time.Time
+}
+
+// ApplyPreview delays the updates of the enforce mode policy template objects caused by a change to the policy
+// template on the Hub, after emitting an event summarizing the fields which will change. This gives the operators of
+// the managed cluster a last-moment signal, and a trail in the events and logs, before the enforcement changes. Changes
+// made to the objects on the managed cluster are still reverted right away. A nil ApplyPreview doesn't delay the
+// updates.
+type ApplyPreview struct {
+	Delay   time.Duration
+	pending map[previewKey]pendingPreview
+	lock    sync.Mutex
+}
+
+// remaining returns how long the update of the existing policy template object to the input policy template object
+// with the input checksum must still be delayed, or zero if it may be applied. The first time an update is seen, the
+// full delay is returned along with true so that the update is previewed.
+func (p *ApplyPreview) remaining(
+	policy types.NamespacedName,
+	existing *unstructured.Unstructured,
+	tObject *unstructured.Unstructured,
+	checksum string,
+	now time.Time,
+) (time.Duration, bool) {
+	if p == nil || p.Delay <= 0 || !isEnforced(tObject) {
+		return 0, false
+	}
+
+	// Like the enforce soak time, only the changes to the policy template are delayed
+	lastApplied, ok := existing.GetAnnotations()[LastAppliedAnnotation]
+	if !ok || lastApplied == tObject.GetAnnotations()[LastAppliedAnnotation] {
+		return 0, false
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.pending == nil {
+		p.pending = map[previewKey]pendingPreview{}
+	}
+
+	key := previewKey{policy: policy, tName: tObject.GetName()}
+
+	// A new change to the policy template during the delay is previewed again
+	preview, ok := p.pending[key]
+	if !ok || preview.checksum != checksum {
+		p.pending[key] = pendingPreview{checksum: checksum, since: now}
+
+		return p.Delay, true
+	}
+
+	if remaining := preview.since.Add(p.Delay).Sub(now); remaining > 0 {
+		return remaining, false
+	}
+
+	delete(p.pending, key)
+
+	return 0, false
+}
+
+// forget discards the pending previews of the input policy, such as when it is deleted.
+func (p *ApplyPreview) forget(policy types.NamespacedName) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for key := range p.pending {
+		if key.policy == policy {
+			delete(p.pending, key)
+		}
+	}
+}
+
+// previewMessage returns the message of the preview event of the update of the existing policy template object to the
+// input policy template object, which lists the fields which will change.
+func previewMessage(tName string, existing, tObject *unstructured.Unstructured, delay time.Duration) string {
+	paths := []string{}
+	changedPaths("spec", existing.Object["spec"], tObject.Object["spec"], &paths)
+
+	summary := strings.Join(paths, ", ")
+	if len(paths) > maxPreviewPaths {
+		summary = fmt.Sprintf(
+			"%s, and %d more", strings.Join(paths[:maxPreviewPaths], ", "), len(paths)-maxPreviewPaths,
+		)
+	}
+
+	if summary == "" {
+		summary = "metadata.annotations"
+	}
+
+	return fmt.Sprintf("Policy template %s will be updated in %s, changing %s", tName, delay, summary)
+}
+
+// changedPaths appends the paths of the fields which differ between the existing and desired values to the input
+// paths. A list whose length changed is reported as a whole.
+func changedPaths(path string, existing, desired interface{}, paths *[]string) {
+	if equality.Semantic.DeepEqual(existing, desired) {
+		return
+	}
+
+	switch typedDesired := desired.(type) {
+	case map[string]interface{}:
+		typedExisting, ok := existing.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(typedDesired)+len(typedExisting))
+
+		for key := range typedDesired {
+			keys = append(keys, key)
+		}
+
+		for key := range typedExisting {
+			if _, ok := typedDesired[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			changedPaths(path+"."+key, typedExisting[key], typedDesired[key], paths)
+		}
+
+		return
+	case []interface{}:
+		typedExisting, ok := existing.([]interface{})
+		if !ok || len(typedExisting) != len(typedDesired) {
+			break
+		}
+
+		for i := range typedDesired {
+			changedPaths(fmt.Sprintf("%s[%d]", path, i), typedExisting[i], typedDesired[i], paths)
+		}
+
+		return
+	}
+
+	*paths = append(*paths, path)
+}
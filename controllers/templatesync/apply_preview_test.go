@@ -0,0 +1,91 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApplyPreview(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now()
+	policy := types.NamespacedName{Namespace: "cluster-ns", Name: "policy"}
+
+	existing := diffTemplate()
+	Expect(unstructured.SetNestedField(existing.Object, "enforce", "spec", "remediationAction")).To(Succeed())
+	setLastApplied(existing)
+
+	updated := existing.DeepCopy()
+	Expect(unstructured.SetNestedField(updated.Object, "dryrun", "spec", "enforcementAction")).To(Succeed())
+	setLastApplied(updated)
+
+	var disabled *ApplyPreview
+	Expect(disabled.remaining(policy, existing, updated, "1", now)).To(BeZero())
+
+	preview := &ApplyPreview{Delay: 30 * time.Second}
+
+	// The first time, the update is previewed and delayed
+	remaining, first := preview.remaining(policy, existing, updated, "1", now)
+	Expect(remaining).To(Equal(30 * time.Second))
+	Expect(first).To(BeTrue())
+
+	remaining, first = preview.remaining(policy, existing, updated, "1", now.Add(20*time.Second))
+	Expect(remaining).To(Equal(10 * time.Second))
+	Expect(first).To(BeFalse())
+
+	// A new change is previewed again
+	remaining, first = preview.remaining(policy, existing, updated, "2", now.Add(20*time.Second))
+	Expect(remaining).To(Equal(30 * time.Second))
+	Expect(first).To(BeTrue())
+
+	Expect(preview.remaining(policy, existing, updated, "2", now.Add(50*time.Second))).To(BeZero())
+	Expect(preview.pending).To(BeEmpty())
+
+	// Reverting changes made on the managed cluster is never delayed
+	Expect(preview.remaining(policy, existing, existing.DeepCopy(), "1", now)).To(BeZero())
+
+	preview.remaining(policy, existing, updated, "3", now)
+	preview.forget(policy)
+	Expect(preview.pending).To(BeEmpty())
+
+	Expect(previewMessage("ns-must-have-gk", existing, updated, 30*time.Second)).To(Equal(
+		"Policy template ns-must-have-gk will be updated in 30s, changing spec.enforcementAction",
+	))
+}
+
+func TestChangedPaths(t *testing.T) {
+	RegisterTestingT(t)
+
+	existing := map[string]interface{}{
+		"remediationAction": "inform",
+		"object-templates": []interface{}{
+			map[string]interface{}{"complianceType": "musthave", "objectDefinition": map[string]interface{}{}},
+		},
+		"namespaceSelector": map[string]interface{}{"include": []interface{}{"default"}},
+		"severity":          "low",
+	}
+	desired := map[string]interface{}{
+		"remediationAction": "enforce",
+		"object-templates": []interface{}{
+			map[string]interface{}{"complianceType": "mustonlyhave", "objectDefinition": map[string]interface{}{}},
+		},
+		"namespaceSelector":   map[string]interface{}{"include": []interface{}{"default", "kube-system"}},
+		"pruneObjectBehavior": "DeleteAll",
+	}
+
+	paths := []string{}
+	changedPaths("spec", existing, desired, &paths)
+	Expect(paths).To(Equal([]string{
+		"spec.namespaceSelector.include",
+		"spec.object-templates[0].complianceType",
+		"spec.pruneObjectBehavior",
+		"spec.remediationAction",
+		"spec.severity",
+	}))
+}
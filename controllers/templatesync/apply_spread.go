@@ -0,0 +1,133 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	applySpreadDelayHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "policy_template_apply_spread_delay_seconds",
+			Help: "How long the updates of the enforce mode policy template objects were delayed to spread them over " +
+				"the apply spread window.",
+			// 1 second to about 1 hour
+			Buckets: prometheus.ExponentialBuckets(1, 2, 13),
+		},
+	)
+	applySpreadPendingGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "policy_template_apply_spread_pending",
+			Help: "The number of updates of the enforce mode policy template objects waiting for their turn in the " +
+				"apply spread window.",
+		},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(applySpreadDelayHistogram, applySpreadPendingGauge)
+}
+
+// pendingSpread is an update of a policy template object waiting for its turn in the spread window.
+type pendingSpread struct {
+	checksum string
+	since    time.Time
+	delay    time.Duration
+}
+
+// ApplySpread spreads the updates of the enforce mode policy template objects caused by a change to the policy
+// templates on the Hub over a window, so that a fleet-wide change to many policies doesn't enforce all of them on the
+// managed cluster at once. Each update is delayed by a jitter within the window derived from its policy, policy
+// template, and checksum, so the updates are spread evenly and all of them are applied within the window. Changes made
+// to the objects on the managed cluster are still reverted right away. A nil ApplySpread doesn't delay the updates.
+type ApplySpread struct {
+	// Window is the longest time an update is delayed.
+	Window  time.Duration
+	pending map[previewKey]pendingSpread
+	lock    sync.Mutex
+}
+
+// spreadDelay returns the delay within the input window of the update of the input policy template to the input
+// checksum. The delay is stable so that a restarted addon spreads the same updates the same way.
+func spreadDelay(key previewKey, checksum string, window time.Duration) time.Duration {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key.policy.String() + "/" + key.tName + "/" + checksum))
+
+	return time.Duration(hash.Sum64() % uint64(window))
+}
+
+// remaining returns how long the update of the existing policy template object to the input policy template object
+// with the input checksum must still be delayed, or zero if it may be applied.
+func (s *ApplySpread) remaining(
+	policy types.NamespacedName,
+	existing *unstructured.Unstructured,
+	tObject *unstructured.Unstructured,
+	checksum string,
+	now time.Time,
+) time.Duration {
+	if s == nil || s.Window <= 0 || !isEnforced(tObject) {
+		return 0
+	}
+
+	// Like the apply preview, only the changes to the policy template are delayed
+	lastApplied, ok := existing.GetAnnotations()[LastAppliedAnnotation]
+	if !ok || lastApplied == tObject.GetAnnotations()[LastAppliedAnnotation] {
+		return 0
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.pending == nil {
+		s.pending = map[previewKey]pendingSpread{}
+	}
+
+	key := previewKey{policy: policy, tName: tObject.GetName()}
+
+	// A new change to the policy template during the delay gets a new turn
+	spread, ok := s.pending[key]
+	if !ok || spread.checksum != checksum {
+		spread = pendingSpread{checksum: checksum, since: now, delay: spreadDelay(key, checksum, s.Window)}
+		s.pending[key] = spread
+
+		applySpreadPendingGauge.Set(float64(len(s.pending)))
+	}
+
+	if remaining := spread.since.Add(spread.delay).Sub(now); remaining > 0 {
+		return remaining
+	}
+
+	delete(s.pending, key)
+
+	applySpreadPendingGauge.Set(float64(len(s.pending)))
+	applySpreadDelayHistogram.Observe(now.Sub(spread.since).Seconds())
+
+	return 0
+}
+
+// forget discards the pending updates of the input policy, such as when it is deleted.
+func (s *ApplySpread) forget(policy types.NamespacedName) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key := range s.pending {
+		if key.policy == policy {
+			delete(s.pending, key)
+		}
+	}
+
+	applySpreadPendingGauge.Set(float64(len(s.pending)))
+}
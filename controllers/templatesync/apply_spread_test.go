@@ -0,0 +1,59 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApplySpread(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now()
+	window := 10 * time.Minute
+	policy := types.NamespacedName{Namespace: "cluster-ns", Name: "policy"}
+
+	existing := diffTemplate()
+	Expect(unstructured.SetNestedField(existing.Object, "enforce", "spec", "remediationAction")).To(Succeed())
+	setLastApplied(existing)
+
+	updated := existing.DeepCopy()
+	Expect(unstructured.SetNestedField(updated.Object, "dryrun", "spec", "enforcementAction")).To(Succeed())
+	setLastApplied(updated)
+
+	var disabled *ApplySpread
+	Expect(disabled.remaining(policy, existing, updated, "1", now)).To(BeZero())
+
+	spread := &ApplySpread{Window: window}
+
+	// The update is delayed by a stable jitter within the window
+	delay := spreadDelay(previewKey{policy: policy, tName: updated.GetName()}, "1", window)
+	Expect(delay).To(BeNumerically("<", window))
+	Expect(spread.remaining(policy, existing, updated, "1", now)).To(Equal(delay))
+	Expect(spread.remaining(policy, existing, updated, "1", now.Add(delay/2))).To(Equal(delay - delay/2))
+	Expect(spread.remaining(policy, existing, updated, "1", now.Add(window))).To(BeZero())
+	Expect(spread.pending).To(BeEmpty())
+
+	// Reverting changes made on the managed cluster is never delayed
+	Expect(spread.remaining(policy, existing, existing.DeepCopy(), "1", now)).To(BeZero())
+
+	// The updates of many policies are spread over the window
+	delays := map[time.Duration]bool{}
+
+	for i := 0; i < 100; i++ {
+		key := previewKey{policy: types.NamespacedName{Namespace: "cluster-ns", Name: fmt.Sprintf("policy-%d", i)}}
+		delays[spreadDelay(key, "1", window)/time.Minute] = true
+	}
+
+	Expect(delays).To(HaveLen(10))
+
+	spread.remaining(policy, existing, updated, "2", now)
+	spread.forget(policy)
+	Expect(spread.pending).To(BeEmpty())
+}
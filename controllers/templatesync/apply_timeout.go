@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+// ApplyTimeouts bounds the create, update, and delete requests of the policy template objects so that a hung admission
+// webhook on the managed cluster doesn't block the reconciles indefinitely.
+type ApplyTimeouts struct {
+	// Default is the timeout of the kinds not in ByKind. If it is not positive, the requests aren't bounded.
+	Default time.Duration
+	// ByKind overrides the timeout of the kinds of policy templates
+	ByKind map[string]time.Duration
+}
+
+// ParseApplyTimeouts parses the input map of kinds to durations, such as from the command line.
+func ParseApplyTimeouts(timeouts map[string]string) (map[string]time.Duration, error) {
+	parsed := make(map[string]time.Duration, len(timeouts))
+
+	for kind, value := range timeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid apply timeout for the kind %s: %w", kind, err)
+		}
+
+		parsed[kind] = timeout
+	}
+
+	return parsed, nil
+}
+
+// timeout returns the apply timeout of the input kind. A nil ApplyTimeouts always returns zero.
+func (t *ApplyTimeouts) timeout(kind string) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	if timeout, ok := t.ByKind[kind]; ok {
+		return timeout
+	}
+
+	return t.Default
+}
+
+// apply calls the input function with a context bounded by the apply timeout of the input kind. If the timeout is
+// reached, the returned error wraps syncerrors.ErrApplyTimeout. The cancellation of the input context, such as when
+// the controller is stopping, is returned as is.
+func (t *ApplyTimeouts) apply(ctx context.Context, kind string, applyFunc func(ctx context.Context) error) error {
+	timeout := t.timeout(kind)
+	if timeout <= 0 {
+		return applyFunc(ctx)
+	}
+
+	applyCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := applyFunc(applyCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(applyCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf(
+			"%w after %s, check the admission webhooks for the kind %s on the managed cluster: %v",
+			syncerrors.ErrApplyTimeout, timeout, kind, err,
+		)
+	}
+
+	return err
+}
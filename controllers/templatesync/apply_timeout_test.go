@@ -0,0 +1,78 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+func TestApplyTimeouts(t *testing.T) {
+	RegisterTestingT(t)
+
+	timeouts := &ApplyTimeouts{
+		Default: time.Hour,
+		ByKind:  map[string]time.Duration{"ConfigurationPolicy": 10 * time.Millisecond},
+	}
+
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	err := timeouts.apply(context.Background(), "ConfigurationPolicy", hang)
+	Expect(errors.Is(err, syncerrors.ErrApplyTimeout)).To(BeTrue())
+	Expect(err.Error()).To(ContainSubstring("ConfigurationPolicy"))
+	Expect(syncerrors.Reason(syncerrors.Wrap(syncerrors.ErrTemplateCreate, err))).To(Equal("ApplyTimeout"))
+
+	// Other errors are returned as is
+	otherErr := errors.New("admission webhook denied the request")
+	err = timeouts.apply(context.Background(), "CertificatePolicy", func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		Expect(hasDeadline).To(BeTrue())
+
+		return otherErr
+	})
+	Expect(err).To(Equal(otherErr))
+	Expect(syncerrors.Reason(syncerrors.Wrap(syncerrors.ErrTemplateCreate, err))).To(Equal("CreateError"))
+
+	// The cancellation of the parent context is not reported as a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = timeouts.apply(ctx, "ConfigurationPolicy", hang)
+	Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	Expect(errors.Is(err, syncerrors.ErrApplyTimeout)).To(BeFalse())
+}
+
+func TestApplyTimeoutsNil(t *testing.T) {
+	RegisterTestingT(t)
+
+	var timeouts *ApplyTimeouts
+
+	err := timeouts.apply(context.Background(), "ConfigurationPolicy", func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		Expect(hasDeadline).To(BeFalse())
+
+		return nil
+	})
+	Expect(err).ToNot(HaveOccurred())
+}
+
+func TestParseApplyTimeouts(t *testing.T) {
+	RegisterTestingT(t)
+
+	timeouts, err := ParseApplyTimeouts(map[string]string{"ConfigurationPolicy": "30s"})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(timeouts).To(Equal(map[string]time.Duration{"ConfigurationPolicy": 30 * time.Second}))
+
+	_, err = ParseApplyTimeouts(map[string]string{"ConfigurationPolicy": "forever"})
+	Expect(err).To(HaveOccurred())
+}
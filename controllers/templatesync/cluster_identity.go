@@ -0,0 +1,165 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+// ClusterIdentityFieldsAnnotation is set on a policy template to the comma separated paths of the fields (e.g.
+// spec.object-templates) in which the cluster identity variables are substituted before the policy template is applied.
+const ClusterIdentityFieldsAnnotation = "policy.open-cluster-management.io/cluster-identity-fields"
+
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=clusterclaims,verbs=get;list
+
+var clusterClaimGVR = schema.GroupVersionResource{
+	Group: "cluster.open-cluster-management.io", Version: "v1alpha1", Resource: "clusterclaims",
+}
+
+// clusterIdentityVariable matches the $(cluster.name) and $(cluster.claims.<claim name>) variables.
+var clusterIdentityVariable = regexp.MustCompile(`\$\((cluster\.(?:name|claims\.[a-zA-Z0-9.-]+))\)`)
+
+// ClusterIdentity substitutes the identity of the managed cluster, which is its name and the values of its cluster
+// claims, in the designated fields of the policy templates, so that cluster-local values don't require Hub templates.
+type ClusterIdentity struct {
+	Client      dynamic.Interface
+	ClusterName string
+}
+
+// values returns the values of the cluster identity variables, keyed by the variable name without the $() delimiters.
+func (c *ClusterIdentity) values(ctx context.Context) (map[string]string, error) {
+	values := map[string]string{"cluster.name": c.ClusterName}
+
+	claims, err := c.Client.Resource(clusterClaimGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		// The cluster claims are optional, so a cluster without them only has its name
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return values, nil
+		}
+
+		return nil, fmt.Errorf("failed to list the cluster claims: %w", err)
+	}
+
+	for _, claim := range claims.Items {
+		value, _, _ := unstructured.NestedString(claim.Object, "spec", "value")
+		values["cluster.claims."+claim.GetName()] = value
+	}
+
+	return values, nil
+}
+
+// inject substitutes the cluster identity variables in the fields of the input policy template object designated by
+// its ClusterIdentityFieldsAnnotation annotation. The values are loaded into the input cache on the first use, so that
+// the cluster claims are only listed once per reconcile. A nil ClusterIdentity leaves the object unchanged.
+func (c *ClusterIdentity) inject(
+	ctx context.Context, obj *unstructured.Unstructured, cache *map[string]string,
+) error {
+	annotation := obj.GetAnnotations()[ClusterIdentityFieldsAnnotation]
+	if c == nil || annotation == "" {
+		return nil
+	}
+
+	if *cache == nil {
+		values, err := c.values(ctx)
+		if err != nil {
+			return syncerrors.WithCause(
+				syncerrors.ErrClusterIdentity,
+				fmt.Sprintf("Failed to get the cluster identity for the policy template: %s", err),
+				err,
+			)
+		}
+
+		*cache = values
+	}
+
+	for _, path := range strings.Split(annotation, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		fields := strings.Split(path, ".")
+
+		parent, found, err := unstructured.NestedFieldNoCopy(obj.Object, fields[:len(fields)-1]...)
+		parentMap, isMap := parent.(map[string]interface{})
+
+		if err != nil || !found || !isMap {
+			return syncerrors.New(
+				syncerrors.ErrClusterIdentity,
+				fmt.Sprintf("The cluster identity field %s was not found in the policy template", path),
+			)
+		}
+
+		value, found := parentMap[fields[len(fields)-1]]
+		if !found {
+			return syncerrors.New(
+				syncerrors.ErrClusterIdentity,
+				fmt.Sprintf("The cluster identity field %s was not found in the policy template", path),
+			)
+		}
+
+		unknown := map[string]bool{}
+		parentMap[fields[len(fields)-1]] = substituteClusterIdentity(value, *cache, unknown)
+
+		if len(unknown) != 0 {
+			variables := make([]string, 0, len(unknown))
+			for variable := range unknown {
+				variables = append(variables, "$("+variable+")")
+			}
+
+			sort.Strings(variables)
+
+			return syncerrors.New(
+				syncerrors.ErrClusterIdentity,
+				fmt.Sprintf(
+					"The cluster identity variables %s in the field %s are not defined on the managed cluster",
+					strings.Join(variables, ", "), path,
+				),
+			)
+		}
+	}
+
+	return nil
+}
+
+// substituteClusterIdentity returns the input value with the cluster identity variables substituted in all of its
+// strings. The variables without a value are recorded in the input unknown map and left as is.
+func substituteClusterIdentity(value interface{}, values map[string]string, unknown map[string]bool) interface{} {
+	switch typedValue := value.(type) {
+	case string:
+		return clusterIdentityVariable.ReplaceAllStringFunc(typedValue, func(match string) string {
+			variable := clusterIdentityVariable.FindStringSubmatch(match)[1]
+
+			if substitution, ok := values[variable]; ok {
+				return substitution
+			}
+
+			unknown[variable] = true
+
+			return match
+		})
+	case map[string]interface{}:
+		for key, nested := range typedValue {
+			typedValue[key] = substituteClusterIdentity(nested, values, unknown)
+		}
+	case []interface{}:
+		for i, nested := range typedValue {
+			typedValue[i] = substituteClusterIdentity(nested, values, unknown)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+func clusterIdentityTemplate(fields string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "ConfigurationPolicy",
+		"metadata": map[string]interface{}{
+			"name":        "case1-config-policy",
+			"annotations": map[string]interface{}{ClusterIdentityFieldsAnnotation: fields},
+		},
+		"spec": map[string]interface{}{
+			"severity": "$(cluster.name)",
+			"object-templates": []interface{}{
+				map[string]interface{}{
+					"complianceType": "musthave",
+					"objectDefinition": map[string]interface{}{
+						"data": map[string]interface{}{
+							"location": "$(cluster.name) in $(cluster.claims.region.open-cluster-management.io)",
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func TestClusterIdentityInject(t *testing.T) {
+	RegisterTestingT(t)
+
+	claim := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cluster.open-cluster-management.io/v1alpha1",
+		"kind":       "ClusterClaim",
+		"metadata":   map[string]interface{}{"name": "region.open-cluster-management.io"},
+		"spec":       map[string]interface{}{"value": "us-east-1"},
+	}}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), map[schema.GroupVersionResource]string{clusterClaimGVR: "ClusterClaimList"}, claim,
+	)
+	identity := &ClusterIdentity{Client: client, ClusterName: "cluster1"}
+
+	// Without the annotation, the object is unchanged
+	var nilIdentity *ClusterIdentity
+
+	var cache map[string]string
+
+	obj := clusterIdentityTemplate("spec.object-templates")
+	Expect(nilIdentity.inject(context.TODO(), obj, &cache)).To(Succeed())
+	Expect(obj.Object).To(Equal(clusterIdentityTemplate("spec.object-templates").Object))
+
+	// Only the designated fields are substituted
+	Expect(identity.inject(context.TODO(), obj, &cache)).To(Succeed())
+	Expect(cache).To(HaveKeyWithValue("cluster.claims.region.open-cluster-management.io", "us-east-1"))
+
+	location, _, _ := unstructured.NestedString(
+		obj.Object["spec"].(map[string]interface{})["object-templates"].([]interface{})[0].(map[string]interface{}),
+		"objectDefinition", "data", "location",
+	)
+	Expect(location).To(Equal("cluster1 in us-east-1"))
+
+	severity, _, _ := unstructured.NestedString(obj.Object, "spec", "severity")
+	Expect(severity).To(Equal("$(cluster.name)"))
+
+	obj = clusterIdentityTemplate("spec.severity, spec.object-templates")
+	Expect(identity.inject(context.TODO(), obj, &cache)).To(Succeed())
+
+	severity, _, _ = unstructured.NestedString(obj.Object, "spec", "severity")
+	Expect(severity).To(Equal("cluster1"))
+
+	// Undefined variables and missing fields are template errors
+	obj = clusterIdentityTemplate("spec.object-templates")
+	cache = map[string]string{"cluster.name": "cluster1"}
+	err := identity.inject(context.TODO(), obj, &cache)
+	Expect(errors.Is(err, syncerrors.ErrClusterIdentity)).To(BeTrue())
+	Expect(err.Error()).To(ContainSubstring("$(cluster.claims.region.open-cluster-management.io)"))
+
+	err = identity.inject(context.TODO(), clusterIdentityTemplate("spec.namespaceSelector"), &cache)
+	Expect(syncerrors.Reason(err)).To(Equal("ClusterIdentityError"))
+}
@@ -0,0 +1,179 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// ObjectDefinitionFromAnnotation can be set on a policy template to a "<configmap>/<key>" reference to a ConfigMap in
+// the cluster namespace on the Hub. The object definition of the policy template is then replaced by the content of
+// the key, which allows sharing large policy templates across many policies.
+const ObjectDefinitionFromAnnotation = "policy.open-cluster-management.io/object-definition-from"
+
+// HubConfigMapResolver resolves policy templates referencing a ConfigMap in the cluster namespace on the Hub. The
+// ConfigMaps are watched so that the policies referencing them are reconciled when they change.
+type HubConfigMapResolver struct {
+	Client    kubernetes.Interface
+	Namespace string
+	lister    corev1listers.ConfigMapLister
+	synced    cache.InformerSynced
+	// refs maps a ConfigMap name to the policies referencing it
+	refs   map[string]map[types.NamespacedName]bool
+	events chan event.GenericEvent
+	once   sync.Once
+	lock   sync.RWMutex
+}
+
+func (h *HubConfigMapResolver) init() {
+	h.once.Do(func() {
+		h.refs = map[string]map[types.NamespacedName]bool{}
+		h.events = make(chan event.GenericEvent, 1024)
+	})
+}
+
+// Events returns the channel of reconcile events for policies referencing a ConfigMap that changed.
+func (h *HubConfigMapResolver) Events() <-chan event.GenericEvent {
+	h.init()
+
+	return h.events
+}
+
+// Start watches the ConfigMaps in the cluster namespace on the Hub until the input context is closed.
+func (h *HubConfigMapResolver) Start(ctx context.Context) error {
+	h.init()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(h.Client, 0, informers.WithNamespace(h.Namespace))
+	configMapInformer := factory.Core().V1().ConfigMaps()
+	informer := configMapInformer.Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		// Policies referencing a ConfigMap that doesn't exist yet are reconciled when it is created
+		AddFunc: h.enqueue,
+		UpdateFunc: func(_, newObj interface{}) {
+			h.enqueue(newObj)
+		},
+		DeleteFunc: h.enqueue,
+	})
+
+	h.lock.Lock()
+	h.lister = configMapInformer.Lister()
+	h.synced = informer.HasSynced
+	h.lock.Unlock()
+
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+
+	return nil
+}
+
+// enqueue sends a reconcile event for each policy referencing the input ConfigMap.
+func (h *HubConfigMapResolver) enqueue(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return
+	}
+
+	h.lock.RLock()
+	policies := make([]types.NamespacedName, 0, len(h.refs[configMap.Name]))
+
+	for policy := range h.refs[configMap.Name] {
+		policies = append(policies, policy)
+	}
+
+	h.lock.RUnlock()
+
+	for _, policy := range policies {
+		h.events <- event.GenericEvent{
+			Object: &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Namespace: policy.Namespace, Name: policy.Name}},
+		}
+	}
+}
+
+// track records the ConfigMaps referenced by the input policy. Passing no ConfigMaps removes the policy.
+func (h *HubConfigMapResolver) track(policy types.NamespacedName, configMaps map[string]bool) {
+	h.init()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for name, policies := range h.refs {
+		if !configMaps[name] {
+			delete(policies, policy)
+
+			if len(policies) == 0 {
+				delete(h.refs, name)
+			}
+		}
+	}
+
+	for name := range configMaps {
+		if h.refs[name] == nil {
+			h.refs[name] = map[types.NamespacedName]bool{}
+		}
+
+		h.refs[name][policy] = true
+	}
+}
+
+// parseConfigMapRef returns the ConfigMap name and key of a "<configmap>/<key>" reference.
+func parseConfigMapRef(ref string) (string, string, error) {
+	name, key, found := strings.Cut(ref, "/")
+	if !found || name == "" || key == "" || strings.Contains(key, "/") {
+		return "", "", errors.NewBadRequest(
+			fmt.Sprintf("the ConfigMap reference %s must be in the format <configmap>/<key>", ref),
+		)
+	}
+
+	return name, key, nil
+}
+
+// Resolve returns the content of the ConfigMap key referenced by the input reference.
+func (h *HubConfigMapResolver) Resolve(ctx context.Context, ref string) ([]byte, error) {
+	name, key, err := parseConfigMapRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	h.lock.RLock()
+	lister, synced := h.lister, h.synced
+	h.lock.RUnlock()
+
+	var configMap *corev1.ConfigMap
+
+	// Fall back to the API until the ConfigMaps are cached
+	if lister != nil && synced() {
+		configMap, err = lister.ConfigMaps(h.Namespace).Get(name)
+	} else {
+		configMap, err = h.Client.CoreV1().ConfigMaps(h.Namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	content, ok := configMap.Data[key]
+	if !ok {
+		return nil, errors.NewBadRequest(fmt.Sprintf("the ConfigMap %s does not have the key %s", name, key))
+	}
+
+	return []byte(content), nil
+}
@@ -0,0 +1,69 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHubConfigMapResolverResolve(t *testing.T) {
+	RegisterTestingT(t)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared-templates", Namespace: "cluster1"},
+		Data:       map[string]string{"config-policy": testTemplate},
+	}
+	resolver := &HubConfigMapResolver{Client: fake.NewSimpleClientset(configMap), Namespace: "cluster1"}
+
+	content, err := resolver.Resolve(context.TODO(), "shared-templates/config-policy")
+	Expect(err).To(BeNil())
+	Expect(string(content)).To(Equal(testTemplate))
+
+	_, err = resolver.Resolve(context.TODO(), "shared-templates/other")
+	Expect(err).ToNot(BeNil())
+
+	for _, invalid := range []string{"shared-templates", "/config-policy", "shared-templates/", "a/b/c"} {
+		_, err = resolver.Resolve(context.TODO(), invalid)
+		Expect(err).ToNot(BeNil())
+	}
+
+	gvk := &schema.GroupVersionKind{
+		Group: "policy.open-cluster-management.io", Version: "v1", Kind: "ConfigurationPolicy",
+	}
+
+	_, err = resolvedObjectDefinition(content, "the ConfigMap", gvk, "oci-template")
+	Expect(err).To(BeNil())
+
+	_, err = resolvedObjectDefinition(content, "the ConfigMap", gvk, "other-name")
+	Expect(err).To(MatchError(ContainSubstring("the ConfigMap defines ConfigurationPolicy oci-template")))
+}
+
+func TestHubConfigMapResolverTrack(t *testing.T) {
+	RegisterTestingT(t)
+
+	resolver := &HubConfigMapResolver{Namespace: "cluster1"}
+	policy := types.NamespacedName{Namespace: "managed", Name: "policy"}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "shared-templates", Namespace: "cluster1"}}
+
+	resolver.track(policy, map[string]bool{"shared-templates": true})
+	resolver.enqueue(configMap)
+	Expect(resolver.Events()).To(HaveLen(1))
+
+	evt := <-resolver.Events()
+	Expect(evt.Object.GetNamespace()).To(Equal("managed"))
+	Expect(evt.Object.GetName()).To(Equal("policy"))
+
+	// The policy no longer references the ConfigMap
+	resolver.track(policy, nil)
+	resolver.enqueue(configMap)
+	Expect(resolver.Events()).To(BeEmpty())
+	Expect(resolver.refs).To(BeEmpty())
+}
@@ -0,0 +1,46 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// setCorrelationID sets the correlation ID of the input policy on the input policy template object it is about to
+// create or update, so that the object is related to the change of the policy on the Hub which last applied it. The
+// annotation is not part of the last applied hash, so a new correlation ID alone doesn't update the object.
+func setCorrelationID(instance *policiesv1.Policy, tObject *unstructured.Unstructured) {
+	id := utils.CorrelationID(instance)
+	if id == "" {
+		return
+	}
+
+	annotations := tObject.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[utils.CorrelationIDAnnotation] = id
+	tObject.SetAnnotations(annotations)
+}
+
+// withoutCorrelationID returns the input annotations of a policy template object without the correlation ID, which is
+// not set on the policy template.
+func withoutCorrelationID(annotations map[string]string) map[string]string {
+	if _, ok := annotations[utils.CorrelationIDAnnotation]; !ok {
+		return annotations
+	}
+
+	filtered := make(map[string]string, len(annotations)-1)
+
+	for key, value := range annotations {
+		if key != utils.CorrelationIDAnnotation {
+			filtered[key] = value
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,39 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestSetCorrelationID(t *testing.T) {
+	RegisterTestingT(t)
+
+	tObject := diffTemplate()
+	setLastApplied(tObject)
+
+	existing := tObject.DeepCopy()
+
+	setCorrelationID(&policiesv1.Policy{}, existing)
+	Expect(existing.GetAnnotations()).ToNot(HaveKey(utils.CorrelationIDAnnotation))
+
+	instance := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.CorrelationIDAnnotation: "abc"}},
+	}
+
+	setCorrelationID(instance, existing)
+	Expect(existing.GetAnnotations()).To(HaveKeyWithValue(utils.CorrelationIDAnnotation, "abc"))
+
+	// The correlation ID of the object is neither a difference nor a suppressed difference
+	suppressed := testutil.ToFloat64(suppressedDiffCounter.WithLabelValues(tObject.GetKind()))
+
+	Expect(templateObjectMatches(existing, tObject)).To(BeTrue())
+	Expect(testutil.ToFloat64(suppressedDiffCounter.WithLabelValues(tObject.GetKind()))).To(Equal(suppressed))
+}
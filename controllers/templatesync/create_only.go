@@ -0,0 +1,45 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	// CreateOnlyAnnotation can be set to "true" on a policy template to have its object created if it is missing but
+	// never updated afterward, such as for bootstrap objects that the users then own on the managed cluster.
+	CreateOnlyAnnotation = "policy.open-cluster-management.io/create-only"
+	// createOnlyDriftMessage is the drift reason of the objects of create-only policy templates which differ from
+	// them.
+	createOnlyDriftMessage = "The object differs from the create-only policy template and is not updated"
+	// createOnlyFeatureGate is the name of the feature gate enabling the CreateOnlyAnnotation annotation.
+	createOnlyFeatureGate = "CreateOnlyTemplates"
+)
+
+// isCreateOnly returns true if the input policy template object has the CreateOnlyAnnotation annotation set to true.
+func isCreateOnly(tObject *unstructured.Unstructured) bool {
+	return strings.EqualFold(tObject.GetAnnotations()[CreateOnlyAnnotation], "true")
+}
+
+// wasDrifted returns true if the input inventory entry was already recorded as drifted on the input policy, so that
+// the drift is only reported once.
+func wasDrifted(instance *policiesv1.Policy, entry utils.InventoryEntry) bool {
+	previous, err := utils.PolicyInventory(instance)
+	if err != nil {
+		return false
+	}
+
+	for _, previousEntry := range previous {
+		if previousEntry.Drifted != "" && previousEntry.Identity() == entry.Identity() {
+			return true
+		}
+	}
+
+	return false
+}
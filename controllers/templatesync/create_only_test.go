@@ -0,0 +1,51 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestIsCreateOnly(t *testing.T) {
+	RegisterTestingT(t)
+
+	tObject := &unstructured.Unstructured{}
+	Expect(isCreateOnly(tObject)).To(BeFalse())
+
+	tObject.SetAnnotations(map[string]string{CreateOnlyAnnotation: "True"})
+	Expect(isCreateOnly(tObject)).To(BeTrue())
+
+	tObject.SetAnnotations(map[string]string{CreateOnlyAnnotation: "false"})
+	Expect(isCreateOnly(tObject)).To(BeFalse())
+}
+
+func TestWasDrifted(t *testing.T) {
+	RegisterTestingT(t)
+
+	entry := utils.InventoryEntry{
+		APIVersion: "v1", Kind: "ConfigMap", Name: "bootstrap", Drifted: createOnlyDriftMessage,
+	}
+	other := utils.InventoryEntry{APIVersion: "v1", Kind: "ConfigMap", Name: "other"}
+
+	value, err := utils.InventoryAnnotationValue([]utils.InventoryEntry{other})
+	Expect(err).ToNot(HaveOccurred())
+
+	instance := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.TemplateInventoryAnnotation: value}},
+	}
+	Expect(wasDrifted(instance, entry)).To(BeFalse())
+
+	// The drift is only reported when it starts
+	value, err = utils.InventoryAnnotationValue([]utils.InventoryEntry{entry, other})
+	Expect(err).ToNot(HaveOccurred())
+
+	instance.Annotations[utils.TemplateInventoryAnnotation] = value
+	Expect(wasDrifted(instance, entry)).To(BeTrue())
+}
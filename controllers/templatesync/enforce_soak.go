@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// isEnforced returns true if the remediationAction of the input policy template object is enforce.
+func isEnforced(tObject *unstructured.Unstructured) bool {
+	remediationAction, _, _ := unstructured.NestedString(tObject.Object, "spec", "remediationAction")
+
+	return strings.EqualFold(remediationAction, string(policiesv1.Enforce))
+}
+
+// compliantSince returns the time since which the input policy template is Compliant, as recorded by the status sync
+// in the utils.LastTransitionTimeAnnotation annotation of its template metadata, or the zero time if the policy
+// template is not Compliant or the time isn't recorded. Unlike the history, which is pruned and collapses the
+// recurring events, the recorded time covers the whole time spent Compliant.
+func compliantSince(instance *policiesv1.Policy, tName string) time.Time {
+	for _, dpt := range instance.Status.Details {
+		if dpt == nil || dpt.TemplateMeta.GetName() != tName {
+			continue
+		}
+
+		if dpt.ComplianceState != policiesv1.Compliant {
+			return time.Time{}
+		}
+
+		return utils.TemplateTimestamp(dpt, utils.LastTransitionTimeAnnotation)
+	}
+
+	return time.Time{}
+}
+
+// enforceSoakRemaining returns how long the update of the existing enforce mode policy template object to the input
+// policy template object must be held until the previous version of the policy template was Compliant for the input
+// soak time, or zero if the update may be applied. Only changes to the policy template are held, so that changes
+// made to the object on the managed cluster are still reverted. The objects applied before the
+// LastAppliedAnnotation annotation existed are not held since their changes can't be told apart.
+func enforceSoakRemaining(
+	instance *policiesv1.Policy,
+	existing *unstructured.Unstructured,
+	tObject *unstructured.Unstructured,
+	soakTime time.Duration,
+	now time.Time,
+) time.Duration {
+	if soakTime <= 0 || !isEnforced(tObject) {
+		return 0
+	}
+
+	lastApplied, ok := existing.GetAnnotations()[LastAppliedAnnotation]
+	if !ok || lastApplied == tObject.GetAnnotations()[LastAppliedAnnotation] {
+		return 0
+	}
+
+	since := compliantSince(instance, existing.GetName())
+	if since.IsZero() {
+		return soakTime
+	}
+
+	if remaining := since.Add(soakTime).Sub(now); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func soakPolicy(state policiesv1.ComplianceState, since time.Time) *policiesv1.Policy {
+	dpt := &policiesv1.DetailsPerTemplate{
+		TemplateMeta:    metav1.ObjectMeta{Name: "ns-must-have-gk"},
+		ComplianceState: state,
+		// The history only has the latest recurring event, whose time doesn't tell when the state started
+		History: []policiesv1.ComplianceHistory{
+			{LastTimestamp: metav1.NewTime(time.Now()), Message: "Compliant; notification"},
+		},
+	}
+
+	if !since.IsZero() {
+		dpt.TemplateMeta.Annotations = map[string]string{
+			utils.LastTransitionTimeAnnotation: since.UTC().Format(time.RFC3339),
+		}
+	}
+
+	return &policiesv1.Policy{Status: policiesv1.PolicyStatus{Details: []*policiesv1.DetailsPerTemplate{dpt}}}
+}
+
+func TestEnforceSoakRemaining(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now().Truncate(time.Second)
+
+	existing := diffTemplate()
+	Expect(unstructured.SetNestedField(existing.Object, "enforce", "spec", "remediationAction")).To(Succeed())
+	setLastApplied(existing)
+
+	updated := existing.DeepCopy()
+	Expect(unstructured.SetNestedField(updated.Object, "dryrun", "spec", "enforcementAction")).To(Succeed())
+	setLastApplied(updated)
+
+	// Compliant for the last two hours
+	compliant := soakPolicy(policiesv1.Compliant, now.Add(-2*time.Hour))
+	Expect(compliantSince(compliant, "ns-must-have-gk")).To(BeTemporally("==", now.Add(-2*time.Hour)))
+	Expect(enforceSoakRemaining(compliant, existing, updated, time.Hour, now)).To(BeZero())
+	Expect(enforceSoakRemaining(compliant, existing, updated, 3*time.Hour, now)).To(Equal(time.Hour))
+
+	// Not Compliant, so the update is held for the whole soak time
+	nonCompliant := soakPolicy(policiesv1.NonCompliant, now.Add(-2*time.Hour))
+	Expect(compliantSince(nonCompliant, "ns-must-have-gk")).To(BeZero())
+
+	// The time since which the policy template is Compliant is not recorded yet
+	Expect(compliantSince(soakPolicy(policiesv1.Compliant, time.Time{}), "ns-must-have-gk")).To(BeZero())
+	Expect(enforceSoakRemaining(nonCompliant, existing, updated, time.Hour, now)).To(Equal(time.Hour))
+	Expect(enforceSoakRemaining(&policiesv1.Policy{}, existing, updated, time.Hour, now)).To(Equal(time.Hour))
+
+	// Reverting changes made on the managed cluster is never held
+	Expect(enforceSoakRemaining(nonCompliant, existing, existing.DeepCopy(), time.Hour, now)).To(BeZero())
+
+	// Inform mode policy templates and a disabled soak time are never held
+	Expect(enforceSoakRemaining(nonCompliant, existing, updated, 0, now)).To(BeZero())
+
+	Expect(unstructured.SetNestedField(updated.Object, "inform", "spec", "remediationAction")).To(Succeed())
+	setLastApplied(updated)
+	Expect(enforceSoakRemaining(nonCompliant, existing, updated, time.Hour, now)).To(BeZero())
+}
@@ -0,0 +1,136 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+// execHookTimeout bounds each execution of an exec hook.
+const execHookTimeout = 30 * time.Second
+
+// ErrInvalidExecHook is returned when the binary of an exec hook is not an absolute path.
+var ErrInvalidExecHook = errors.New("the exec hook must be an absolute path to a binary")
+
+// ExecHooks runs external binaries on the policy templates of the configured kinds before they are applied, so that
+// custom checks and transformations can be plugged in without recompiling the addon. A hook receives the JSON of the
+// policy template object on stdin. A nonzero exit code rejects the policy template with the stderr as the reason, and
+// a JSON object on stdout replaces the policy template object. An empty stdout leaves it unchanged.
+type ExecHooks struct {
+	// byKind maps the kinds of policy templates to the path of the binary of their hook
+	byKind map[string]string
+}
+
+// NewExecHooks returns the ExecHooks running the input binaries, mapped by the kinds of policy templates.
+func NewExecHooks(byKind map[string]string) (*ExecHooks, error) {
+	hooks := &ExecHooks{byKind: make(map[string]string, len(byKind))}
+
+	for kind, path := range byKind {
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("%w: %s=%s", ErrInvalidExecHook, kind, path)
+		}
+
+		hooks.byKind[kind] = path
+	}
+
+	return hooks, nil
+}
+
+// run runs the hook of the kind of the input policy template object, if any, and replaces the object with the
+// transformed object returned by the hook. A nil ExecHooks does nothing.
+func (h *ExecHooks) run(ctx context.Context, obj *unstructured.Unstructured) error {
+	if h == nil {
+		return nil
+	}
+
+	path, ok := h.byKind[obj.GetKind()]
+	if !ok {
+		return nil
+	}
+
+	input, err := json.Marshal(obj.Object)
+	if err != nil {
+		return syncerrors.WithCause(
+			syncerrors.ErrExecHook,
+			fmt.Sprintf("Failed to marshal the policy template for the exec hook: %s", err),
+			err,
+		)
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, execHookTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(hookCtx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+
+		if errors.As(err, &exitErr) && hookCtx.Err() == nil {
+			reason := strings.TrimSpace(stderr.String())
+			if reason == "" {
+				reason = exitErr.Error()
+			}
+
+			return syncerrors.New(
+				syncerrors.ErrExecHookRejected,
+				fmt.Sprintf("The policy template was rejected by the exec hook %s: %s", path, reason),
+			)
+		}
+
+		if hookCtx.Err() != nil && ctx.Err() == nil {
+			err = fmt.Errorf("the exec hook did not complete within %s", execHookTimeout)
+		}
+
+		return syncerrors.WithCause(
+			syncerrors.ErrExecHook, fmt.Sprintf("Failed to run the exec hook %s: %s", path, err), err,
+		)
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+
+	transformed := &unstructured.Unstructured{}
+
+	err = json.Unmarshal(stdout.Bytes(), &transformed.Object)
+	if err != nil {
+		return syncerrors.WithCause(
+			syncerrors.ErrExecHook,
+			fmt.Sprintf("The exec hook %s returned an invalid policy template object: %s", path, err),
+			err,
+		)
+	}
+
+	// The identity of the object is used for its ownership and the policy status, so the hooks can't change it
+	if transformed.GetAPIVersion() != obj.GetAPIVersion() || transformed.GetKind() != obj.GetKind() ||
+		transformed.GetName() != obj.GetName() || transformed.GetNamespace() != obj.GetNamespace() {
+		return syncerrors.New(
+			syncerrors.ErrExecHook,
+			fmt.Sprintf(
+				"The exec hook %s changed the apiVersion, kind, name, or namespace of the policy template", path,
+			),
+		)
+	}
+
+	obj.Object = transformed.Object
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+func writeExecHook(t *testing.T, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "hook")
+
+	err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func execHookTemplate() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "ConfigurationPolicy",
+		"metadata":   map[string]interface{}{"name": "case1-config-policy"},
+		"spec":       map[string]interface{}{"remediationAction": "inform"},
+	}}
+}
+
+func TestNewExecHooks(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewExecHooks(map[string]string{"ConfigurationPolicy": "hooks/check"})
+	Expect(errors.Is(err, ErrInvalidExecHook)).To(BeTrue())
+
+	hooks, err := NewExecHooks(map[string]string{"ConfigurationPolicy": "/hooks/check"})
+	Expect(err).To(BeNil())
+	Expect(hooks.byKind).To(HaveKeyWithValue("ConfigurationPolicy", "/hooks/check"))
+}
+
+func TestExecHooksRun(t *testing.T) {
+	RegisterTestingT(t)
+
+	var nilHooks *ExecHooks
+	Expect(nilHooks.run(context.TODO(), execHookTemplate())).To(Succeed())
+
+	// The kinds without a hook are not passed to a hook
+	hooks := &ExecHooks{byKind: map[string]string{"CertificatePolicy": "/does/not/exist"}}
+	Expect(hooks.run(context.TODO(), execHookTemplate())).To(Succeed())
+
+	// An empty stdout leaves the object unchanged
+	hooks.byKind["ConfigurationPolicy"] = writeExecHook(t, "cat > /dev/null\n")
+	obj := execHookTemplate()
+	Expect(hooks.run(context.TODO(), obj)).To(Succeed())
+	Expect(obj.Object).To(Equal(execHookTemplate().Object))
+
+	// A JSON object on stdout replaces the object
+	hooks.byKind["ConfigurationPolicy"] = writeExecHook(t, "sed 's/inform/enforce/'\n")
+	Expect(hooks.run(context.TODO(), obj)).To(Succeed())
+	Expect(obj.Object["spec"]).To(Equal(map[string]interface{}{"remediationAction": "enforce"}))
+
+	// A nonzero exit code rejects the object
+	hooks.byKind["ConfigurationPolicy"] = writeExecHook(t, "echo 'enforce is not allowed' >&2\nexit 1\n")
+	err := hooks.run(context.TODO(), obj)
+	Expect(errors.Is(err, syncerrors.ErrExecHookRejected)).To(BeTrue())
+	Expect(err.Error()).To(HaveSuffix("enforce is not allowed"))
+	Expect(syncerrors.Permanent(err)).To(BeTrue())
+
+	// The identity of the object can't change
+	hooks.byKind["ConfigurationPolicy"] = writeExecHook(t, "sed 's/case1-config-policy/other/'\n")
+	err = hooks.run(context.TODO(), obj)
+	Expect(errors.Is(err, syncerrors.ErrExecHook)).To(BeTrue())
+	Expect(obj.GetName()).To(Equal("case1-config-policy"))
+
+	// An invalid object is not applied
+	hooks.byKind["ConfigurationPolicy"] = writeExecHook(t, "echo 'not JSON'\n")
+	err = hooks.run(context.TODO(), obj)
+	Expect(syncerrors.Reason(err)).To(Equal("ExecHookError"))
+	Expect(syncerrors.Permanent(err)).To(BeFalse())
+
+	// A hook that can't be run is retried
+	hooks.byKind["ConfigurationPolicy"] = "/does/not/exist"
+	err = hooks.run(context.TODO(), obj)
+	Expect(errors.Is(err, syncerrors.ErrExecHook)).To(BeTrue())
+}
@@ -0,0 +1,451 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"container/list"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// OCIArtifactAnnotation is set on a policy template's object definition to have the object definition replaced
+	// by the content of an OCI artifact. The value must be a digest reference such as
+	// registry.example.com/policies/my-template@sha256:<hex>.
+	OCIArtifactAnnotation = "policy.open-cluster-management.io/oci-artifact"
+	// maxOCIArtifactSize is the largest manifest or blob that will be read from a registry.
+	maxOCIArtifactSize = 4 * 1024 * 1024
+	// DefaultOCICacheSize is the default total size in bytes of the OCI artifact contents kept in memory.
+	DefaultOCICacheSize = 64 * 1024 * 1024
+	// ociRequestTimeout is the timeout of each request to a registry, so that an unresponsive registry doesn't block
+	// the reconcile of the policy templates.
+	ociRequestTimeout = 30 * time.Second
+	// cosignSignatureAnnotation is the layer annotation cosign uses to store the base64 encoded signature.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	ociManifestMediaTypes     = "application/vnd.oci.image.manifest.v1+json," +
+		"application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var (
+	ErrInvalidOCIReference = errors.New(
+		"the OCI artifact reference must be in the form <registry>/<repository>@sha256:<digest>",
+	)
+	ErrOCIDigestMismatch    = errors.New("the OCI artifact content does not match the expected digest")
+	ErrOCISignatureNotFound = errors.New("no valid signature was found for the OCI artifact")
+	ErrOCIArtifactEmpty     = errors.New("the OCI artifact manifest does not contain any layers")
+)
+
+type ociReference struct {
+	registry   string
+	repository string
+	digest     string
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// OCIFetcher retrieves policy template object definitions stored as OCI artifacts. Since the artifacts are always
+// referenced by digest, the content is immutable and is cached after the first successful fetch, up to CacheSize.
+type OCIFetcher struct {
+	Client *http.Client
+	// PublicKey is used to verify the cosign signature of the artifact. If it is nil, signatures are not verified.
+	PublicKey *ecdsa.PublicKey
+	// CacheSize is the total size in bytes of the cached contents, beyond which the least recently used contents are
+	// evicted. If it is zero, DefaultOCICacheSize is used.
+	CacheSize int
+	cache     ociCache
+}
+
+// ociCacheEntry is a cached OCI artifact content.
+type ociCacheEntry struct {
+	reference string
+	content   []byte
+}
+
+// ociCache is a least recently used cache of OCI artifact contents by reference, bounded by their total size, so that
+// the artifacts no longer referenced by any policy are eventually evicted.
+type ociCache struct {
+	// entries are the cached contents from the most to the least recently used
+	entries    *list.List
+	references map[string]*list.Element
+	size       int
+	lock       sync.Mutex
+}
+
+// get returns the cached content of the input reference and marks it as the most recently used.
+func (c *ociCache) get(reference string) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.references[reference]
+	if !ok {
+		return nil, false
+	}
+
+	c.entries.MoveToFront(element)
+
+	//nolint:forcetypeassert
+	return element.Value.(*ociCacheEntry).content, true
+}
+
+// add caches the content of the input reference and evicts the least recently used contents beyond the input
+// maximum size. A content larger than the maximum size isn't cached.
+func (c *ociCache) add(reference string, content []byte, maxSize int) {
+	if len(content) > maxSize {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.entries == nil {
+		c.entries = list.New()
+		c.references = map[string]*list.Element{}
+	}
+
+	if _, ok := c.references[reference]; ok {
+		return
+	}
+
+	c.references[reference] = c.entries.PushFront(&ociCacheEntry{reference: reference, content: content})
+	c.size += len(content)
+
+	for c.size > maxSize {
+		oldest := c.entries.Back()
+		//nolint:forcetypeassert
+		entry := c.entries.Remove(oldest).(*ociCacheEntry)
+
+		delete(c.references, entry.reference)
+		c.size -= len(entry.content)
+	}
+}
+
+// NewOCIFetcher returns an OCIFetcher which verifies cosign signatures with the PEM encoded ECDSA public key at the
+// input path. If the path is empty, signatures are not verified.
+func NewOCIFetcher(publicKeyPath string) (*OCIFetcher, error) {
+	fetcher := &OCIFetcher{Client: &http.Client{Timeout: ociRequestTimeout}}
+
+	if publicKeyPath == "" {
+		return fetcher, nil
+	}
+
+	ecdsaKey, err := readECDSAPublicKey(publicKeyPath, "OCI signature public key")
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher.PublicKey = ecdsaKey
+
+	return fetcher, nil
+}
+
+// readECDSAPublicKey returns the PEM encoded ECDSA public key at the input path. The description of the key is used
+// in the error messages.
+func readECDSAPublicKey(path string, description string) (*ecdsa.PublicKey, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s: %w", description, err)
+	}
+
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return nil, fmt.Errorf("the %s at %s is not PEM encoded", description, path)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s: %w", description, err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the %s at %s is not an ECDSA key", description, path)
+	}
+
+	return ecdsaKey, nil
+}
+
+// Fetch returns the content of the first layer of the OCI artifact at the input digest reference after verifying
+// the digests and, if configured, the cosign signature.
+func (f *OCIFetcher) Fetch(ctx context.Context, reference string) ([]byte, error) {
+	ref, err := parseOCIReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	content, cached := f.cache.get(reference)
+	if cached {
+		return content, nil
+	}
+
+	manifestBytes, err := f.get(ctx, ref, "manifests/"+ref.digest, ociManifestMediaTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyDigest(manifestBytes, ref.digest); err != nil {
+		return nil, err
+	}
+
+	manifest := ociManifest{}
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse the OCI artifact manifest: %w", err)
+	}
+
+	if len(manifest.Layers) == 0 {
+		return nil, ErrOCIArtifactEmpty
+	}
+
+	content, err = f.getBlob(ctx, ref, manifest.Layers[0].Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.PublicKey != nil {
+		if err := f.verifySignature(ctx, ref); err != nil {
+			return nil, err
+		}
+	}
+
+	cacheSize := f.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = DefaultOCICacheSize
+	}
+
+	f.cache.add(reference, content, cacheSize)
+
+	return content, nil
+}
+
+// verifySignature looks up the cosign signature manifest stored at the sha256-<digest>.sig tag and verifies that
+// at least one of its signatures is valid for the public key and that the signed payload references the digest.
+func (f *OCIFetcher) verifySignature(ctx context.Context, ref ociReference) error {
+	sigTag := strings.Replace(ref.digest, ":", "-", 1) + ".sig"
+
+	manifestBytes, err := f.get(ctx, ref, "manifests/"+sigTag, ociManifestMediaTypes)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOCISignatureNotFound, err)
+	}
+
+	manifest := ociManifest{}
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return fmt.Errorf("failed to parse the OCI signature manifest: %w", err)
+	}
+
+	for _, layer := range manifest.Layers {
+		encodedSig := layer.Annotations[cosignSignatureAnnotation]
+		if encodedSig == "" {
+			continue
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(encodedSig)
+		if err != nil {
+			continue
+		}
+
+		payload, err := f.getBlob(ctx, ref, layer.Digest)
+		if err != nil {
+			return err
+		}
+
+		payloadHash := sha256.Sum256(payload)
+		if !ecdsa.VerifyASN1(f.PublicKey, payloadHash[:], sig) {
+			continue
+		}
+
+		simpleSigning := struct {
+			Critical struct {
+				Image struct {
+					DockerManifestDigest string `json:"docker-manifest-digest"`
+				} `json:"image"`
+			} `json:"critical"`
+		}{}
+
+		if err := json.Unmarshal(payload, &simpleSigning); err != nil {
+			continue
+		}
+
+		if simpleSigning.Critical.Image.DockerManifestDigest == ref.digest {
+			return nil
+		}
+	}
+
+	return ErrOCISignatureNotFound
+}
+
+func (f *OCIFetcher) getBlob(ctx context.Context, ref ociReference, digest string) ([]byte, error) {
+	blob, err := f.get(ctx, ref, "blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyDigest(blob, digest); err != nil {
+		return nil, err
+	}
+
+	return blob, nil
+}
+
+// get performs a GET request against the OCI distribution API of the registry. If the registry responds with a
+// bearer token challenge, an anonymous token is requested and the request is retried.
+func (f *OCIFetcher) get(ctx context.Context, ref ociReference, path string, accept string) ([]byte, error) {
+	endpoint := fmt.Sprintf("https://%s/v2/%s/%s", ref.registry, ref.repository, path)
+
+	resp, err := f.doRequest(ctx, endpoint, accept, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		token, err := f.getToken(ctx, challenge)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err = f.doRequest(ctx, endpoint, accept, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: the registry responded with %s", endpoint, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOCIArtifactSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", endpoint, err)
+	}
+
+	if len(body) > maxOCIArtifactSize {
+		return nil, fmt.Errorf("the content at %s exceeds the maximum size of %d bytes", endpoint, maxOCIArtifactSize)
+	}
+
+	return body, nil
+}
+
+func (f *OCIFetcher) doRequest(ctx context.Context, endpoint, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return f.Client.Do(req)
+}
+
+// getToken requests an anonymous bearer token based on the input WWW-Authenticate challenge.
+func (f *OCIFetcher) getToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("the registry requires an unsupported authentication method: %s", challenge)
+	}
+
+	params := map[string]string{}
+
+	for _, param := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		keyValue := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(keyValue) == 2 {
+			params[keyValue[0]] = strings.Trim(keyValue[1], `"`)
+		}
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("the registry returned an invalid authentication realm: %s", params["realm"])
+	}
+
+	query := realm.Query()
+
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+
+	realm.RawQuery = query.Encode()
+
+	resp, err := f.doRequest(ctx, realm.String(), "", "")
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get a registry token: the server responded with %s", resp.Status)
+	}
+
+	tokenResp := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOCIArtifactSize)).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to parse the registry token response: %w", err)
+	}
+
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+func parseOCIReference(reference string) (ociReference, error) {
+	name, digest, found := strings.Cut(reference, "@")
+	if !found || !strings.HasPrefix(digest, "sha256:") || len(digest) != len("sha256:")+sha256.Size*2 {
+		return ociReference{}, ErrInvalidOCIReference
+	}
+
+	registry, repository, found := strings.Cut(name, "/")
+	if !found || registry == "" || repository == "" {
+		return ociReference{}, ErrInvalidOCIReference
+	}
+
+	return ociReference{registry: registry, repository: repository, digest: digest}, nil
+}
+
+func verifyDigest(content []byte, digest string) error {
+	hash := sha256.Sum256(content)
+
+	if "sha256:"+hex.EncodeToString(hash[:]) != digest {
+		return fmt.Errorf("%w: expected %s", ErrOCIDigestMismatch, digest)
+	}
+
+	return nil
+}
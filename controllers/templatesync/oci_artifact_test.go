@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testTemplate = `{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+	`"metadata":{"name":"oci-template"}}`
+
+func sha256Digest(content []byte) string {
+	hash := sha256.Sum256(content)
+
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// newTestRegistry returns a registry serving a single artifact containing testTemplate and, if a private key is
+// provided, a cosign signature for it.
+func newTestRegistry(privateKey *ecdsa.PrivateKey) (*httptest.Server, string) {
+	blobs := map[string][]byte{}
+	manifests := map[string][]byte{}
+
+	layer := []byte(testTemplate)
+	blobs[sha256Digest(layer)] = layer
+
+	manifest, _ := json.Marshal(ociManifest{Layers: []ociDescriptor{{Digest: sha256Digest(layer)}}})
+	manifestDigest := sha256Digest(manifest)
+	manifests[manifestDigest] = manifest
+
+	if privateKey != nil {
+		payload := []byte(`{"critical":{"image":{"docker-manifest-digest":"` + manifestDigest + `"}}}`)
+		payloadHash := sha256.Sum256(payload)
+		sig, _ := ecdsa.SignASN1(rand.Reader, privateKey, payloadHash[:])
+		blobs[sha256Digest(payload)] = payload
+
+		sigManifest, _ := json.Marshal(ociManifest{Layers: []ociDescriptor{{
+			Digest:      sha256Digest(payload),
+			Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+		}}})
+		manifests[strings.Replace(manifestDigest, ":", "-", 1)+".sig"] = sigManifest
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var content []byte
+
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/policies/template/manifests/"):
+			content = manifests[strings.TrimPrefix(r.URL.Path, "/v2/policies/template/manifests/")]
+		case strings.HasPrefix(r.URL.Path, "/v2/policies/template/blobs/"):
+			content = blobs[strings.TrimPrefix(r.URL.Path, "/v2/policies/template/blobs/")]
+		}
+
+		if content == nil {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write(content)
+	}))
+
+	return server, manifestDigest
+}
+
+func TestOCIFetcherFetch(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, digest := newTestRegistry(nil)
+	defer server.Close()
+
+	fetcher := &OCIFetcher{Client: server.Client()}
+	ref := strings.TrimPrefix(server.URL, "https://") + "/policies/template@" + digest
+
+	content, err := fetcher.Fetch(context.TODO(), ref)
+	Expect(err).To(BeNil())
+	Expect(string(content)).To(Equal(testTemplate))
+
+	// Verify that the content is served from the cache once the registry is no longer reachable
+	server.Close()
+
+	content, err = fetcher.Fetch(context.TODO(), ref)
+	Expect(err).To(BeNil())
+	Expect(string(content)).To(Equal(testTemplate))
+}
+
+func TestOCICacheEviction(t *testing.T) {
+	RegisterTestingT(t)
+
+	cache := ociCache{}
+
+	cache.add("a", []byte("aaaa"), 8)
+	cache.add("b", []byte("bbbb"), 8)
+
+	// Use a so that b is the least recently used
+	_, cached := cache.get("a")
+	Expect(cached).To(BeTrue())
+
+	cache.add("c", []byte("cccc"), 8)
+
+	_, cached = cache.get("b")
+	Expect(cached).To(BeFalse())
+
+	content, cached := cache.get("a")
+	Expect(cached).To(BeTrue())
+	Expect(string(content)).To(Equal("aaaa"))
+
+	_, cached = cache.get("c")
+	Expect(cached).To(BeTrue())
+	Expect(cache.size).To(Equal(8))
+
+	// A content larger than the maximum size is not cached and does not evict anything
+	cache.add("d", []byte("ddddddddd"), 8)
+
+	_, cached = cache.get("d")
+	Expect(cached).To(BeFalse())
+	Expect(cache.entries.Len()).To(Equal(2))
+}
+
+func TestOCIFetcherDigestMismatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, _ := newTestRegistry(nil)
+	defer server.Close()
+
+	fetcher := &OCIFetcher{Client: server.Client()}
+	ref := strings.TrimPrefix(server.URL, "https://") + "/policies/template@" + sha256Digest([]byte("other"))
+
+	_, err := fetcher.Fetch(context.TODO(), ref)
+	Expect(err).ToNot(BeNil())
+}
+
+func TestOCIFetcherSignature(t *testing.T) {
+	RegisterTestingT(t)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	server, digest := newTestRegistry(privateKey)
+	defer server.Close()
+
+	ref := strings.TrimPrefix(server.URL, "https://") + "/policies/template@" + digest
+
+	fetcher := &OCIFetcher{Client: server.Client(), PublicKey: &privateKey.PublicKey}
+	_, err = fetcher.Fetch(context.TODO(), ref)
+	Expect(err).To(BeNil())
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	fetcher = &OCIFetcher{Client: server.Client(), PublicKey: &otherKey.PublicKey}
+	_, err = fetcher.Fetch(context.TODO(), ref)
+	Expect(errors.Is(err, ErrOCISignatureNotFound)).To(BeTrue())
+}
+
+func TestParseOCIReference(t *testing.T) {
+	RegisterTestingT(t)
+
+	digest := sha256Digest([]byte("content"))
+
+	ref, err := parseOCIReference("quay.io/org/repo@" + digest)
+	Expect(err).To(BeNil())
+	Expect(ref).To(Equal(ociReference{registry: "quay.io", repository: "org/repo", digest: digest}))
+
+	for _, invalid := range []string{"quay.io/org/repo:latest", "quay.io@" + digest, "quay.io/org/repo@sha256:abc"} {
+		_, err := parseOCIReference(invalid)
+		Expect(errors.Is(err, ErrInvalidOCIReference)).To(BeTrue())
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// CopyPolicyMetadataAnnotation can be set to "true" or "false" on a policy to override whether the standards,
+// categories, and controls annotations of the policy are copied onto the objects of its policy templates. It stands in
+// for the spec.copyPolicyMetadata field, which the Policy API used by the addon doesn't have yet.
+const CopyPolicyMetadataAnnotation = "policy.open-cluster-management.io/copy-policy-metadata"
+
+// copiedPolicyAnnotations are the annotations of a policy which are copied onto the objects of its policy templates.
+var copiedPolicyAnnotations = []string{
+	"policy.open-cluster-management.io/standards",
+	"policy.open-cluster-management.io/categories",
+	"policy.open-cluster-management.io/controls",
+}
+
+// copyPolicyMetadata returns true if the metadata of the input policy should be copied onto the objects of its policy
+// templates. The CopyPolicyMetadataAnnotation on the policy takes precedence over the CopyPolicyMetadata default.
+func (r *PolicyReconciler) copyPolicyMetadata(instance *policiesv1.Policy) bool {
+	if value, ok := instance.GetAnnotations()[CopyPolicyMetadataAnnotation]; ok {
+		copyMetadata, err := strconv.ParseBool(value)
+		if err == nil {
+			return copyMetadata
+		}
+
+		log.Info("Ignoring the invalid annotation value on the policy", "annotation", CopyPolicyMetadataAnnotation,
+			"value", value, "namespace", instance.GetNamespace(), "name", instance.GetName())
+	}
+
+	return r.CopyPolicyMetadata
+}
+
+// setPolicyMetadata copies the standards, categories, and controls annotations of the input policy onto the policy
+// template object. The annotations already set in the policy template are kept.
+func setPolicyMetadata(instance *policiesv1.Policy, tObjectUnstructured *unstructured.Unstructured) {
+	annotations := tObjectUnstructured.GetAnnotations()
+
+	for _, key := range copiedPolicyAnnotations {
+		value, ok := instance.GetAnnotations()[key]
+		if !ok {
+			continue
+		}
+
+		if _, set := annotations[key]; set {
+			continue
+		}
+
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[key] = value
+	}
+
+	tObjectUnstructured.SetAnnotations(annotations)
+}
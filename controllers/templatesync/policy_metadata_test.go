@@ -0,0 +1,64 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestCopyPolicyMetadata(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"}}
+
+	Expect((&PolicyReconciler{}).copyPolicyMetadata(policy)).To(BeFalse())
+	Expect((&PolicyReconciler{CopyPolicyMetadata: true}).copyPolicyMetadata(policy)).To(BeTrue())
+
+	policy.SetAnnotations(map[string]string{CopyPolicyMetadataAnnotation: "false"})
+	Expect((&PolicyReconciler{CopyPolicyMetadata: true}).copyPolicyMetadata(policy)).To(BeFalse())
+
+	policy.SetAnnotations(map[string]string{CopyPolicyMetadataAnnotation: "true"})
+	Expect((&PolicyReconciler{}).copyPolicyMetadata(policy)).To(BeTrue())
+
+	// An invalid value falls back to the default
+	policy.SetAnnotations(map[string]string{CopyPolicyMetadataAnnotation: "maybe"})
+	Expect((&PolicyReconciler{CopyPolicyMetadata: true}).copyPolicyMetadata(policy)).To(BeTrue())
+}
+
+func TestSetPolicyMetadata(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "policy",
+			Namespace: "managed",
+			Annotations: map[string]string{
+				"policy.open-cluster-management.io/standards":  "NIST SP 800-53",
+				"policy.open-cluster-management.io/categories": "CM Configuration Management",
+				"policy.open-cluster-management.io/controls":   "CM-2 Baseline Configuration",
+				"other": "annotation",
+			},
+		},
+	}
+
+	template := &unstructured.Unstructured{}
+	template.SetAnnotations(map[string]string{"policy.open-cluster-management.io/controls": "CM-6"})
+
+	setPolicyMetadata(policy, template)
+
+	Expect(template.GetAnnotations()).To(Equal(map[string]string{
+		"policy.open-cluster-management.io/standards":  "NIST SP 800-53",
+		"policy.open-cluster-management.io/categories": "CM Configuration Management",
+		"policy.open-cluster-management.io/controls":   "CM-6",
+	}))
+
+	// A policy without the annotations leaves the template unchanged
+	template = &unstructured.Unstructured{Object: map[string]interface{}{}}
+	setPolicyMetadata(&policiesv1.Policy{}, template)
+	Expect(template.GetAnnotations()).To(BeNil())
+}
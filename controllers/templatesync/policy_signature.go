@@ -0,0 +1,82 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// PolicySignatureAnnotation is set on a policy to the base64 encoded signature of its policy templates, as produced by
+// `cosign sign-blob` for the compact JSON of the spec.policy-templates field of the policy.
+const PolicySignatureAnnotation = "policy.open-cluster-management.io/signature"
+
+var (
+	ErrPolicySignatureMissing = errors.New("the policy does not have the " + PolicySignatureAnnotation + " annotation")
+	ErrPolicySignatureInvalid = errors.New("the policy signature is not valid for the policy templates")
+)
+
+// PolicyVerifier verifies a policy before its enforce mode policy templates are applied on the managed cluster.
+type PolicyVerifier interface {
+	Verify(policy *policiesv1.Policy) error
+}
+
+// PolicySignatureVerifier is a PolicyVerifier which requires the PolicySignatureAnnotation of the policy to be a valid
+// signature of its policy templates for the public key.
+type PolicySignatureVerifier struct {
+	PublicKey *ecdsa.PublicKey
+}
+
+// NewPolicySignatureVerifier returns a PolicySignatureVerifier for the PEM encoded ECDSA public key at the input path.
+func NewPolicySignatureVerifier(publicKeyPath string) (*PolicySignatureVerifier, error) {
+	publicKey, err := readECDSAPublicKey(publicKeyPath, "policy signature public key")
+	if err != nil {
+		return nil, err
+	}
+
+	return &PolicySignatureVerifier{PublicKey: publicKey}, nil
+}
+
+// Verify returns an error if the policy doesn't have a valid signature of its policy templates.
+func (v *PolicySignatureVerifier) Verify(policy *policiesv1.Policy) error {
+	encodedSig := policy.GetAnnotations()[PolicySignatureAnnotation]
+	if encodedSig == "" {
+		return ErrPolicySignatureMissing
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPolicySignatureInvalid, err)
+	}
+
+	payload, err := json.Marshal(policy.Spec.PolicyTemplates)
+	if err != nil {
+		return fmt.Errorf("failed to encode the policy templates: %w", err)
+	}
+
+	payloadHash := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(v.PublicKey, payloadHash[:], sig) {
+		return ErrPolicySignatureInvalid
+	}
+
+	return nil
+}
+
+// enforcesTemplate returns true if the input policy template object is in enforce mode once the remediationAction of
+// the policy is applied.
+func enforcesTemplate(instance *policiesv1.Policy, tObjectUnstructured *unstructured.Unstructured) bool {
+	tObject := tObjectUnstructured.DeepCopy()
+	overrideRemediationAction(instance, tObject)
+
+	remediationAction, _, _ := unstructured.NestedString(tObject.Object, "spec", "remediationAction")
+
+	return strings.EqualFold(remediationAction, string(policiesv1.Enforce))
+}
@@ -0,0 +1,104 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func signedTestPolicy(t *testing.T, privateKey *ecdsa.PrivateKey) *policiesv1.Policy {
+	t.Helper()
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"},
+		Spec: policiesv1.PolicySpec{
+			PolicyTemplates: []*policiesv1.PolicyTemplate{
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(testTemplate)}},
+			},
+		},
+	}
+
+	payload, err := json.Marshal(policy.Spec.PolicyTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payloadHash := sha256.Sum256(payload)
+
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, payloadHash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy.SetAnnotations(map[string]string{PolicySignatureAnnotation: base64.StdEncoding.EncodeToString(sig)})
+
+	return policy
+}
+
+func TestPolicySignatureVerifier(t *testing.T) {
+	RegisterTestingT(t)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	verifier := &PolicySignatureVerifier{PublicKey: &privateKey.PublicKey}
+
+	policy := signedTestPolicy(t, privateKey)
+	Expect(verifier.Verify(policy)).To(Succeed())
+
+	// Changing the policy templates invalidates the signature
+	tampered := policy.DeepCopy()
+	tampered.Spec.PolicyTemplates[0].ObjectDefinition.Raw = []byte(
+		`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+			`"metadata":{"name":"other-template"}}`,
+	)
+	Expect(errors.Is(verifier.Verify(tampered), ErrPolicySignatureInvalid)).To(BeTrue())
+
+	// A signature from another key is rejected
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(errors.Is(verifier.Verify(signedTestPolicy(t, otherKey)), ErrPolicySignatureInvalid)).To(BeTrue())
+
+	// A signature which isn't base64 encoded is rejected
+	invalid := policy.DeepCopy()
+	invalid.SetAnnotations(map[string]string{PolicySignatureAnnotation: "not base64!"})
+	Expect(errors.Is(verifier.Verify(invalid), ErrPolicySignatureInvalid)).To(BeTrue())
+
+	unsigned := policy.DeepCopy()
+	unsigned.SetAnnotations(nil)
+	Expect(errors.Is(verifier.Verify(unsigned), ErrPolicySignatureMissing)).To(BeTrue())
+}
+
+func TestEnforcesTemplate(t *testing.T) {
+	RegisterTestingT(t)
+
+	template := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "ConfigurationPolicy",
+		"spec":       map[string]interface{}{"remediationAction": "Enforce"},
+	}}
+
+	policy := &policiesv1.Policy{}
+	Expect(enforcesTemplate(policy, template)).To(BeTrue())
+
+	// The remediationAction of the policy overrides the one of the policy template
+	policy.Spec.RemediationAction = policiesv1.Inform
+	Expect(enforcesTemplate(policy, template)).To(BeFalse())
+
+	// The policy template is not modified
+	remediationAction, _, _ := unstructured.NestedString(template.Object, "spec", "remediationAction")
+	Expect(remediationAction).To(Equal("Enforce"))
+}
@@ -0,0 +1,245 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// templateVerbs are the verbs the addon needs on the resources of policy templates in use.
+var templateVerbs = []string{"create", "delete", "get", "list", "patch", "update", "watch"}
+
+// addonRules are the rules the addon needs regardless of which policy templates are in use. Keep these in sync with
+// the kubebuilder RBAC markers on the controllers.
+var addonRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"create", "delete", "get", "list", "update", "watch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"events"},
+		Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"namespaces"},
+		Verbs:     []string{"create", "get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+		Verbs:     []string{"get", "list"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"secrets"},
+		Verbs:     []string{"create"},
+	},
+	{
+		APIGroups:     []string{""},
+		Resources:     []string{"secrets"},
+		ResourceNames: []string{"policy-encryption-key"},
+		Verbs:         []string{"delete", "get", "list", "update"},
+	},
+	{
+		APIGroups: []string{"cluster.open-cluster-management.io"},
+		Resources: []string{"clusterclaims"},
+		Verbs:     []string{"get", "list"},
+	},
+	{
+		APIGroups: []string{policiesv1.GroupVersion.Group},
+		Resources: []string{"compliancesummaries"},
+		Verbs:     []string{"create", "get", "list", "update", "watch"},
+	},
+	{
+		APIGroups: []string{policiesv1.GroupVersion.Group},
+		Resources: []string{"compliancesummaries/status"},
+		Verbs:     []string{"get", "patch", "update"},
+	},
+	{
+		APIGroups: []string{policiesv1.GroupVersion.Group},
+		Resources: []string{"policies"},
+		Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
+	},
+	{
+		APIGroups: []string{policiesv1.GroupVersion.Group},
+		Resources: []string{"policies/finalizers"},
+		Verbs:     []string{"update"},
+	},
+	{
+		APIGroups: []string{policiesv1.GroupVersion.Group},
+		Resources: []string{"policies/status"},
+		Verbs:     []string{"get", "patch", "update"},
+	},
+}
+
+// TemplateResources returns the sorted and deduplicated resources of the policy templates in the input policies.
+// Policy templates that can't be decoded or mapped are returned as errors and otherwise ignored.
+func TemplateResources(
+	policies []policiesv1.Policy, mapper meta.RESTMapper,
+) ([]schema.GroupVersionResource, []error) {
+	resourceSet := map[schema.GroupVersionResource]bool{}
+	errs := []error{}
+
+	for i := range policies {
+		for tIndex, policyT := range policies[i].Spec.PolicyTemplates {
+			_, gvk, err := unstructured.UnstructuredJSONScheme.Decode(policyT.ObjectDefinition.Raw, nil, nil)
+			if err != nil {
+				errs = append(errs, fmt.Errorf(
+					"failed to decode the policy template at index %d in policy %s: %w", tIndex, policies[i].Name, err,
+				))
+
+				continue
+			}
+
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				errs = append(errs, fmt.Errorf(
+					"failed to map the kind %s in policy %s: %w", gvk.Kind, policies[i].Name, err,
+				))
+
+				continue
+			}
+
+			resourceSet[mapping.Resource] = true
+		}
+	}
+
+	resources := make([]schema.GroupVersionResource, 0, len(resourceSet))
+	for resource := range resourceSet {
+		resources = append(resources, resource)
+	}
+
+	sortResources(resources)
+
+	return resources, errs
+}
+
+// MinimalClusterRole returns a ClusterRole with the rules the addon needs to manage only the input policy template
+// resources, as an alternative to the wildcard access on the policy.open-cluster-management.io group.
+func MinimalClusterRole(name string, resources []schema.GroupVersionResource) *rbacv1.ClusterRole {
+	rules := make([]rbacv1.PolicyRule, 0, len(addonRules)+len(resources)*2)
+	rules = append(rules, addonRules...)
+
+	// Group the resources by API group to keep the ClusterRole compact
+	resourcesByGroup := map[string][]string{}
+	groups := []string{}
+
+	for _, resource := range resources {
+		if _, ok := resourcesByGroup[resource.Group]; !ok {
+			groups = append(groups, resource.Group)
+		}
+
+		resourcesByGroup[resource.Group] = append(resourcesByGroup[resource.Group], resource.Resource)
+	}
+
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		groupResources := resourcesByGroup[group]
+		statusResources := make([]string, 0, len(groupResources))
+
+		for _, resource := range groupResources {
+			statusResources = append(statusResources, resource+"/status")
+		}
+
+		rules = append(rules,
+			rbacv1.PolicyRule{APIGroups: []string{group}, Resources: groupResources, Verbs: templateVerbs},
+			rbacv1.PolicyRule{APIGroups: []string{group}, Resources: statusResources, Verbs: []string{"patch"}},
+		)
+	}
+
+	return &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Rules:      rules,
+	}
+}
+
+// MinimalClusterRoleYAML returns the YAML of MinimalClusterRole.
+func MinimalClusterRoleYAML(name string, resources []schema.GroupVersionResource) (string, error) {
+	clusterRoleYAML, err := yaml.Marshal(MinimalClusterRole(name, resources))
+	if err != nil {
+		return "", err
+	}
+
+	return string(clusterRoleYAML), nil
+}
+
+func sortResources(resources []schema.GroupVersionResource) {
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].String() < resources[j].String()
+	})
+}
+
+// rbacReport keeps track of the policy template resources in use by each policy so that a change in the minimal RBAC
+// needed by the addon can be reported at runtime.
+type rbacReport struct {
+	resourcesByPolicy map[string]map[schema.GroupVersionResource]bool
+	lastReport        string
+	lock              sync.Mutex
+}
+
+// update records the resources used by the input policy. Passing nil resources removes the policy. If the resulting
+// minimal ClusterRole differs from the last reported one, it is logged.
+func (r *rbacReport) update(policy string, resources map[schema.GroupVersionResource]bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.resourcesByPolicy == nil {
+		r.resourcesByPolicy = map[string]map[schema.GroupVersionResource]bool{}
+	}
+
+	if resources == nil {
+		delete(r.resourcesByPolicy, policy)
+	} else {
+		r.resourcesByPolicy[policy] = resources
+	}
+
+	resourceSet := map[schema.GroupVersionResource]bool{}
+
+	for _, policyResources := range r.resourcesByPolicy {
+		for resource := range policyResources {
+			resourceSet[resource] = true
+		}
+	}
+
+	allResources := make([]schema.GroupVersionResource, 0, len(resourceSet))
+	for resource := range resourceSet {
+		allResources = append(allResources, resource)
+	}
+
+	sortResources(allResources)
+
+	clusterRoleYAML, err := MinimalClusterRoleYAML("governance-policy-framework-addon", allResources)
+	if err != nil {
+		log.Error(err, "Failed to generate the minimal ClusterRole for the policy templates in use")
+
+		return
+	}
+
+	if clusterRoleYAML == r.lastReport {
+		return
+	}
+
+	r.lastReport = clusterRoleYAML
+
+	log.Info(
+		"The minimal RBAC for the policy templates in use changed",
+		"resources", fmt.Sprint(allResources),
+		"clusterRole", strings.TrimSpace(clusterRoleYAML),
+	)
+}
@@ -0,0 +1,54 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// RecreateOnChangeAnnotation can be set to "true" on a policy template to have its object deleted and recreated
+// instead of updated whenever its spec changes, for policy engines whose controllers only evaluate objects when they
+// are created.
+const RecreateOnChangeAnnotation = "policy.open-cluster-management.io/recreate-on-change"
+
+// The reasons of the policy template object recreations in the metrics
+const (
+	recreateReasonSpecChange      = "spec-change"
+	recreateReasonImmutableChange = "immutable-change"
+)
+
+var templateRecreationCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "policy_template_recreations_total",
+		Help: "The number of times a policy template object was deleted and recreated instead of updated, either " +
+			"because its spec changed (spec-change) or an immutable field changed (immutable-change).",
+	},
+	[]string{"kind", "reason"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(templateRecreationCounter)
+}
+
+// recreateOnChange returns true if the existing policy template object must be deleted and recreated instead of
+// updated to match the input policy template object, which is when their specs differ and recreating is enabled for
+// the kind of the policy template or through the annotation. Like in templateObjectMatches, the spec is unchanged when
+// the existing spec only has additional fields, such as defaults set by a mutating webhook or the CRD.
+func (r *PolicyReconciler) recreateOnChange(existing, tObject *unstructured.Unstructured) bool {
+	if isSubset(tObject.Object["spec"], existing.Object["spec"]) {
+		return false
+	}
+
+	for _, kind := range r.RecreateOnChangeKinds {
+		if kind == tObject.GetKind() {
+			return true
+		}
+	}
+
+	return strings.EqualFold(tObject.GetAnnotations()[RecreateOnChangeAnnotation], "true")
+}
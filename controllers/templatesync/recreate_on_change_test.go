@@ -0,0 +1,40 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRecreateOnChange(t *testing.T) {
+	RegisterTestingT(t)
+
+	existing := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "K8sRequiredLabels",
+		"spec": map[string]interface{}{"parameters": map[string]interface{}{"labels": []interface{}{"owner"}}},
+	}}
+	tObject := existing.DeepCopy()
+
+	r := &PolicyReconciler{RecreateOnChangeKinds: []string{"K8sRequiredLabels"}}
+
+	// An annotation change alone doesn't recreate the object
+	tObject.SetAnnotations(map[string]string{"example.com/owner": "team-a"})
+	Expect(r.recreateOnChange(existing, tObject)).To(BeFalse())
+
+	// Neither does an annotation change of an object with a field defaulted by the API server
+	existing.Object["spec"].(map[string]interface{})["enforcementAction"] = "deny"
+	tObject.SetAnnotations(map[string]string{"example.com/owner": "team-b"})
+	Expect(r.recreateOnChange(existing, tObject)).To(BeFalse())
+
+	tObject.Object["spec"] = map[string]interface{}{"parameters": map[string]interface{}{"labels": []interface{}{}}}
+	Expect(r.recreateOnChange(existing, tObject)).To(BeTrue())
+
+	r.RecreateOnChangeKinds = nil
+	Expect(r.recreateOnChange(existing, tObject)).To(BeFalse())
+
+	tObject.SetAnnotations(map[string]string{RecreateOnChangeAnnotation: "true"})
+	Expect(r.recreateOnChange(existing, tObject)).To(BeTrue())
+}
@@ -0,0 +1,46 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+// fieldValidation returns the field validation of the create and update requests of the policy template objects. In
+// strict mode, the API server rejects the objects with fields unknown to the schema of their kind rather than silently
+// dropping them, such as a misspelled remediationAction.
+func (r *PolicyReconciler) fieldValidation() string {
+	if r.StrictTemplateValidation {
+		return metav1.FieldValidationStrict
+	}
+
+	return ""
+}
+
+// isUnknownFieldsError returns true if the input error of a create or update request is the API server rejecting
+// unknown or duplicate fields of the object with the strict field validation.
+func isUnknownFieldsError(err error) bool {
+	if !k8serrors.IsBadRequest(err) && !k8serrors.IsInvalid(err) {
+		return false
+	}
+
+	message := err.Error()
+
+	return strings.Contains(message, "strict decoding error") || strings.Contains(message, "unknown field")
+}
+
+// applyError returns the template error for the input error of a request applying a policy template object, of the
+// input kind unless the request was rejected for unknown fields in strict mode. Since only a change to the policy
+// template fixes the unknown fields, that error is permanent.
+func (r *PolicyReconciler) applyError(kind error, message string, err error) error {
+	if r.StrictTemplateValidation && isUnknownFieldsError(err) {
+		return syncerrors.WithCause(syncerrors.ErrTemplateUnknownFields, message, err)
+	}
+
+	return syncerrors.WithCause(kind, message, err)
+}
@@ -0,0 +1,49 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+func TestStrictTemplateValidation(t *testing.T) {
+	RegisterTestingT(t)
+
+	unknownErr := k8serrors.NewBadRequest(`ConfigurationPolicy in version "v1" cannot be handled as a ` +
+		`ConfigurationPolicy: strict decoding error: unknown field "spec.remediationactoin"`)
+
+	Expect(isUnknownFieldsError(unknownErr)).To(BeTrue())
+	Expect(isUnknownFieldsError(k8serrors.NewBadRequest("the object is invalid"))).To(BeFalse())
+	Expect(isUnknownFieldsError(errors.New(`unknown field "spec.remediationactoin"`))).To(BeFalse())
+
+	r := &PolicyReconciler{}
+	Expect(r.fieldValidation()).To(BeEmpty())
+
+	// Without strict mode, the error keeps the kind of the request
+	err := r.applyError(syncerrors.ErrTemplateCreate, "Failed to create policy template", unknownErr)
+	Expect(syncerrors.Reason(err)).To(Equal("CreateError"))
+	Expect(syncerrors.Permanent(err)).To(BeFalse())
+
+	r.StrictTemplateValidation = true
+	Expect(r.fieldValidation()).To(Equal(metav1.FieldValidationStrict))
+
+	err = r.applyError(syncerrors.ErrTemplateUpdate, "Failed to update policy template", unknownErr)
+	Expect(err).To(MatchError("Failed to update policy template"))
+	Expect(syncerrors.Reason(err)).To(Equal("UnknownFields"))
+	Expect(syncerrors.Permanent(err)).To(BeTrue())
+	Expect(k8serrors.IsBadRequest(err)).To(BeTrue())
+
+	// The other errors are not affected by strict mode
+	conflictErr := k8serrors.NewConflict(schema.GroupResource{Resource: "configurationpolicies"}, "policy", nil)
+
+	err = r.applyError(syncerrors.ErrTemplateUpdate, "Failed to update policy template", conflictErr)
+	Expect(syncerrors.Reason(err)).To(Equal("UpdateError"))
+}
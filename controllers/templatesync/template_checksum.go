@@ -0,0 +1,43 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TemplateChecksumLabel is set on the policy template objects to the checksum of the rendered spec of their policy
+// template, so that GitOps tools and auditors can verify that the objects on the managed cluster match the policy
+// templates rendered on the Hub.
+const TemplateChecksumLabel = "policy.open-cluster-management.io/template-checksum"
+
+// templateChecksum returns the first 128 bits of the SHA-256 checksum of the JSON of the spec of the input policy
+// template object, in hexadecimal so that it fits in a label value.
+func templateChecksum(tObject *unstructured.Unstructured) string {
+	// A map is marshaled with sorted keys, so the checksum is stable
+	content, err := json.Marshal(tObject.Object["spec"])
+	if err != nil {
+		log.Error(err, "Failed to compute the checksum of the policy template", "name", tObject.GetName())
+
+		return ""
+	}
+
+	checksum := sha256.Sum256(content)
+
+	return hex.EncodeToString(checksum[:16])
+}
+
+// setTemplateChecksum sets the TemplateChecksumLabel label of the input object to the input checksum.
+func setTemplateChecksum(obj *unstructured.Unstructured, checksum string) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels[TemplateChecksumLabel] = checksum
+	obj.SetLabels(labels)
+}
@@ -0,0 +1,39 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTemplateChecksum(t *testing.T) {
+	RegisterTestingT(t)
+
+	tObject := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "ConfigurationPolicy",
+		"metadata":   map[string]interface{}{"name": "case1", "labels": map[string]interface{}{"app": "test"}},
+		"spec":       map[string]interface{}{"severity": "low", "remediationAction": "inform"},
+	}}
+
+	// sha256 of {"remediationAction":"inform","severity":"low"}, truncated to 128 bits
+	checksum := templateChecksum(tObject)
+	Expect(checksum).To(Equal("092089be71085e149802566466fdc609"))
+
+	setTemplateChecksum(tObject, checksum)
+	Expect(tObject.GetLabels()).To(Equal(map[string]string{"app": "test", TemplateChecksumLabel: checksum}))
+
+	// The labels and annotations don't change the checksum, unlike the spec
+	tObject.SetAnnotations(map[string]string{"note": "value"})
+	Expect(templateChecksum(tObject)).To(Equal(checksum))
+
+	tObject.Object["spec"].(map[string]interface{})["remediationAction"] = "enforce"
+	Expect(templateChecksum(tObject)).ToNot(Equal(checksum))
+
+	unlabeled := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	setTemplateChecksum(unlabeled, checksum)
+	Expect(unlabeled.GetLabels()).To(Equal(map[string]string{TemplateChecksumLabel: checksum}))
+}
@@ -0,0 +1,141 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// PruneObjectBehaviorAnnotation can be set on a policy to the pruneObjectBehavior of its ConfigurationPolicy templates
+// which don't set it, overriding the pruneObjectBehavior of the ConfigurationPolicyDefaults for this policy.
+const PruneObjectBehaviorAnnotation = "policy.open-cluster-management.io/prune-object-behavior"
+
+// pruneObjectBehaviors are the valid values of the pruneObjectBehavior of a ConfigurationPolicy.
+var pruneObjectBehaviors = []string{"None", "DeleteIfCreated", "DeleteAll"}
+
+// templateDefault is a default value of a spec field of the ConfigurationPolicy templates.
+type templateDefault struct {
+	path  []string
+	value string
+}
+
+// ConfigurationPolicyDefaults sets the spec fields of the ConfigurationPolicy templates which they don't set, such as
+// pruneObjectBehavior or evaluationInterval, so that these defaults are set for the whole fleet from the addon
+// configuration instead of in every policy on the Hub. They also apply to the other kinds registered with SpecDefaults
+// in the TemplateKindRegistry.
+type ConfigurationPolicyDefaults struct {
+	defaults []templateDefault
+}
+
+// ParseConfigurationPolicyDefaults parses the input map of the dot-separated paths of spec fields (e.g.
+// evaluationInterval.compliant) to their default values, such as from the command line. It returns nil if there are no
+// defaults.
+func ParseConfigurationPolicyDefaults(defaults map[string]string) (*ConfigurationPolicyDefaults, error) {
+	if len(defaults) == 0 {
+		return nil, nil
+	}
+
+	parsed := &ConfigurationPolicyDefaults{}
+
+	for field, value := range defaults {
+		path := strings.Split(strings.TrimPrefix(field, "spec."), ".")
+
+		for _, segment := range path {
+			if segment == "" {
+				return nil, fmt.Errorf("invalid ConfigurationPolicy field %q", field)
+			}
+		}
+
+		if len(path) == 1 && path[0] == "pruneObjectBehavior" {
+			if err := validatePruneObjectBehavior(value); err != nil {
+				return nil, err
+			}
+		}
+
+		parsed.defaults = append(parsed.defaults, templateDefault{path: path, value: value})
+	}
+
+	// Sort the defaults so that a parent field (e.g. evaluationInterval) is set before its children
+	sort.Slice(parsed.defaults, func(i, j int) bool {
+		return strings.Join(parsed.defaults[i].path, ".") < strings.Join(parsed.defaults[j].path, ".")
+	})
+
+	return parsed, nil
+}
+
+// apply sets the default spec fields which the input policy template object doesn't set if its kind has SpecDefaults
+// in the input registry. A nil ConfigurationPolicyDefaults does nothing.
+func (d *ConfigurationPolicyDefaults) apply(kinds *TemplateKindRegistry, tObject *unstructured.Unstructured) {
+	if d == nil || !kinds.specDefaults(tObject.GroupVersionKind().GroupKind()) {
+		return
+	}
+
+	for _, templateDefault := range d.defaults {
+		path := append([]string{"spec"}, templateDefault.path...)
+
+		// The field is skipped if one of its parents is not an object, such as when a parent is set to a string
+		_, found, err := unstructured.NestedFieldNoCopy(tObject.Object, path...)
+		if err == nil && !found {
+			err = unstructured.SetNestedField(tObject.Object, templateDefault.value, path...)
+		}
+
+		if err != nil {
+			log.V(2).Info("Skipping the ConfigurationPolicy default", "name", tObject.GetName(),
+				"field", strings.Join(templateDefault.path, "."), "reason", err.Error())
+		}
+	}
+}
+
+// validatePruneObjectBehavior returns an error if the input value isn't a valid pruneObjectBehavior.
+func validatePruneObjectBehavior(value string) error {
+	for _, behavior := range pruneObjectBehaviors {
+		if value == behavior {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"invalid pruneObjectBehavior %q, it must be one of %s", value, strings.Join(pruneObjectBehaviors, ", "),
+	)
+}
+
+// setPruneObjectBehavior sets the pruneObjectBehavior of the input policy template object to the value of the
+// PruneObjectBehaviorAnnotation on the input policy, if its kind has SpecDefaults in the input registry. It must be
+// called before the ConfigurationPolicyDefaults are applied, so that the pruneObjectBehavior is resolved in this order:
+//
+//  1. The pruneObjectBehavior set in the policy template, since it is the most specific.
+//  2. The PruneObjectBehaviorAnnotation on the policy, which beats the cluster default.
+//  3. The pruneObjectBehavior of the ConfigurationPolicyDefaults, which is the cluster default.
+//
+// An invalid annotation value is ignored so that the cluster default still applies.
+func setPruneObjectBehavior(
+	kinds *TemplateKindRegistry, instance *policiesv1.Policy, tObject *unstructured.Unstructured,
+) {
+	value, ok := instance.GetAnnotations()[PruneObjectBehaviorAnnotation]
+	if !ok || !kinds.specDefaults(tObject.GroupVersionKind().GroupKind()) {
+		return
+	}
+
+	if err := validatePruneObjectBehavior(value); err != nil {
+		log.Info("Ignoring the invalid annotation value on the policy", "annotation", PruneObjectBehaviorAnnotation,
+			"value", value, "namespace", instance.GetNamespace(), "name", instance.GetName())
+
+		return
+	}
+
+	// The field is skipped if the spec is not an object, like the ConfigurationPolicyDefaults
+	_, found, err := unstructured.NestedFieldNoCopy(tObject.Object, "spec", "pruneObjectBehavior")
+	if err == nil && !found {
+		err = unstructured.SetNestedField(tObject.Object, value, "spec", "pruneObjectBehavior")
+	}
+
+	if err != nil {
+		log.V(2).Info("Skipping the pruneObjectBehavior of the policy", "name", tObject.GetName(),
+			"reason", err.Error())
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestConfigurationPolicyDefaults(t *testing.T) {
+	RegisterTestingT(t)
+
+	defaults, err := ParseConfigurationPolicyDefaults(map[string]string{
+		"pruneObjectBehavior":               "DeleteIfCreated",
+		"spec.evaluationInterval.compliant": "10m",
+		"evaluationInterval.noncompliant":   "45s",
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	configPolicy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "ConfigurationPolicy",
+		"spec": map[string]interface{}{
+			"pruneObjectBehavior": "None",
+			"evaluationInterval":  map[string]interface{}{"compliant": "1h"},
+		},
+	}}
+	defaults.apply(nil, configPolicy)
+
+	// Only the fields which aren't set get the default values
+	Expect(configPolicy.Object["spec"]).To(Equal(map[string]interface{}{
+		"pruneObjectBehavior": "None",
+		"evaluationInterval":  map[string]interface{}{"compliant": "1h", "noncompliant": "45s"},
+	}))
+
+	empty := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "ConfigurationPolicy",
+	}}
+	defaults.apply(nil, empty)
+	Expect(empty.Object["spec"]).To(Equal(map[string]interface{}{
+		"pruneObjectBehavior": "DeleteIfCreated",
+		"evaluationInterval":  map[string]interface{}{"compliant": "10m", "noncompliant": "45s"},
+	}))
+
+	// The other kinds are left as is
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+		"kind":       "K8sRequiredLabels",
+	}}
+	defaults.apply(nil, constraint)
+	Expect(constraint.Object).ToNot(HaveKey("spec"))
+
+	// Unless they are registered with SpecDefaults
+	kinds := NewTemplateKindRegistry(DefaultTemplateKinds()...)
+	kinds.Register(TemplateKind{
+		GroupKind: constraint.GroupVersionKind().GroupKind(), SpecDefaults: true,
+	})
+	defaults.apply(kinds, constraint)
+	Expect(constraint.Object["spec"]).To(HaveKeyWithValue("pruneObjectBehavior", "DeleteIfCreated"))
+
+	_, err = ParseConfigurationPolicyDefaults(map[string]string{"evaluationInterval.": "10m"})
+	Expect(err).To(HaveOccurred())
+
+	_, err = ParseConfigurationPolicyDefaults(map[string]string{"pruneObjectBehavior": "DeleteSome"})
+	Expect(err).To(HaveOccurred())
+
+	defaults, err = ParseConfigurationPolicyDefaults(nil)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(defaults).To(BeNil())
+	defaults.apply(nil, empty)
+}
+
+func TestPruneObjectBehavior(t *testing.T) {
+	RegisterTestingT(t)
+
+	defaults, err := ParseConfigurationPolicyDefaults(map[string]string{"pruneObjectBehavior": "DeleteIfCreated"})
+	Expect(err).ToNot(HaveOccurred())
+
+	configPolicy := func(spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "policy.open-cluster-management.io/v1",
+			"kind":       "ConfigurationPolicy",
+			"spec":       spec,
+		}}
+	}
+
+	policy := &policiesv1.Policy{}
+	policy.SetAnnotations(map[string]string{PruneObjectBehaviorAnnotation: "DeleteAll"})
+
+	// The policy template beats the policy annotation
+	tObject := configPolicy(map[string]interface{}{"pruneObjectBehavior": "None"})
+	setPruneObjectBehavior(nil, policy, tObject)
+	defaults.apply(nil, tObject)
+	Expect(tObject.Object["spec"]).To(HaveKeyWithValue("pruneObjectBehavior", "None"))
+
+	// The policy annotation beats the cluster default
+	tObject = configPolicy(map[string]interface{}{})
+	setPruneObjectBehavior(nil, policy, tObject)
+	defaults.apply(nil, tObject)
+	Expect(tObject.Object["spec"]).To(HaveKeyWithValue("pruneObjectBehavior", "DeleteAll"))
+
+	// An invalid policy annotation falls back to the cluster default
+	policy.SetAnnotations(map[string]string{PruneObjectBehaviorAnnotation: "DeleteSome"})
+	tObject = configPolicy(map[string]interface{}{})
+	setPruneObjectBehavior(nil, policy, tObject)
+	defaults.apply(nil, tObject)
+	Expect(tObject.Object["spec"]).To(HaveKeyWithValue("pruneObjectBehavior", "DeleteIfCreated"))
+
+	// The other kinds are left as is
+	policy.SetAnnotations(map[string]string{PruneObjectBehaviorAnnotation: "DeleteAll"})
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+		"kind":       "K8sRequiredLabels",
+	}}
+	setPruneObjectBehavior(nil, policy, constraint)
+	Expect(constraint.Object).ToNot(HaveKey("spec"))
+}
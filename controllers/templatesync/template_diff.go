@@ -0,0 +1,129 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// LastAppliedAnnotation is set on the policy template objects to the hash of the spec and annotations of the policy
+// template last applied, so that the changes made by mutating webhooks on the managed cluster are told apart from
+// changes to the policy template.
+const LastAppliedAnnotation = "policy.open-cluster-management.io/last-applied-template"
+
+var suppressedDiffCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "policy_template_suppressed_diffs_total",
+		Help: "The number of times a policy template object differed from its policy template only by fields added " +
+			"on the managed cluster, such as defaults set by mutating webhooks, so it was not updated.",
+	},
+	[]string{"kind"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(suppressedDiffCounter)
+}
+
+// setLastApplied sets the LastAppliedAnnotation annotation of the input policy template object to the hash of its
+// spec and other annotations.
+func setLastApplied(tObject *unstructured.Unstructured) {
+	annotations := map[string]string{}
+
+	for key, value := range tObject.GetAnnotations() {
+		if key != LastAppliedAnnotation {
+			annotations[key] = value
+		}
+	}
+
+	// A map is marshaled with sorted keys, so the hash is stable
+	content, err := json.Marshal(map[string]interface{}{"spec": tObject.Object["spec"], "annotations": annotations})
+	if err != nil {
+		log.Error(err, "Failed to hash the policy template", "name", tObject.GetName())
+
+		return
+	}
+
+	hash := sha256.Sum256(content)
+
+	annotations[LastAppliedAnnotation] = hex.EncodeToString(hash[:])
+	tObject.SetAnnotations(annotations)
+}
+
+// templateObjectMatches returns true if the existing policy template object doesn't need to be updated to match the
+// input policy template object. Besides an exact match, this is the case when the policy template didn't change since
+// it was last applied to the existing object and the existing object only has additional fields, such as defaults set
+// by a mutating webhook. A suppressed difference is counted in the policy_template_suppressed_diffs_total metric.
+func templateObjectMatches(existing, tObject *unstructured.Unstructured) bool {
+	if equality.Semantic.DeepEqual(existing.Object["spec"], tObject.Object["spec"]) &&
+		equality.Semantic.DeepEqual(withoutCorrelationID(existing.GetAnnotations()), tObject.GetAnnotations()) {
+		return true
+	}
+
+	lastApplied, ok := existing.GetAnnotations()[LastAppliedAnnotation]
+	if !ok || lastApplied != tObject.GetAnnotations()[LastAppliedAnnotation] {
+		return false
+	}
+
+	existingAnnotations := map[string]interface{}{}
+	for key, value := range existing.GetAnnotations() {
+		existingAnnotations[key] = value
+	}
+
+	tAnnotations := map[string]interface{}{}
+	for key, value := range tObject.GetAnnotations() {
+		tAnnotations[key] = value
+	}
+
+	if !isSubset(tObject.Object["spec"], existing.Object["spec"]) || !isSubset(tAnnotations, existingAnnotations) {
+		return false
+	}
+
+	suppressedDiffCounter.WithLabelValues(tObject.GetKind()).Inc()
+
+	return true
+}
+
+// isSubset returns true if all the fields of the desired value are set to the same values in the existing value, which
+// may have additional fields in its maps, including in the maps of its list items. The lists must have the same
+// length.
+func isSubset(desired, existing interface{}) bool {
+	switch typedDesired := desired.(type) {
+	case map[string]interface{}:
+		typedExisting, ok := existing.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		for key, value := range typedDesired {
+			existingValue, ok := typedExisting[key]
+			if !ok || !isSubset(value, existingValue) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		typedExisting, ok := existing.([]interface{})
+		if !ok || len(typedExisting) != len(typedDesired) {
+			return false
+		}
+
+		for i := range typedDesired {
+			if !isSubset(typedDesired[i], typedExisting[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return equality.Semantic.DeepEqual(desired, existing)
+	}
+}
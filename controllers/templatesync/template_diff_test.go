@@ -0,0 +1,78 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func diffTemplate() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+		"kind":       "K8sRequiredLabels",
+		"metadata": map[string]interface{}{
+			"name":        "ns-must-have-gk",
+			"annotations": map[string]interface{}{"policy.open-cluster-management.io/standards": "NIST"},
+		},
+		"spec": map[string]interface{}{
+			"match": map[string]interface{}{
+				"kinds": []interface{}{map[string]interface{}{"kinds": []interface{}{"Namespace"}}},
+			},
+		},
+	}}
+}
+
+func TestTemplateObjectMatches(t *testing.T) {
+	RegisterTestingT(t)
+
+	tObject := diffTemplate()
+	setLastApplied(tObject)
+	Expect(tObject.GetAnnotations()).To(HaveKey(LastAppliedAnnotation))
+	Expect(tObject.GetAnnotations()).To(HaveKey("policy.open-cluster-management.io/standards"))
+
+	// The hash is stable
+	hash := tObject.GetAnnotations()[LastAppliedAnnotation]
+	setLastApplied(tObject)
+	Expect(tObject.GetAnnotations()[LastAppliedAnnotation]).To(Equal(hash))
+
+	existing := tObject.DeepCopy()
+	Expect(templateObjectMatches(existing, tObject)).To(BeTrue())
+
+	// Defaults added by a mutating webhook are not a difference
+	suppressed := testutil.ToFloat64(suppressedDiffCounter.WithLabelValues("K8sRequiredLabels"))
+
+	Expect(unstructured.SetNestedField(existing.Object, "deny", "spec", "enforcementAction")).To(Succeed())
+
+	kinds, _, _ := unstructured.NestedSlice(existing.Object, "spec", "match", "kinds")
+	kinds[0].(map[string]interface{})["apiGroups"] = []interface{}{""}
+	Expect(unstructured.SetNestedSlice(existing.Object, kinds, "spec", "match", "kinds")).To(Succeed())
+
+	annotations := existing.GetAnnotations()
+	annotations["webhook.example.com/mutated"] = "true"
+	existing.SetAnnotations(annotations)
+
+	Expect(templateObjectMatches(existing, tObject)).To(BeTrue())
+	Expect(testutil.ToFloat64(suppressedDiffCounter.WithLabelValues("K8sRequiredLabels"))).To(Equal(suppressed + 1))
+
+	// A field set by the policy template that was modified on the managed cluster is a difference
+	modified := existing.DeepCopy()
+	Expect(unstructured.SetNestedSlice(
+		modified.Object, []interface{}{map[string]interface{}{"kinds": []interface{}{"Pod"}}}, "spec", "match", "kinds",
+	)).To(Succeed())
+	Expect(templateObjectMatches(modified, tObject)).To(BeFalse())
+
+	// A change to the policy template is a difference, even if it only removes a field
+	changed := diffTemplate()
+	unstructured.RemoveNestedField(changed.Object, "spec", "match")
+	setLastApplied(changed)
+	Expect(templateObjectMatches(existing, changed)).To(BeFalse())
+
+	// Objects applied before the annotation existed are updated once
+	legacy := diffTemplate()
+	Expect(unstructured.SetNestedField(legacy.Object, "deny", "spec", "enforcementAction")).To(Succeed())
+	Expect(templateObjectMatches(legacy, tObject)).To(BeFalse())
+}
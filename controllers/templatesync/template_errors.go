@@ -0,0 +1,59 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"fmt"
+	"strings"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+type templateError struct {
+	template string
+	reason   string
+}
+
+// templateErrorBatch collects the template errors of a policy during a reconcile so that they are
+// reported in a single event instead of a burst of events when many policy templates are invalid.
+type templateErrorBatch struct {
+	policy *policiesv1.Policy
+	errors []templateError
+	// known are the permanent template errors already reported for the generation of the policy, by template name
+	known map[string]string
+	// terminal are the permanent template errors of the reconcile, by template name
+	terminal map[string]string
+}
+
+// newTemplateErrorBatch returns a batch for the template errors of the input policy, which knows the permanent template
+// errors already reported for its generation.
+func newTemplateErrorBatch(policy *policiesv1.Policy) *templateErrorBatch {
+	return &templateErrorBatch{policy: policy, known: knownTerminalErrors(policy), terminal: map[string]string{}}
+}
+
+func (b *templateErrorBatch) add(template, reason string) {
+	b.errors = append(b.errors, templateError{template: template, reason: reason})
+}
+
+// summary returns the message of the summarized template error event, or an empty string if there
+// are no template errors.
+func (b *templateErrorBatch) summary() string {
+	if len(b.errors) == 0 {
+		return ""
+	}
+
+	failures := make([]string, 0, len(b.errors))
+
+	for _, tErr := range b.errors {
+		failures = append(failures, fmt.Sprintf("%s (%s)", tErr.template, tErr.reason))
+	}
+
+	noun := "templates"
+	if len(b.errors) == 1 {
+		noun = "template"
+	}
+
+	return fmt.Sprintf(
+		"%d policy %s failed to sync: %s", len(b.errors), noun, strings.Join(failures, ", "),
+	)
+}
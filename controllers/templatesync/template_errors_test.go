@@ -0,0 +1,24 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTemplateErrorBatchSummary(t *testing.T) {
+	RegisterTestingT(t)
+
+	batch := &templateErrorBatch{}
+	Expect(batch.summary()).To(BeEmpty())
+
+	batch.add("[template 0]", "DecodeError")
+	Expect(batch.summary()).To(Equal("1 policy template failed to sync: [template 0] (DecodeError)"))
+
+	batch.add("case10-middle-tmpl", "MappingNotFound")
+	Expect(batch.summary()).To(Equal(
+		"2 policy templates failed to sync: [template 0] (DecodeError), case10-middle-tmpl (MappingNotFound)",
+	))
+}
@@ -0,0 +1,142 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// supersededEntries returns the entries of the previous inventory of objects in the namespace of the policy whose
+// policy template now has another kind, identified by an entry in the current inventory with the same name but another
+// group or kind. A change of the API version alone refers to the same object, so it doesn't supersede it. The current
+// entry superseding each returned entry is returned in the same order.
+func supersededEntries(
+	previous []utils.InventoryEntry, current []utils.InventoryEntry,
+) ([]utils.InventoryEntry, []utils.InventoryEntry) {
+	groupKind := func(entry utils.InventoryEntry) schema.GroupKind {
+		return schema.FromAPIVersionAndKind(entry.APIVersion, entry.Kind).GroupKind()
+	}
+
+	currentByName := map[string][]utils.InventoryEntry{}
+	currentGroupKinds := map[schema.GroupKind]map[string]bool{}
+
+	for _, entry := range current {
+		if entry.Namespace != "" {
+			continue
+		}
+
+		currentByName[entry.Name] = append(currentByName[entry.Name], entry)
+
+		if currentGroupKinds[groupKind(entry)] == nil {
+			currentGroupKinds[groupKind(entry)] = map[string]bool{}
+		}
+
+		currentGroupKinds[groupKind(entry)][entry.Name] = true
+	}
+
+	superseded := []utils.InventoryEntry{}
+	supersedingEntries := []utils.InventoryEntry{}
+
+	for _, entry := range previous {
+		if entry.Namespace != "" || currentGroupKinds[groupKind(entry)][entry.Name] {
+			continue
+		}
+
+		if successors := currentByName[entry.Name]; len(successors) != 0 {
+			superseded = append(superseded, entry)
+			supersedingEntries = append(supersedingEntries, successors[0])
+		}
+	}
+
+	return superseded, supersedingEntries
+}
+
+// deleteSupersededObjects deletes the objects in the namespace of the input policy recorded in its inventory whose
+// policy template changed kind, such as from ConfigurationPolicy to OperatorPolicy, since they would otherwise be left
+// behind until the policy is deleted. Only the objects still owned by the policy are deleted.
+func (r *PolicyReconciler) deleteSupersededObjects(
+	ctx context.Context,
+	instance *policiesv1.Policy,
+	inventory []utils.InventoryEntry,
+	rMapper meta.RESTMapper,
+	dClient dynamic.Interface,
+) error {
+	previous, err := utils.PolicyInventory(instance)
+	if err != nil {
+		return fmt.Errorf("failed to parse the template inventory annotation: %w", err)
+	}
+
+	superseded, supersedingEntries := supersededEntries(previous, inventory)
+
+	var deleteErr error
+
+	for i, entry := range superseded {
+		gv, err := schema.ParseGroupVersion(entry.APIVersion)
+		if err != nil {
+			deleteErr = err
+
+			continue
+		}
+
+		mapping, err := rMapper.RESTMapping(gv.WithKind(entry.Kind).GroupKind(), gv.Version)
+		if err != nil {
+			// The kind is no longer served so there is nothing to delete
+			if !meta.IsNoMatchError(err) {
+				deleteErr = err
+			}
+
+			continue
+		}
+
+		res := dClient.Resource(mapping.Resource).Namespace(instance.GetNamespace())
+
+		obj, err := res.Get(ctx, entry.Name, metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				deleteErr = err
+			}
+
+			continue
+		}
+
+		if _, owned := templateObjectOwner(instance, obj); !owned {
+			continue
+		}
+
+		log.Info("Deleting the policy template object superseded by a kind change", "policy", instance.GetName(),
+			"kind", entry.Kind, "name", entry.Name, "newKind", supersedingEntries[i].Kind)
+
+		err = r.ApplyTimeouts.apply(ctx, obj.GetKind(), func(ctx context.Context) error {
+			return res.Delete(
+				ctx, obj.GetName(), metav1.DeleteOptions{Preconditions: utils.UnchangedPreconditions(obj)},
+			)
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			deleteErr = fmt.Errorf("failed to delete the superseded policy template object %s: %w",
+				obj.GetName(), utils.DeleteConflictError(obj, err))
+
+			continue
+		}
+
+		if err := r.Engines.Cleanup(ctx, dClient, obj); err != nil {
+			deleteErr = err
+		}
+
+		r.Recorder.Event(instance, "Normal", "PolicyTemplateSync", fmt.Sprintf(
+			"Policy template %s of kind %s was deleted since the policy template changed to kind %s",
+			entry.Name, entry.Kind, supersedingEntries[i].Kind,
+		))
+	}
+
+	return deleteErr
+}
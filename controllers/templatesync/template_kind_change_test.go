@@ -0,0 +1,56 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestSupersededEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	configPolicy := utils.InventoryEntry{
+		APIVersion: "policy.open-cluster-management.io/v1", Kind: "ConfigurationPolicy", Name: "install-operator",
+	}
+	operatorPolicy := utils.InventoryEntry{
+		APIVersion: "policy.open-cluster-management.io/v1beta1", Kind: "OperatorPolicy", Name: "install-operator",
+	}
+	constraint := utils.InventoryEntry{
+		APIVersion: "constraints.gatekeeper.sh/v1beta1", Kind: "K8sRequiredLabels", Name: "ns-must-have-gk",
+	}
+	placed := utils.InventoryEntry{
+		APIVersion: "mutations.gatekeeper.sh/v1", Kind: "Assign", Name: "ns-must-have-gk",
+		Namespace: "gatekeeper-system",
+	}
+
+	// The kind of the install-operator policy template changed
+	superseded, successors := supersededEntries(
+		[]utils.InventoryEntry{configPolicy, constraint},
+		[]utils.InventoryEntry{operatorPolicy, constraint},
+	)
+	Expect(superseded).To(Equal([]utils.InventoryEntry{configPolicy}))
+	Expect(successors).To(Equal([]utils.InventoryEntry{operatorPolicy}))
+
+	// A change of the API version alone is the same object
+	upgraded := configPolicy
+	upgraded.APIVersion = "policy.open-cluster-management.io/v1beta1"
+
+	superseded, _ = supersededEntries([]utils.InventoryEntry{configPolicy}, []utils.InventoryEntry{upgraded})
+	Expect(superseded).To(BeEmpty())
+
+	// Removed policy templates and placed objects are not superseded
+	superseded, _ = supersededEntries(
+		[]utils.InventoryEntry{configPolicy, placed}, []utils.InventoryEntry{constraint},
+	)
+	Expect(superseded).To(BeEmpty())
+
+	// Two policy templates with the same name and different kinds are both kept
+	superseded, _ = supersededEntries(
+		[]utils.InventoryEntry{configPolicy, operatorPolicy}, []utils.InventoryEntry{configPolicy, operatorPolicy},
+	)
+	Expect(superseded).To(BeEmpty())
+}
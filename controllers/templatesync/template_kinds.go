@@ -0,0 +1,251 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// dependencyRequeueInterval is how long to wait before checking again if the dependencies of a held policy template
+// are Compliant.
+const dependencyRequeueInterval = 10 * time.Second
+
+// policyGroup is the API group of the policy template kinds of the policy framework.
+const policyGroup = "policy.open-cluster-management.io"
+
+// TemplateKind describes how the policy framework handles the policy templates of a kind, so that new kinds can be
+// supported without changes to the reconciler. The kinds which are not registered are applied as is. The handling
+// specific to the policy engine evaluating the kind, such as translating its policy templates or cleaning up after
+// its objects are deleted, is done by the adapter of the engine in the engines.Registry instead.
+type TemplateKind struct {
+	GroupKind schema.GroupKind
+	// HubTemplates is true if the policy templates of the kind may contain Hub templates, which the policy engine of
+	// the kind resolves. The policy templates of the other kinds containing Hub templates are rejected.
+	HubTemplates bool
+	// SpecDefaults is true if the ConfigurationPolicyDefaults and the PruneObjectBehaviorAnnotation apply to the
+	// policy templates of the kind, whose spec then has the fields of the ConfigurationPolicy spec.
+	SpecDefaults bool
+	// ComplianceField is the path of the compliance state in the objects of the kind, such as status.compliant. If it
+	// is empty, the objects of the kind are considered Compliant once they exist.
+	ComplianceField []string
+	// DependsOn are the kinds whose policy templates in the same policy are applied first. A policy template of this
+	// kind is held as Pending until the objects of the policy templates of these kinds in the policy are Compliant.
+	DependsOn []schema.GroupKind
+}
+
+// compliant returns true if the input object of the kind is Compliant.
+func (k TemplateKind) compliant(obj *unstructured.Unstructured) bool {
+	if len(k.ComplianceField) == 0 {
+		return true
+	}
+
+	state, _, _ := unstructured.NestedString(obj.Object, k.ComplianceField...)
+
+	return strings.EqualFold(state, string(policiesv1.Compliant))
+}
+
+// DefaultTemplateKinds returns the policy template kinds of the policy framework. Only the ConfigurationPolicy kind
+// supports Hub templates and gets the ConfigurationPolicyDefaults.
+func DefaultTemplateKinds() []TemplateKind {
+	compliantField := []string{"status", "compliant"}
+
+	return []TemplateKind{
+		{
+			GroupKind:       schema.GroupKind{Group: policyGroup, Kind: "ConfigurationPolicy"},
+			HubTemplates:    true,
+			SpecDefaults:    true,
+			ComplianceField: compliantField,
+		},
+		{GroupKind: schema.GroupKind{Group: policyGroup, Kind: "CertificatePolicy"}, ComplianceField: compliantField},
+		{GroupKind: schema.GroupKind{Group: policyGroup, Kind: "IamPolicy"}, ComplianceField: compliantField},
+		{GroupKind: schema.GroupKind{Group: policyGroup, Kind: "OperatorPolicy"}, ComplianceField: compliantField},
+	}
+}
+
+// defaultTemplateKinds is used by a nil TemplateKindRegistry.
+var defaultTemplateKinds = NewTemplateKindRegistry(DefaultTemplateKinds()...)
+
+// TemplateKindRegistry holds the policy template kinds with a specific handling. Downstream builds can register more
+// kinds, or replace the handling of a kind, before the controller starts. A nil TemplateKindRegistry has the
+// DefaultTemplateKinds.
+type TemplateKindRegistry struct {
+	kinds map[schema.GroupKind]TemplateKind
+	lock  sync.RWMutex
+}
+
+// NewTemplateKindRegistry returns a TemplateKindRegistry of the input kinds. A kind registered twice has the handling
+// of its last registration.
+func NewTemplateKindRegistry(kinds ...TemplateKind) *TemplateKindRegistry {
+	registry := &TemplateKindRegistry{kinds: make(map[schema.GroupKind]TemplateKind, len(kinds))}
+
+	for _, kind := range kinds {
+		registry.Register(kind)
+	}
+
+	return registry
+}
+
+// Register adds the input kind to the registry, replacing the handling of the kind if it is already registered.
+func (r *TemplateKindRegistry) Register(kind TemplateKind) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.kinds[kind.GroupKind] = kind
+}
+
+// Lookup returns the handling of the input kind and true, or false if the kind isn't registered.
+func (r *TemplateKindRegistry) Lookup(gk schema.GroupKind) (TemplateKind, bool) {
+	if r == nil {
+		r = defaultTemplateKinds
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	kind, ok := r.kinds[gk]
+
+	return kind, ok
+}
+
+// hubTemplatesAllowed returns true if the policy templates of the input kind may contain Hub templates.
+func (r *TemplateKindRegistry) hubTemplatesAllowed(gk schema.GroupKind) bool {
+	kind, _ := r.Lookup(gk)
+
+	return kind.HubTemplates
+}
+
+// specDefaults returns true if the ConfigurationPolicyDefaults apply to the policy templates of the input kind.
+func (r *TemplateKindRegistry) specDefaults(gk schema.GroupKind) bool {
+	kind, _ := r.Lookup(gk)
+
+	return kind.SpecDefaults
+}
+
+// templateGroupKinds returns the kinds of the input policy templates, by index. The kind of a policy template which
+// can't be decoded is empty.
+func templateGroupKinds(templates []*policiesv1.PolicyTemplate) []schema.GroupKind {
+	kinds := make([]schema.GroupKind, len(templates))
+
+	for i, policyT := range templates {
+		if policyT == nil {
+			continue
+		}
+
+		typeMeta := struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+		}{}
+
+		if err := json.Unmarshal(policyT.ObjectDefinition.Raw, &typeMeta); err != nil {
+			continue
+		}
+
+		kinds[i] = schema.FromAPIVersionAndKind(typeMeta.APIVersion, typeMeta.Kind).GroupKind()
+	}
+
+	return kinds
+}
+
+// applyOrder returns the indexes of the input policy templates in the order they are applied, so that the policy
+// templates of a kind come after those of the kinds it depends on. The policy templates are otherwise kept in their
+// order in the policy.
+func (r *TemplateKindRegistry) applyOrder(kinds []schema.GroupKind) []int {
+	depths := map[schema.GroupKind]int{}
+
+	// depth returns how many levels of dependencies the input kind has, ignoring the dependency cycles
+	var depth func(gk schema.GroupKind, visiting map[schema.GroupKind]bool) int
+
+	depth = func(gk schema.GroupKind, visiting map[schema.GroupKind]bool) int {
+		if d, ok := depths[gk]; ok {
+			return d
+		}
+
+		kind, _ := r.Lookup(gk)
+		visiting[gk] = true
+		d := 0
+
+		for _, dependency := range kind.DependsOn {
+			if visiting[dependency] {
+				continue
+			}
+
+			if dependencyDepth := depth(dependency, visiting) + 1; dependencyDepth > d {
+				d = dependencyDepth
+			}
+		}
+
+		delete(visiting, gk)
+		depths[gk] = d
+
+		return d
+	}
+
+	order := make([]int, len(kinds))
+	for i := range kinds {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return depth(kinds[order[i]], map[schema.GroupKind]bool{}) < depth(kinds[order[j]], map[schema.GroupKind]bool{})
+	})
+
+	return order
+}
+
+// templateDependencies tracks which policy templates of each kind in a policy are Compliant during a reconcile, so
+// that the policy templates of the kinds depending on them are held until they are.
+type templateDependencies struct {
+	registry *TemplateKindRegistry
+	// pending is the number of policy templates of each kind in the policy which are not yet Compliant
+	pending map[schema.GroupKind]int
+}
+
+// newTemplateDependencies returns the dependency tracking of the policy templates of the input kinds.
+func (r *TemplateKindRegistry) newTemplateDependencies(kinds []schema.GroupKind) *templateDependencies {
+	pending := map[schema.GroupKind]int{}
+
+	for _, gk := range kinds {
+		pending[gk]++
+	}
+
+	return &templateDependencies{registry: r, pending: pending}
+}
+
+// observe records whether the input object of a policy template is Compliant.
+func (d *templateDependencies) observe(obj *unstructured.Unstructured) {
+	gk := obj.GroupVersionKind().GroupKind()
+
+	kind, _ := d.registry.Lookup(gk)
+	if kind.compliant(obj) && d.pending[gk] > 0 {
+		d.pending[gk]--
+	}
+}
+
+// unmet returns a message listing the kinds which the input kind depends on whose policy templates in the policy are
+// not all Compliant, or an empty string if there are none.
+func (d *templateDependencies) unmet(gk schema.GroupKind) string {
+	kind, _ := d.registry.Lookup(gk)
+	unmet := []string{}
+
+	for _, dependency := range kind.DependsOn {
+		if d.pending[dependency] > 0 {
+			unmet = append(unmet, dependency.Kind)
+		}
+	}
+
+	if len(unmet) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("The policy template is held until the %s policy templates of the policy are Compliant",
+		strings.Join(unmet, ", "))
+}
@@ -0,0 +1,84 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func policyTemplate(apiVersion string, kind string) *policiesv1.PolicyTemplate {
+	return &policiesv1.PolicyTemplate{ObjectDefinition: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"` + apiVersion + `","kind":"` + kind + `","metadata":{"name":"template"}}`),
+	}}
+}
+
+func TestTemplateKindRegistry(t *testing.T) {
+	RegisterTestingT(t)
+
+	configPolicy := schema.GroupKind{Group: policyGroup, Kind: "ConfigurationPolicy"}
+	operatorPolicy := schema.GroupKind{Group: policyGroup, Kind: "OperatorPolicy"}
+	constraint := schema.GroupKind{Group: "constraints.gatekeeper.sh", Kind: "K8sRequiredLabels"}
+
+	// A nil registry has the default kinds
+	var nilRegistry *TemplateKindRegistry
+
+	Expect(nilRegistry.hubTemplatesAllowed(configPolicy)).To(BeTrue())
+	Expect(nilRegistry.hubTemplatesAllowed(operatorPolicy)).To(BeFalse())
+	Expect(nilRegistry.hubTemplatesAllowed(constraint)).To(BeFalse())
+	Expect(nilRegistry.specDefaults(configPolicy)).To(BeTrue())
+	Expect(nilRegistry.specDefaults(operatorPolicy)).To(BeFalse())
+
+	_, ok := nilRegistry.Lookup(constraint)
+	Expect(ok).To(BeFalse())
+
+	registry := NewTemplateKindRegistry(DefaultTemplateKinds()...)
+	registry.Register(TemplateKind{
+		GroupKind:       configPolicy,
+		HubTemplates:    true,
+		ComplianceField: []string{"status", "compliant"},
+		DependsOn:       []schema.GroupKind{operatorPolicy},
+	})
+	registry.Register(TemplateKind{
+		GroupKind: constraint,
+		DependsOn: []schema.GroupKind{configPolicy},
+	})
+
+	// The policy templates are applied after those of the kinds they depend on, and otherwise in order
+	templates := []*policiesv1.PolicyTemplate{
+		policyTemplate("constraints.gatekeeper.sh/v1beta1", "K8sRequiredLabels"),
+		policyTemplate("policy.open-cluster-management.io/v1", "ConfigurationPolicy"),
+		{ObjectDefinition: runtime.RawExtension{Raw: []byte(`not JSON`)}},
+		policyTemplate("policy.open-cluster-management.io/v1beta1", "OperatorPolicy"),
+		policyTemplate("v1", "ConfigMap"),
+	}
+	kinds := templateGroupKinds(templates)
+	Expect(kinds[2]).To(Equal(schema.GroupKind{}))
+	Expect(registry.applyOrder(kinds)).To(Equal([]int{2, 3, 4, 1, 0}))
+
+	// The dependent policy templates are held until the policy templates of their dependencies are Compliant
+	dependencies := registry.newTemplateDependencies(kinds)
+	Expect(dependencies.unmet(operatorPolicy)).To(BeEmpty())
+	Expect(dependencies.unmet(configPolicy)).To(ContainSubstring("OperatorPolicy"))
+
+	operatorObj := &unstructured.Unstructured{}
+	operatorObj.SetAPIVersion("policy.open-cluster-management.io/v1beta1")
+	operatorObj.SetKind("OperatorPolicy")
+
+	dependencies.observe(operatorObj)
+	Expect(dependencies.unmet(configPolicy)).To(ContainSubstring("OperatorPolicy"))
+
+	Expect(unstructured.SetNestedField(operatorObj.Object, "Compliant", "status", "compliant")).To(Succeed())
+	dependencies.observe(operatorObj)
+	Expect(dependencies.unmet(configPolicy)).To(BeEmpty())
+	Expect(dependencies.unmet(constraint)).To(ContainSubstring("ConfigurationPolicy"))
+
+	// A dependency cycle doesn't prevent ordering the policy templates
+	registry.Register(TemplateKind{GroupKind: operatorPolicy, DependsOn: []schema.GroupKind{constraint}})
+	Expect(registry.applyOrder(kinds)).To(HaveLen(len(kinds)))
+}
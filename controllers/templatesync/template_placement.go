@@ -0,0 +1,202 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// templateNamespace returns the namespace of the object of a policy template of the input kind, which is the namespace
+// of the policy unless the kind is namespaced and placed elsewhere by the template placements.
+func (r *PolicyReconciler) templateNamespace(instance *policiesv1.Policy, mapping *meta.RESTMapping) string {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return instance.GetNamespace()
+	}
+
+	return r.TemplatePlacements.TemplateNamespace(mapping.GroupVersionKind.GroupKind(), instance.GetNamespace())
+}
+
+// isPlaced returns true if the input policy template object is placed outside the namespace of the input policy.
+func isPlaced(instance *policiesv1.Policy, obj *unstructured.Unstructured) bool {
+	return obj.GetNamespace() != "" && obj.GetNamespace() != instance.GetNamespace()
+}
+
+// templateObjectOwner returns the policy owning the input policy template object and whether it is the input policy.
+// The objects placed outside the namespace of the policy are owned through labels since an owner reference can't refer
+// to an object in another namespace.
+func templateObjectOwner(instance *policiesv1.Policy, obj *unstructured.Unstructured) (string, bool) {
+	if name, placed := obj.GetLabels()[utils.PlacedByPolicyLabel]; placed {
+		namespace := obj.GetLabels()[utils.PlacedByPolicyNamespaceLabel]
+
+		return namespace + "/" + name, namespace == instance.GetNamespace() && name == instance.GetName()
+	}
+
+	if len(obj.GetOwnerReferences()) == 0 {
+		return "", false
+	}
+
+	name := obj.GetOwnerReferences()[0].Name
+
+	return name, name == instance.GetName()
+}
+
+// placedObjectSelector returns the label selector of the objects placed outside the namespace of the input policy.
+func placedObjectSelector(policy types.NamespacedName) string {
+	return labels.SelectorFromSet(labels.Set{
+		utils.PlacedByPolicyLabel:          policy.Name,
+		utils.PlacedByPolicyNamespaceLabel: policy.Namespace,
+	}).String()
+}
+
+// deleteStalePlacedObjects deletes the placed objects recorded in the inventory of the input policy which are no longer
+// in the input current inventory, because their policy template was removed or their kind was placed elsewhere. Unlike
+// the objects in the namespace of the policy, they aren't garbage collected through an owner reference.
+func (r *PolicyReconciler) deleteStalePlacedObjects(
+	ctx context.Context,
+	instance *policiesv1.Policy,
+	inventory []utils.InventoryEntry,
+	rMapper meta.RESTMapper,
+	dClient dynamic.Interface,
+) error {
+	previous, err := utils.PolicyInventory(instance)
+	if err != nil {
+		return fmt.Errorf("failed to parse the template inventory annotation: %w", err)
+	}
+
+	// The entries are compared by the object they identify, regardless of its state
+	current := make(map[utils.InventoryEntry]bool, len(inventory))
+	for _, entry := range inventory {
+		current[entry.Identity()] = true
+	}
+
+	var deleteErr error
+
+	for _, entry := range previous {
+		entry = entry.Identity()
+		if entry.Namespace == "" || current[entry] {
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(entry.APIVersion)
+		if err != nil {
+			deleteErr = err
+
+			continue
+		}
+
+		mapping, err := rMapper.RESTMapping(gv.WithKind(entry.Kind).GroupKind(), gv.Version)
+		if err != nil {
+			deleteErr = err
+
+			continue
+		}
+
+		res := dClient.Resource(mapping.Resource).Namespace(entry.Namespace)
+
+		obj, err := res.Get(ctx, entry.Name, metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				deleteErr = err
+			}
+
+			continue
+		}
+
+		if _, owned := templateObjectOwner(instance, obj); !owned {
+			continue
+		}
+
+		log.Info("Deleting the stale placed policy template object", "policy", instance.GetName(),
+			"kind", entry.Kind, "namespace", entry.Namespace, "name", entry.Name)
+
+		err = r.deletePlacedObject(ctx, dClient, res, obj)
+		if err != nil {
+			deleteErr = err
+		}
+	}
+
+	return deleteErr
+}
+
+// deleteOrphanedPlacedObjects deletes the objects placed outside the namespace of the input deleted policy in the
+// namespaces of the current template placements.
+func (r *PolicyReconciler) deleteOrphanedPlacedObjects(ctx context.Context, policy types.NamespacedName) error {
+	placements := r.TemplatePlacements.Placements()
+	if len(placements) == 0 {
+		return nil
+	}
+
+	rMapper, dClient, err := r.templateClients()
+	if err != nil {
+		return err
+	}
+
+	var deleteErr error
+
+	for _, placement := range placements {
+		mapping, err := rMapper.RESTMapping(schema.GroupKind{Group: placement.Group, Kind: placement.Kind})
+		if err != nil {
+			// The kind is no longer served so there is nothing to delete
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+
+			deleteErr = err
+
+			continue
+		}
+
+		res := dClient.Resource(mapping.Resource).Namespace(placement.Namespace)
+
+		objects, err := res.List(ctx, metav1.ListOptions{LabelSelector: placedObjectSelector(policy)})
+		if err != nil {
+			deleteErr = err
+
+			continue
+		}
+
+		for i := range objects.Items {
+			log.Info("Deleting the placed policy template object of the deleted policy", "policy", policy.String(),
+				"kind", placement.Kind, "namespace", placement.Namespace, "name", objects.Items[i].GetName())
+
+			err = r.deletePlacedObject(ctx, dClient, res, &objects.Items[i])
+			if err != nil {
+				deleteErr = err
+			}
+		}
+	}
+
+	return deleteErr
+}
+
+// deletePlacedObject deletes the input placed object and runs the cleanup of its policy engine adapter and of its
+// kind. The deletion is preconditioned on the UID and resource version so that an object replaced or modified in the
+// meantime is not deleted.
+func (r *PolicyReconciler) deletePlacedObject(
+	ctx context.Context, dClient dynamic.Interface, res dynamic.ResourceInterface, obj *unstructured.Unstructured,
+) error {
+	err := r.ApplyTimeouts.apply(ctx, obj.GetKind(), func(ctx context.Context) error {
+		return res.Delete(
+			ctx, obj.GetName(), metav1.DeleteOptions{Preconditions: utils.UnchangedPreconditions(obj)},
+		)
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the placed policy template object %s: %w",
+			obj.GetName(), utils.DeleteConflictError(obj, err))
+	}
+
+	return r.Engines.Cleanup(ctx, dClient, obj)
+}
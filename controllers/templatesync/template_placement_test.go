@@ -0,0 +1,161 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func placedTestObject(name, namespace string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("mutations.gatekeeper.sh/v1")
+	obj.SetKind("Assign")
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+
+	return obj
+}
+
+func TestSetTemplateOwnershipPlaced(t *testing.T) {
+	RegisterTestingT(t)
+
+	instance := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"}}
+
+	inPolicyNamespace := placedTestObject("assign", "")
+	setTemplateOwnership(instance, inPolicyNamespace)
+	Expect(inPolicyNamespace.GetOwnerReferences()).To(HaveLen(1))
+	Expect(inPolicyNamespace.GetLabels()).ToNot(HaveKey(utils.PlacedByPolicyLabel))
+	Expect(inventoryEntry(inPolicyNamespace).Namespace).To(BeEmpty())
+
+	owner, owned := templateObjectOwner(instance, inPolicyNamespace)
+	Expect(owner).To(Equal("policy"))
+	Expect(owned).To(BeTrue())
+
+	placed := placedTestObject("assign", "gatekeeper-system")
+	setTemplateOwnership(instance, placed)
+	Expect(placed.GetOwnerReferences()).To(BeEmpty())
+	Expect(placed.GetLabels()).To(HaveKeyWithValue(utils.PlacedByPolicyLabel, "policy"))
+	Expect(placed.GetLabels()).To(HaveKeyWithValue(utils.PlacedByPolicyNamespaceLabel, "managed"))
+	Expect(inventoryEntry(placed).Namespace).To(Equal("gatekeeper-system"))
+
+	owner, owned = templateObjectOwner(instance, placed)
+	Expect(owner).To(Equal("managed/policy"))
+	Expect(owned).To(BeTrue())
+
+	otherCluster := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "other"}}
+	_, owned = templateObjectOwner(otherCluster, placed)
+	Expect(owned).To(BeFalse())
+}
+
+func TestDeleteStalePlacedObjects(t *testing.T) {
+	RegisterTestingT(t)
+
+	gvr := schema.GroupVersionResource{Group: "mutations.gatekeeper.sh", Version: "v1", Resource: "assigns"}
+	gv := gvr.GroupVersion()
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gv})
+	mapper.Add(gv.WithKind("Assign"), meta.RESTScopeNamespace)
+
+	instance := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"}}
+
+	kept := placedTestObject("kept", "gatekeeper-system")
+	setTemplateOwnership(instance, kept)
+
+	stale := placedTestObject("stale", "gatekeeper-system")
+	setTemplateOwnership(instance, stale)
+
+	// An object with the same name placed by another policy is not deleted
+	unowned := placedTestObject("unowned", "gatekeeper-system")
+	other := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "managed"}}
+	setTemplateOwnership(other, unowned)
+
+	dClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "AssignList"}, kept, stale, unowned,
+	)
+
+	value, err := utils.InventoryAnnotationValue(
+		[]utils.InventoryEntry{inventoryEntry(kept), inventoryEntry(stale), inventoryEntry(unowned)},
+	)
+	Expect(err).To(BeNil())
+
+	instance.SetAnnotations(map[string]string{utils.TemplateInventoryAnnotation: value})
+
+	r := &PolicyReconciler{}
+	err = r.deleteStalePlacedObjects(
+		context.TODO(), instance, []utils.InventoryEntry{inventoryEntry(kept)}, mapper, dClient,
+	)
+	Expect(err).To(BeNil())
+
+	objects, err := dClient.Resource(gvr).Namespace("gatekeeper-system").List(context.TODO(), metav1.ListOptions{})
+	Expect(err).To(BeNil())
+
+	names := []string{}
+	for _, obj := range objects.Items {
+		names = append(names, obj.GetName())
+	}
+
+	Expect(names).To(ConsistOf("kept", "unowned"))
+}
+
+func TestUnverifiedTemplateKeepsPlacedObject(t *testing.T) {
+	RegisterTestingT(t)
+
+	gvr := schema.GroupVersionResource{Group: "mutations.gatekeeper.sh", Version: "v1", Resource: "assigns"}
+	gv := gvr.GroupVersion()
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gv})
+	mapper.Add(gv.WithKind("Assign"), meta.RESTScopeNamespace)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	rotatedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	// The policy was signed with a key which was rotated
+	instance := signedTestPolicy(t, signingKey)
+	Expect((&PolicySignatureVerifier{PublicKey: &rotatedKey.PublicKey}).Verify(instance)).ToNot(Succeed())
+
+	placed := placedTestObject("assign", "gatekeeper-system")
+	setTemplateOwnership(instance, placed)
+
+	value, err := utils.InventoryAnnotationValue([]utils.InventoryEntry{inventoryEntry(placed)})
+	Expect(err).To(BeNil())
+
+	instance.Annotations[utils.TemplateInventoryAnnotation] = value
+
+	// The object of the unverified policy template is carried over from the previous inventory
+	tObject := placedTestObject("assign", "gatekeeper-system")
+
+	entry, found := previousInventoryEntry(instance, tObject, "gatekeeper-system")
+	Expect(found).To(BeTrue())
+	Expect(entry).To(Equal(inventoryEntry(placed)))
+
+	_, found = previousInventoryEntry(instance, placedTestObject("other", "gatekeeper-system"), "gatekeeper-system")
+	Expect(found).To(BeFalse())
+
+	dClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "AssignList"}, placed,
+	)
+
+	r := &PolicyReconciler{}
+	Expect(r.deleteStalePlacedObjects(
+		context.TODO(), instance, []utils.InventoryEntry{entry}, mapper, dClient,
+	)).To(Succeed())
+
+	_, err = dClient.Resource(gvr).Namespace("gatekeeper-system").Get(context.TODO(), "assign", metav1.GetOptions{})
+	Expect(err).To(BeNil())
+}
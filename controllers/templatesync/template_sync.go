@@ -6,12 +6,14 @@ package templatesync
 import (
 	"context"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"strings"
+	"time"
 
-	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -26,13 +28,26 @@ import (
 	"open-cluster-management.io/governance-policy-propagator/controllers/common"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/yaml"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
 
 const (
 	ControllerName string = "policy-template-sync"
-	policyFmtStr   string = "policy: %s/%s"
+	// RecreateOnImmutableChangeAnnotation can be set to "true" on a policy template to have its object deleted and
+	// recreated when an update is rejected because an immutable field changed.
+	RecreateOnImmutableChangeAnnotation = "policy.open-cluster-management.io/recreate-on-immutable-change"
+	// SkipRemediationActionOverrideAnnotation can be set to "true" on a policy to retain the remediationAction of
+	// each of its policy templates instead of overriding them with the remediationAction of the policy.
+	SkipRemediationActionOverrideAnnotation = "policy.open-cluster-management.io/skip-remediation-action-override"
 )
 
 var log = ctrl.Log.WithName(ControllerName)
@@ -42,11 +57,39 @@ var log = ctrl.Log.WithName(ControllerName)
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		Named(ControllerName).
 		For(&policiesv1.Policy{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
-		Complete(r)
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Concurrency.Workers(r.ConcurrentReconciles)}).
+		// The annotations are also considered since SkipRemediationActionOverrideAnnotation affects the templates
+		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))
+
+	if r.ConfigMapResolver != nil {
+		// Reconcile the policies referencing a Hub ConfigMap when it changes
+		builder = builder.Watches(
+			&source.Channel{Source: r.ConfigMapResolver.Events()}, &handler.EnqueueRequestForObject{},
+		)
+	}
+
+	if r.TemplateWatcher != nil {
+		// Reconcile the policies whose template objects are modified or deleted. Status changes and the additions of
+		// the template objects created by this controller are ignored.
+		builder = builder.Watches(
+			&source.Channel{Source: r.TemplateWatcher.Events(ControllerName, templateObjectChanged)},
+			&handler.EnqueueRequestForObject{},
+		)
+	}
+
+	return builder.Complete(r.Concurrency.Reconciler(syncerrors.Reconciler(ControllerName, r)))
+}
+
+// templateObjectChanged returns true if the template object was deleted or its generation changed.
+func templateObjectChanged(oldObj, newObj *metav1.PartialObjectMetadata) bool {
+	if oldObj == nil {
+		return false
+	}
+
+	return newObj == nil || oldObj.GetGeneration() != newObj.GetGeneration()
 }
 
 // blank assignment to verify that ReconcilePolicy implements reconcile.Reconciler
@@ -60,6 +103,83 @@ type PolicyReconciler struct {
 	Scheme   *runtime.Scheme
 	Config   *rest.Config
 	Recorder record.EventRecorder
+	// OCIFetcher resolves policy templates delivered as OCI artifacts. If it is nil, policy templates referencing an
+	// OCI artifact are rejected.
+	OCIFetcher *OCIFetcher
+	// RBACReport enables logging the minimal ClusterRole needed for the policy templates in use whenever it changes.
+	RBACReport bool
+	rbacReport rbacReport
+	// RecreateOnImmutableChange enables deleting and recreating policy template objects whose update is rejected
+	// because an immutable field changed, regardless of the RecreateOnImmutableChangeAnnotation annotation.
+	RecreateOnImmutableChange bool
+	// RecreateOnChangeKinds are the policy template kinds whose objects are deleted and recreated instead of updated
+	// when their spec changes, regardless of the RecreateOnChangeAnnotation annotation.
+	RecreateOnChangeKinds []string
+	// CreateOnlyTemplates enables the CreateOnlyAnnotation annotation on the policy templates. If it is false, the
+	// objects of the create-only policy templates are updated like the others.
+	CreateOnlyTemplates bool
+	// ConfigMapResolver resolves policy templates referencing a ConfigMap on the Hub. If it is nil, policy templates
+	// referencing a ConfigMap are rejected.
+	ConfigMapResolver *HubConfigMapResolver
+	// NamespaceGuard pauses the reconciles while the cluster namespace is unavailable
+	NamespaceGuard *utils.NamespaceGuard
+	// Verifier verifies the policy before its enforce mode policy templates are applied. If it is nil, the policy is
+	// not verified.
+	Verifier PolicyVerifier
+	// CopyPolicyMetadata enables copying the standards, categories, and controls annotations of the policies onto the
+	// objects of their policy templates, unless overridden by the CopyPolicyMetadataAnnotation on a policy.
+	CopyPolicyMetadata bool
+	// ApplyTimeouts bounds the create, update, and delete requests of the policy template objects. If it is nil, the
+	// requests are only bounded by the reconcile context.
+	ApplyTimeouts *ApplyTimeouts
+	// TemplateWatcher watches the template objects so that changes to them are reverted. If it is nil, the template
+	// objects are only synced when the policy changes.
+	TemplateWatcher *utils.DynamicWatcher
+	// TemplatePlacements places the objects of namespaced policy templates of some kinds outside the namespace of the
+	// policy. If it is nil, all the objects are in the namespace of the policy.
+	TemplatePlacements *utils.TemplatePlacements
+	// ExecHooks validates or transforms the policy templates of some kinds with external binaries before they are
+	// applied. If it is nil, the policy templates are applied as is.
+	ExecHooks *ExecHooks
+	// ClusterIdentity substitutes the name and cluster claims of the managed cluster in the fields of the policy
+	// templates designated by the ClusterIdentityFieldsAnnotation annotation. If it is nil, the annotation is ignored.
+	ClusterIdentity *ClusterIdentity
+	// EnforceSoakTime holds the updates of the enforce mode policy templates until the previous version of each policy
+	// template was Compliant for this long, so that a broken policy change doesn't roll out to the whole fleet at
+	// once. If it is zero, the updates are applied right away.
+	EnforceSoakTime time.Duration
+	// Engines are the policy engine adapters which translate the policy templates of the kinds they evaluate before
+	// they are applied and clean up after their objects are deleted. If it is nil, the policy templates are applied as
+	// is.
+	Engines *engines.Registry
+	// Concurrency adapts the number of concurrent reconciles to the CPU and memory usage of the container. If it is
+	// nil, up to ConcurrentReconciles policies are reconciled at a time.
+	Concurrency *utils.AdaptiveConcurrency
+	// ConcurrentReconciles is the number of policies reconciled at a time when the Concurrency is nil. The reconciles
+	// of the same policy are never concurrent. It defaults to one.
+	ConcurrentReconciles int
+	// ConfigurationPolicyDefaults sets the spec fields of the ConfigurationPolicy templates which they don't set. If it
+	// is nil, the ConfigurationPolicy templates are applied as is.
+	ConfigurationPolicyDefaults *ConfigurationPolicyDefaults
+	// ApplyPreview emits an event previewing the updates of the enforce mode policy template objects and delays them.
+	// If it is nil, the updates are applied right away.
+	ApplyPreview *ApplyPreview
+	// ApplySpread spreads the updates of the enforce mode policy template objects over a window. If it is nil, the
+	// updates are applied right away.
+	ApplySpread *ApplySpread
+	// TemplateKinds describes the handling of the policy template kinds, such as which kinds support Hub templates and
+	// the kinds they depend on. If it is nil, the DefaultTemplateKinds are used.
+	TemplateKinds *TemplateKindRegistry
+	// StrictTemplateValidation rejects the policy template objects with fields unknown to the schema of their kind
+	// with a permanent template error, rather than letting the API server silently drop the fields.
+	StrictTemplateValidation bool
+	// resync tracks the trigger-update annotation of the policies to force the updates of their template objects when
+	// it changes
+	resync utils.ResyncTracker
+	// templateObjects serializes the lookup and creation of each policy template object across the concurrent
+	// reconciles, so that of two policies with a policy template of the same object, one creates it and the other
+	// reports the name conflict
+	templateObjects utils.KeyedLock
 }
 
 // Reconcile reads that state of the cluster for a Policy object and makes changes based on the state read
@@ -68,9 +188,17 @@ type PolicyReconciler struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	ctx = utils.WithController(ctx, ControllerName)
+
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling the Policy")
 
+	if !r.NamespaceGuard.Available() {
+		reqLogger.Info("The cluster namespace is unavailable, pausing the reconcile")
+
+		return reconcile.Result{RequeueAfter: utils.NamespaceUnavailableRequeue}, nil
+	}
+
 	// Fetch the Policy instance
 	instance := &policiesv1.Policy{}
 
@@ -82,6 +210,26 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			// Return and don't requeue
 			reqLogger.Info("Policy not found, may have been deleted, reconciliation completed")
 
+			if r.RBACReport {
+				r.rbacReport.update(request.String(), nil)
+			}
+
+			if r.ConfigMapResolver != nil {
+				r.ConfigMapResolver.track(request.NamespacedName, nil)
+			}
+
+			r.TemplateWatcher.Track(ControllerName, request.NamespacedName, nil)
+			r.resync.Forget(request.NamespacedName)
+			r.ApplyPreview.forget(request.NamespacedName)
+			r.ApplySpread.forget(request.NamespacedName)
+
+			err = r.deleteOrphanedPlacedObjects(ctx, request.NamespacedName)
+			if err != nil {
+				reqLogger.Error(err, "Failed to delete the placed policy template objects of the deleted policy")
+
+				return reconcile.Result{}, err
+			}
+
 			return reconcile.Result{}, nil
 		}
 
@@ -91,32 +239,51 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, err
 	}
 
+	forceResync := r.resync.Requested(instance)
+	if forceResync {
+		reqLogger.Info("A resync of the policy was requested with the trigger-update annotation")
+
+		r.Recorder.Event(instance, "Normal", "PolicyResync",
+			fmt.Sprintf("Policy %s templates are resynced as requested with the %s annotation", instance.GetName(),
+				utils.TriggerUpdateAnnotation))
+	}
+
 	var rMapper meta.RESTMapper
 	var dClient dynamic.Interface
 
 	if len(instance.Spec.PolicyTemplates) > 0 {
-		// initialize restmapper
-		clientset := kubernetes.NewForConfigOrDie(r.Config)
-		dd := clientset.Discovery()
-
-		apigroups, err := restmapper.GetAPIGroupResources(dd)
+		rMapper, dClient, err = r.templateClients()
 		if err != nil {
-			reqLogger.Error(err, "Failed to create restmapper")
+			reqLogger.Error(err, "Failed to create the clients for the policy templates")
 
 			return reconcile.Result{}, err
 		}
+	} else {
+		reqLogger.Info("Spec.PolicyTemplates is empty, nothing to reconcile")
+
+		err = r.updateInventory(ctx, instance, nil)
+		if err != nil {
+			reqLogger.Error(err, "Failed to update the template inventory annotation on the policy")
 
-		rMapper = restmapper.NewDiscoveryRESTMapper(apigroups)
+			return reconcile.Result{}, err
+		}
 
-		// initialize dynamic client
-		dClient, err = dynamic.NewForConfig(r.Config)
+		err = r.updateTerminalErrors(ctx, instance, nil)
 		if err != nil {
-			reqLogger.Error(err, "Failed to create dynamic client")
+			reqLogger.Error(err, "Failed to update the template errors annotation on the policy")
 
 			return reconcile.Result{}, err
 		}
-	} else {
-		reqLogger.Info("Spec.PolicyTemplates is empty, nothing to reconcile")
+
+		if r.RBACReport {
+			r.rbacReport.update(request.String(), nil)
+		}
+
+		if r.ConfigMapResolver != nil {
+			r.ConfigMapResolver.track(request.NamespacedName, nil)
+		}
+
+		r.TemplateWatcher.Track(ControllerName, request.NamespacedName, nil)
 
 		return reconcile.Result{}, nil
 	}
@@ -126,16 +293,43 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 	// As a quirk of the error handling, only the last occurring error is "returned" by Reconcile.
 	var resultError error
 
+	// The resources of the policy templates, used for the RBAC report
+	templateResources := map[schema.GroupVersionResource]bool{}
+	// The Hub ConfigMaps referenced by the policy templates
+	configMapRefs := map[string]bool{}
+	// The objects created from the policy templates, recorded on the policy
+	inventory := []utils.InventoryEntry{}
+	// The checksums of the policy templates whose objects match them, by policy template name
+	checksums := map[string]string{}
+	// The template errors, reported in a single event once all the policy templates are processed
+	templateErrs := newTemplateErrorBatch(instance)
+	// The result of verifying the policy, which is only done if it has enforce mode policy templates
+	var verifyErr error
+	verified := false
+	// The values of the cluster identity variables, only loaded if a policy template uses them
+	var clusterIdentityValues map[string]string
+	// When to reconcile again to apply the policy template updates held by the enforce soak time
+	var requeueAfter time.Duration
+
+	// The policy templates are applied after those of the kinds they depend on, and held until these are Compliant
+	templateKinds := templateGroupKinds(instance.Spec.PolicyTemplates)
+	dependencies := r.TemplateKinds.newTemplateDependencies(templateKinds)
+
 	// PolicyTemplates is not empty
 	// loop through policy templates
-	for tIndex, policyT := range instance.Spec.PolicyTemplates {
-		object, gvk, err := unstructured.UnstructuredJSONScheme.Decode(policyT.ObjectDefinition.Raw, nil, nil)
+	for _, tIndex := range r.TemplateKinds.applyOrder(templateKinds) {
+		policyT := instance.Spec.PolicyTemplates[tIndex]
+		rawObjectDefinition := policyT.ObjectDefinition.Raw
+
+		object, gvk, err := unstructured.UnstructuredJSONScheme.Decode(rawObjectDefinition, nil, nil)
 		if err != nil {
-			resultError = err
-			errMsg := fmt.Sprintf("Failed to decode policy template with err: %s", err)
+			tErr := syncerrors.WithCause(
+				syncerrors.ErrTemplateDecode, fmt.Sprintf("Failed to decode policy template with err: %s", err), err,
+			)
+			resultError = syncerrors.Prefer(resultError, tErr)
 
-			r.emitTemplateError(instance, tIndex, fmt.Sprintf("[template %v]", tIndex), errMsg)
-			reqLogger.Error(resultError, "Failed to decode the policy template", "templateIndex", tIndex)
+			r.emitTemplateError(templateErrs, tIndex, fmt.Sprintf("[template %v]", tIndex), tErr)
+			reqLogger.Error(err, "Failed to decode the policy template", "templateIndex", tIndex)
 
 			continue
 		}
@@ -146,28 +340,75 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		}
 
 		if tName == "" {
-			errMsg := fmt.Sprintf("Failed to get name from policy template at index %v", tIndex)
-			resultError = errors.NewBadRequest(errMsg)
+			tErr := syncerrors.New(
+				syncerrors.ErrTemplateMissingName,
+				fmt.Sprintf("Failed to get name from policy template at index %v", tIndex),
+			)
+			resultError = syncerrors.Prefer(resultError, tErr)
 
-			r.emitTemplateError(instance, tIndex, fmt.Sprintf("[template %v]", tIndex), errMsg)
-			reqLogger.Error(resultError, "Failed to process the policy template", "templateIndex", tIndex)
+			r.emitTemplateError(templateErrs, tIndex, fmt.Sprintf("[template %v]", tIndex), tErr)
+			reqLogger.Error(tErr, "Failed to process the policy template", "templateIndex", tIndex)
 
 			continue
 		}
 
 		tLogger := reqLogger.WithValues("template", tName)
 
+		if artifactRef := object.(metav1.Object).GetAnnotations()[OCIArtifactAnnotation]; artifactRef != "" {
+			rawObjectDefinition, err = r.resolveOCIArtifact(ctx, artifactRef, gvk, tName)
+			if err != nil {
+				tErr := syncerrors.WithCause(
+					syncerrors.ErrOCIArtifact,
+					fmt.Sprintf("Failed to resolve the OCI artifact %s: %s", artifactRef, err),
+					err,
+				)
+				resultError = syncerrors.Prefer(resultError, tErr)
+
+				r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+				tLogger.Error(err, "Failed to resolve the OCI artifact", "reference", artifactRef)
+
+				continue
+			}
+		}
+
+		if configMapRef := object.(metav1.Object).GetAnnotations()[ObjectDefinitionFromAnnotation]; configMapRef != "" {
+			// Track the ConfigMap even if it can't be resolved so that the policy is reconciled when it's fixed
+			if configMapName, _, err := parseConfigMapRef(configMapRef); err == nil {
+				configMapRefs[configMapName] = true
+			}
+
+			rawObjectDefinition, err = r.resolveConfigMapRef(ctx, configMapRef, gvk, tName)
+			if err != nil {
+				tErr := syncerrors.WithCause(
+					syncerrors.ErrConfigMapRef,
+					fmt.Sprintf("Failed to resolve the ConfigMap reference %s: %s", configMapRef, err),
+					err,
+				)
+				resultError = syncerrors.Prefer(resultError, tErr)
+
+				r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+				tLogger.Error(err, "Failed to resolve the ConfigMap reference", "reference", configMapRef)
+
+				continue
+			}
+		}
+
 		var rsrc schema.GroupVersionResource
 
 		mapping, err := rMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 
 		if mapping != nil {
 			rsrc = mapping.Resource
+			templateResources[rsrc] = true
 		} else {
-			resultError = err
-			errMsg := fmt.Sprintf("Mapping not found, please check if you have CRD deployed: %s", err)
+			tErr := syncerrors.WithCause(
+				syncerrors.ErrMappingNotFound,
+				fmt.Sprintf("Mapping not found, please check if you have CRD deployed: %s", err),
+				err,
+			)
+			resultError = syncerrors.Prefer(resultError, tErr)
 
-			r.emitTemplateError(instance, tIndex, tName, errMsg)
+			r.emitTemplateError(templateErrs, tIndex, tName, tErr)
 			tLogger.Error(err, "Could not find an API mapping for the object definition",
 				"group", gvk.Group,
 				"version", gvk.Version,
@@ -177,77 +418,183 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			continue
 		}
 
-		// reject if not configuration policy and has templates
-		if gvk.Kind != "ConfigurationPolicy" {
-			// if not configuration policies ,do a simple check for templates {{hub and reject
+		// reject if the kind doesn't support Hub templates and has templates
+		if !r.TemplateKinds.hubTemplatesAllowed(gvk.GroupKind()) {
+			// if the kind doesn't support them, do a simple check for templates {{hub and reject
 			// only checking for hub and not {{ as they could be valid cases where they are valid chars.
-			if strings.Contains(string(policyT.ObjectDefinition.Raw), "{{hub ") {
-				errMsg := fmt.Sprintf("Templates are not supported for kind : %s", gvk.Kind)
-				resultError = errors.NewBadRequest(errMsg)
+			if strings.Contains(string(rawObjectDefinition), "{{hub ") {
+				tErr := syncerrors.New(
+					syncerrors.ErrHubTemplatesUnsupported,
+					fmt.Sprintf("Templates are not supported for kind : %s", gvk.Kind),
+				)
+				resultError = syncerrors.Prefer(resultError, tErr)
 
-				r.emitTemplateError(instance, tIndex, tName, errMsg)
-				tLogger.Error(resultError, "Failed to process the policy template")
+				r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+				tLogger.Error(tErr, "Failed to process the policy template")
 
 				continue
 			}
 		}
 
 		// fetch resource
-		res := dClient.Resource(rsrc).Namespace(instance.GetNamespace())
+		tNamespace := r.templateNamespace(instance, mapping)
+		res := dClient.Resource(rsrc).Namespace(tNamespace)
 		tObjectUnstructured := &unstructured.Unstructured{}
-		err = json.Unmarshal(policyT.ObjectDefinition.Raw, tObjectUnstructured)
+		err = json.Unmarshal(rawObjectDefinition, tObjectUnstructured)
 
 		if err != nil {
-			resultError = err
-			errMsg := fmt.Sprintf("Failed to unmarshal the policy template: %s", err)
+			tErr := syncerrors.WithCause(
+				syncerrors.ErrTemplateUnmarshal, fmt.Sprintf("Failed to unmarshal the policy template: %s", err), err,
+			)
+			resultError = syncerrors.Prefer(resultError, tErr)
+
+			r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+			tLogger.Error(err, "Failed to unmarshal the policy template")
+
+			continue
+		}
+
+		if err := r.ClusterIdentity.inject(ctx, tObjectUnstructured, &clusterIdentityValues); err != nil {
+			resultError = syncerrors.Prefer(resultError, err)
 
-			r.emitTemplateError(instance, tIndex, tName, errMsg)
-			tLogger.Error(resultError, "Failed to unmarshal the policy template")
+			r.emitTemplateError(templateErrs, tIndex, tName, err)
+			tLogger.Error(err, "Failed to substitute the cluster identity in the policy template")
 
 			continue
 		}
 
+		setPruneObjectBehavior(r.TemplateKinds, instance, tObjectUnstructured)
+		r.ConfigurationPolicyDefaults.apply(r.TemplateKinds, tObjectUnstructured)
+
+		if tNamespace != instance.GetNamespace() {
+			tObjectUnstructured.SetNamespace(tNamespace)
+		}
+
+		if r.copyPolicyMetadata(instance) {
+			setPolicyMetadata(instance, tObjectUnstructured)
+		}
+
+		if err := r.ExecHooks.run(ctx, tObjectUnstructured); err != nil {
+			resultError = syncerrors.Prefer(resultError, err)
+
+			r.emitTemplateError(templateErrs, tIndex, tName, err)
+			tLogger.Error(err, "Failed to run the exec hook of the policy template")
+
+			continue
+		}
+
+		if err := r.Engines.Translate(tObjectUnstructured); err != nil {
+			tErr := syncerrors.WithCause(
+				syncerrors.ErrEngineTranslate,
+				fmt.Sprintf("Failed to translate the policy template for its policy engine: %s", err), err,
+			)
+			resultError = syncerrors.Prefer(resultError, tErr)
+
+			r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+			tLogger.Error(err, "Failed to translate the policy template for its policy engine")
+
+			continue
+		}
+
+		if r.Verifier != nil && enforcesTemplate(instance, tObjectUnstructured) {
+			// Only verify the policy once per reconcile
+			if !verified {
+				verifyErr = r.Verifier.Verify(instance)
+				verified = true
+			}
+
+			if verifyErr != nil {
+				// The policy is reconciled again when its signature annotation changes, so this is not retried
+				tErr := syncerrors.WithCause(
+					syncerrors.ErrVerificationFailed,
+					fmt.Sprintf("Failed to verify the policy before enforcing the policy template: %s", verifyErr),
+					verifyErr,
+				)
+
+				// The error skips the deletion of the stale objects, and the object previously applied is kept in
+				// the inventory, so that it is frozen rather than removed
+				resultError = syncerrors.Prefer(resultError, tErr)
+
+				if entry, found := previousInventoryEntry(instance, tObjectUnstructured, tNamespace); found {
+					inventory = append(inventory, entry)
+				}
+
+				r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+				tLogger.Error(verifyErr, "Refusing to apply the enforce mode policy template")
+
+				continue
+			}
+		}
+
+		unlockObject := r.templateObjects.Lock(gvk.GroupKind().String() + "/" + tNamespace + "/" + tName)
+
 		eObject, err := res.Get(ctx, tName, metav1.GetOptions{})
+		if !errors.IsNotFound(err) {
+			unlockObject()
+		}
+
 		if err != nil {
 			if errors.IsNotFound(err) {
-				// not found should create it
-				plcOwnerReferences := *metav1.NewControllerRef(instance, schema.GroupVersionKind{
-					Group:   policiesv1.SchemeGroupVersion.Group,
-					Version: policiesv1.SchemeGroupVersion.Version,
-					Kind:    policiesv1.Kind,
-				})
-				labels := tObjectUnstructured.GetLabels()
-
-				if labels == nil {
-					labels = map[string]string{
-						"cluster-name":               instance.GetLabels()[common.ClusterNameLabel],
-						common.ClusterNameLabel:      instance.GetLabels()[common.ClusterNameLabel],
-						"cluster-namespace":          instance.GetLabels()[common.ClusterNamespaceLabel],
-						common.ClusterNamespaceLabel: instance.GetLabels()[common.ClusterNamespaceLabel],
+				if pending := dependencies.unmet(gvk.GroupKind()); pending != "" {
+					unlockObject()
+
+					if requeueAfter == 0 || dependencyRequeueInterval < requeueAfter {
+						requeueAfter = dependencyRequeueInterval
 					}
-				} else {
-					labels["cluster-name"] = instance.GetLabels()[common.ClusterNameLabel]
-					labels[common.ClusterNameLabel] = instance.GetLabels()[common.ClusterNameLabel]
-					labels["cluster-namespace"] = instance.GetLabels()[common.ClusterNamespaceLabel]
-					labels[common.ClusterNamespaceLabel] = instance.GetLabels()[common.ClusterNamespaceLabel]
-				}
 
-				tObjectUnstructured.SetLabels(labels)
-				tObjectUnstructured.SetOwnerReferences([]metav1.OwnerReference{plcOwnerReferences})
+					// The entry of the missing object records that it is held, so that it is only reported once
+					entry := inventoryEntry(tObjectUnstructured)
+					if tNamespace != instance.GetNamespace() {
+						entry.Namespace = tNamespace
+					}
+
+					entry.Pending = pending
+					inventory = append(inventory, entry)
+
+					if !wasPending(instance, entry) {
+						r.Recorder.Event(instance, "Normal", "PolicyTemplatePending",
+							fmt.Sprintf("Policy template %s is Pending: %s", tName, pending))
+					}
+
+					tLogger.Info("Holding the creation of the policy template until its dependencies are Compliant")
+
+					continue
+				}
 
+				// not found should create it
+				setTemplateOwnership(instance, tObjectUnstructured)
 				overrideRemediationAction(instance, tObjectUnstructured)
+				setLastApplied(tObjectUnstructured)
+
+				checksum := templateChecksum(tObjectUnstructured)
+				setTemplateChecksum(tObjectUnstructured, checksum)
+				setCorrelationID(instance, tObjectUnstructured)
+
+				err = r.ApplyTimeouts.apply(ctx, gvk.Kind, func(ctx context.Context) error {
+					_, err := res.Create(
+						ctx, tObjectUnstructured, metav1.CreateOptions{FieldValidation: r.fieldValidation()},
+					)
+
+					return err
+				})
+
+				unlockObject()
 
-				_, err = res.Create(ctx, tObjectUnstructured, metav1.CreateOptions{})
 				if err != nil {
-					resultError = err
-					errMsg := fmt.Sprintf("Failed to create policy template: %s", err)
+					tErr := r.applyError(
+						syncerrors.ErrTemplateCreate, fmt.Sprintf("Failed to create policy template: %s", err), err,
+					)
+					resultError = syncerrors.Prefer(resultError, tErr)
 
-					r.emitTemplateError(instance, tIndex, tName, errMsg)
-					tLogger.Error(resultError, "Failed to create policy template")
+					r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+					tLogger.Error(err, "Failed to create policy template")
 
 					continue
 				}
 
+				inventory = append(inventory, inventoryEntry(tObjectUnstructured))
+				checksums[tName] = checksum
+
 				successMsg := fmt.Sprintf("Policy template %s created successfully", tName)
 				tLogger.Info("Policy template created successfully", "PolicyTemplateName", tName)
 
@@ -260,12 +607,16 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 				continue
 			} else {
 				// a different error getting template object from cluster
-				resultError = err
-				errMsg := fmt.Sprintf("Failed to get the object in the policy template: %s", err)
+				tErr := syncerrors.WithCause(
+					syncerrors.ErrTemplateGet,
+					fmt.Sprintf("Failed to get the object in the policy template: %s", err),
+					err,
+				)
+				resultError = syncerrors.Prefer(resultError, tErr)
 
-				r.emitTemplateError(instance, tIndex, tName, errMsg)
+				r.emitTemplateError(templateErrs, tIndex, tName, tErr)
 				tLogger.Error(err, "Failed to get the object in the policy template",
-					"namespace", instance.GetNamespace(),
+					"namespace", tNamespace,
 					"kind", gvk.Kind,
 				)
 
@@ -273,45 +624,243 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			}
 		}
 
-		refName := eObject.GetOwnerReferences()[0].Name
+		refName, owned := templateObjectOwner(instance, eObject)
 		// violation if object reference and policy don't match
-		if instance.GetName() != refName {
-			errMsg := fmt.Sprintf(
+		if !owned {
+			tErr := syncerrors.New(syncerrors.ErrTemplateNameConflict, fmt.Sprintf(
 				"Template name must be unique. Policy template with kind: %s name: %s already exists in policy %s",
 				tObjectUnstructured.Object["kind"],
 				tName,
-				refName)
-			resultError = errors.NewBadRequest(errMsg)
+				refName))
+			resultError = syncerrors.Prefer(resultError, tErr)
 
-			r.emitTemplateError(instance, tIndex, tName, errMsg)
-			tLogger.Error(resultError, "Failed to create the policy template")
+			r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+			tLogger.Error(tErr, "Failed to create the policy template")
+
+			continue
+		}
+
+		entry := inventoryEntry(eObject)
+
+		dependencies.observe(eObject)
+
+		if pending := dependencies.unmet(gvk.GroupKind()); pending != "" {
+			entry.Pending = pending
+			inventory = append(inventory, entry)
+
+			if requeueAfter == 0 || dependencyRequeueInterval < requeueAfter {
+				requeueAfter = dependencyRequeueInterval
+			}
+
+			if !wasPending(instance, entry) {
+				r.Recorder.Event(instance, "Normal", "PolicyTemplatePending",
+					fmt.Sprintf("Policy template %s is Pending: %s", tName, pending))
+			}
+
+			tLogger.Info("Holding the update of the policy template until its dependencies are Compliant")
 
 			continue
 		}
 
 		overrideRemediationAction(instance, tObjectUnstructured)
+		setLastApplied(tObjectUnstructured)
+
+		checksum := templateChecksum(tObjectUnstructured)
+		setTemplateChecksum(tObjectUnstructured, checksum)
+
+		matches := templateObjectMatches(eObject, tObjectUnstructured)
+
+		if r.CreateOnlyTemplates && isCreateOnly(tObjectUnstructured) {
+			if !matches {
+				entry.Drifted = createOnlyDriftMessage
+
+				if !wasDrifted(instance, entry) {
+					r.Recorder.Event(instance, "Warning", "PolicyTemplateDrift",
+						fmt.Sprintf("Policy template %s has drifted: %s", tName, entry.Drifted))
+				}
+
+				tLogger.Info("The object of the create-only policy template differs from it, not updating it")
+			} else {
+				checksums[tName] = checksum
+			}
+
+			inventory = append(inventory, entry)
+
+			err = r.handleSyncSuccess(ctx, instance, tIndex, tName, "", res)
+			if err != nil {
+				resultError = err
+				tLogger.Error(resultError, "Error after confirming the create-only template exists (will requeue)")
+			}
+
+			continue
+		}
+
+		if remaining := enforceSoakRemaining(
+			instance, eObject, tObjectUnstructured, r.EnforceSoakTime, time.Now(),
+		); remaining > 0 && !matches {
+			entry.Pending = fmt.Sprintf(
+				"The update is held until the previous version of the policy template is Compliant for %s",
+				r.EnforceSoakTime,
+			)
+			inventory = append(inventory, entry)
+
+			if requeueAfter == 0 || remaining < requeueAfter {
+				requeueAfter = remaining
+			}
+
+			if !wasPending(instance, entry) {
+				r.Recorder.Event(instance, "Normal", "PolicyTemplatePending",
+					fmt.Sprintf("Policy template %s is Pending: %s", tName, entry.Pending))
+			}
+
+			tLogger.Info("Holding the update of the policy template for the enforce soak time",
+				"remaining", remaining.String())
+
+			continue
+		}
+
+		if !matches {
+			remaining, first := r.ApplyPreview.remaining(
+				request.NamespacedName, eObject, tObjectUnstructured, checksum, time.Now(),
+			)
+
+			if first {
+				message := previewMessage(tName, eObject, tObjectUnstructured, remaining)
+
+				r.Recorder.Event(instance, "Normal", "PolicyTemplatePreview", message)
+				tLogger.Info("Previewing the update of the enforce mode policy template", "message", message)
+			}
+
+			if remaining > 0 {
+				inventory = append(inventory, entry)
+
+				if requeueAfter == 0 || remaining < requeueAfter {
+					requeueAfter = remaining
+				}
+
+				continue
+			}
+
+			remaining = r.ApplySpread.remaining(
+				request.NamespacedName, eObject, tObjectUnstructured, checksum, time.Now(),
+			)
+			if remaining > 0 {
+				inventory = append(inventory, entry)
+
+				if requeueAfter == 0 || remaining < requeueAfter {
+					requeueAfter = remaining
+				}
+
+				tLogger.Info(
+					"Spreading the update of the enforce mode policy template", "remaining", remaining.String(),
+				)
+
+				continue
+			}
+		}
+
+		inventory = append(inventory, entry)
+
+		// The annotation is ignored while the feature gate is disabled, so the object is updated like the others
+		if !matches && isCreateOnly(tObjectUnstructured) {
+			r.Recorder.Event(instance, "Warning", "PolicyTemplateCreateOnlyIgnored", fmt.Sprintf(
+				"Policy template %s has the %s annotation, but its object is updated since the %s feature gate is "+
+					"disabled", tName, CreateOnlyAnnotation, createOnlyFeatureGate,
+			))
+			tLogger.Info("Updating the object of the create-only policy template since the feature gate is disabled")
+		}
+
 		// got object, need to compare both spec and annotation and update
 		eObjectUnstructured := eObject.UnstructuredContent()
-		if (!equality.Semantic.DeepEqual(eObjectUnstructured["spec"], tObjectUnstructured.Object["spec"])) ||
-			(!equality.Semantic.DeepEqual(eObject.GetAnnotations(), tObjectUnstructured.GetAnnotations())) {
+		// The objects created before the checksum label was introduced are updated to add it
+		if forceResync || !matches || eObject.GetLabels()[TemplateChecksumLabel] != checksum {
 			// doesn't match
+			if r.recreateOnChange(eObject, tObjectUnstructured) {
+				tLogger.Info("The spec of the policy template changed, will delete and recreate the object")
+
+				err = r.recreateTemplateObject(ctx, instance, res, eObject, tObjectUnstructured)
+				if err != nil {
+					tErr := r.applyError(
+						syncerrors.ErrTemplateUpdate,
+						fmt.Sprintf("Failed to recreate policy template %s: %s", tName, err),
+						err,
+					)
+					resultError = syncerrors.Prefer(resultError, tErr)
+
+					r.emitTemplateError(templateErrs, tIndex, tName, tErr)
+					tLogger.Error(err, "Failed to recreate the policy template")
+
+					continue
+				}
+
+				templateRecreationCounter.WithLabelValues(gvk.Kind, recreateReasonSpecChange).Inc()
+
+				checksums[tName] = checksum
+				successMsg := fmt.Sprintf("Policy template %s was recreated because its spec changed", tName)
+
+				err = r.handleSyncSuccess(ctx, instance, tIndex, tName, successMsg, res)
+				if err != nil {
+					resultError = err
+					tLogger.Error(resultError, "Error after recreating template (will requeue)")
+				}
+
+				tLogger.Info("Existing object has been recreated")
+
+				continue
+			}
+
 			tLogger.Info("Existing object and template didn't match, will update")
 
-			eObjectUnstructured["spec"] = tObjectUnstructured.Object["spec"]
+			eObjectUnstructured["spec"] = runtime.DeepCopyJSONValue(tObjectUnstructured.Object["spec"])
 
 			eObject.SetAnnotations(tObjectUnstructured.GetAnnotations())
+			setTemplateChecksum(eObject, checksum)
+			setCorrelationID(instance, eObject)
+
+			err = r.ApplyTimeouts.apply(ctx, gvk.Kind, func(ctx context.Context) error {
+				_, err := res.Update(ctx, eObject, metav1.UpdateOptions{FieldValidation: r.fieldValidation()})
+
+				return err
+			})
+			if err != nil && isImmutableFieldError(err) && r.recreateOnImmutableChange(tObjectUnstructured) {
+				tLogger.Info("An immutable field of the policy template changed, will delete and recreate the object")
+
+				err = r.recreateTemplateObject(ctx, instance, res, eObject, tObjectUnstructured)
+				if err == nil {
+					templateRecreationCounter.WithLabelValues(gvk.Kind, recreateReasonImmutableChange).Inc()
+
+					checksums[tName] = checksum
+					successMsg := fmt.Sprintf(
+						"Policy template %s was recreated because an immutable field changed", tName,
+					)
+
+					err = r.handleSyncSuccess(ctx, instance, tIndex, tName, successMsg, res)
+					if err != nil {
+						resultError = err
+						tLogger.Error(resultError, "Error after recreating template (will requeue)")
+					}
+
+					tLogger.Info("Existing object has been recreated")
+
+					continue
+				}
+			}
 
-			_, err = res.Update(ctx, eObject, metav1.UpdateOptions{})
 			if err != nil {
-				resultError = err
-				errMsg := fmt.Sprintf("Failed to update policy template %s: %s", tName, err)
+				tErr := r.applyError(
+					syncerrors.ErrTemplateUpdate,
+					fmt.Sprintf("Failed to update policy template %s: %s", tName, err),
+					err,
+				)
+				resultError = syncerrors.Prefer(resultError, tErr)
 
-				r.emitTemplateError(instance, tIndex, tName, errMsg)
+				r.emitTemplateError(templateErrs, tIndex, tName, tErr)
 				tLogger.Error(err, "Failed to update the policy template")
 
 				continue
 			}
 
+			checksums[tName] = checksum
 			successMsg := fmt.Sprintf("Policy template %s was updated successfully", tName)
 
 			err = r.handleSyncSuccess(ctx, instance, tIndex, tName, successMsg, res)
@@ -322,6 +871,8 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 
 			tLogger.Info("Existing object has been updated")
 		} else {
+			checksums[tName] = checksum
+
 			err = r.handleSyncSuccess(ctx, instance, tIndex, tName, "", res)
 			if err != nil {
 				resultError = err
@@ -332,12 +883,383 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		}
 	}
 
+	r.emitTemplateErrorSummary(templateErrs)
+
+	if r.RBACReport {
+		r.rbacReport.update(request.String(), templateResources)
+	}
+
+	if r.ConfigMapResolver != nil {
+		r.ConfigMapResolver.track(request.NamespacedName, configMapRefs)
+	}
+
+	r.TemplateWatcher.Track(ControllerName, request.NamespacedName, templateResources)
+
+	// Only delete the stale placed objects and the objects superseded by a kind change when all the policy templates
+	// synced so that the objects of the policy templates that failed to sync are kept
+	if resultError == nil {
+		err = r.deleteStalePlacedObjects(ctx, instance, inventory, rMapper, dClient)
+		if err != nil {
+			resultError = err
+			reqLogger.Error(err, "Failed to delete the stale placed policy template objects")
+		}
+
+		err = r.deleteSupersededObjects(ctx, instance, inventory, rMapper, dClient)
+		if err != nil {
+			reqLogger.Error(err, "Failed to delete the policy template objects superseded by a kind change")
+
+			// Keep the previous inventory so that the superseded objects are still known on the retry
+			return reconcile.Result{}, err
+		}
+	}
+
+	for i := range inventory {
+		inventory[i].Checksum = checksums[inventory[i].Name]
+	}
+
+	err = r.updateInventory(ctx, instance, inventory)
+	if err != nil {
+		resultError = err
+		reqLogger.Error(err, "Failed to update the template inventory annotation on the policy")
+	}
+
+	err = r.updateTerminalErrors(ctx, instance, templateErrs.terminal)
+	if err != nil {
+		resultError = err
+		reqLogger.Error(err, "Failed to update the template errors annotation on the policy")
+	}
+
 	reqLogger.Info("Completed the reconciliation")
 
-	return reconcile.Result{}, resultError
+	return reconcile.Result{RequeueAfter: requeueAfter}, resultError
+}
+
+// resolveOCIArtifact fetches the object definition referenced by the OCI artifact annotation and verifies that it
+// defines the same kind and name as the placeholder object definition in the policy template.
+func (r *PolicyReconciler) resolveOCIArtifact(
+	ctx context.Context, artifactRef string, gvk *schema.GroupVersionKind, tName string,
+) ([]byte, error) {
+	if r.OCIFetcher == nil {
+		return nil, errors.NewBadRequest("policy templates delivered as OCI artifacts are not enabled")
+	}
+
+	content, err := r.OCIFetcher.Fetch(ctx, artifactRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolvedObjectDefinition(content, "the OCI artifact", gvk, tName)
+}
+
+// resolveConfigMapRef fetches the object definition referenced by the ConfigMap reference annotation and verifies
+// that it defines the same kind and name as the placeholder object definition in the policy template.
+func (r *PolicyReconciler) resolveConfigMapRef(
+	ctx context.Context, configMapRef string, gvk *schema.GroupVersionKind, tName string,
+) ([]byte, error) {
+	if r.ConfigMapResolver == nil {
+		return nil, errors.NewBadRequest("policy templates referencing a Hub ConfigMap are not enabled")
+	}
+
+	content, err := r.ConfigMapResolver.Resolve(ctx, configMapRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolvedObjectDefinition(content, "the ConfigMap", gvk, tName)
+}
+
+// resolvedObjectDefinition converts the input YAML or JSON object definition from an external source to JSON and
+// verifies that it defines the same kind and name as the placeholder object definition in the policy template.
+func resolvedObjectDefinition(
+	content []byte, sourceDesc string, gvk *schema.GroupVersionKind, tName string,
+) ([]byte, error) {
+	rawObjectDefinition, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("%s is not valid YAML or JSON: %s", sourceDesc, err))
+	}
+
+	resolvedObj := &unstructured.Unstructured{}
+
+	err = json.Unmarshal(rawObjectDefinition, resolvedObj)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("%s is not a valid object definition: %s", sourceDesc, err))
+	}
+
+	if resolvedObj.GroupVersionKind() != *gvk || resolvedObj.GetName() != tName {
+		return nil, errors.NewBadRequest(fmt.Sprintf(
+			"%s defines %s %s but the policy template defines %s %s",
+			sourceDesc, resolvedObj.GetKind(), resolvedObj.GetName(), gvk.Kind, tName,
+		))
+	}
+
+	return rawObjectDefinition, nil
+}
+
+// templateClients returns the REST mapper and the dynamic client used to manage the policy template objects.
+func (r *PolicyReconciler) templateClients() (meta.RESTMapper, dynamic.Interface, error) {
+	// initialize restmapper
+	clientset := kubernetes.NewForConfigOrDie(r.Config)
+	dd := clientset.Discovery()
+
+	apigroups, err := restmapper.GetAPIGroupResources(dd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the restmapper: %w", err)
+	}
+
+	// initialize dynamic client
+	dClient, err := dynamic.NewForConfig(r.Config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the dynamic client: %w", err)
+	}
+
+	return restmapper.NewDiscoveryRESTMapper(apigroups), dClient, nil
+}
+
+// inventoryEntry returns the inventory entry of the input policy template object. The namespace is only recorded for
+// the objects placed outside the namespace of the policy.
+func inventoryEntry(obj *unstructured.Unstructured) utils.InventoryEntry {
+	entry := utils.InventoryEntry{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Name: obj.GetName()}
+
+	if _, placed := obj.GetLabels()[utils.PlacedByPolicyLabel]; placed {
+		entry.Namespace = obj.GetNamespace()
+	}
+
+	return entry
+}
+
+// wasPending returns true if the input inventory entry was already recorded as Pending for the same reason on the
+// input policy, so that a policy template held on every reconcile is only reported when it becomes Pending.
+func wasPending(instance *policiesv1.Policy, entry utils.InventoryEntry) bool {
+	previous, err := utils.PolicyInventory(instance)
+	if err != nil {
+		return false
+	}
+
+	for _, previousEntry := range previous {
+		if previousEntry.Pending == entry.Pending && previousEntry.Identity() == entry.Identity() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// previousInventoryEntry returns the entry of the object of the input policy template in the inventory recorded on
+// the input policy, so that the object of a policy template which isn't synced, such as when the policy fails its
+// verification, stays in the inventory. False is returned if the object isn't in the inventory.
+func previousInventoryEntry(
+	instance *policiesv1.Policy, tObject *unstructured.Unstructured, tNamespace string,
+) (utils.InventoryEntry, bool) {
+	previous, err := utils.PolicyInventory(instance)
+	if err != nil {
+		return utils.InventoryEntry{}, false
+	}
+
+	entry := inventoryEntry(tObject)
+	if tNamespace != instance.GetNamespace() {
+		entry.Namespace = tNamespace
+	}
+
+	for _, previousEntry := range previous {
+		if previousEntry.Identity() == entry.Identity() {
+			return previousEntry, true
+		}
+	}
+
+	return utils.InventoryEntry{}, false
+}
+
+// templatePendingCondition returns the TemplatePending condition listing the policy templates held as Pending in the
+// input inventory, or nil if none is held.
+func templatePendingCondition(inventory []utils.InventoryEntry) *metav1.Condition {
+	pending := []string{}
+
+	for _, entry := range inventory {
+		if entry.Pending != "" {
+			pending = append(pending, fmt.Sprintf("Policy template %s is Pending: %s", entry.Name, entry.Pending))
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	return &metav1.Condition{
+		Type:               utils.TemplatePendingCondition,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(time.Now().UTC().Truncate(time.Second)),
+		Reason:             "PolicyTemplatePending",
+		Message:            strings.Join(pending, "; "),
+	}
+}
+
+// updateInventory sets the template inventory and template checksum annotations on the policy to the input inventory
+// and its checksum if they changed, along with the utils.TemplatePendingAnnotation annotation reporting the policy
+// templates of the inventory held as Pending.
+func (r *PolicyReconciler) updateInventory(
+	ctx context.Context, instance *policiesv1.Policy, inventory []utils.InventoryEntry,
+) error {
+	value, err := utils.InventoryAnnotationValue(inventory)
+	if err != nil {
+		return err
+	}
+
+	checksum := utils.InventoryChecksum(inventory)
+
+	current, found := instance.GetAnnotations()[utils.TemplateInventoryAnnotation]
+	currentChecksum := instance.GetAnnotations()[utils.TemplateChecksumAnnotation]
+
+	pendingPlc := instance.DeepCopy()
+
+	pendingChanged, err := utils.SetConditionAnnotation(
+		pendingPlc, utils.TemplatePendingAnnotation, templatePendingCondition(inventory),
+	)
+	if err != nil {
+		return err
+	}
+
+	if !pendingChanged && currentChecksum == checksum && (current == value || (!found && len(inventory) == 0)) {
+		return nil
+	}
+
+	// A null value removes the annotation
+	var checksumValue, pendingValue interface{}
+	if checksum != "" {
+		checksumValue = checksum
+	}
+
+	if pending, ok := pendingPlc.GetAnnotations()[utils.TemplatePendingAnnotation]; ok {
+		pendingValue = pending
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				utils.TemplateInventoryAnnotation: value,
+				utils.TemplateChecksumAnnotation:  checksumValue,
+				utils.TemplatePendingAnnotation:   pendingValue,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.Patch(ctx, instance, client.RawPatch(types.MergePatchType, patch))
+}
+
+// setTemplateOwnership sets the cluster labels and the owner reference of the input policy on the policy template
+// object before it is created. The objects placed outside the namespace of the policy get the placed by policy labels
+// instead of the owner reference.
+func setTemplateOwnership(instance *policiesv1.Policy, tObjectUnstructured *unstructured.Unstructured) {
+	plcOwnerReferences := *metav1.NewControllerRef(instance, schema.GroupVersionKind{
+		Group:   policiesv1.SchemeGroupVersion.Group,
+		Version: policiesv1.SchemeGroupVersion.Version,
+		Kind:    policiesv1.Kind,
+	})
+	labels := tObjectUnstructured.GetLabels()
+
+	if labels == nil {
+		labels = map[string]string{
+			"cluster-name":               instance.GetLabels()[common.ClusterNameLabel],
+			common.ClusterNameLabel:      instance.GetLabels()[common.ClusterNameLabel],
+			"cluster-namespace":          instance.GetLabels()[common.ClusterNamespaceLabel],
+			common.ClusterNamespaceLabel: instance.GetLabels()[common.ClusterNamespaceLabel],
+		}
+	} else {
+		labels["cluster-name"] = instance.GetLabels()[common.ClusterNameLabel]
+		labels[common.ClusterNameLabel] = instance.GetLabels()[common.ClusterNameLabel]
+		labels["cluster-namespace"] = instance.GetLabels()[common.ClusterNamespaceLabel]
+		labels[common.ClusterNamespaceLabel] = instance.GetLabels()[common.ClusterNamespaceLabel]
+	}
+
+	if isPlaced(instance, tObjectUnstructured) {
+		labels[utils.PlacedByPolicyLabel] = instance.GetName()
+		labels[utils.PlacedByPolicyNamespaceLabel] = instance.GetNamespace()
+
+		tObjectUnstructured.SetLabels(labels)
+
+		return
+	}
+
+	tObjectUnstructured.SetLabels(labels)
+	tObjectUnstructured.SetOwnerReferences([]metav1.OwnerReference{plcOwnerReferences})
+}
+
+// isImmutableFieldError returns true if the input error is the API server rejecting an update because an immutable
+// field changed, based on the causes of the error rather than its full message.
+func isImmutableFieldError(err error) bool {
+	if !errors.IsInvalid(err) {
+		return false
+	}
+
+	var statusErr errors.APIStatus
+	if !goerrors.As(err, &statusErr) || statusErr.Status().Details == nil {
+		return false
+	}
+
+	for _, cause := range statusErr.Status().Details.Causes {
+		if strings.HasSuffix(cause.Message, validation.FieldImmutableErrorMsg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recreateOnImmutableChange returns true if the policy template object may be deleted and recreated when an immutable
+// field changes, either because it is enabled for all policy templates or through the annotation.
+func (r *PolicyReconciler) recreateOnImmutableChange(tObjectUnstructured *unstructured.Unstructured) bool {
+	if r.RecreateOnImmutableChange {
+		return true
+	}
+
+	return strings.EqualFold(tObjectUnstructured.GetAnnotations()[RecreateOnImmutableChangeAnnotation], "true")
+}
+
+// recreateTemplateObject deletes the existing policy template object and creates it from the policy template. The
+// deletion is preconditioned on the UID and resource version so that an object replaced or modified in the meantime is
+// not deleted.
+func (r *PolicyReconciler) recreateTemplateObject(
+	ctx context.Context,
+	instance *policiesv1.Policy,
+	res dynamic.ResourceInterface,
+	eObject *unstructured.Unstructured,
+	tObjectUnstructured *unstructured.Unstructured,
+) error {
+	kind := tObjectUnstructured.GetKind()
+
+	err := r.ApplyTimeouts.apply(ctx, kind, func(ctx context.Context) error {
+		return res.Delete(
+			ctx, eObject.GetName(), metav1.DeleteOptions{Preconditions: utils.UnchangedPreconditions(eObject)},
+		)
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf(
+			"failed to delete the policy template object to recreate it: %w", utils.DeleteConflictError(eObject, err),
+		)
+	}
+
+	setTemplateOwnership(instance, tObjectUnstructured)
+	setCorrelationID(instance, tObjectUnstructured)
+
+	err = r.ApplyTimeouts.apply(ctx, kind, func(ctx context.Context) error {
+		_, err := res.Create(ctx, tObjectUnstructured, metav1.CreateOptions{FieldValidation: r.fieldValidation()})
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to recreate the policy template object: %w", err)
+	}
+
+	return nil
 }
 
 func overrideRemediationAction(instance *policiesv1.Policy, tObjectUnstructured *unstructured.Unstructured) {
+	if strings.EqualFold(instance.GetAnnotations()[SkipRemediationActionOverrideAnnotation], "true") {
+		return
+	}
+
 	// override RemediationAction only when it is set on parent
 	if instance.Spec.RemediationAction != "" {
 		if spec, ok := tObjectUnstructured.Object["spec"]; ok {
@@ -351,19 +1273,53 @@ func overrideRemediationAction(instance *policiesv1.Policy, tObjectUnstructured
 
 // emitTemplateError performs actions that ensure correct reporting of template errors in the
 // policy framework. If the policy's status already reflects the current error, then no actions
-// are taken.
-func (r *PolicyReconciler) emitTemplateError(pol *policiesv1.Policy, tIndex int, tName, errMsg string) {
+// are taken. Otherwise, the error is added to the input batch to be reported in the summarized
+// event of emitTemplateErrorSummary.
+func (r *PolicyReconciler) emitTemplateError(batch *templateErrorBatch, tIndex int, tName string, tErr error) {
+	pol := batch.policy
+	errMsg := tErr.Error()
+
+	if syncerrors.Permanent(tErr) {
+		reason := syncerrors.Reason(tErr)
+		batch.terminal[tName] = reason
+
+		// the error was already reported for this generation of the policy, such as before a restart
+		if batch.known[tName] == reason {
+			return
+		}
+	}
+
 	// check if the error is already present in the policy status - if so, return early
 	if strings.Contains(getLatestStatusMessage(pol, tIndex), errMsg) {
 		return
 	}
 
-	// emit the non-compliance event
-	policyComplianceReason := fmt.Sprintf(policyFmtStr, pol.GetNamespace(), tName)
-	r.Recorder.Event(pol, "Warning", policyComplianceReason, "NonCompliant; template-error; "+errMsg)
+	// emit the non-compliance event, with the structured fields of the policy template when it can be decoded
+	policyComplianceReason := utils.ComplianceEventReason(pol.GetNamespace(), tName)
+	fields := utils.ComplianceEventFields{PolicyGeneration: pol.GetGeneration()}
+
+	if tIndex >= 0 && tIndex < len(pol.Spec.PolicyTemplates) {
+		_, fields = utils.TemplateEventFields(pol, pol.Spec.PolicyTemplates[tIndex])
+	}
+
+	r.Recorder.AnnotatedEventf(
+		pol, fields.Annotations(), "Warning", policyComplianceReason, "%s", "NonCompliant; template-error; "+errMsg,
+	)
 
-	// emit an informational event
-	r.Recorder.Event(pol, "Warning", "PolicyTemplateSync", errMsg)
+	// emit an informational event per template only when debugging since they are summarized
+	if log.V(1).Enabled() {
+		r.Recorder.Event(pol, "Warning", "PolicyTemplateSync", errMsg)
+	}
+
+	batch.add(tName, syncerrors.Reason(tErr))
+}
+
+// emitTemplateErrorSummary emits a single informational event listing the failing policy templates
+// of the input batch with their reason codes. Nothing is emitted if the batch is empty.
+func (r *PolicyReconciler) emitTemplateErrorSummary(batch *templateErrorBatch) {
+	if summary := batch.summary(); summary != "" {
+		r.Recorder.Event(batch.policy, "Warning", "PolicyTemplateSync", summary)
+	}
 }
 
 // handleSyncSuccess performs common actions that should be run whenever a template is in sync,
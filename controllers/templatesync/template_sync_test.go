@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestIsImmutableFieldError(t *testing.T) {
+	RegisterTestingT(t)
+
+	gk := schema.GroupKind{Group: "constraints.gatekeeper.sh", Kind: "K8sRequiredLabels"}
+
+	immutableErr := errors.NewInvalid(gk, "ns-must-have-gk", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "match"), "", "field is immutable"),
+	})
+	Expect(isImmutableFieldError(immutableErr)).To(BeTrue())
+
+	invalidErr := errors.NewInvalid(gk, "ns-must-have-gk", field.ErrorList{
+		field.Required(field.NewPath("spec", "parameters"), ""),
+	})
+	Expect(isImmutableFieldError(invalidErr)).To(BeFalse())
+	Expect(isImmutableFieldError(errors.NewConflict(schema.GroupResource{}, "", nil))).To(BeFalse())
+}
+
+func TestRecreateOnImmutableChange(t *testing.T) {
+	RegisterTestingT(t)
+
+	tObject := &unstructured.Unstructured{}
+	r := &PolicyReconciler{}
+	Expect(r.recreateOnImmutableChange(tObject)).To(BeFalse())
+
+	tObject.SetAnnotations(map[string]string{RecreateOnImmutableChangeAnnotation: "true"})
+	Expect(r.recreateOnImmutableChange(tObject)).To(BeTrue())
+
+	r.RecreateOnImmutableChange = true
+	Expect(r.recreateOnImmutableChange(&unstructured.Unstructured{})).To(BeTrue())
+}
+
+func TestOverrideRemediationAction(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := &policiesv1.Policy{Spec: policiesv1.PolicySpec{RemediationAction: "enforce"}}
+	newTemplate := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"remediationAction": "inform"},
+		}}
+	}
+
+	tObject := newTemplate()
+	overrideRemediationAction(policy, tObject)
+	Expect(tObject.Object["spec"]).To(HaveKeyWithValue("remediationAction", "enforce"))
+
+	policy.SetAnnotations(map[string]string{SkipRemediationActionOverrideAnnotation: "true"})
+
+	tObject = newTemplate()
+	overrideRemediationAction(policy, tObject)
+	Expect(tObject.Object["spec"]).To(HaveKeyWithValue("remediationAction", "inform"))
+}
+
+func TestWasPending(t *testing.T) {
+	RegisterTestingT(t)
+
+	entry := utils.InventoryEntry{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Pending: "held"}
+
+	value, err := utils.InventoryAnnotationValue([]utils.InventoryEntry{entry.Identity()})
+	Expect(err).ToNot(HaveOccurred())
+
+	instance := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.TemplateInventoryAnnotation: value}},
+	}
+	Expect(wasPending(instance, entry)).To(BeFalse())
+
+	// The policy template is only reported when it becomes Pending, or is held for another reason
+	value, err = utils.InventoryAnnotationValue([]utils.InventoryEntry{entry})
+	Expect(err).ToNot(HaveOccurred())
+
+	instance.Annotations[utils.TemplateInventoryAnnotation] = value
+	Expect(wasPending(instance, entry)).To(BeTrue())
+
+	entry.Pending = "held for another reason"
+	Expect(wasPending(instance, entry)).To(BeFalse())
+}
+
+func TestTemplatePendingCondition(t *testing.T) {
+	RegisterTestingT(t)
+
+	inventory := []utils.InventoryEntry{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "applied"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "held", Pending: "dependencies"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "soaking", Pending: "soak time"},
+	}
+
+	condition := templatePendingCondition(inventory)
+	Expect(condition).ToNot(BeNil())
+	Expect(condition.Type).To(Equal(utils.TemplatePendingCondition))
+	Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	Expect(condition.Message).To(Equal(
+		"Policy template held is Pending: dependencies; Policy template soaking is Pending: soak time",
+	))
+
+	Expect(templatePendingCondition(inventory[:1])).To(BeNil())
+}
@@ -0,0 +1,80 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// terminalErrors are the permanent template errors of a generation of a policy, recorded in the
+// TemplateErrorsAnnotation annotation. Since a permanent error is only resolved by a change to the policy, recording
+// them lets a restarted addon know which policy templates are known to be invalid rather than reporting them again.
+type terminalErrors struct {
+	Generation int64 `json:"generation"`
+	// Templates are the reason codes of the permanent template errors by template name
+	Templates map[string]string `json:"templates"`
+}
+
+// knownTerminalErrors returns the permanent template errors recorded on the input policy for its current generation.
+// The errors recorded for a previous generation are ignored since the policy templates may have been fixed.
+func knownTerminalErrors(instance *policiesv1.Policy) map[string]string {
+	value, ok := instance.GetAnnotations()[utils.TemplateErrorsAnnotation]
+	if !ok {
+		return map[string]string{}
+	}
+
+	recorded := terminalErrors{}
+
+	err := json.Unmarshal([]byte(value), &recorded)
+	if err != nil || recorded.Generation != instance.GetGeneration() || recorded.Templates == nil {
+		return map[string]string{}
+	}
+
+	return recorded.Templates
+}
+
+// updateTerminalErrors sets the template errors annotation on the policy to the input permanent template errors of its
+// current generation if they changed, or removes it if there are none.
+func (r *PolicyReconciler) updateTerminalErrors(
+	ctx context.Context, instance *policiesv1.Policy, templates map[string]string,
+) error {
+	_, found := instance.GetAnnotations()[utils.TemplateErrorsAnnotation]
+
+	var value interface{}
+
+	if len(templates) != 0 {
+		if found && equality.Semantic.DeepEqual(knownTerminalErrors(instance), templates) {
+			return nil
+		}
+
+		// A map is marshaled with sorted keys, so the value is stable
+		content, err := json.Marshal(terminalErrors{Generation: instance.GetGeneration(), Templates: templates})
+		if err != nil {
+			return err
+		}
+
+		value = string(content)
+	} else if !found {
+		return nil
+	}
+
+	// A null value removes the annotation
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{utils.TemplateErrorsAnnotation: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.Patch(ctx, instance, client.RawPatch(types.MergePatchType, patch))
+}
@@ -0,0 +1,65 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestTerminalErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster1", Generation: 2},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &PolicyReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).Build(),
+		Recorder: recorder,
+	}
+
+	// The first reconcile reports the permanent error and records it
+	batch := newTemplateErrorBatch(policy)
+	r.emitTemplateError(batch, 0, "invalid", syncerrors.New(syncerrors.ErrTemplateMissingName, "missing name"))
+	r.emitTemplateError(batch, 1, "timeout", syncerrors.New(syncerrors.ErrApplyTimeout, "timed out"))
+	Expect(recorder.Events).To(HaveLen(2))
+	Expect(batch.terminal).To(Equal(map[string]string{"invalid": "MissingName"}))
+	Expect(r.updateTerminalErrors(context.TODO(), policy, batch.terminal)).To(Succeed())
+
+	updated := &policiesv1.Policy{}
+	Expect(r.Get(context.TODO(), client.ObjectKeyFromObject(policy), updated)).To(Succeed())
+	Expect(updated.Annotations).To(HaveKeyWithValue(
+		utils.TemplateErrorsAnnotation, `{"generation":2,"templates":{"invalid":"MissingName"}}`,
+	))
+
+	// After a restart, only the errors which aren't known are reported again
+	batch = newTemplateErrorBatch(updated)
+	r.emitTemplateError(batch, 0, "invalid", syncerrors.New(syncerrors.ErrTemplateMissingName, "missing name"))
+	r.emitTemplateError(batch, 1, "timeout", syncerrors.New(syncerrors.ErrApplyTimeout, "timed out"))
+	Expect(recorder.Events).To(HaveLen(3))
+	Expect(batch.summary()).To(Equal("1 policy template failed to sync: timeout (ApplyTimeout)"))
+
+	// The errors recorded for a previous generation are not known
+	updated.Generation = 3
+	Expect(knownTerminalErrors(updated)).To(BeEmpty())
+
+	// The annotation is removed once the policy templates are fixed
+	Expect(r.updateTerminalErrors(context.TODO(), updated, map[string]string{})).To(Succeed())
+	Expect(r.Get(context.TODO(), client.ObjectKeyFromObject(policy), updated)).To(Succeed())
+	Expect(updated.Annotations).NotTo(HaveKey(utils.TemplateErrorsAnnotation))
+}
@@ -0,0 +1,400 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultCgroupRoot is where the cgroup filesystem of the container is mounted.
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+	// concurrencyHighWatermark is the utilization of the CPU or memory limit above which the concurrency is halved.
+	concurrencyHighWatermark = 0.85
+	// concurrencyLowWatermark is the utilization of the CPU and memory limits below which the concurrency is increased.
+	concurrencyLowWatermark = 0.6
+	// defaultConcurrencyInterval is how often the resource usage is sampled when no interval is set.
+	defaultConcurrencyInterval = 10 * time.Second
+)
+
+var (
+	concurrencyLog   = ctrl.Log.WithName("adaptive-concurrency")
+	concurrencyGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "policy_framework_reconcile_concurrency",
+			Help: "The current limit of the concurrent reconciles of each controller set by the adaptive concurrency.",
+		},
+	)
+	utilizationGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_framework_resource_utilization",
+			Help: "The fraction of the cgroup limit of the resource (cpu or memory) used by the addon container.",
+		},
+		[]string{"resource"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(concurrencyGauge, utilizationGauge)
+}
+
+// errNoLimit is returned when the cgroup of the container doesn't limit a resource.
+var errNoLimit = errors.New("the resource is not limited")
+
+// AdaptiveConcurrency limits the concurrent reconciles of each controller according to the CPU and memory usage of the
+// container relative to its cgroup limits. The limit starts at one and is increased by one while the usage stays low,
+// and is halved as soon as the usage approaches a limit, so that the addon uses the available headroom on large
+// clusters without being OOMKilled on small ones. A nil AdaptiveConcurrency doesn't limit the reconciles.
+type AdaptiveConcurrency struct {
+	// Max is the highest number of concurrent reconciles of each controller.
+	Max int
+	// Interval is how often the resource usage is sampled.
+	Interval time.Duration
+	// CgroupRoot is where the cgroup filesystem is mounted. It defaults to DefaultCgroupRoot.
+	CgroupRoot string
+	limit      int
+	lastCPU    time.Duration
+	lastSample time.Time
+	// changed is closed and replaced when a reconcile completes or the limit is increased
+	changed chan struct{}
+	lock    sync.Mutex
+}
+
+// MaxConcurrentReconciles returns the number of workers of the controllers, which is one when the reconciles are not
+// adaptively limited.
+func (a *AdaptiveConcurrency) MaxConcurrentReconciles() int {
+	if a == nil || a.Max < 1 {
+		return 1
+	}
+
+	return a.Max
+}
+
+// Workers returns the number of workers of a controller configured with the input number of concurrent reconciles,
+// which is at least one. When the reconciles are adaptively limited, the highest adaptive limit takes precedence over
+// the configured number.
+func (a *AdaptiveConcurrency) Workers(configured int) int {
+	if a != nil && a.Max >= 1 {
+		return a.Max
+	}
+
+	if configured < 1 {
+		return 1
+	}
+
+	return configured
+}
+
+// Start samples the resource usage of the container on every interval until the input context is closed.
+func (a *AdaptiveConcurrency) Start(ctx context.Context) error {
+	interval := a.Interval
+	if interval <= 0 {
+		interval = defaultConcurrencyInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			a.adjust(a.utilization(time.Now()))
+		}
+	}
+}
+
+// currentLimit returns the limit of the concurrent reconciles, initializing it if needed. The lock must be held.
+func (a *AdaptiveConcurrency) currentLimit() int {
+	if a.limit == 0 {
+		a.limit = 1
+		a.changed = make(chan struct{})
+	}
+
+	return a.limit
+}
+
+// adjust halves the limit of the concurrent reconciles if the input utilization is above the high watermark, and
+// increases it by one if it is below the low watermark.
+func (a *AdaptiveConcurrency) adjust(utilization float64) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	limit := a.currentLimit()
+
+	switch {
+	case utilization >= concurrencyHighWatermark:
+		limit /= 2
+		if limit < 1 {
+			limit = 1
+		}
+	case utilization < concurrencyLowWatermark && limit < a.MaxConcurrentReconciles():
+		limit++
+	}
+
+	if limit != a.limit {
+		concurrencyLog.Info("Adjusting the concurrent reconciles", "utilization", utilization, "limit", limit)
+
+		if limit > a.limit {
+			a.notify()
+		}
+
+		a.limit = limit
+	}
+
+	concurrencyGauge.Set(float64(limit))
+}
+
+// notify wakes up the reconciles waiting for the limit. The lock must be held.
+func (a *AdaptiveConcurrency) notify() {
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// utilization returns the highest fraction of the CPU and memory limits of the container that is used. A resource
+// which isn't limited or can't be read is ignored.
+func (a *AdaptiveConcurrency) utilization(now time.Time) float64 {
+	root := a.CgroupRoot
+	if root == "" {
+		root = DefaultCgroupRoot
+	}
+
+	highest := 0.0
+
+	memUsed, memLimit, err := memoryUsage(root)
+	if err == nil {
+		utilization := float64(memUsed) / float64(memLimit)
+		utilizationGauge.WithLabelValues("memory").Set(utilization)
+
+		highest = utilization
+	} else if !errors.Is(err, errNoLimit) {
+		concurrencyLog.V(2).Info("Failed to read the memory usage", "error", err.Error())
+	}
+
+	cpuUsed, cpuLimit, err := cpuUsage(root)
+	if err != nil {
+		if !errors.Is(err, errNoLimit) {
+			concurrencyLog.V(2).Info("Failed to read the CPU usage", "error", err.Error())
+		}
+
+		return highest
+	}
+
+	a.lock.Lock()
+	lastCPU, lastSample := a.lastCPU, a.lastSample
+	a.lastCPU, a.lastSample = cpuUsed, now
+	a.lock.Unlock()
+
+	// The CPU utilization is the rate of the CPU usage between two samples
+	if lastSample.IsZero() || !now.After(lastSample) {
+		return highest
+	}
+
+	utilization := float64(cpuUsed-lastCPU) / float64(now.Sub(lastSample)) / cpuLimit
+	utilizationGauge.WithLabelValues("cpu").Set(utilization)
+
+	if utilization > highest {
+		highest = utilization
+	}
+
+	return highest
+}
+
+// readCgroupValue returns the trimmed content of the input cgroup file.
+func readCgroupValue(path ...string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(path...))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// readCgroupInt returns the integer in the input cgroup file.
+func readCgroupInt(path ...string) (int64, error) {
+	value, err := readCgroupValue(path...)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// memoryUsage returns the memory usage and limit in bytes of the cgroup at the input root, with cgroup v2 or v1.
+func memoryUsage(root string) (used int64, limit int64, err error) {
+	limitValue, err := readCgroupValue(root, "memory.max")
+	if err == nil {
+		if limitValue == "max" {
+			return 0, 0, errNoLimit
+		}
+
+		if limit, err = strconv.ParseInt(limitValue, 10, 64); err != nil {
+			return 0, 0, err
+		}
+
+		used, err = readCgroupInt(root, "memory.current")
+
+		return used, limit, err
+	}
+
+	limit, err = readCgroupInt(root, "memory", "memory.limit_in_bytes")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// cgroup v1 reports an unlimited memory as a value close to the maximum int64
+	if limit <= 0 || limit >= 1<<62 {
+		return 0, 0, errNoLimit
+	}
+
+	used, err = readCgroupInt(root, "memory", "memory.usage_in_bytes")
+
+	return used, limit, err
+}
+
+// cpuUsage returns the cumulative CPU usage and the number of CPUs the cgroup at the input root is limited to, with
+// cgroup v2 or v1.
+func cpuUsage(root string) (used time.Duration, cpus float64, err error) {
+	maxValue, err := readCgroupValue(root, "cpu.max")
+	if err == nil {
+		fields := strings.Fields(maxValue)
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, 0, errNoLimit
+		}
+
+		if cpus, err = cpuQuota(fields[0], fields[1]); err != nil {
+			return 0, 0, err
+		}
+
+		stat, err := readCgroupValue(root, "cpu.stat")
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, line := range strings.Split(stat, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[0] == "usage_usec" {
+				usec, err := strconv.ParseInt(fields[1], 10, 64)
+
+				return time.Duration(usec) * time.Microsecond, cpus, err
+			}
+		}
+
+		return 0, 0, errors.New("the cpu.stat file doesn't have the usage_usec field")
+	}
+
+	quota, err := readCgroupValue(root, "cpu", "cpu.cfs_quota_us")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if quota == "-1" {
+		return 0, 0, errNoLimit
+	}
+
+	period, err := readCgroupValue(root, "cpu", "cpu.cfs_period_us")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if cpus, err = cpuQuota(quota, period); err != nil {
+		return 0, 0, err
+	}
+
+	nsec, err := readCgroupInt(root, "cpuacct", "cpuacct.usage")
+
+	return time.Duration(nsec), cpus, err
+}
+
+// cpuQuota returns the number of CPUs of the input CFS quota and period.
+func cpuQuota(quota string, period string) (float64, error) {
+	quotaValue, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	periodValue, err := strconv.ParseFloat(period, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if quotaValue <= 0 || periodValue <= 0 {
+		return 0, errNoLimit
+	}
+
+	return quotaValue / periodValue, nil
+}
+
+// limitedReconciler runs the reconciles of the wrapped reconciler within the adaptive concurrency limit.
+type limitedReconciler struct {
+	concurrency *AdaptiveConcurrency
+	reconciler  reconcile.Reconciler
+	inFlight    int
+}
+
+// Reconciler wraps the input reconciler so that its concurrent reconciles stay within the adaptive limit. Each
+// wrapped reconciler has its own count of concurrent reconciles. A nil AdaptiveConcurrency returns the input
+// reconciler.
+func (a *AdaptiveConcurrency) Reconciler(reconciler reconcile.Reconciler) reconcile.Reconciler {
+	if a == nil {
+		return reconciler
+	}
+
+	return &limitedReconciler{concurrency: a, reconciler: reconciler}
+}
+
+func (l *limitedReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if err := l.acquire(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	defer l.release()
+
+	return l.reconciler.Reconcile(ctx, request)
+}
+
+// acquire waits until the number of concurrent reconciles is below the limit, or the input context is closed.
+func (l *limitedReconciler) acquire(ctx context.Context) error {
+	for {
+		l.concurrency.lock.Lock()
+
+		if l.inFlight < l.concurrency.currentLimit() {
+			l.inFlight++
+			l.concurrency.lock.Unlock()
+
+			return nil
+		}
+
+		changed := l.concurrency.changed
+		l.concurrency.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release records that a reconcile completed and wakes up the waiting reconciles.
+func (l *limitedReconciler) release() {
+	l.concurrency.lock.Lock()
+	defer l.concurrency.lock.Unlock()
+
+	l.inFlight--
+	l.concurrency.notify()
+}
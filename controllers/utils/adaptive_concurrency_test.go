@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func writeCgroupFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o700)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content+"\n"), 0o600)).To(Succeed())
+	}
+}
+
+func TestAdaptiveConcurrencyUtilization(t *testing.T) {
+	RegisterTestingT(t)
+
+	// cgroup v2 with a limit of 2 CPUs and 100 MiB
+	v2 := t.TempDir()
+	writeCgroupFiles(t, v2, map[string]string{
+		"memory.max":     "104857600",
+		"memory.current": "52428800",
+		"cpu.max":        "200000 100000",
+		"cpu.stat":       "usage_usec 1000000\nuser_usec 800000",
+	})
+
+	concurrency := &AdaptiveConcurrency{CgroupRoot: v2}
+	now := time.Now()
+	Expect(concurrency.utilization(now)).To(BeNumerically("~", 0.5, 0.001))
+
+	// 1.8 CPUs were used over the last second
+	writeCgroupFiles(t, v2, map[string]string{"cpu.stat": "usage_usec 2800000"})
+	Expect(concurrency.utilization(now.Add(time.Second))).To(BeNumerically("~", 0.9, 0.001))
+
+	// cgroup v1 without a CPU limit
+	v1 := t.TempDir()
+	writeCgroupFiles(t, v1, map[string]string{
+		"memory/memory.limit_in_bytes": "1000",
+		"memory/memory.usage_in_bytes": "700",
+		"cpu/cpu.cfs_quota_us":         "-1",
+		"cpu/cpu.cfs_period_us":        "100000",
+	})
+
+	concurrency = &AdaptiveConcurrency{CgroupRoot: v1}
+	Expect(concurrency.utilization(now)).To(BeNumerically("~", 0.7, 0.001))
+
+	// No limits at all
+	unlimited := t.TempDir()
+	writeCgroupFiles(t, unlimited, map[string]string{"memory.max": "max", "cpu.max": "max 100000"})
+
+	concurrency = &AdaptiveConcurrency{CgroupRoot: unlimited}
+	Expect(concurrency.utilization(now)).To(BeZero())
+}
+
+func TestAdaptiveConcurrencyAdjust(t *testing.T) {
+	RegisterTestingT(t)
+
+	concurrency := &AdaptiveConcurrency{Max: 4}
+
+	// The limit increases by one while there is headroom, up to the maximum
+	for i := 0; i < 5; i++ {
+		concurrency.adjust(0.1)
+	}
+
+	Expect(concurrency.limit).To(Equal(4))
+
+	// The limit is kept between the watermarks
+	concurrency.adjust(0.7)
+	Expect(concurrency.limit).To(Equal(4))
+
+	// The limit is halved when a resource limit is approached, but never below one
+	concurrency.adjust(0.9)
+	Expect(concurrency.limit).To(Equal(2))
+	concurrency.adjust(0.95)
+	concurrency.adjust(0.95)
+	Expect(concurrency.limit).To(Equal(1))
+
+	var nilConcurrency *AdaptiveConcurrency
+	Expect(nilConcurrency.MaxConcurrentReconciles()).To(Equal(1))
+	Expect(concurrency.MaxConcurrentReconciles()).To(Equal(4))
+
+	// The adaptive maximum takes precedence over the configured concurrency of a controller
+	Expect(nilConcurrency.Workers(0)).To(Equal(1))
+	Expect(nilConcurrency.Workers(8)).To(Equal(8))
+	Expect(concurrency.Workers(8)).To(Equal(4))
+}
+
+// blockingReconciler records the highest number of concurrent reconciles until it is released.
+type blockingReconciler struct {
+	release  chan struct{}
+	lock     sync.Mutex
+	inFlight int
+	highest  int
+}
+
+func (b *blockingReconciler) Reconcile(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	b.lock.Lock()
+	b.inFlight++
+
+	if b.inFlight > b.highest {
+		b.highest = b.inFlight
+	}
+
+	b.lock.Unlock()
+
+	<-b.release
+
+	b.lock.Lock()
+	b.inFlight--
+	b.lock.Unlock()
+
+	return reconcile.Result{}, nil
+}
+
+func (b *blockingReconciler) highestInFlight() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.highest
+}
+
+func TestAdaptiveConcurrencyReconciler(t *testing.T) {
+	RegisterTestingT(t)
+
+	var nilConcurrency *AdaptiveConcurrency
+
+	wrapped := &blockingReconciler{release: make(chan struct{})}
+	Expect(nilConcurrency.Reconciler(wrapped)).To(BeIdenticalTo(wrapped))
+
+	concurrency := &AdaptiveConcurrency{Max: 3}
+	reconciler := concurrency.Reconciler(wrapped)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _ = reconciler.Reconcile(context.TODO(), reconcile.Request{})
+		}()
+	}
+
+	// Only one reconcile runs at first
+	Eventually(wrapped.highestInFlight).Should(Equal(1))
+	Consistently(wrapped.highestInFlight, "100ms").Should(Equal(1))
+
+	// Increasing the limit lets another waiting reconcile run
+	concurrency.adjust(0)
+	Eventually(wrapped.highestInFlight).Should(Equal(2))
+
+	close(wrapped.release)
+	wg.Wait()
+
+	// A waiting reconcile stops when its context is closed
+	blocked := &blockingReconciler{release: make(chan struct{})}
+	reconciler = (&AdaptiveConcurrency{Max: 1}).Reconciler(blocked)
+
+	go func() { _, _ = reconciler.Reconcile(context.TODO(), reconcile.Request{}) }()
+
+	Eventually(blocked.highestInFlight).Should(Equal(1))
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{})
+	Expect(err).To(MatchError(context.Canceled))
+
+	close(blocked.release)
+}
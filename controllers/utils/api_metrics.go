@@ -0,0 +1,121 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// otherController is the controller label of the API requests not made during a reconcile, such as the list and
+// watch requests of the caches.
+const otherController = "other"
+
+type controllerKey struct{}
+
+var apiRequestCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "policy_framework_api_requests_total",
+		Help: "The number of API requests made by the addon, by client (hub or managed), controller, and verb.",
+	},
+	[]string{"client", "controller", "verb"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(apiRequestCounter)
+}
+
+// WithController returns a copy of the input context identifying the input controller, so that the API requests made
+// with it are attributed to the controller in the API request metrics.
+func WithController(ctx context.Context, controller string) context.Context {
+	return context.WithValue(ctx, controllerKey{}, controller)
+}
+
+// controllerFromContext returns the controller set on the input context by WithController.
+func controllerFromContext(ctx context.Context) string {
+	if controller, ok := ctx.Value(controllerKey{}).(string); ok {
+		return controller
+	}
+
+	return otherController
+}
+
+// apiRequestCounterRoundTripper counts the API requests by verb.
+type apiRequestCounterRoundTripper struct {
+	next       http.RoundTripper
+	clientName string
+}
+
+// NewAPIRequestCounterWrapper returns a function that wraps a round tripper to count the API requests by verb and
+// controller, to be passed to rest.Config.Wrap. The client name identifies the client in the metric labels.
+func NewAPIRequestCounterWrapper(clientName string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &apiRequestCounterRoundTripper{next: next, clientName: clientName}
+	}
+}
+
+// RoundTrip counts and performs the request.
+func (c *apiRequestCounterRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiRequestCounter.WithLabelValues(c.clientName, controllerFromContext(req.Context()), requestVerb(req)).Inc()
+
+	return c.next.RoundTrip(req)
+}
+
+// requestVerb returns the Kubernetes API verb of the input request, such as list for a GET request on a collection.
+func requestVerb(req *http.Request) string {
+	switch req.Method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if isCollectionPath(req.URL.Path) {
+			return "deletecollection"
+		}
+
+		return "delete"
+	case http.MethodGet, http.MethodHead:
+		if req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1" {
+			return "watch"
+		}
+
+		if isCollectionPath(req.URL.Path) {
+			return "list"
+		}
+
+		return "get"
+	default:
+		return strings.ToLower(req.Method)
+	}
+}
+
+// isCollectionPath returns true if the input API path refers to a collection of resources rather than a single
+// resource, such as /api/v1/namespaces/default/events as opposed to /api/v1/namespaces/default/events/my-event.
+func isCollectionPath(path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	// Strip the API group and version prefix
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		segments = segments[3:]
+	default:
+		// Non-resource paths, such as discovery, are not collections
+		return false
+	}
+
+	// A namespace itself is a single resource, but the resources in it are scoped by it
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		segments = segments[2:]
+	}
+
+	return len(segments) == 1
+}
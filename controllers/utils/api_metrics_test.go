@@ -0,0 +1,77 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testPoliciesPath = "/apis/policy.open-cluster-management.io/v1/namespaces/managed/policies"
+
+func TestRequestVerb(t *testing.T) {
+	RegisterTestingT(t)
+
+	tests := map[string]struct {
+		method string
+		url    string
+	}{
+		"get":              {http.MethodGet, "/api/v1/namespaces/managed/events/my-event"},
+		"list":             {http.MethodGet, testPoliciesPath},
+		"watch":            {http.MethodGet, "/api/v1/namespaces/managed/events?watch=true"},
+		"create":           {http.MethodPost, "/api/v1/namespaces/managed/events"},
+		"update":           {http.MethodPut, testPoliciesPath + "/p"},
+		"patch":            {http.MethodPatch, testPoliciesPath + "/p"},
+		"delete":           {http.MethodDelete, "/api/v1/namespaces/managed/secrets/policy-encryption-key"},
+		"deletecollection": {http.MethodDelete, "/api/v1/namespaces/managed/events"},
+	}
+
+	for verb, test := range tests {
+		req := httptest.NewRequest(test.method, test.url, nil)
+		Expect(requestVerb(req)).To(Equal(verb), test.url)
+	}
+
+	// A namespace and a cluster scoped collection
+	Expect(requestVerb(httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/managed", nil))).To(Equal("get"))
+	Expect(requestVerb(httptest.NewRequest(http.MethodGet, "/api/v1/namespaces", nil))).To(Equal("list"))
+	// Discovery is not a collection
+	Expect(requestVerb(httptest.NewRequest(http.MethodGet, "/apis", nil))).To(Equal("get"))
+}
+
+func TestAPIRequestCounter(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: NewAPIRequestCounterWrapper("hub")(http.DefaultTransport)}
+	counter := apiRequestCounter.WithLabelValues("hub", "policy-spec-sync", "list")
+	before := testutil.ToFloat64(counter)
+
+	ctx := WithController(context.Background(), "policy-spec-sync")
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, server.URL+testPoliciesPath, nil,
+	)
+	Expect(err).ToNot(HaveOccurred())
+
+	resp, err := httpClient.Do(req)
+	Expect(err).ToNot(HaveOccurred())
+	resp.Body.Close()
+
+	Expect(testutil.ToFloat64(counter)).To(Equal(before + 1))
+
+	// Requests outside of a reconcile are attributed to the other controller
+	other := apiRequestCounter.WithLabelValues("hub", otherController, "get")
+	before = testutil.ToFloat64(other)
+
+	resp, err = httpClient.Get(server.URL + "/api/v1/namespaces/managed")
+	Expect(err).ToNot(HaveOccurred())
+	resp.Body.Close()
+
+	Expect(testutil.ToFloat64(other)).To(Equal(before + 1))
+}
@@ -0,0 +1,85 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ErrAPIResourceUnavailable is returned by the readiness check of an APIResourceWaiter until the API resource is
+// available.
+var ErrAPIResourceUnavailable = errors.New("the API resource is not available")
+
+// APIResourceWaiter waits for an API resource to be served, such as the Policy CRD on the Hub while it is being
+// upgraded. This allows the controllers that depend on the API resource to be started after the others.
+type APIResourceWaiter struct {
+	Client   discovery.DiscoveryInterface
+	Resource schema.GroupVersionResource
+	// MaxDelay is the maximum delay between the attempts, which start at one second and double after each attempt.
+	MaxDelay  time.Duration
+	available int32
+}
+
+// Wait blocks until the API resource is served, retrying with an exponential backoff. It returns an error only if
+// the input context is closed.
+func (w *APIResourceWaiter) Wait(ctx context.Context) error {
+	log := ctrl.Log.WithName("api-resource-waiter").WithValues("resource", w.Resource.String())
+	delay := time.Second
+
+	for {
+		err := w.served()
+		if err == nil {
+			log.Info("The API resource is available")
+			atomic.StoreInt32(&w.available, 1)
+
+			return nil
+		}
+
+		log.Info("The API resource is not available yet, will retry", "retryIn", delay.String(), "reason", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if w.MaxDelay > 0 && delay > w.MaxDelay {
+			delay = w.MaxDelay
+		}
+	}
+}
+
+// served returns an error if the API resource is not served.
+func (w *APIResourceWaiter) served() error {
+	resources, err := w.Client.ServerResourcesForGroupVersion(w.Resource.GroupVersion().String())
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == w.Resource.Resource {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrAPIResourceUnavailable, w.Resource.String())
+}
+
+// Check is a readiness check which fails until the API resource is available.
+func (w *APIResourceWaiter) Check(_ *http.Request) error {
+	if atomic.LoadInt32(&w.available) == 1 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrAPIResourceUnavailable, w.Resource.String())
+}
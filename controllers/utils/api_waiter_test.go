@@ -0,0 +1,44 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestAPIResourceWaiter(t *testing.T) {
+	RegisterTestingT(t)
+
+	fake := &clienttesting.Fake{}
+	waiter := &APIResourceWaiter{
+		Client:   &fakediscovery.FakeDiscovery{Fake: fake},
+		Resource: policiesv1.GroupVersion.WithResource("policies"),
+		MaxDelay: time.Second,
+	}
+
+	Expect(errors.Is(waiter.Check(nil), ErrAPIResourceUnavailable)).To(BeTrue())
+
+	// The wait only returns early if the context is closed while the API resource is unavailable
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	Expect(waiter.Wait(ctx)).To(MatchError(context.DeadlineExceeded))
+	Expect(errors.Is(waiter.Check(nil), ErrAPIResourceUnavailable)).To(BeTrue())
+
+	fake.Resources = []*metav1.APIResourceList{{
+		GroupVersion: policiesv1.GroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "policies"}},
+	}}
+
+	Expect(waiter.Wait(context.Background())).To(Succeed())
+	Expect(waiter.Check(nil)).To(Succeed())
+}
@@ -0,0 +1,189 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// defaultClockSkewInterval is how often the clocks of the API servers are compared when no interval is set.
+const defaultClockSkewInterval = 5 * time.Minute
+
+var (
+	clockSkewLog   = ctrl.Log.WithName("clock-skew")
+	clockSkewGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "policy_framework_clock_skew_seconds",
+			Help: "The difference between the clock of the Hub API server and the clock of the managed cluster API " +
+				"server, positive when the Hub is ahead.",
+		},
+	)
+	clockSkewWarningGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "policy_framework_clock_skew_warning",
+			Help: "Whether the clock skew between the Hub and the managed cluster exceeds the threshold (1) or not " +
+				"(0). The compliance event timestamps are then shifted to the Hub clock.",
+		},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(clockSkewGauge, clockSkewWarningGauge)
+}
+
+// ClockSkew periodically compares the clocks of the Hub and managed cluster API servers through the Date header of
+// their responses. When the skew exceeds the threshold, the timestamps of the compliance events of the managed cluster
+// are shifted to the Hub clock so that they are ordered consistently with the timestamps assigned on the Hub, such as
+// in a compliance history restored from the Hub. A nil ClockSkew doesn't shift the timestamps.
+type ClockSkew struct {
+	HubConfig     *rest.Config
+	ManagedConfig *rest.Config
+	// Threshold is the skew above which the timestamps are shifted and a warning is reported.
+	Threshold time.Duration
+	// Interval is how often the clocks are compared.
+	Interval time.Duration
+	// offset is added to the managed cluster timestamps, which is zero while the skew is within the threshold
+	offset time.Duration
+	lock   sync.RWMutex
+}
+
+// Start compares the clocks right away and then on every interval until the input context is closed.
+func (c *ClockSkew) Start(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultClockSkewInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.probe(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe measures the skew between the clocks of the API servers and records it.
+func (c *ClockSkew) probe(ctx context.Context) {
+	hubOffset, err := apiServerClockOffset(ctx, c.HubConfig)
+	if err != nil {
+		clockSkewLog.V(1).Info("Failed to read the clock of the Hub API server", "error", err.Error())
+
+		return
+	}
+
+	managedOffset, err := apiServerClockOffset(ctx, c.ManagedConfig)
+	if err != nil {
+		clockSkewLog.V(1).Info("Failed to read the clock of the managed cluster API server", "error", err.Error())
+
+		return
+	}
+
+	c.record(hubOffset - managedOffset)
+}
+
+// record reports the input skew of the Hub clock relative to the managed cluster clock and updates the offset of the
+// timestamps. Since the Date header has a one second resolution, the offset is only replaced when the skew moves away
+// from it by more than the threshold, so that the same event keeps the same shifted timestamp across reconciles.
+func (c *ClockSkew) record(skew time.Duration) {
+	clockSkewGauge.Set(skew.Seconds())
+
+	exceeded := skew > c.Threshold || skew < -c.Threshold
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch {
+	case !exceeded:
+		if c.offset != 0 {
+			clockSkewLog.Info("The clock skew between the Hub and the managed cluster is back within the threshold",
+				"skew", skew.String(), "threshold", c.Threshold.String())
+		}
+
+		c.offset = 0
+
+		clockSkewWarningGauge.Set(0)
+	case c.offset == 0 || skew-c.offset > c.Threshold || c.offset-skew > c.Threshold:
+		clockSkewLog.Info("Warning: the clock skew between the Hub and the managed cluster exceeds the threshold, "+
+			"shifting the compliance event timestamps to the Hub clock",
+			"skew", skew.String(), "threshold", c.Threshold.String())
+
+		c.offset = skew.Round(time.Second)
+
+		clockSkewWarningGauge.Set(1)
+	}
+}
+
+// ToHubTime returns the input timestamp of the managed cluster shifted to the Hub clock.
+func (c *ClockSkew) ToHubTime(timestamp metav1.Time) metav1.Time {
+	if c == nil || timestamp.IsZero() {
+		return timestamp
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.offset == 0 {
+		return timestamp
+	}
+
+	return metav1.NewTime(timestamp.Add(c.offset))
+}
+
+// apiServerClockOffset returns how far the clock of the API server of the input configuration is ahead of the local
+// clock, from the Date header of its response to a version request. The round trip time is split evenly.
+func apiServerClockOffset(ctx context.Context, config *rest.Config) (time.Duration, error) {
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return 0, err
+	}
+
+	serverURL, _, err := rest.DefaultServerURL(
+		config.Host, "", schema.GroupVersion{}, rest.IsConfigTransportTLS(*config),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	serverURL.Path = "/version"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := time.Now()
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	received := time.Now()
+
+	resp.Body.Close()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid Date header in the API server response: %w", err)
+	}
+
+	return serverTime.Sub(sent.Add(received.Sub(sent) / 2)), nil
+}
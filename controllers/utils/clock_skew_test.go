@@ -0,0 +1,65 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestAPIServerClockOffset(t *testing.T) {
+	RegisterTestingT(t)
+
+	// An API server whose clock is one hour ahead
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Expect(r.URL.Path).To(Equal("/version"))
+
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	offset, err := apiServerClockOffset(context.TODO(), &rest.Config{Host: server.URL})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(offset).To(BeNumerically("~", time.Hour, 2*time.Second))
+}
+
+func TestClockSkewRecord(t *testing.T) {
+	RegisterTestingT(t)
+
+	timestamp := metav1.NewTime(time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC))
+
+	var nilSkew *ClockSkew
+	Expect(nilSkew.ToHubTime(timestamp)).To(Equal(timestamp))
+
+	skew := &ClockSkew{Threshold: 30 * time.Second}
+
+	// A skew within the threshold isn't compensated
+	skew.record(10 * time.Second)
+	Expect(skew.ToHubTime(timestamp)).To(Equal(timestamp))
+	Expect(testutil.ToFloat64(clockSkewWarningGauge)).To(Equal(0.0))
+
+	skew.record(-time.Minute)
+	Expect(skew.ToHubTime(timestamp).Time).To(Equal(timestamp.Add(-time.Minute)))
+	Expect(testutil.ToFloat64(clockSkewWarningGauge)).To(Equal(1.0))
+	Expect(testutil.ToFloat64(clockSkewGauge)).To(Equal(-60.0))
+
+	// The offset is kept while the skew stays close to it
+	skew.record(-time.Minute - time.Second)
+	Expect(skew.ToHubTime(timestamp).Time).To(Equal(timestamp.Add(-time.Minute)))
+
+	skew.record(-2 * time.Minute)
+	Expect(skew.ToHubTime(timestamp).Time).To(Equal(timestamp.Add(-2 * time.Minute)))
+
+	skew.record(time.Second)
+	Expect(skew.ToHubTime(timestamp)).To(Equal(timestamp))
+	Expect(testutil.ToFloat64(clockSkewWarningGauge)).To(Equal(0.0))
+}
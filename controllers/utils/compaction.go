@@ -0,0 +1,55 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"strings"
+)
+
+// PolicyCompaction strips the annotations of the replicated policies on the Hub which aren't in the allow-list before
+// they are applied on the managed cluster, such as the last applied configuration of kubectl. This reduces the size of
+// the policies on the managed cluster. Labels are always retained since the addon relies on the labels set by the
+// policy propagator. A nil PolicyCompaction retains all annotations.
+type PolicyCompaction struct {
+	// AllowList contains the annotation keys to retain. An entry ending with "*" matches all the keys with that
+	// prefix.
+	AllowList []string
+}
+
+// allowed returns true if the input annotation key is retained. The TriggerUpdateAnnotation annotation is always
+// retained.
+func (c *PolicyCompaction) allowed(key string) bool {
+	if c == nil || key == TriggerUpdateAnnotation {
+		return true
+	}
+
+	for _, entry := range c.AllowList {
+		if strings.HasSuffix(entry, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(entry, "*")) {
+				return true
+			}
+		} else if key == entry {
+			return true
+		}
+	}
+
+	return false
+}
+
+// annotations returns a copy of the input annotations with only the retained annotations. A nil input is returned as
+// nil to keep the nil versus empty distinction.
+func (c *PolicyCompaction) annotations(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		return nil
+	}
+
+	compacted := make(map[string]string, len(annotations))
+
+	for key, value := range annotations {
+		if c.allowed(key) {
+			compacted[key] = value
+		}
+	}
+
+	return compacted
+}
@@ -0,0 +1,48 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestPolicyCompaction(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:      "policy",
+		Namespace: "cluster-ns",
+		Annotations: map[string]string{
+			"policy.open-cluster-management.io/standards":      "NIST",
+			"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			"example.com/dashboard":                            "team-a",
+		},
+		Labels: map[string]string{"policy.open-cluster-management.io/root-policy": "default.policy"},
+	}}
+	compaction := &PolicyCompaction{
+		AllowList: []string{"policy.open-cluster-management.io/*", "example.com/dashboard"},
+	}
+
+	managedPlc := ManagedPolicyFromHub(hubPlc, "managed", compaction)
+	Expect(managedPlc.Annotations).To(Equal(map[string]string{
+		"policy.open-cluster-management.io/standards": "NIST",
+		"example.com/dashboard":                       "team-a",
+	}))
+	Expect(managedPlc.Labels).To(Equal(hubPlc.Labels))
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, compaction)).To(BeTrue())
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, nil)).To(BeFalse())
+
+	// Annotations synced before the compaction was enabled are removed
+	managedPlc.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = "{}"
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, compaction)).To(BeFalse())
+
+	SyncAnnotations(hubPlc, managedPlc, compaction)
+	Expect(managedPlc.Annotations).ToNot(HaveKey("kubectl.kubernetes.io/last-applied-configuration"))
+
+	// A nil compaction retains all the annotations
+	Expect(ManagedPolicyFromHub(hubPlc, "managed", nil).Annotations).To(Equal(hubPlc.Annotations))
+}
@@ -0,0 +1,186 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// ComplianceEventReasonPrefix is the prefix of the reason of compliance events on a replicated policy.
+const ComplianceEventReasonPrefix = "policy: "
+
+const (
+	// ComplianceEventKindAnnotation can be set on a compliance event to the kind of its policy template.
+	ComplianceEventKindAnnotation = "policy.open-cluster-management.io/template-kind"
+	// ComplianceEventSeverityAnnotation can be set on a compliance event to the severity of its policy template, such
+	// as low, medium, high, or critical.
+	ComplianceEventSeverityAnnotation = "policy.open-cluster-management.io/severity"
+	// ComplianceEventGenerationAnnotation can be set on a compliance event to the generation of the replicated policy
+	// which the policy template was evaluated for.
+	ComplianceEventGenerationAnnotation = "policy.open-cluster-management.io/policy-generation"
+)
+
+// ComplianceEventFields are the structured fields of a compliance event, carried by its annotations so that the
+// consumers of the event don't need to parse its message. The message keeps its compliance state prefix (e.g.
+// "NonCompliant; ") so that the events without these fields are still understood.
+type ComplianceEventFields struct {
+	Kind             string `json:"kind,omitempty"`
+	Severity         string `json:"severity,omitempty"`
+	PolicyGeneration int64  `json:"policyGeneration,omitempty"`
+}
+
+// IsZero returns true if none of the fields is set.
+func (f ComplianceEventFields) IsZero() bool {
+	return f == ComplianceEventFields{}
+}
+
+// Annotations returns the annotations of a compliance event with the set fields, to be passed to
+// record.EventRecorder.AnnotatedEventf.
+func (f ComplianceEventFields) Annotations() map[string]string {
+	annotations := map[string]string{}
+
+	if f.Kind != "" {
+		annotations[ComplianceEventKindAnnotation] = f.Kind
+	}
+
+	if f.Severity != "" {
+		annotations[ComplianceEventSeverityAnnotation] = f.Severity
+	}
+
+	if f.PolicyGeneration != 0 {
+		annotations[ComplianceEventGenerationAnnotation] = strconv.FormatInt(f.PolicyGeneration, 10)
+	}
+
+	return annotations
+}
+
+// Or returns the fields, with the unset ones taken from the input fields.
+func (f ComplianceEventFields) Or(fallback ComplianceEventFields) ComplianceEventFields {
+	if f.Kind == "" {
+		f.Kind = fallback.Kind
+	}
+
+	if f.Severity == "" {
+		f.Severity = fallback.Severity
+	}
+
+	if f.PolicyGeneration == 0 {
+		f.PolicyGeneration = fallback.PolicyGeneration
+	}
+
+	return f
+}
+
+// ComplianceEventFieldsFromAnnotations returns the fields set in the input annotations of a compliance event. An
+// invalid policy generation is ignored.
+func ComplianceEventFieldsFromAnnotations(annotations map[string]string) ComplianceEventFields {
+	fields := ComplianceEventFields{
+		Kind:     annotations[ComplianceEventKindAnnotation],
+		Severity: strings.ToLower(annotations[ComplianceEventSeverityAnnotation]),
+	}
+
+	if generation, err := strconv.ParseInt(annotations[ComplianceEventGenerationAnnotation], 10, 64); err == nil {
+		fields.PolicyGeneration = generation
+	}
+
+	return fields
+}
+
+// TemplateEventFields returns the fields of the compliance events of the input policy template of the input policy:
+// the kind and spec.severity of the policy template, and the generation of the policy. It also returns the name of
+// the policy template, which is empty if the policy template can't be decoded.
+func TemplateEventFields(
+	policy *policiesv1.Policy, policyT *policiesv1.PolicyTemplate,
+) (string, ComplianceEventFields) {
+	fields := ComplianceEventFields{PolicyGeneration: policy.GetGeneration()}
+
+	if policyT == nil {
+		return "", fields
+	}
+
+	template := struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Severity string `json:"severity"`
+		} `json:"spec"`
+	}{}
+
+	if err := json.Unmarshal(policyT.ObjectDefinition.Raw, &template); err != nil {
+		return "", fields
+	}
+
+	fields.Kind = template.Kind
+	fields.Severity = strings.ToLower(template.Spec.Severity)
+
+	return template.Metadata.Name, fields
+}
+
+// ComplianceEventReason returns the reason of a compliance event on a replicated policy for the input policy
+// template. The status sync uses the reason to determine which policy template the event is about.
+func ComplianceEventReason(policyNamespace, templateName string) string {
+	return fmt.Sprintf("%s%s/%s", ComplianceEventReasonPrefix, policyNamespace, templateName)
+}
+
+// ComplianceEventSource returns the event source for compliance events emitted by the input policy controller. Policy
+// controllers should use the same name as their entry in the trusted event sources of the status sync.
+func ComplianceEventSource(controllerName string) corev1.EventSource {
+	return corev1.EventSource{Component: strings.ToLower(controllerName)}
+}
+
+// NewComplianceEventRecorder returns an event recorder for compliance events in the input namespace whose event source
+// is ComplianceEventSource of the input policy controller. The scheme must contain the Policy type.
+func NewComplianceEventRecorder(
+	client kubernetes.Interface, scheme *runtime.Scheme, namespace, controllerName string,
+) record.EventRecorder {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(namespace)})
+
+	return eventBroadcaster.NewRecorder(scheme, ComplianceEventSource(controllerName))
+}
+
+// EventSourceTrust filters the compliance events by the event source component of the policy controllers. It isn't
+// a security boundary: the event source and reporting controller are set by whoever creates the event, and the API
+// server doesn't verify them, so any client allowed to create events in the cluster namespace can claim a trusted
+// component. It only leaves out the compliance events of misconfigured or unknown policy controllers. Restricting who
+// can create events in the cluster namespace with RBAC is what prevents spoofed compliance events. A nil or empty
+// EventSourceTrust trusts all event sources.
+type EventSourceTrust map[string]bool
+
+// NewEventSourceTrust returns an EventSourceTrust that trusts the input event source components. No components
+// results in trusting all event sources.
+func NewEventSourceTrust(components ...string) EventSourceTrust {
+	trust := EventSourceTrust{}
+
+	for _, component := range components {
+		component = strings.ToLower(strings.TrimSpace(component))
+		if component != "" {
+			trust[component] = true
+		}
+	}
+
+	return trust
+}
+
+// Trusted returns true if an event with the input source and reporting controller claims to be from a trusted event
+// source. Events created with the events.k8s.io API set the reporting controller instead of the source. Since both are
+// set by the creator of the event, this doesn't prove which controller emitted the event.
+func (t EventSourceTrust) Trusted(source corev1.EventSource, reportingController string) bool {
+	if len(t) == 0 {
+		return true
+	}
+
+	return t[strings.ToLower(source.Component)] || t[strings.ToLower(reportingController)]
+}
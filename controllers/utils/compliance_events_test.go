@@ -0,0 +1,75 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestEventSourceTrust(t *testing.T) {
+	RegisterTestingT(t)
+
+	configPolicyEvent := &corev1.Event{Source: ComplianceEventSource("Configuration-Policy-Controller")}
+	reportedEvent := &corev1.Event{ReportingController: "gatekeeper-constraint-status-sync"}
+	spoofedEvent := &corev1.Event{Source: corev1.EventSource{Component: "some-pod"}}
+
+	trustAll := NewEventSourceTrust()
+	Expect(trustAll.Trusted(spoofedEvent.Source, spoofedEvent.ReportingController)).To(BeTrue())
+
+	trust := NewEventSourceTrust(" configuration-policy-controller", "gatekeeper-constraint-status-sync", "")
+	Expect(trust).To(HaveLen(2))
+	Expect(trust.Trusted(configPolicyEvent.Source, configPolicyEvent.ReportingController)).To(BeTrue())
+	Expect(trust.Trusted(reportedEvent.Source, reportedEvent.ReportingController)).To(BeTrue())
+	Expect(trust.Trusted(spoofedEvent.Source, spoofedEvent.ReportingController)).To(BeFalse())
+}
+
+func TestComplianceEventReason(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ComplianceEventReason("managed", "config-policy")).To(Equal("policy: managed/config-policy"))
+}
+
+func TestComplianceEventFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	fields := ComplianceEventFields{Kind: "ConfigurationPolicy", Severity: "high", PolicyGeneration: 2}
+	Expect(fields.Annotations()).To(Equal(map[string]string{
+		ComplianceEventKindAnnotation:       "ConfigurationPolicy",
+		ComplianceEventSeverityAnnotation:   "high",
+		ComplianceEventGenerationAnnotation: "2",
+	}))
+	Expect(ComplianceEventFieldsFromAnnotations(fields.Annotations())).To(Equal(fields))
+
+	// An invalid generation is ignored and the severity is normalized
+	parsed := ComplianceEventFieldsFromAnnotations(map[string]string{
+		ComplianceEventSeverityAnnotation:   "Critical",
+		ComplianceEventGenerationAnnotation: "latest",
+	})
+	Expect(parsed).To(Equal(ComplianceEventFields{Severity: "critical"}))
+	Expect(parsed.Or(fields)).To(Equal(
+		ComplianceEventFields{Kind: "ConfigurationPolicy", Severity: "critical", PolicyGeneration: 2},
+	))
+	Expect(ComplianceEventFieldsFromAnnotations(nil).IsZero()).To(BeTrue())
+
+	policy := &policiesv1.Policy{}
+	policy.SetGeneration(5)
+
+	tName, templateFields := TemplateEventFields(policy, &policiesv1.PolicyTemplate{
+		ObjectDefinition: runtime.RawExtension{
+			Raw: []byte(`{"kind":"CertificatePolicy","metadata":{"name":"certs"},"spec":{"severity":"Low"}}`),
+		},
+	})
+	Expect(tName).To(Equal("certs"))
+	Expect(templateFields).To(Equal(
+		ComplianceEventFields{Kind: "CertificatePolicy", Severity: "low", PolicyGeneration: 5},
+	))
+
+	tName, templateFields = TemplateEventFields(policy, nil)
+	Expect(tName).To(BeEmpty())
+	Expect(templateFields).To(Equal(ComplianceEventFields{PolicyGeneration: 5}))
+}
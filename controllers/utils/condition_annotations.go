@@ -0,0 +1,130 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TemplatePendingAnnotation is set on the replicated policy on the managed cluster by the template sync to the JSON
+	// TemplatePending condition while the updates of some of its policy templates are held.
+	TemplatePendingAnnotation = "policy.open-cluster-management.io/template-pending"
+	// TemplatePendingCondition is the type of the condition reporting that the policy templates of a policy are held
+	// as Pending, such as until the policy templates they depend on are Compliant.
+	TemplatePendingCondition = "TemplatePending"
+	// RootPolicyCollisionAnnotation is set on the policy on the managed cluster by the spec sync and status sync to the
+	// JSON RootPolicyCollision condition while it collides with a replicated policy on the Hub from another root
+	// policy.
+	RootPolicyCollisionAnnotation = "policy.open-cluster-management.io/root-policy-collision"
+	// RootPolicyCollisionCondition is the type of the condition reporting that a policy on the managed cluster isn't
+	// synced since it is from another root policy than the replicated policy with the same name on the Hub.
+	RootPolicyCollisionCondition = "RootPolicyCollision"
+	// DeleteConflictAnnotation is set on the policy on the managed cluster by the spec sync and status sync to the JSON
+	// DeleteConflict condition when its deletion was refused since it changed after it was read.
+	DeleteConflictAnnotation = "policy.open-cluster-management.io/delete-conflict"
+	// DeleteConflictCondition is the type of the condition reporting that the deletion of a policy on the managed
+	// cluster was refused since it was modified by another actor after it was read.
+	DeleteConflictCondition = "DeleteConflict"
+)
+
+// ConditionAnnotation returns the condition in the input annotation of the input policy, or nil if it isn't set or
+// is invalid.
+func ConditionAnnotation(plc *policiesv1.Policy, annotation string) *metav1.Condition {
+	value, ok := plc.GetAnnotations()[annotation]
+	if !ok {
+		return nil
+	}
+
+	condition := &metav1.Condition{}
+	if err := json.Unmarshal([]byte(value), condition); err != nil {
+		return nil
+	}
+
+	return condition
+}
+
+// SetConditionAnnotation sets the input annotation of the input policy to the JSON input condition, or removes it if
+// the condition is nil. Since the policy status is owned by the policy framework, the conditions reported by the addon
+// are kept in annotations instead. The time of the condition is kept if it is already set with the same status. It
+// returns true if the annotation changed.
+func SetConditionAnnotation(plc *policiesv1.Policy, annotation string, condition *metav1.Condition) (bool, error) {
+	annotations := plc.GetAnnotations()
+	current, set := annotations[annotation]
+
+	if condition == nil {
+		if !set {
+			return false, nil
+		}
+
+		delete(annotations, annotation)
+		plc.SetAnnotations(annotations)
+
+		return true, nil
+	}
+
+	conditions := []metav1.Condition{}
+
+	if existing := ConditionAnnotation(plc, annotation); existing != nil {
+		conditions = append(conditions, *existing)
+	}
+
+	meta.SetStatusCondition(&conditions, *condition)
+
+	value, err := json.Marshal(conditions[0])
+	if err != nil {
+		return false, err
+	}
+
+	if set && current == string(value) {
+		return false, nil
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[annotation] = string(value)
+	plc.SetAnnotations(annotations)
+
+	return true, nil
+}
+
+// PatchConditionAnnotation sets the input annotation of the input policy to a True condition of the input type with
+// the message of the input error, or removes it if the error is nil, and patches the policy if the annotation changed.
+// The condition type is also its reason. On success, the input policy reflects the patched policy.
+func PatchConditionAnnotation(
+	ctx context.Context, c client.Client, plc *policiesv1.Policy, annotation string, conditionType string, err error,
+) error {
+	var condition *metav1.Condition
+
+	if err != nil {
+		condition = &metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  conditionType,
+			Message: err.Error(),
+		}
+	}
+
+	patched := plc.DeepCopy()
+
+	changed, setErr := SetConditionAnnotation(patched, annotation, condition)
+	if setErr != nil || !changed {
+		return setErr
+	}
+
+	if err := c.Patch(ctx, patched, client.MergeFrom(plc)); err != nil {
+		return err
+	}
+
+	*plc = *patched
+
+	return nil
+}
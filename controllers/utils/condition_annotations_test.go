@@ -0,0 +1,52 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestConditionAnnotation(t *testing.T) {
+	RegisterTestingT(t)
+
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster-ns"}}
+	Expect(ConditionAnnotation(plc, TemplatePendingAnnotation)).To(BeNil())
+
+	since := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	condition := &metav1.Condition{
+		Type:               TemplatePendingCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "PolicyTemplatePending",
+		Message:            "Policy template a is Pending",
+		LastTransitionTime: since,
+	}
+
+	Expect(SetConditionAnnotation(plc, TemplatePendingAnnotation, condition)).To(BeTrue())
+	Expect(SetConditionAnnotation(plc, TemplatePendingAnnotation, condition)).To(BeFalse())
+
+	// A new message keeps the transition time
+	updated := condition.DeepCopy()
+	updated.Message = "Policy template b is Pending"
+	updated.LastTransitionTime = metav1.NewTime(since.Add(time.Hour))
+	Expect(SetConditionAnnotation(plc, TemplatePendingAnnotation, updated)).To(BeTrue())
+
+	annotated := ConditionAnnotation(plc, TemplatePendingAnnotation)
+	Expect(annotated).ToNot(BeNil())
+	Expect(annotated.Message).To(Equal("Policy template b is Pending"))
+	Expect(annotated.LastTransitionTime.Time.Equal(since.Time)).To(BeTrue())
+
+	// The annotation is only set on the managed cluster
+	Expect(syncedAnnotations(plc)).ToNot(HaveKey(TemplatePendingAnnotation))
+
+	// An invalid annotation is ignored
+	plc.Annotations[TemplatePendingAnnotation] = "not JSON"
+	Expect(ConditionAnnotation(plc, TemplatePendingAnnotation)).To(BeNil())
+
+	Expect(SetConditionAnnotation(plc, TemplatePendingAnnotation, nil)).To(BeTrue())
+	Expect(plc.GetAnnotations()).ToNot(HaveKey(TemplatePendingAnnotation))
+}
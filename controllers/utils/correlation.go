@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// CorrelationIDAnnotation is set on the replicated policy on the managed cluster by the spec sync to an identifier of
+// the last change of the policy on the Hub. It is carried over to the events of the policy, the template objects
+// created or updated for the change, and the compliance history entries recorded after it, so that they can be related
+// to the change across the Hub and the managed cluster. The identifier has the format of an OpenTelemetry trace ID, and
+// the one set on the policy on the Hub is used if there is one.
+const CorrelationIDAnnotation = "policy.open-cluster-management.io/correlation-id"
+
+// NewCorrelationID returns a random correlation ID in the format of an OpenTelemetry trace ID, which is 16 bytes
+// encoded in 32 lowercase hexadecimal characters.
+func NewCorrelationID() string {
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+		// This is not expected to happen, and the correlation ID is only informative
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// SetCorrelationID sets the CorrelationIDAnnotation annotation of the input managed policy for a change of the input
+// policy on the Hub, to the correlation ID of the policy on the Hub or otherwise to a new one.
+func SetCorrelationID(hubPlc *policiesv1.Policy, managedPlc *policiesv1.Policy) {
+	id := hubPlc.GetAnnotations()[CorrelationIDAnnotation]
+	if id == "" {
+		id = NewCorrelationID()
+	}
+
+	if id == "" {
+		return
+	}
+
+	annotations := managedPlc.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[CorrelationIDAnnotation] = id
+	managedPlc.SetAnnotations(annotations)
+}
+
+// CorrelationID returns the correlation ID of the input object, or an empty string if it has none.
+func CorrelationID(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+
+	return accessor.GetAnnotations()[CorrelationIDAnnotation]
+}
+
+// correlatedRecorder sets the correlation ID of the involved object as an annotation of the events it records.
+type correlatedRecorder struct {
+	record.EventRecorder
+}
+
+// CorrelatedRecorder returns an event recorder wrapping the input one which annotates the events with the
+// CorrelationIDAnnotation annotation of their involved object when it is set.
+func CorrelatedRecorder(recorder record.EventRecorder) record.EventRecorder {
+	return correlatedRecorder{EventRecorder: recorder}
+}
+
+func (r correlatedRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, "%s", message)
+}
+
+func (r correlatedRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, messageFmt, args...)
+}
+
+func (r correlatedRecorder) AnnotatedEventf(
+	object runtime.Object,
+	annotations map[string]string,
+	eventtype, reason, messageFmt string,
+	args ...interface{},
+) {
+	id := CorrelationID(object)
+	if id == "" {
+		if annotations == nil {
+			r.EventRecorder.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+
+			return
+		}
+
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+
+		return
+	}
+
+	eventAnnotations := make(map[string]string, len(annotations)+1)
+	for key, value := range annotations {
+		eventAnnotations[key] = value
+	}
+
+	eventAnnotations[CorrelationIDAnnotation] = id
+
+	r.EventRecorder.AnnotatedEventf(object, eventAnnotations, eventtype, reason, messageFmt, args...)
+}
@@ -0,0 +1,76 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// annotationRecorder records the messages and annotations of the events.
+type annotationRecorder struct {
+	record.FakeRecorder
+	messages    []string
+	annotations []map[string]string
+}
+
+func (r *annotationRecorder) Event(_ runtime.Object, _, _, message string) {
+	r.messages = append(r.messages, message)
+	r.annotations = append(r.annotations, nil)
+}
+
+func (r *annotationRecorder) AnnotatedEventf(
+	_ runtime.Object, annotations map[string]string, _, _, messageFmt string, args ...interface{},
+) {
+	r.messages = append(r.messages, fmt.Sprintf(messageFmt, args...))
+	r.annotations = append(r.annotations, annotations)
+}
+
+func TestSetCorrelationID(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{}
+	managedPlc := &policiesv1.Policy{}
+
+	SetCorrelationID(hubPlc, managedPlc)
+
+	first := CorrelationID(managedPlc)
+	Expect(first).To(MatchRegexp("^[0-9a-f]{32}$"))
+
+	// Every change gets a new correlation ID
+	SetCorrelationID(hubPlc, managedPlc)
+	Expect(CorrelationID(managedPlc)).ToNot(Equal(first))
+
+	// The correlation ID of the policy on the Hub is used if it is set
+	hubPlc.SetAnnotations(map[string]string{CorrelationIDAnnotation: "4bf92f3577b34da6a3ce929d0e0e4736"})
+
+	SetCorrelationID(hubPlc, managedPlc)
+	Expect(CorrelationID(managedPlc)).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+}
+
+func TestCorrelatedRecorder(t *testing.T) {
+	RegisterTestingT(t)
+
+	fake := &annotationRecorder{}
+	recorder := CorrelatedRecorder(fake)
+
+	recorder.Event(&policiesv1.Policy{}, "Normal", "PolicySpecSync", "no correlation ID")
+
+	plc := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{CorrelationIDAnnotation: "abc"}},
+	}
+
+	recorder.Eventf(plc, "Normal", "PolicySpecSync", "Policy %s was updated", "policy")
+	recorder.AnnotatedEventf(plc, map[string]string{"other": "value"}, "Normal", "PolicySpecSync", "annotated")
+
+	Expect(fake.messages).To(Equal([]string{"no correlation ID", "Policy policy was updated", "annotated"}))
+	Expect(fake.annotations[0]).To(BeNil())
+	Expect(fake.annotations[1]).To(Equal(map[string]string{CorrelationIDAnnotation: "abc"}))
+	Expect(fake.annotations[2]).To(Equal(map[string]string{CorrelationIDAnnotation: "abc", "other": "value"}))
+}
@@ -0,0 +1,61 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DeletionGuard delays the deletion of a policy on the managed cluster after its replicated policy disappears from the
+// Hub. This protects against transient inconsistencies in the Hub cache or list results which would otherwise cause
+// destructive delete and recreate cycles of the policy and its template objects.
+type DeletionGuard struct {
+	GracePeriod  time.Duration
+	missingSince map[types.NamespacedName]time.Time
+	lock         sync.Mutex
+}
+
+// Remaining records that the input policy is missing on the Hub and returns how much longer to wait before deleting
+// it on the managed cluster. Zero means that the grace period elapsed and the deletion can proceed after verifying
+// that the policy is still missing. A nil DeletionGuard always returns zero.
+func (g *DeletionGuard) Remaining(policy types.NamespacedName) time.Duration {
+	if g == nil || g.GracePeriod <= 0 {
+		return 0
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.missingSince == nil {
+		g.missingSince = map[types.NamespacedName]time.Time{}
+	}
+
+	since, ok := g.missingSince[policy]
+	if !ok {
+		since = time.Now()
+		g.missingSince[policy] = since
+	}
+
+	remaining := g.GracePeriod - time.Since(since)
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+// Clear forgets that the input policy was missing on the Hub, such as when it is found again or after it is deleted
+// on the managed cluster. A nil DeletionGuard does nothing.
+func (g *DeletionGuard) Clear(policy types.NamespacedName) {
+	if g == nil {
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	delete(g.missingSince, policy)
+}
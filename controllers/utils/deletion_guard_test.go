@@ -0,0 +1,33 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDeletionGuard(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := types.NamespacedName{Namespace: "managed", Name: "policy"}
+
+	var nilGuard *DeletionGuard
+	Expect(nilGuard.Remaining(policy)).To(BeZero())
+	nilGuard.Clear(policy)
+
+	guard := &DeletionGuard{GracePeriod: time.Hour}
+	remaining := guard.Remaining(policy)
+	Expect(remaining).To(BeNumerically(">", 59*time.Minute))
+	Expect(guard.Remaining(policy)).To(BeNumerically("<=", remaining))
+
+	guard.missingSince[policy] = time.Now().Add(-2 * time.Hour)
+	Expect(guard.Remaining(policy)).To(BeZero())
+
+	// The grace period starts over once the policy is found again
+	guard.Clear(policy)
+	Expect(guard.Remaining(policy)).To(BeNumerically(">", 59*time.Minute))
+}
@@ -0,0 +1,116 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// DryRunMessagePrefix is the prefix of the messages of the events emitted in dry run mode.
+const DryRunMessagePrefix = "Dry run: "
+
+// dryRunReasonPrefix is the prefix of the reason of the compliance events emitted in dry run mode, so that the status
+// sync doesn't record them in the compliance history.
+const dryRunReasonPrefix = "dry-run "
+
+// eventsPathRegex matches the paths of the event API requests, which are still made in dry run mode.
+var eventsPathRegex = regexp.MustCompile(`^/api/v1/(namespaces/[^/]+/)?events(/|$)|^/apis/events\.k8s\.io/`)
+
+// dryRunRoundTripper turns the API requests modifying objects into server-side dry run requests.
+type dryRunRoundTripper struct {
+	next http.RoundTripper
+	log  logr.Logger
+}
+
+// NewDryRunWrapper returns a function that wraps a round tripper so that the API server validates the requests
+// creating, updating, patching, or deleting objects without persisting the changes, to be passed to rest.Config.Wrap.
+// Each of these requests is logged with the controller making it. The event requests are left as is so that the
+// controllers can report what they would change. The client name identifies the client in the logs.
+func NewDryRunWrapper(clientName string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &dryRunRoundTripper{
+			next: next,
+			log:  ctrl.Log.WithName("dry-run").WithValues("client", clientName),
+		}
+	}
+}
+
+// RoundTrip performs the request, as a dry run if it modifies an object.
+func (d *dryRunRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isWriteRequest(req) || eventsPathRegex.MatchString(req.URL.Path) {
+		return d.next.RoundTrip(req)
+	}
+
+	// A round tripper must not modify the input request
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("dryRun", "All")
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := d.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	d.log.Info("Would have made the API request", "controller", controllerFromContext(req.Context()),
+		"method", req.Method, "path", req.URL.Path, "statusCode", resp.StatusCode)
+
+	return resp, err
+}
+
+// isWriteRequest returns true if the input API request creates, updates, patches, or deletes objects.
+func isWriteRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// dryRunRecorder marks the events it emits as coming from a dry run.
+type dryRunRecorder struct {
+	record.EventRecorder
+}
+
+// DryRunRecorder returns an event recorder which prefixes the messages of the events with DryRunMessagePrefix, so
+// that the events about the changes a controller would make aren't mistaken for actual changes. The reason of the
+// compliance events is also prefixed so that the status sync doesn't record them in the compliance history.
+func DryRunRecorder(recorder record.EventRecorder) record.EventRecorder {
+	return dryRunRecorder{EventRecorder: recorder}
+}
+
+func (r dryRunRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.EventRecorder.Event(object, eventtype, dryRunReason(reason), DryRunMessagePrefix+message)
+}
+
+func (r dryRunRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.EventRecorder.Eventf(object, eventtype, dryRunReason(reason), DryRunMessagePrefix+messageFmt, args...)
+}
+
+func (r dryRunRecorder) AnnotatedEventf(
+	object runtime.Object,
+	annotations map[string]string,
+	eventtype, reason, messageFmt string,
+	args ...interface{},
+) {
+	r.EventRecorder.AnnotatedEventf(
+		object, annotations, eventtype, dryRunReason(reason), DryRunMessagePrefix+messageFmt, args...,
+	)
+}
+
+// dryRunReason returns the reason of an event emitted in dry run mode.
+func dryRunReason(reason string) string {
+	if strings.HasPrefix(strings.ToLower(reason), ComplianceEventReasonPrefix) {
+		return dryRunReasonPrefix + reason
+	}
+
+	return reason
+}
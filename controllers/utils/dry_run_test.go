@@ -0,0 +1,71 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+type queryRecordingRoundTripper struct {
+	queries []string
+}
+
+func (q *queryRecordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	q.queries = append(q.queries, req.URL.RawQuery)
+
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestDryRunWrapper(t *testing.T) {
+	RegisterTestingT(t)
+
+	next := &queryRecordingRoundTripper{}
+	dryRun := NewDryRunWrapper("managed")(next)
+
+	for _, test := range []struct {
+		method   string
+		url      string
+		expected string
+	}{
+		{http.MethodGet, "https://managed:6443/api/v1/namespaces/cluster/secrets", ""},
+		{http.MethodPost, "https://managed:6443/api/v1/namespaces/cluster/secrets", "dryRun=All"},
+		{
+			http.MethodPatch,
+			"https://managed:6443/apis/policy.open-cluster-management.io/v1/namespaces/cluster/policies/p?force=true",
+			"dryRun=All&force=true",
+		},
+		{http.MethodDelete, "https://managed:6443/api/v1/namespaces/cluster/eventsources/e", "dryRun=All"},
+		{http.MethodPost, "https://managed:6443/api/v1/namespaces/cluster/events", ""},
+		{http.MethodPatch, "https://managed:6443/apis/events.k8s.io/v1/namespaces/cluster/events/e", ""},
+	} {
+		req, err := http.NewRequestWithContext(context.TODO(), test.method, test.url, nil)
+		Expect(err).To(BeNil())
+
+		resp, err := dryRun.RoundTrip(req)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(next.queries[len(next.queries)-1]).To(Equal(test.expected), test.method+" "+test.url)
+		// The input request is left as is
+		Expect(req.URL.Query().Get("dryRun")).To(BeEmpty())
+	}
+}
+
+func TestDryRunRecorder(t *testing.T) {
+	RegisterTestingT(t)
+
+	fakeRecorder := record.NewFakeRecorder(2)
+	recorder := DryRunRecorder(fakeRecorder)
+	obj := &corev1.ConfigMap{}
+
+	recorder.Event(obj, "Normal", "PolicySpecSync", "Policy cluster/p was updated")
+	Expect(<-fakeRecorder.Events).To(Equal("Normal PolicySpecSync Dry run: Policy cluster/p was updated"))
+
+	recorder.Eventf(obj, "Warning", ComplianceEventReason("cluster", "t"), "NonCompliant; %s", "template-error")
+	Expect(<-fakeRecorder.Events).To(Equal("Warning dry-run policy: cluster/t Dry run: NonCompliant; template-error"))
+}
@@ -0,0 +1,260 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var watcherLog = ctrl.Log.WithName("dynamic-watcher")
+
+// WatchFilter determines if a change to a watched object is sent to a consumer. The old object is nil when the object
+// is added, and the new object is nil when the object is deleted.
+type WatchFilter func(oldObj, newObj *metav1.PartialObjectMetadata) bool
+
+// watchRef is a reference from a policy of a consumer to a watched resource.
+type watchRef struct {
+	consumer string
+	policy   types.NamespacedName
+}
+
+type watchSubscriber struct {
+	events chan event.GenericEvent
+	filter WatchFilter
+}
+
+// DynamicWatcher maintains watches on the resources of the policy templates in use in the cluster namespace. Each
+// consumer tracks the resources used by each of its policies, and a watch is started when a resource is first
+// referenced and stopped when it is no longer referenced by any policy of any consumer. A change to a watched object
+// results in a reconcile event for the policy owning it, sent to each consumer tracking the resource for that policy.
+// Since the watches only trigger reconciles, they only cache the metadata of the objects to limit the memory usage.
+type DynamicWatcher struct {
+	Client    metadata.Interface
+	Namespace string
+	ctx       context.Context
+	// refs maps a resource to the policies of the consumers referencing it
+	refs        map[schema.GroupVersionResource]map[watchRef]bool
+	watches     map[schema.GroupVersionResource]context.CancelFunc
+	subscribers map[string]*watchSubscriber
+	once        sync.Once
+	lock        sync.RWMutex
+}
+
+func (w *DynamicWatcher) init() {
+	w.once.Do(func() {
+		w.refs = map[schema.GroupVersionResource]map[watchRef]bool{}
+		w.watches = map[schema.GroupVersionResource]context.CancelFunc{}
+		w.subscribers = map[string]*watchSubscriber{}
+	})
+}
+
+// Events returns the channel of reconcile events for the policies of the input consumer owning an object that changed.
+// Only the changes passing the input filter are sent. If the filter is nil, all changes are sent.
+func (w *DynamicWatcher) Events(consumer string, filter WatchFilter) <-chan event.GenericEvent {
+	w.init()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.subscribers[consumer] == nil {
+		w.subscribers[consumer] = &watchSubscriber{events: make(chan event.GenericEvent, 1024), filter: filter}
+	}
+
+	return w.subscribers[consumer].events
+}
+
+// Start starts the watches on the tracked resources and stops all the watches when the input context is closed.
+func (w *DynamicWatcher) Start(ctx context.Context) error {
+	w.init()
+
+	w.lock.Lock()
+	w.ctx = ctx
+
+	for gvr := range w.refs {
+		w.startWatch(gvr)
+	}
+
+	w.lock.Unlock()
+
+	<-ctx.Done()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for gvr, cancel := range w.watches {
+		cancel()
+		delete(w.watches, gvr)
+	}
+
+	return nil
+}
+
+// Track records the resources used by the input policy of the input consumer, starting and stopping watches as
+// needed. Passing no resources removes the policy. A nil DynamicWatcher does nothing.
+func (w *DynamicWatcher) Track(
+	consumer string, policy types.NamespacedName, resources map[schema.GroupVersionResource]bool,
+) {
+	if w == nil {
+		return
+	}
+
+	w.init()
+
+	ref := watchRef{consumer: consumer, policy: policy}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for gvr, refs := range w.refs {
+		if resources[gvr] {
+			continue
+		}
+
+		delete(refs, ref)
+
+		if len(refs) == 0 {
+			delete(w.refs, gvr)
+			w.stopWatch(gvr)
+		}
+	}
+
+	for gvr := range resources {
+		if w.refs[gvr] == nil {
+			w.refs[gvr] = map[watchRef]bool{}
+		}
+
+		w.refs[gvr][ref] = true
+
+		w.startWatch(gvr)
+	}
+}
+
+// Watching returns true if the input resource is watched.
+func (w *DynamicWatcher) Watching(gvr schema.GroupVersionResource) bool {
+	w.init()
+
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	_, watching := w.watches[gvr]
+
+	return watching
+}
+
+// startWatch starts a watch on the input resource if it isn't already watched and the DynamicWatcher is started. The
+// lock must be held by the caller.
+func (w *DynamicWatcher) startWatch(gvr schema.GroupVersionResource) {
+	if w.ctx == nil || w.ctx.Err() != nil {
+		return
+	}
+
+	if _, ok := w.watches[gvr]; ok {
+		return
+	}
+
+	watcherLog.Info("Starting a watch on the policy template resource", "resource", gvr.String())
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	w.watches[gvr] = cancel
+
+	informer := metadatainformer.NewFilteredMetadataInformer(
+		w.Client, gvr, w.Namespace, 0, cache.Indexers{}, nil,
+	).Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.dispatch(gvr, nil, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.dispatch(gvr, oldObj, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+
+			w.dispatch(gvr, obj, nil)
+		},
+	})
+
+	go informer.Run(ctx.Done())
+}
+
+// stopWatch stops the watch on the input resource if it is watched. The lock must be held by the caller.
+func (w *DynamicWatcher) stopWatch(gvr schema.GroupVersionResource) {
+	cancel, ok := w.watches[gvr]
+	if !ok {
+		return
+	}
+
+	watcherLog.Info(
+		"Stopping the watch on the policy template resource since it is no longer used", "resource", gvr.String(),
+	)
+
+	cancel()
+	delete(w.watches, gvr)
+}
+
+// dispatch sends a reconcile event for the policy owning the changed object to each consumer tracking the resource
+// for that policy whose filter accepts the change.
+func (w *DynamicWatcher) dispatch(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+	oldMeta, _ := oldObj.(*metav1.PartialObjectMetadata)
+	newMeta, _ := newObj.(*metav1.PartialObjectMetadata)
+
+	obj := newMeta
+	if obj == nil {
+		obj = oldMeta
+	}
+
+	if obj == nil {
+		return
+	}
+
+	owner := ""
+
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.Kind == policiesv1.Kind && ownerRef.APIVersion == policiesv1.GroupVersion.String() {
+			owner = ownerRef.Name
+
+			break
+		}
+	}
+
+	if owner == "" {
+		return
+	}
+
+	policy := types.NamespacedName{Namespace: obj.GetNamespace(), Name: owner}
+	subscribers := []*watchSubscriber{}
+
+	w.lock.RLock()
+
+	for consumer, subscriber := range w.subscribers {
+		if w.refs[gvr][watchRef{consumer: consumer, policy: policy}] {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+
+	w.lock.RUnlock()
+
+	for _, subscriber := range subscribers {
+		if subscriber.filter != nil && !subscriber.filter(oldMeta, newMeta) {
+			continue
+		}
+
+		subscriber.events <- event.GenericEvent{
+			Object: &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Namespace: policy.Namespace, Name: policy.Name}},
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestDynamicWatcher(t *testing.T) {
+	RegisterTestingT(t)
+
+	gvr := schema.GroupVersionResource{
+		Group: "policy.open-cluster-management.io", Version: "v1", Resource: "configurationpolicies",
+	}
+	scheme := runtime.NewScheme()
+	Expect(metav1.AddMetaToScheme(scheme)).To(Succeed())
+
+	client := metadatafake.NewSimpleMetadataClient(scheme)
+	watcher := &DynamicWatcher{Client: client, Namespace: "managed"}
+	events := watcher.Events("consumer-a", nil)
+	_ = watcher.Events("consumer-b", func(oldObj, newObj *metav1.PartialObjectMetadata) bool { return false })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Resources tracked before the start are watched once started
+	policy := types.NamespacedName{Namespace: "managed", Name: "policy"}
+	watcher.Track("consumer-a", policy, map[schema.GroupVersionResource]bool{gvr: true})
+	Expect(watcher.Watching(gvr)).To(BeFalse())
+
+	go func() {
+		_ = watcher.Start(ctx)
+	}()
+
+	Eventually(func() bool { return watcher.Watching(gvr) }, 5*time.Second).Should(BeTrue())
+
+	watcher.Track("consumer-b", policy, map[schema.GroupVersionResource]bool{gvr: true})
+
+	templateObj := &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: "policy.open-cluster-management.io/v1", Kind: "ConfigurationPolicy"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "template",
+			Namespace: "managed",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: policiesv1.GroupVersion.String(), Kind: policiesv1.Kind, Name: "policy"},
+			},
+		},
+	}
+
+	//nolint:forcetypeassert
+	_, err := client.Resource(gvr).Namespace("managed").(metadatafake.MetadataClient).CreateFake(
+		templateObj, metav1.CreateOptions{},
+	)
+	Expect(err).To(BeNil())
+
+	Eventually(events, 5*time.Second).Should(Receive(WithTransform(
+		func(e event.GenericEvent) string { return e.Object.GetName() }, Equal("policy"),
+	)))
+
+	// The watch is kept until no consumer references the resource
+	watcher.Track("consumer-a", policy, nil)
+	Expect(watcher.Watching(gvr)).To(BeTrue())
+
+	watcher.Track("consumer-b", policy, nil)
+	Expect(watcher.Watching(gvr)).To(BeFalse())
+
+	// A nil DynamicWatcher does nothing
+	var nilWatcher *DynamicWatcher
+	nilWatcher.Track("consumer-a", policy, map[schema.GroupVersionResource]bool{gvr: true})
+}
@@ -0,0 +1,171 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// LastHubSyncAnnotation is set on the addon lease to the last time the addon successfully read from the Hub.
+	LastHubSyncAnnotation = "policy.open-cluster-management.io/last-hub-sync"
+	// LastStatusWriteAnnotation is set on the addon lease to the last time the addon successfully wrote a policy
+	// status to the Hub.
+	LastStatusWriteAnnotation = "policy.open-cluster-management.io/last-status-write"
+)
+
+// Heartbeat records the last successful interactions with the Hub. A nil Heartbeat is valid and records nothing.
+type Heartbeat struct {
+	lastHubSync     int64
+	lastStatusWrite int64
+}
+
+// RecordHubSync records that the addon successfully read from the Hub.
+func (h *Heartbeat) RecordHubSync() {
+	if h != nil {
+		atomic.StoreInt64(&h.lastHubSync, time.Now().Unix())
+	}
+}
+
+// hubSyncRoundTripper records the successful reads from the Hub in a Heartbeat.
+type hubSyncRoundTripper struct {
+	next      http.RoundTripper
+	heartbeat *Heartbeat
+}
+
+// NewHubSyncWrapper returns a function that wraps a round tripper so that each read answered by the Hub API server is
+// recorded as a Hub sync in the input Heartbeat, to be passed to the rest.Config.Wrap of the Hub. Recording the API
+// responses rather than the reads of the controllers, which may be served by the cache during a Hub outage, ensures
+// that the recorded time is that of an actual round trip with the Hub. A read of a missing object counts as a sync.
+func NewHubSyncWrapper(heartbeat *Heartbeat) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &hubSyncRoundTripper{next: next, heartbeat: heartbeat}
+	}
+}
+
+// RoundTrip performs the request and records a Hub sync if it is a read answered successfully.
+func (h *hubSyncRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := h.next.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet {
+		return resp, err
+	}
+
+	if resp.StatusCode < http.StatusMultipleChoices || resp.StatusCode == http.StatusNotFound {
+		h.heartbeat.RecordHubSync()
+	}
+
+	return resp, err
+}
+
+// RecordStatusWrite records that the addon successfully wrote a policy status to the Hub.
+func (h *Heartbeat) RecordStatusWrite() {
+	if h != nil {
+		atomic.StoreInt64(&h.lastStatusWrite, time.Now().Unix())
+	}
+}
+
+// annotations returns the lease annotations for the recorded times. Times that were never recorded are omitted.
+func (h *Heartbeat) annotations() map[string]string {
+	annotations := map[string]string{}
+
+	if h == nil {
+		return annotations
+	}
+
+	for annotation, timestamp := range map[string]*int64{
+		LastHubSyncAnnotation:     &h.lastHubSync,
+		LastStatusWriteAnnotation: &h.lastStatusWrite,
+	} {
+		if unix := atomic.LoadInt64(timestamp); unix != 0 {
+			annotations[annotation] = time.Unix(unix, 0).UTC().Format(time.RFC3339)
+		}
+	}
+
+	return annotations
+}
+
+// LeaseAnnotator periodically sets the Heartbeat times as annotations on the addon lease so that the Hub addon manager
+// can distinguish an addon that is running but failing to sync with the Hub from a healthy addon. The lease itself is
+// renewed by the addon framework lease updater.
+type LeaseAnnotator struct {
+	Heartbeat      *Heartbeat
+	Client         kubernetes.Interface
+	LeaseName      string
+	LeaseNamespace string
+	// HubClient and HubLeaseNamespace are used when the lease is not on the managed cluster, in which case the addon
+	// framework lease updater falls back to a lease on the Hub.
+	HubClient         kubernetes.Interface
+	HubLeaseNamespace string
+	Interval          time.Duration
+}
+
+// Start annotates the lease every interval until the input context is closed.
+func (a *LeaseAnnotator) Start(ctx context.Context) {
+	wait.UntilWithContext(ctx, a.annotate, a.Interval)
+}
+
+func (a *LeaseAnnotator) annotate(ctx context.Context) {
+	log := ctrl.Log.WithName("lease-annotator")
+
+	annotations := a.Heartbeat.annotations()
+	if len(annotations) == 0 {
+		return
+	}
+
+	leases := a.Client.CoordinationV1().Leases(a.LeaseNamespace)
+
+	lease, err := leases.Get(ctx, a.LeaseName, metav1.GetOptions{})
+	if errors.IsNotFound(err) && a.HubClient != nil {
+		// The lease isn't on the managed cluster, in which case the addon framework lease updater falls back to a
+		// lease on the Hub
+		leases = a.HubClient.CoordinationV1().Leases(a.HubLeaseNamespace)
+
+		lease, err = leases.Get(ctx, a.LeaseName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return
+		}
+	}
+
+	if err != nil {
+		log.Error(err, "Failed to get the lease", "name", a.LeaseName)
+
+		return
+	}
+
+	// The deployments of a split deployment each annotate the times they recorded, so a time is only set if it is
+	// later than the one already on the lease
+	for annotation, timestamp := range annotations {
+		if current := lease.GetAnnotations()[annotation]; current >= timestamp {
+			delete(annotations, annotation)
+		}
+	}
+
+	if len(annotations) == 0 {
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		log.Error(err, "Failed to generate the lease patch")
+
+		return
+	}
+
+	_, err = leases.Patch(ctx, a.LeaseName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to annotate the lease", "namespace", lease.GetNamespace(), "name", a.LeaseName)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLeaseAnnotator(t *testing.T) {
+	RegisterTestingT(t)
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "governance-policy-framework", Namespace: "cluster1"},
+	}
+	client := fake.NewSimpleClientset()
+	hubClient := fake.NewSimpleClientset(lease)
+	heartbeat := &Heartbeat{}
+
+	annotator := &LeaseAnnotator{
+		Heartbeat:         heartbeat,
+		Client:            client,
+		LeaseName:         "governance-policy-framework",
+		LeaseNamespace:    "open-cluster-management-agent-addon",
+		HubClient:         hubClient,
+		HubLeaseNamespace: "cluster1",
+		Interval:          time.Minute,
+	}
+
+	// Nothing was recorded yet, so the lease is left alone
+	annotator.annotate(context.TODO())
+	Expect(hubClient.Actions()).To(BeEmpty())
+
+	heartbeat.RecordHubSync()
+	annotator.annotate(context.TODO())
+
+	// The lease isn't on the managed cluster, so the Hub lease is annotated instead
+	hubLease, err := hubClient.CoordinationV1().Leases("cluster1").Get(
+		context.TODO(), "governance-policy-framework", metav1.GetOptions{},
+	)
+	Expect(err).To(BeNil())
+	Expect(hubLease.Annotations).To(HaveKey(LastHubSyncAnnotation))
+	Expect(hubLease.Annotations).ToNot(HaveKey(LastStatusWriteAnnotation))
+
+	lastHubSync, err := time.Parse(time.RFC3339, hubLease.Annotations[LastHubSyncAnnotation])
+	Expect(err).To(BeNil())
+	Expect(lastHubSync).To(BeTemporally("~", time.Now(), 5*time.Second))
+
+	heartbeat.RecordStatusWrite()
+	annotator.annotate(context.TODO())
+
+	hubLease, err = hubClient.CoordinationV1().Leases("cluster1").Get(
+		context.TODO(), "governance-policy-framework", metav1.GetOptions{},
+	)
+	Expect(err).To(BeNil())
+	Expect(hubLease.Annotations).To(HaveKey(LastStatusWriteAnnotation))
+
+	// A later time recorded by the other deployment of a split deployment is kept
+	later := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	hubLease.Annotations[LastHubSyncAnnotation] = later
+	_, err = hubClient.CoordinationV1().Leases("cluster1").Update(context.TODO(), hubLease, metav1.UpdateOptions{})
+	Expect(err).To(BeNil())
+
+	annotator.annotate(context.TODO())
+
+	hubLease, err = hubClient.CoordinationV1().Leases("cluster1").Get(
+		context.TODO(), "governance-policy-framework", metav1.GetOptions{},
+	)
+	Expect(err).To(BeNil())
+	Expect(hubLease.Annotations[LastHubSyncAnnotation]).To(Equal(later))
+}
+
+func TestNilHeartbeat(t *testing.T) {
+	RegisterTestingT(t)
+
+	var heartbeat *Heartbeat
+
+	Expect(heartbeat.RecordHubSync).ToNot(Panic())
+	Expect(heartbeat.RecordStatusWrite).ToNot(Panic())
+}
+
+type statusRoundTripper struct {
+	statusCode int
+	err        error
+}
+
+func (s *statusRoundTripper) RoundTrip(_ *http.Request) (*http.Response, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return &http.Response{StatusCode: s.statusCode}, nil
+}
+
+func TestHubSyncWrapper(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, test := range []struct {
+		method     string
+		statusCode int
+		err        error
+		recorded   bool
+	}{
+		{http.MethodGet, http.StatusOK, nil, true},
+		{http.MethodGet, http.StatusNotFound, nil, true},
+		{http.MethodGet, http.StatusServiceUnavailable, nil, false},
+		{http.MethodGet, 0, errors.New("connection refused"), false},
+		{http.MethodPatch, http.StatusOK, nil, false},
+	} {
+		heartbeat := &Heartbeat{}
+		wrapped := NewHubSyncWrapper(heartbeat)(&statusRoundTripper{statusCode: test.statusCode, err: test.err})
+
+		req, err := http.NewRequestWithContext(
+			context.TODO(), test.method, "https://hub:6443/apis/policy.open-cluster-management.io/v1/policies", nil,
+		)
+		Expect(err).To(BeNil())
+
+		_, _ = wrapped.RoundTrip(req)
+
+		if test.recorded {
+			Expect(heartbeat.annotations()).To(HaveKey(LastHubSyncAnnotation), test.method)
+		} else {
+			Expect(heartbeat.annotations()).To(BeEmpty(), test.method)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultHistoryCarryOverTTL is how long the compliance history of a deleted policy is kept for a policy with the same
+// identifier to be created.
+const DefaultHistoryCarryOverTTL = time.Hour
+
+const (
+	// historyCarryOverLabel is set on the ConfigMaps keeping the compliance history of the deleted policies.
+	historyCarryOverLabel = "policy.open-cluster-management.io/history-carry-over"
+	// historyCarryOverIDKey is the ConfigMap key with the identifier of the deleted policy.
+	historyCarryOverIDKey = "id"
+	// historyCarryOverDetailsKey is the ConfigMap key with the compliance history of the deleted policy.
+	historyCarryOverDetailsKey = "details"
+	// historyCarryOverStoredAtKey is the ConfigMap key with the time the compliance history was kept.
+	historyCarryOverStoredAtKey = "storedAt"
+)
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;delete
+
+// HistoryCarryOver keeps the compliance history of the deleted policies, keyed by the value of a stable identifier
+// annotation, so that it can be restored on the next policy with the same identifier. When a policy is renamed on the
+// Hub, which is a delete and a create, this continues its compliance history rather than starting over. The history is
+// kept for the TTL in a ConfigMap in the namespace of the policy on the managed cluster, so that it survives a restart
+// and is shared by the deployments of a split deployment, where the policy may be deleted by the spec sync controller
+// in one pod and recreated by the status sync controller in another.
+type HistoryCarryOver struct {
+	// Client reads and writes the ConfigMaps on the managed cluster. It should read from the API server rather than a
+	// cache, so that a history isn't restored twice.
+	Client client.Client
+	// Annotation is the policy annotation with the stable identifier of the policy
+	Annotation string
+	// TTL is how long the history of a deleted policy is kept. If it is not positive, DefaultHistoryCarryOverTTL is
+	// used.
+	TTL time.Duration
+}
+
+func (h *HistoryCarryOver) ttl() time.Duration {
+	if h.TTL <= 0 {
+		return DefaultHistoryCarryOverTTL
+	}
+
+	return h.TTL
+}
+
+// historyCarryOverName returns the name of the ConfigMap keeping the compliance history for the input identifier. The
+// identifier is hashed since it may not be a valid name.
+func historyCarryOverName(id string) string {
+	sum := sha256.Sum256([]byte(id))
+
+	return "compliance-history-carry-over-" + hex.EncodeToString(sum[:8])
+}
+
+// expired returns true if the input ConfigMap keeps a compliance history older than the TTL.
+func (h *HistoryCarryOver) expired(configMap *corev1.ConfigMap) bool {
+	storedAt, err := time.Parse(time.RFC3339Nano, configMap.Data[historyCarryOverStoredAtKey])
+
+	return err != nil || time.Since(storedAt) > h.ttl()
+}
+
+// Store keeps the compliance history of the input policy, which is being deleted. Policies without the identifier
+// annotation or without a compliance history are ignored. A nil HistoryCarryOver does nothing.
+func (h *HistoryCarryOver) Store(ctx context.Context, policy *policiesv1.Policy) error {
+	if h == nil || policy == nil {
+		return nil
+	}
+
+	id := policy.GetAnnotations()[h.Annotation]
+	if id == "" || len(policy.Status.Details) == 0 {
+		return nil
+	}
+
+	// Drop the expired entries so that they don't accumulate
+	configMaps := &corev1.ConfigMapList{}
+
+	err := h.Client.List(
+		ctx, configMaps, client.InNamespace(policy.GetNamespace()), client.HasLabels{historyCarryOverLabel},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list the compliance history carry-over ConfigMaps: %w", err)
+	}
+
+	for i := range configMaps.Items {
+		if h.expired(&configMaps.Items[i]) {
+			if err := h.Client.Delete(ctx, &configMaps.Items[i]); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete an expired compliance history carry-over ConfigMap: %w", err)
+			}
+		}
+	}
+
+	details, err := json.Marshal(policy.Status.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode the compliance history: %w", err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      historyCarryOverName(id),
+			Namespace: policy.GetNamespace(),
+			Labels:    map[string]string{historyCarryOverLabel: ""},
+		},
+		Data: map[string]string{
+			historyCarryOverIDKey:       id,
+			historyCarryOverDetailsKey:  string(details),
+			historyCarryOverStoredAtKey: time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	}
+
+	err = h.Client.Create(ctx, configMap)
+	if errors.IsAlreadyExists(err) {
+		existing := &corev1.ConfigMap{}
+
+		if err := h.Client.Get(ctx, client.ObjectKeyFromObject(configMap), existing); err != nil {
+			return fmt.Errorf("failed to get the compliance history carry-over ConfigMap: %w", err)
+		}
+
+		existing.Labels = configMap.Labels
+		existing.Data = configMap.Data
+		err = h.Client.Update(ctx, existing)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write the compliance history carry-over ConfigMap: %w", err)
+	}
+
+	return nil
+}
+
+// Restore sets the compliance history kept for the identifier of the input policy on the policy status and returns
+// true if it did. The history is only restored once, since its ConfigMap is deleted, and only on a policy without a
+// compliance history. A nil HistoryCarryOver does nothing.
+func (h *HistoryCarryOver) Restore(ctx context.Context, policy *policiesv1.Policy) (bool, error) {
+	if h == nil || len(policy.Status.Details) != 0 {
+		return false, nil
+	}
+
+	id := policy.GetAnnotations()[h.Annotation]
+	if id == "" {
+		return false, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+
+	err := h.Client.Get(
+		ctx, types.NamespacedName{Namespace: policy.GetNamespace(), Name: historyCarryOverName(id)}, configMap,
+	)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to get the compliance history carry-over ConfigMap: %w", err)
+	}
+
+	// Guard against a hash collision
+	if configMap.Data[historyCarryOverIDKey] != id {
+		return false, nil
+	}
+
+	// The deletion is preconditioned on the ConfigMap read so that only one controller restores the history
+	err = h.Client.Delete(ctx, configMap, client.Preconditions(*UnchangedPreconditions(configMap)))
+	if errors.IsNotFound(err) || errors.IsConflict(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to delete the compliance history carry-over ConfigMap: %w", err)
+	}
+
+	if h.expired(configMap) {
+		return false, nil
+	}
+
+	details := []*policiesv1.DetailsPerTemplate{}
+
+	if err := json.Unmarshal([]byte(configMap.Data[historyCarryOverDetailsKey]), &details); err != nil {
+		return false, fmt.Errorf("failed to decode the carried over compliance history: %w", err)
+	}
+
+	policy.Status.Details = details
+
+	return true, nil
+}
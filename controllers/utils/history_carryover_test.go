@@ -0,0 +1,111 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testPolicyIDAnnotation = "policy.open-cluster-management.io/policy-id"
+
+func policyWithID(name string, id string) *policiesv1.Policy {
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed"}}
+
+	if id != "" {
+		policy.SetAnnotations(map[string]string{testPolicyIDAnnotation: id})
+	}
+
+	return policy
+}
+
+func carryOverClient() client.Client {
+	scheme := runtime.NewScheme()
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	return fake.NewClientBuilder().WithScheme(scheme).Build()
+}
+
+func TestHistoryCarryOver(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := context.TODO()
+	c := carryOverClient()
+	carryOver := &HistoryCarryOver{Client: c, Annotation: testPolicyIDAnnotation}
+
+	oldPolicy := policyWithID("old-name", "1234")
+	oldPolicy.Status.Details = []*policiesv1.DetailsPerTemplate{{
+		TemplateMeta: metav1.ObjectMeta{Name: "template"},
+		History:      []policiesv1.ComplianceHistory{{EventName: "event", Message: "Compliant; no violations"}},
+	}}
+
+	Expect(carryOver.Store(ctx, oldPolicy)).To(Succeed())
+
+	// A policy with another identifier doesn't get the history
+	Expect(carryOver.Restore(ctx, policyWithID("other", "5678"))).To(BeFalse())
+	Expect(carryOver.Restore(ctx, policyWithID("other", ""))).To(BeFalse())
+
+	// The history is kept on the managed cluster, so another instance, such as the other deployment of a split
+	// deployment, restores it
+	otherCarryOver := &HistoryCarryOver{Client: c, Annotation: testPolicyIDAnnotation}
+
+	newPolicy := policyWithID("new-name", "1234")
+	Expect(otherCarryOver.Restore(ctx, newPolicy)).To(BeTrue())
+	Expect(newPolicy.Status.Details).To(HaveLen(1))
+	Expect(newPolicy.Status.Details[0].TemplateMeta.Name).To(Equal("template"))
+	Expect(newPolicy.Status.Details[0].History).To(Equal(oldPolicy.Status.Details[0].History))
+
+	// The history is only restored once
+	Expect(carryOver.Restore(ctx, policyWithID("new-name", "1234"))).To(BeFalse())
+
+	configMaps := &corev1.ConfigMapList{}
+	Expect(c.List(ctx, configMaps)).To(Succeed())
+	Expect(configMaps.Items).To(BeEmpty())
+}
+
+func TestHistoryCarryOverExpired(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := context.TODO()
+	c := carryOverClient()
+	carryOver := &HistoryCarryOver{Client: c, Annotation: testPolicyIDAnnotation, TTL: time.Millisecond}
+
+	oldPolicy := policyWithID("old-name", "1234")
+	oldPolicy.Status.Details = []*policiesv1.DetailsPerTemplate{{TemplateMeta: metav1.ObjectMeta{Name: "template"}}}
+
+	Expect(carryOver.Store(ctx, oldPolicy)).To(Succeed())
+	time.Sleep(5 * time.Millisecond)
+
+	Expect(carryOver.Restore(ctx, policyWithID("new-name", "1234"))).To(BeFalse())
+
+	// The expired histories are dropped when another history is kept
+	Expect(carryOver.Store(ctx, oldPolicy)).To(Succeed())
+	time.Sleep(5 * time.Millisecond)
+
+	otherPolicy := policyWithID("other", "5678")
+	otherPolicy.Status.Details = oldPolicy.Status.Details
+	Expect(carryOver.Store(ctx, otherPolicy)).To(Succeed())
+
+	configMaps := &corev1.ConfigMapList{}
+	Expect(c.List(ctx, configMaps)).To(Succeed())
+	Expect(configMaps.Items).To(HaveLen(1))
+	Expect(configMaps.Items[0].Name).To(Equal(historyCarryOverName("5678")))
+}
+
+func TestHistoryCarryOverNil(t *testing.T) {
+	RegisterTestingT(t)
+
+	var carryOver *HistoryCarryOver
+
+	Expect(carryOver.Store(context.TODO(), policyWithID("old-name", "1234"))).To(Succeed())
+	Expect(carryOver.Restore(context.TODO(), policyWithID("new-name", "1234"))).To(BeFalse())
+}
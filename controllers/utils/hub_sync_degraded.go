@@ -0,0 +1,151 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+const (
+	// HubSyncDegradedAnnotation is set on the replicated policy on the managed cluster by the status sync to the JSON
+	// HubSyncDegraded condition while its status fails to be synced to the Hub for longer than the threshold.
+	HubSyncDegradedAnnotation = "policy.open-cluster-management.io/hub-sync-degraded"
+	// HubSyncDegradedCondition is the type of the condition reporting that the policy statuses fail to be synced to
+	// the Hub.
+	HubSyncDegradedCondition = "HubSyncDegraded"
+)
+
+// hubSyncFailure is when the status of a policy started failing to be synced to the Hub and the last error.
+type hubSyncFailure struct {
+	since   time.Time
+	message string
+}
+
+// HubSyncMonitor tracks the policies whose status fails to be synced to the Hub, so that the degradation is reported
+// on the managed cluster once it lasts longer than the threshold. A nil HubSyncMonitor tracks nothing.
+type HubSyncMonitor struct {
+	// Threshold is how long the status of a policy must fail to be synced before it is reported as degraded.
+	Threshold time.Duration
+	failures  map[types.NamespacedName]hubSyncFailure
+	lock      sync.Mutex
+}
+
+// RecordFailure records that the status of the input policy failed to be synced to the Hub with the input error.
+func (m *HubSyncMonitor) RecordFailure(key types.NamespacedName, err error, now time.Time) {
+	if m == nil || err == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.failures == nil {
+		m.failures = map[types.NamespacedName]hubSyncFailure{}
+	}
+
+	failure, ok := m.failures[key]
+	if !ok {
+		failure.since = now
+	}
+
+	failure.message = err.Error()
+	m.failures[key] = failure
+}
+
+// RecordSuccess records that the status of the input policy was synced to the Hub, or that the policy was deleted.
+func (m *HubSyncMonitor) RecordSuccess(key types.NamespacedName) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.failures, key)
+}
+
+// PolicyCondition returns the HubSyncDegraded condition of the input policy, or nil if its status has not failed to
+// be synced for longer than the threshold.
+func (m *HubSyncMonitor) PolicyCondition(key types.NamespacedName, now time.Time) *metav1.Condition {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	failure, ok := m.failures[key]
+	if !ok || now.Sub(failure.since) < m.Threshold {
+		return nil
+	}
+
+	return &metav1.Condition{
+		Type:               HubSyncDegradedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "HubStatusSyncFailed",
+		Message:            failure.message,
+		LastTransitionTime: metav1.NewTime(failure.since.UTC().Truncate(time.Second)),
+	}
+}
+
+// Condition returns the HubSyncDegraded condition of the addon, which is true while the status of at least one policy
+// has failed to be synced for longer than the threshold. Its message is the last error of the policy failing for the
+// longest time.
+func (m *HubSyncMonitor) Condition(now time.Time) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    HubSyncDegradedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  "HubStatusSyncSucceeded",
+		Message: "The policy statuses are synced to the Hub",
+	}
+
+	if m == nil {
+		return condition
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	degraded := 0
+
+	var oldest hubSyncFailure
+
+	for _, failure := range m.failures {
+		if now.Sub(failure.since) < m.Threshold {
+			continue
+		}
+
+		degraded++
+
+		if oldest.since.IsZero() || failure.since.Before(oldest.since) {
+			oldest = failure
+		}
+	}
+
+	if degraded == 0 {
+		return condition
+	}
+
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = "HubStatusSyncFailed"
+	condition.Message = fmt.Sprintf(
+		"The status of %d policies failed to be synced to the Hub for longer than %s: %s",
+		degraded, m.Threshold, oldest.message,
+	)
+	condition.LastTransitionTime = metav1.NewTime(oldest.since.UTC().Truncate(time.Second))
+
+	return condition
+}
+
+// SetHubSyncDegraded sets the HubSyncDegradedAnnotation annotation of the input policy to the input condition, or
+// removes it if the condition is nil. The time of the condition is kept if it is already set with the same status. It
+// returns true if the annotation changed.
+func SetHubSyncDegraded(plc *policiesv1.Policy, condition *metav1.Condition) (bool, error) {
+	return SetConditionAnnotation(plc, HubSyncDegradedAnnotation, condition)
+}
@@ -0,0 +1,85 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestHubSyncMonitor(t *testing.T) {
+	RegisterTestingT(t)
+
+	monitor := &HubSyncMonitor{Threshold: 10 * time.Minute}
+	key := types.NamespacedName{Namespace: "cluster-ns", Name: "policy"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	monitor.RecordFailure(key, errors.New("connection refused"), start)
+	Expect(monitor.PolicyCondition(key, start.Add(5*time.Minute))).To(BeNil())
+	Expect(monitor.Condition(start.Add(5 * time.Minute)).Status).To(Equal(metav1.ConditionFalse))
+
+	// The failure is reported from the first failure with the last error
+	monitor.RecordFailure(key, errors.New("i/o timeout"), start.Add(8*time.Minute))
+
+	condition := monitor.PolicyCondition(key, start.Add(11*time.Minute))
+	Expect(condition).ToNot(BeNil())
+	Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	Expect(condition.Message).To(Equal("i/o timeout"))
+	Expect(condition.LastTransitionTime.Time).To(Equal(start))
+
+	summary := monitor.Condition(start.Add(11 * time.Minute))
+	Expect(summary.Status).To(Equal(metav1.ConditionTrue))
+	Expect(summary.Message).To(ContainSubstring("The status of 1 policies"))
+	Expect(summary.Message).To(HaveSuffix("i/o timeout"))
+
+	monitor.RecordSuccess(key)
+	Expect(monitor.PolicyCondition(key, start.Add(12*time.Minute))).To(BeNil())
+	Expect(monitor.Condition(start.Add(12 * time.Minute)).Status).To(Equal(metav1.ConditionFalse))
+
+	var nilMonitor *HubSyncMonitor
+
+	nilMonitor.RecordFailure(key, errors.New("connection refused"), start)
+	Expect(nilMonitor.PolicyCondition(key, start.Add(time.Hour))).To(BeNil())
+}
+
+func TestSetHubSyncDegraded(t *testing.T) {
+	RegisterTestingT(t)
+
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster-ns"}}
+	since := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	condition := &metav1.Condition{
+		Type:               HubSyncDegradedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "HubStatusSyncFailed",
+		Message:            "connection refused",
+		LastTransitionTime: since,
+	}
+
+	Expect(SetHubSyncDegraded(plc, nil)).To(BeFalse())
+	Expect(SetHubSyncDegraded(plc, condition)).To(BeTrue())
+	Expect(SetHubSyncDegraded(plc, condition)).To(BeFalse())
+
+	// A new error updates the message but keeps the transition time
+	updated := condition.DeepCopy()
+	updated.Message = "i/o timeout"
+	updated.LastTransitionTime = metav1.NewTime(since.Add(time.Hour))
+	Expect(SetHubSyncDegraded(plc, updated)).To(BeTrue())
+
+	annotated := metav1.Condition{}
+	Expect(json.Unmarshal([]byte(plc.GetAnnotations()[HubSyncDegradedAnnotation]), &annotated)).To(Succeed())
+	Expect(annotated.Message).To(Equal("i/o timeout"))
+	Expect(annotated.LastTransitionTime.Time.Equal(since.Time)).To(BeTrue())
+
+	// The annotation is only set on the managed cluster
+	Expect(syncedAnnotations(plc)).ToNot(HaveKey(HubSyncDegradedAnnotation))
+
+	Expect(SetHubSyncDegraded(plc, nil)).To(BeTrue())
+	Expect(plc.GetAnnotations()).ToNot(HaveKey(HubSyncDegradedAnnotation))
+}
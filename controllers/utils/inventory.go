@@ -0,0 +1,187 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+const (
+	// TemplateInventoryAnnotation is set on the replicated policy on the managed cluster by the template sync to the
+	// JSON list of the objects created from its policy templates.
+	TemplateInventoryAnnotation = "policy.open-cluster-management.io/template-inventory"
+	// StatusWriterAnnotation is set on the replicated policy on the Hub by the status sync to the addon instance which
+	// last wrote its status and the number of status writes of each addon instance.
+	StatusWriterAnnotation = "policy.open-cluster-management.io/status-writer"
+	// TemplateErrorsAnnotation is set on the replicated policy on the managed cluster by the template sync to the
+	// permanent template errors of the current generation of the policy, so that they aren't reported again after a
+	// restart of the addon.
+	TemplateErrorsAnnotation = "policy.open-cluster-management.io/template-errors"
+	// TemplateChecksumAnnotation is set on the replicated policy on the managed cluster by the template sync to the
+	// checksum of the policy templates whose objects match them, as computed by InventoryChecksum. The status sync
+	// also sets it on the template metadata in the policy status to the checksum of each policy template.
+	TemplateChecksumAnnotation = "policy.open-cluster-management.io/template-checksum"
+)
+
+// managedOnlyAnnotations are set on the replicated policy on the managed cluster by the addon, so they are not synced
+// from the Hub.
+var managedOnlyAnnotations = []string{
+	TemplateInventoryAnnotation, TemplateErrorsAnnotation, TemplateChecksumAnnotation, HubSyncDegradedAnnotation,
+	TemplatePendingAnnotation, CorrelationIDAnnotation, RootPlacementBindingsAnnotation, RootPlacementsAnnotation,
+	RootPolicyCollisionAnnotation, DeleteConflictAnnotation,
+}
+
+// hubOnlyAnnotations are set on the replicated policy on the Hub by the addon, so they are not synced to the managed
+// cluster.
+var hubOnlyAnnotations = []string{StatusWriterAnnotation}
+
+// InventoryEntry identifies an object created from a policy template. The namespace is only set for objects placed
+// outside the namespace of the policy. Pending is set to the reason the object is not yet updated to the latest policy
+// template while its update is held, and Drifted to how the object of a create-only policy template differs from it.
+// Checksum is set to the checksum of the rendered policy template while the object matches it.
+type InventoryEntry struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	Pending    string `json:"pending,omitempty"`
+	Drifted    string `json:"drifted,omitempty"`
+	Checksum   string `json:"checksum,omitempty"`
+}
+
+// Identity returns the entry without the fields describing the state of the object, so that the entries of the same
+// object are equal.
+func (e InventoryEntry) Identity() InventoryEntry {
+	return InventoryEntry{APIVersion: e.APIVersion, Kind: e.Kind, Name: e.Name, Namespace: e.Namespace}
+}
+
+// InventoryChecksum returns the SHA-256 checksum of the objects in the input entries and the checksums of their
+// policy templates, or an empty string if there are no entries. Each entry is written on its own line as
+// <apiVersion>/<kind>/<namespace>/<name>=<checksum>, and the lines are sorted, so that the checksum can be computed
+// from the rendered policy templates elsewhere, such as by GitOps tools, and compared.
+func InventoryChecksum(entries []InventoryEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf(
+			"%s/%s/%s/%s=%s", entry.APIVersion, entry.Kind, entry.Namespace, entry.Name, entry.Checksum,
+		))
+	}
+
+	sort.Strings(lines)
+
+	checksum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+
+	return hex.EncodeToString(checksum[:])
+}
+
+// InventoryAnnotationValue returns the value of the TemplateInventoryAnnotation annotation for the input entries,
+// sorted so that the value is stable.
+func InventoryAnnotationValue(entries []InventoryEntry) (string, error) {
+	sorted := make([]InventoryEntry, len(entries))
+	copy(sorted, entries)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].APIVersion != sorted[j].APIVersion {
+			return sorted[i].APIVersion < sorted[j].APIVersion
+		}
+
+		if sorted[i].Kind != sorted[j].Kind {
+			return sorted[i].Kind < sorted[j].Kind
+		}
+
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+
+		return sorted[i].Namespace < sorted[j].Namespace
+	})
+
+	value, err := json.Marshal(sorted)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// PolicyInventory returns the objects created from the policy templates of the input replicated policy, as recorded
+// in the TemplateInventoryAnnotation annotation.
+func PolicyInventory(plc *policiesv1.Policy) ([]InventoryEntry, error) {
+	value, ok := plc.GetAnnotations()[TemplateInventoryAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	entries := []InventoryEntry{}
+
+	err := json.Unmarshal([]byte(value), &entries)
+
+	return entries, err
+}
+
+// syncedAnnotations returns the annotations of the input policy without the annotations only set on the managed
+// cluster or on the Hub by the addon.
+func syncedAnnotations(plc *policiesv1.Policy) map[string]string {
+	annotations := plc.GetAnnotations()
+	if annotations == nil {
+		return nil
+	}
+
+	synced := make(map[string]string, len(annotations))
+
+	for key, value := range annotations {
+		synced[key] = value
+	}
+
+	for _, key := range managedOnlyAnnotations {
+		delete(synced, key)
+	}
+
+	for _, key := range hubOnlyAnnotations {
+		delete(synced, key)
+	}
+
+	return synced
+}
+
+// CompareSpecAndAnnotation returns true if the replicated policy on the Hub and the policy on the managed cluster have
+// the same spec and annotations, ignoring the annotations only set on the managed cluster, the Hub annotations removed
+// by the input compaction, and the order of the policy templates.
+func CompareSpecAndAnnotation(
+	hubPlc *policiesv1.Policy, managedPlc *policiesv1.Policy, compaction *PolicyCompaction,
+) bool {
+	return equality.Semantic.DeepEqual(
+		compaction.annotations(syncedAnnotations(hubPlc)), syncedAnnotations(managedPlc),
+	) && EquivalentSpecs(hubPlc.Spec, managedPlc.Spec)
+}
+
+// SyncAnnotations sets the annotations of the policy on the managed cluster to those of the replicated policy on the
+// Hub not removed by the input compaction, retaining the annotations only set on the managed cluster.
+func SyncAnnotations(hubPlc *policiesv1.Policy, managedPlc *policiesv1.Policy, compaction *PolicyCompaction) {
+	annotations := compaction.annotations(syncedAnnotations(hubPlc))
+
+	for _, key := range managedOnlyAnnotations {
+		if value, ok := managedPlc.GetAnnotations()[key]; ok {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+
+			annotations[key] = value
+		}
+	}
+
+	managedPlc.SetAnnotations(annotations)
+}
@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestInventoryAnnotationValue(t *testing.T) {
+	RegisterTestingT(t)
+
+	entries := []InventoryEntry{
+		{APIVersion: "policy.open-cluster-management.io/v1", Kind: "ConfigurationPolicy", Name: "b"},
+		{APIVersion: "constraints.gatekeeper.sh/v1beta1", Kind: "K8sRequiredLabels", Name: "c"},
+		{APIVersion: "policy.open-cluster-management.io/v1", Kind: "ConfigurationPolicy", Name: "a"},
+	}
+
+	value, err := InventoryAnnotationValue(entries)
+	Expect(err).To(BeNil())
+
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{TemplateInventoryAnnotation: value},
+	}}
+
+	inventory, err := PolicyInventory(plc)
+	Expect(err).To(BeNil())
+	Expect(inventory).To(Equal([]InventoryEntry{entries[1], entries[2], entries[0]}))
+
+	value, err = InventoryAnnotationValue(nil)
+	Expect(err).To(BeNil())
+	Expect(value).To(Equal("[]"))
+}
+
+func TestInventoryChecksum(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(InventoryChecksum(nil)).To(BeEmpty())
+
+	entries := []InventoryEntry{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "b", Checksum: "1234"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "a", Namespace: "placed", Checksum: "5678"},
+	}
+	reversed := []InventoryEntry{entries[1], entries[0]}
+
+	// sha256 of "v1/ConfigMap//b=1234\nv1/ConfigMap/placed/a=5678"
+	checksum := InventoryChecksum(entries)
+	Expect(checksum).To(Equal("c69dc65fd2bd885399e240d1714d1b32a58d7af986f0ee31d4ad1f08c5b3d051"))
+	Expect(InventoryChecksum(reversed)).To(Equal(checksum))
+
+	// An object which doesn't match its policy template changes the checksum
+	entries[0].Checksum = ""
+	Expect(InventoryChecksum(entries)).ToNot(Equal(checksum))
+
+	Expect(entries[0].Identity()).To(Equal(InventoryEntry{APIVersion: "v1", Kind: "ConfigMap", Name: "b"}))
+}
+
+func TestCompareSpecAndAnnotation(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"policy.open-cluster-management.io/standards": "NIST"},
+		},
+		Spec: policiesv1.PolicySpec{RemediationAction: "inform"},
+	}
+	managedPlc := hubPlc.DeepCopy()
+	managedPlc.Annotations[TemplateInventoryAnnotation] = "[]"
+
+	// The inventory annotation is only on the managed cluster, so it is ignored
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, nil)).To(BeTrue())
+
+	// The status writer annotation is only on the Hub, so it is ignored
+	hubPlc.Annotations[StatusWriterAnnotation] = `{"writer":"addon"}`
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, nil)).To(BeTrue())
+	Expect(ManagedPolicyFromHub(hubPlc, "managed", nil).Annotations).ToNot(HaveKey(StatusWriterAnnotation))
+
+	hubPlc.Annotations["policy.open-cluster-management.io/categories"] = "CM"
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, nil)).To(BeFalse())
+
+	SyncAnnotations(hubPlc, managedPlc, nil)
+	Expect(managedPlc.Annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/categories", "CM"))
+	Expect(managedPlc.Annotations).To(HaveKeyWithValue(TemplateInventoryAnnotation, "[]"))
+	Expect(managedPlc.Annotations).ToNot(HaveKey(StatusWriterAnnotation))
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, nil)).To(BeTrue())
+
+	// A policy without annotations matches one with only the inventory annotation
+	hubPlc.Annotations = nil
+	managedPlc.Annotations = map[string]string{TemplateInventoryAnnotation: "[]"}
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, nil)).To(BeTrue())
+}
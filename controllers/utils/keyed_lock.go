@@ -0,0 +1,52 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import "sync"
+
+// keyedLockEntry is the lock of a key and the number of goroutines holding or waiting for it.
+type keyedLockEntry struct {
+	lock    sync.Mutex
+	waiters int
+}
+
+// KeyedLock partitions the work of concurrent reconciles by key, such as a policy or a policy template object, so
+// that the work on the same key is serialized while the work on different keys runs in parallel. The entry of a key is
+// removed once nothing holds or waits for it. The zero value is ready to use.
+type KeyedLock struct {
+	entries map[string]*keyedLockEntry
+	lock    sync.Mutex
+}
+
+// Lock waits until the input key is not held and holds it. The returned function releases the key and must be called
+// exactly once.
+func (k *KeyedLock) Lock(key string) (unlock func()) {
+	k.lock.Lock()
+
+	if k.entries == nil {
+		k.entries = map[string]*keyedLockEntry{}
+	}
+
+	entry, ok := k.entries[key]
+	if !ok {
+		entry = &keyedLockEntry{}
+		k.entries[key] = entry
+	}
+
+	entry.waiters++
+	k.lock.Unlock()
+
+	entry.lock.Lock()
+
+	return func() {
+		entry.lock.Unlock()
+
+		k.lock.Lock()
+		defer k.lock.Unlock()
+
+		entry.waiters--
+		if entry.waiters == 0 {
+			delete(k.entries, key)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestKeyedLock(t *testing.T) {
+	RegisterTestingT(t)
+
+	keyedLock := &KeyedLock{}
+	unlockA := keyedLock.Lock("a")
+
+	// A different key isn't blocked
+	unlockB := keyedLock.Lock("b")
+	unlockB()
+
+	locked := make(chan struct{})
+
+	go func() {
+		unlock := keyedLock.Lock("a")
+		close(locked)
+		unlock()
+	}()
+
+	Consistently(locked, 100*time.Millisecond).ShouldNot(BeClosed())
+	unlockA()
+	Eventually(locked).Should(BeClosed())
+
+	// The work on the same key is serialized
+	counter := 0
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			unlock := keyedLock.Lock("counter")
+			defer unlock()
+
+			value := counter
+			time.Sleep(time.Millisecond)
+			counter = value + 1
+		}()
+	}
+
+	wg.Wait()
+	Expect(counter).To(Equal(20))
+
+	Eventually(func() int {
+		keyedLock.lock.Lock()
+		defer keyedLock.lock.Unlock()
+
+		return len(keyedLock.entries)
+	}).Should(Equal(0))
+}
@@ -0,0 +1,156 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// NamespaceUnavailableRequeue is how long reconciles are paused while the cluster namespace is unavailable.
+const NamespaceUnavailableRequeue = 30 * time.Second
+
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create
+
+// NamespaceGuard watches the cluster namespace on the managed cluster so that controllers can pause their reconciles
+// while it is being deleted or is missing, instead of error looping. Until the namespace has been observed, it is
+// assumed to be available. A nil NamespaceGuard always reports the namespace as available.
+type NamespaceGuard struct {
+	Client    kubernetes.Interface
+	Namespace string
+	// Recreate causes the namespace to be recreated after it is deleted.
+	Recreate bool
+	// Recorder emits events on the namespace when it becomes unavailable. It is optional.
+	Recorder    record.EventRecorder
+	unavailable string
+	lock        sync.RWMutex
+}
+
+// Start watches the cluster namespace until the input context is closed.
+func (g *NamespaceGuard) Start(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		g.Client,
+		0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", g.Namespace).String()
+		}),
+	)
+
+	informer := factory.Core().V1().Namespaces().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if namespace, ok := obj.(*corev1.Namespace); ok {
+				g.observe(namespace)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if namespace, ok := newObj.(*corev1.Namespace); ok {
+				g.observe(namespace)
+			}
+		},
+		DeleteFunc: func(_ interface{}) {
+			g.setUnavailable(fmt.Sprintf("the cluster namespace %s was deleted", g.Namespace), nil)
+
+			if g.Recreate {
+				g.recreate(ctx)
+			}
+		},
+	})
+
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+
+	return nil
+}
+
+// observe updates the availability based on the input namespace.
+func (g *NamespaceGuard) observe(namespace *corev1.Namespace) {
+	if namespace.DeletionTimestamp != nil || namespace.Status.Phase == corev1.NamespaceTerminating {
+		g.setUnavailable(
+			fmt.Sprintf("the cluster namespace %s is being deleted, pausing the policy synchronization", g.Namespace),
+			namespace,
+		)
+
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.unavailable != "" {
+		ctrl.Log.WithName("namespace-guard").Info(
+			"The cluster namespace is available, resuming the policy synchronization", "namespace", g.Namespace,
+		)
+	}
+
+	g.unavailable = ""
+}
+
+// setUnavailable records the reason the namespace is unavailable. The first time, it is logged and an event is
+// emitted on the namespace if it is provided.
+func (g *NamespaceGuard) setUnavailable(reason string, namespace *corev1.Namespace) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.unavailable == reason {
+		return
+	}
+
+	g.unavailable = reason
+
+	ctrl.Log.WithName("namespace-guard").Info("The cluster namespace is unavailable", "reason", reason)
+
+	if g.Recorder != nil && namespace != nil {
+		g.Recorder.Event(namespace, "Warning", "ClusterNamespaceUnavailable", reason)
+	}
+}
+
+// recreate creates the cluster namespace after it was deleted.
+func (g *NamespaceGuard) recreate(ctx context.Context) {
+	log := ctrl.Log.WithName("namespace-guard")
+
+	_, err := g.Client.CoreV1().Namespaces().Create(
+		ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.Namespace}}, metav1.CreateOptions{},
+	)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		log.Error(err, "Failed to recreate the cluster namespace", "namespace", g.Namespace)
+
+		return
+	}
+
+	log.Info("Recreated the cluster namespace", "namespace", g.Namespace)
+}
+
+// Available returns true if the cluster namespace is available.
+func (g *NamespaceGuard) Available() bool {
+	return g.Check(nil) == nil
+}
+
+// Check is a readiness check that fails with the reason the cluster namespace is unavailable.
+func (g *NamespaceGuard) Check(_ *http.Request) error {
+	if g == nil {
+		return nil
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.unavailable != "" {
+		return fmt.Errorf("%s", g.unavailable)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestNamespaceGuard(t *testing.T) {
+	RegisterTestingT(t)
+
+	var nilGuard *NamespaceGuard
+	Expect(nilGuard.Available()).To(BeTrue())
+
+	recorder := record.NewFakeRecorder(10)
+	guard := &NamespaceGuard{Namespace: "managed", Recorder: recorder}
+	Expect(guard.Available()).To(BeTrue())
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "managed"}}
+	guard.observe(namespace)
+	Expect(guard.Available()).To(BeTrue())
+
+	now := metav1.Now()
+	namespace.DeletionTimestamp = &now
+	guard.observe(namespace)
+	guard.observe(namespace)
+	Expect(guard.Available()).To(BeFalse())
+	Expect(guard.Check(nil)).To(MatchError(ContainSubstring("is being deleted")))
+	// The event is only emitted once
+	Expect(recorder.Events).To(HaveLen(1))
+
+	namespace.DeletionTimestamp = nil
+	guard.observe(namespace)
+	Expect(guard.Available()).To(BeTrue())
+}
+
+func TestNamespaceGuardRecreate(t *testing.T) {
+	RegisterTestingT(t)
+
+	client := fake.NewSimpleClientset()
+	guard := &NamespaceGuard{Client: client, Namespace: "managed", Recreate: true}
+
+	guard.recreate(context.TODO())
+	// Recreating an existing namespace is not an error
+	guard.recreate(context.TODO())
+
+	_, err := client.CoreV1().Namespaces().Get(context.TODO(), "managed", metav1.GetOptions{})
+	Expect(err).To(BeNil())
+}
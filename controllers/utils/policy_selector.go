@@ -0,0 +1,57 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// ParsePolicySelector parses the input label selector of the replicated policies on the Hub synced by the addon. An
+// empty selector selects all the policies.
+func ParsePolicySelector(selector string) (labels.Selector, error) {
+	return labels.Parse(selector)
+}
+
+// PolicySelected returns true if the input replicated policy on the Hub matches the input label selector of the
+// policies synced by the addon. A nil selector selects all the policies.
+func PolicySelected(selector labels.Selector, hubPlc *policiesv1.Policy) bool {
+	return selector == nil || selector.Matches(labels.Set(hubPlc.GetLabels()))
+}
+
+// SkipTracker records the policies a controller skips since they don't match the policy label selector, so that each
+// policy is only reported once each time it stops matching the selector. The zero value is ready to use.
+type SkipTracker struct {
+	skipped map[types.NamespacedName]bool
+	lock    sync.Mutex
+}
+
+// Skip records that the input policy is skipped, and returns true if it wasn't already skipped.
+func (t *SkipTracker) Skip(key types.NamespacedName) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.skipped == nil {
+		t.skipped = map[types.NamespacedName]bool{}
+	}
+
+	if t.skipped[key] {
+		return false
+	}
+
+	t.skipped[key] = true
+
+	return true
+}
+
+// Forget records that the input policy is no longer skipped, such as when it matches the selector again or when it is
+// deleted.
+func (t *SkipTracker) Forget(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.skipped, key)
+}
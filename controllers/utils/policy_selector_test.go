@@ -0,0 +1,52 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestPolicySelected(t *testing.T) {
+	RegisterTestingT(t)
+
+	critical := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"tier": "critical"}}}
+	unlabeled := &policiesv1.Policy{}
+
+	selector, err := ParsePolicySelector("")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(PolicySelected(selector, critical)).To(BeTrue())
+	Expect(PolicySelected(selector, unlabeled)).To(BeTrue())
+
+	selector, err = ParsePolicySelector("tier=critical")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(PolicySelected(selector, critical)).To(BeTrue())
+	Expect(PolicySelected(selector, unlabeled)).To(BeFalse())
+
+	selector, err = ParsePolicySelector("tier notin (critical)")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(PolicySelected(selector, critical)).To(BeFalse())
+	Expect(PolicySelected(selector, unlabeled)).To(BeTrue())
+
+	_, err = ParsePolicySelector("tier in critical")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestSkipTracker(t *testing.T) {
+	RegisterTestingT(t)
+
+	tracker := &SkipTracker{}
+	key := types.NamespacedName{Namespace: "cluster", Name: "policy"}
+
+	// A skipped policy is only reported once until it matches the selector again
+	Expect(tracker.Skip(key)).To(BeTrue())
+	Expect(tracker.Skip(key)).To(BeFalse())
+	Expect(tracker.Skip(types.NamespacedName{Namespace: "cluster", Name: "other"})).To(BeTrue())
+
+	tracker.Forget(key)
+	Expect(tracker.Skip(key)).To(BeTrue())
+}
@@ -0,0 +1,63 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+// UnchangedPreconditions returns the delete preconditions matching the UID and resource version of the input object,
+// so that the deletion fails if the object was replaced or modified by another actor since it was read.
+func UnchangedPreconditions(obj metav1.Object) *metav1.Preconditions {
+	uid := obj.GetUID()
+	resourceVersion := obj.GetResourceVersion()
+
+	return &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion}
+}
+
+// DeleteConflictError returns the input error of a preconditioned deletion of the input object as a
+// syncerrors.ErrDeleteConflict error if the preconditions failed. Other errors are returned as is.
+func DeleteConflictError(obj metav1.Object, err error) error {
+	if !k8serrors.IsConflict(err) {
+		return err
+	}
+
+	return syncerrors.WithCause(
+		syncerrors.ErrDeleteConflict,
+		fmt.Sprintf(
+			"Refusing to delete %s/%s since it was replaced or modified since it was read: %v",
+			obj.GetNamespace(), obj.GetName(), err,
+		),
+		err,
+	)
+}
+
+// DeleteUnchanged deletes the input object only if it still has the same UID and resource version on the API server.
+// If the object was replaced or modified by another actor since it was read, a syncerrors.ErrDeleteConflict error is
+// returned and the object is not deleted.
+func DeleteUnchanged(ctx context.Context, c client.Client, obj client.Object) error {
+	err := c.Delete(ctx, obj, client.Preconditions(*UnchangedPreconditions(obj)))
+
+	return DeleteConflictError(obj, err)
+}
+
+// ReportDeleteConflict sets the DeleteConflict condition of the input policy on the managed cluster to the input
+// syncerrors.ErrDeleteConflict error of DeleteUnchanged, or removes it if the error is nil, and patches the policy if
+// the condition changed. The next deletion is then preconditioned on the patched policy, so the condition doesn't
+// prevent it. Other errors are ignored.
+func ReportDeleteConflict(ctx context.Context, c client.Client, plc *policiesv1.Policy, conflict error) error {
+	if conflict != nil && !errors.Is(conflict, syncerrors.ErrDeleteConflict) {
+		return nil
+	}
+
+	return PatchConditionAnnotation(ctx, c, plc, DeleteConflictAnnotation, DeleteConflictCondition, conflict)
+}
@@ -0,0 +1,102 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+func TestUnchangedPreconditions(t *testing.T) {
+	RegisterTestingT(t)
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{UID: "uid-1", ResourceVersion: "42"}}
+
+	preconditions := UnchangedPreconditions(obj)
+	Expect(*preconditions.UID).To(BeEquivalentTo("uid-1"))
+	Expect(*preconditions.ResourceVersion).To(Equal("42"))
+}
+
+func TestDeleteConflictError(t *testing.T) {
+	RegisterTestingT(t)
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "managed", Name: "policy"}}
+
+	Expect(DeleteConflictError(obj, nil)).To(BeNil())
+
+	notFound := k8serrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "policy")
+	Expect(DeleteConflictError(obj, notFound)).To(Equal(notFound))
+
+	conflict := k8serrors.NewConflict(
+		schema.GroupResource{Resource: "configmaps"}, "policy", errors.New("the UID in the precondition differs"),
+	)
+
+	err := DeleteConflictError(obj, conflict)
+	Expect(errors.Is(err, syncerrors.ErrDeleteConflict)).To(BeTrue())
+	Expect(k8serrors.IsConflict(err)).To(BeTrue())
+	Expect(err.Error()).To(HavePrefix("Refusing to delete managed/policy"))
+	Expect(syncerrors.Reason(err)).To(Equal("DeleteConflict"))
+	Expect(syncerrors.Permanent(err)).To(BeFalse())
+}
+
+func TestDeleteUnchanged(t *testing.T) {
+	RegisterTestingT(t)
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "managed", Name: "policy"}}
+	c := fake.NewClientBuilder().WithObjects(obj).Build()
+
+	current := &corev1.ConfigMap{}
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(obj), current)).To(Succeed())
+	Expect(DeleteUnchanged(context.TODO(), c, current)).To(Succeed())
+
+	err := c.Get(context.TODO(), client.ObjectKeyFromObject(obj), current)
+	Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+}
+
+func TestReportDeleteConflict(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Namespace: "managed", Name: "policy"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(plc).Build()
+	ctx := context.TODO()
+
+	Expect(c.Get(ctx, client.ObjectKeyFromObject(plc), plc)).To(Succeed())
+
+	// Only the delete conflicts are reported
+	Expect(ReportDeleteConflict(ctx, c, plc, errors.New("other error"))).To(Succeed())
+	Expect(plc.GetAnnotations()).ToNot(HaveKey(DeleteConflictAnnotation))
+
+	stale := plc.DeepCopy()
+	stale.SetResourceVersion("1")
+	conflict := DeleteConflictError(stale, k8serrors.NewConflict(
+		schema.GroupResource{Resource: "policies"}, "policy", errors.New("the resource version changed"),
+	))
+	Expect(ReportDeleteConflict(ctx, c, plc, conflict)).To(Succeed())
+
+	stored := &policiesv1.Policy{}
+	Expect(c.Get(ctx, client.ObjectKeyFromObject(plc), stored)).To(Succeed())
+
+	condition := ConditionAnnotation(stored, DeleteConflictAnnotation)
+	Expect(condition).ToNot(BeNil())
+	Expect(condition.Type).To(Equal(DeleteConflictCondition))
+	Expect(condition.Message).To(Equal(conflict.Error()))
+
+	// The deletion is preconditioned on the patched policy
+	Expect(DeleteUnchanged(ctx, c, plc)).To(Succeed())
+}
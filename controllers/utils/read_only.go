@@ -0,0 +1,114 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// maxLoggedWriteBody is the maximum number of bytes of the body of a suppressed write which are logged.
+const maxLoggedWriteBody = 2048
+
+// successStatus is the body of the response to a suppressed delete request.
+const successStatus = `{"kind":"Status","apiVersion":"v1","metadata":{},"status":"Success"}`
+
+// readOnlyRoundTripper suppresses the API requests modifying objects.
+type readOnlyRoundTripper struct {
+	next http.RoundTripper
+	log  logr.Logger
+}
+
+// NewReadOnlyWrapper returns a function that wraps a round tripper so that the requests creating, updating, patching,
+// or deleting objects, including events, are logged instead of being sent, to be passed to rest.Config.Wrap. The
+// controllers are answered as if the writes succeeded so that they carry on with their work:
+//
+//   - A create or update request gets its own object back.
+//   - A patch request gets the current object, since the patch isn't applied.
+//   - A delete request gets a success status.
+//
+// The client name identifies the client in the logs.
+func NewReadOnlyWrapper(clientName string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &readOnlyRoundTripper{
+			next: next,
+			log:  ctrl.Log.WithName("read-only").WithValues("client", clientName),
+		}
+	}
+}
+
+// RoundTrip performs the request if it doesn't modify an object, and otherwise logs it and answers it.
+func (r *readOnlyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isWriteRequest(req) {
+		return r.next.RoundTrip(req)
+	}
+
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logValues := []interface{}{
+		"controller", controllerFromContext(req.Context()), "method", req.Method, "path", req.URL.Path,
+	}
+
+	// The secrets, such as the policy encryption key, are not logged
+	if !strings.Contains(req.URL.Path, "/secrets") && len(body) != 0 {
+		if len(body) > maxLoggedWriteBody {
+			logValues = append(logValues, "body", string(body[:maxLoggedWriteBody])+"...")
+		} else {
+			logValues = append(logValues, "body", string(body))
+		}
+	}
+
+	r.log.Info("Suppressed the API request in read-only mode", logValues...)
+
+	switch req.Method {
+	case http.MethodPost:
+		return readOnlyResponse(req, http.StatusCreated, req.Header.Get("Content-Type"), body), nil
+	case http.MethodPut:
+		return readOnlyResponse(req, http.StatusOK, req.Header.Get("Content-Type"), body), nil
+	case http.MethodDelete:
+		return readOnlyResponse(req, http.StatusOK, "application/json", []byte(successStatus)), nil
+	}
+
+	// The current object is the result of a patch which isn't applied
+	getReq := req.Clone(req.Context())
+	getReq.Method = http.MethodGet
+	getReq.Body = nil
+	getReq.GetBody = nil
+	getReq.ContentLength = 0
+	getReq.URL.RawQuery = ""
+	getReq.Header.Del("Content-Type")
+
+	return r.next.RoundTrip(getReq)
+}
+
+// readOnlyResponse returns a response to the input suppressed request with the input status code and body.
+func readOnlyResponse(req *http.Request, statusCode int, contentType string, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type methodRecordingRoundTripper struct {
+	requests []string
+}
+
+func (m *methodRecordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.requests = append(m.requests, req.Method+" "+req.URL.String())
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"kind":"Policy","metadata":{"name":"current"}}`)),
+	}, nil
+}
+
+func TestReadOnlyWrapper(t *testing.T) {
+	RegisterTestingT(t)
+
+	next := &methodRecordingRoundTripper{}
+	readOnly := NewReadOnlyWrapper("hub")(next)
+	policyURL := "https://hub:6443/apis/policy.open-cluster-management.io/v1/namespaces/cluster/policies"
+	policy := `{"kind":"Policy","metadata":{"name":"policy"}}`
+
+	for _, test := range []struct {
+		method       string
+		url          string
+		body         string
+		expectedCode int
+		expectedBody string
+		expectedSent []string
+	}{
+		{http.MethodGet, policyURL, "", http.StatusOK, `{"kind":"Policy","metadata":{"name":"current"}}`,
+			[]string{"GET " + policyURL}},
+		{http.MethodPost, policyURL, policy, http.StatusCreated, policy, nil},
+		{http.MethodPut, policyURL + "/policy/status", policy, http.StatusOK, policy, nil},
+		{http.MethodDelete, policyURL + "/policy", "", http.StatusOK, successStatus, nil},
+		{http.MethodPost, "https://hub:6443/api/v1/namespaces/cluster/events", policy, http.StatusCreated, policy, nil},
+		{
+			http.MethodPatch, policyURL + "/policy/status?fieldManager=addon", `{"status":{}}`, http.StatusOK,
+			`{"kind":"Policy","metadata":{"name":"current"}}`, []string{"GET " + policyURL + "/policy/status"},
+		},
+	} {
+		next.requests = nil
+
+		req, err := http.NewRequestWithContext(context.TODO(), test.method, test.url, strings.NewReader(test.body))
+		Expect(err).To(BeNil())
+
+		resp, err := readOnly.RoundTrip(req)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(test.expectedCode))
+
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(string(body)).To(Equal(test.expectedBody))
+		Expect(next.requests).To(Equal(test.expectedSent), test.method+" "+test.url)
+	}
+}
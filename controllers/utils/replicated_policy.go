@@ -0,0 +1,165 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+)
+
+// ManagedPolicyFromHub returns the policy that should exist on the managed cluster for the input replicated policy on
+// the Hub. The Hub policy is not modified. The returned policy is stripped of any server populated metadata and
+// ownership so that it can be created in the target namespace on the managed cluster, and of the annotations removed
+// by the input compaction.
+func ManagedPolicyFromHub(
+	hubPlc *policiesv1.Policy, targetNamespace string, compaction *PolicyCompaction,
+) *policiesv1.Policy {
+	managedPlc := &policiesv1.Policy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       policiesv1.Kind,
+			APIVersion: policiesv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        hubPlc.GetName(),
+			Namespace:   targetNamespace,
+			Labels:      map[string]string{},
+			Annotations: compaction.annotations(syncedAnnotations(hubPlc)),
+		},
+		Spec: *hubPlc.Spec.DeepCopy(),
+	}
+
+	for key, value := range hubPlc.GetLabels() {
+		managedPlc.Labels[key] = value
+	}
+
+	if managedPlc.Labels[common.ClusterNamespaceLabel] != "" {
+		managedPlc.Labels[common.ClusterNamespaceLabel] = targetNamespace
+	}
+
+	// Keep the nil versus empty distinction of the Hub policy so that comparisons with the Hub policy don't report a
+	// difference.
+	if hubPlc.GetLabels() == nil {
+		managedPlc.Labels = nil
+	}
+
+	return managedPlc
+}
+
+// SyncFromHub sets the annotations and the spec of the input policy on the managed cluster to those of the input
+// replicated policy on the Hub, like SyncAnnotations for the annotations. The Hub policy may be in the informer cache,
+// so the spec is deep copied rather than shared, since the update of the managed policy decodes the response into it.
+func SyncFromHub(hubPlc *policiesv1.Policy, managedPlc *policiesv1.Policy, compaction *PolicyCompaction) {
+	SyncAnnotations(hubPlc, managedPlc, compaction)
+	managedPlc.Spec = *hubPlc.Spec.DeepCopy()
+}
+
+// CreateManagedPolicy creates the policy on the managed cluster for the input replicated policy on the Hub and
+// restores the status from the Hub policy if it has one. If the policy already exists on the managed cluster, such
+// as when another controller created it concurrently, the existing policy is returned instead, with the status
+// restored if it has none, so that the status is still restored when a previous restore failed after the creation.
+func CreateManagedPolicy(
+	ctx context.Context,
+	managedClient client.Client,
+	hubPlc *policiesv1.Policy,
+	targetNamespace string,
+	compaction *PolicyCompaction,
+) (*policiesv1.Policy, error) {
+	managedPlc := ManagedPolicyFromHub(hubPlc, targetNamespace, compaction)
+	SetCorrelationID(hubPlc, managedPlc)
+
+	err := managedClient.Create(ctx, managedPlc)
+	if err != nil {
+		if !k8serrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+
+		existingPlc := &policiesv1.Policy{}
+
+		err = managedClient.Get(
+			ctx, types.NamespacedName{Namespace: targetNamespace, Name: hubPlc.GetName()}, existingPlc,
+		)
+		if err != nil {
+			return existingPlc, err
+		}
+
+		if existingPlc.Status.ComplianceState != "" || len(existingPlc.Status.Details) != 0 {
+			return existingPlc, nil
+		}
+
+		return existingPlc, restoreHubStatus(ctx, managedClient, hubPlc, existingPlc)
+	}
+
+	return managedPlc, restoreHubStatus(ctx, managedClient, hubPlc, managedPlc)
+}
+
+// restoreHubStatus sets the status of the input replicated policy on the Hub on the input policy on the managed
+// cluster, if the Hub policy has one.
+func restoreHubStatus(
+	ctx context.Context, managedClient client.Client, hubPlc *policiesv1.Policy, managedPlc *policiesv1.Policy,
+) error {
+	if hubPlc.Status.ComplianceState == "" && len(hubPlc.Status.Details) == 0 {
+		return nil
+	}
+
+	status := policiesv1.PolicyStatus{
+		ComplianceState: hubPlc.Status.ComplianceState,
+		Details:         hubPlc.DeepCopy().Status.Details,
+	}
+
+	// The status is applied like the status writes of the Status Sync controller so that it owns its fields
+	return ApplyPolicyStatus(ctx, managedClient, managedPlc, status)
+}
+
+// RootPolicyCollision returns a syncerrors.ErrRootPolicyCollision error naming both root policies if the input policies
+// are replicated from different root policies, as determined by their root policy labels. Policies without the label
+// are not considered to collide.
+func RootPolicyCollision(hubPlc *policiesv1.Policy, managedPlc *policiesv1.Policy) error {
+	hubRoot := hubPlc.GetLabels()[common.RootPolicyLabel]
+	managedRoot := managedPlc.GetLabels()[common.RootPolicyLabel]
+
+	if hubRoot == "" || managedRoot == "" || hubRoot == managedRoot {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: the policy %s/%s is replicated from the root policy %s, refusing to sync it from the root policy %s",
+		syncerrors.ErrRootPolicyCollision, managedPlc.GetNamespace(), managedPlc.GetName(), managedRoot, hubRoot,
+	)
+}
+
+// RootPolicyCollisionRequeue is how long to wait before checking again if a policy on the managed cluster still
+// collides with the replicated policy on the Hub, since the spec sync doesn't watch the policies on the managed
+// cluster.
+const RootPolicyCollisionRequeue = 5 * time.Minute
+
+// ReplaceCollidingPolicyAnnotation can be set to "true" on a policy on the managed cluster which collides with the
+// replicated policy on the Hub from another root policy, so that the spec sync replaces it with the replicated policy.
+const ReplaceCollidingPolicyAnnotation = "policy.open-cluster-management.io/replace-colliding-policy"
+
+// ReplaceCollidingPolicy returns true if the input policy on the managed cluster should be replaced by the colliding
+// replicated policy on the Hub, as requested with the ReplaceCollidingPolicyAnnotation annotation.
+func ReplaceCollidingPolicy(managedPlc *policiesv1.Policy) bool {
+	return strings.EqualFold(managedPlc.GetAnnotations()[ReplaceCollidingPolicyAnnotation], "true")
+}
+
+// ReportRootPolicyCollision sets the RootPolicyCollision condition of the input policy on the managed cluster from
+// the input error of RootPolicyCollision, or removes it if the error is nil, and patches the policy if the condition
+// changed.
+func ReportRootPolicyCollision(
+	ctx context.Context, c client.Client, managedPlc *policiesv1.Policy, collision error,
+) error {
+	return PatchConditionAnnotation(
+		ctx, c, managedPlc, RootPolicyCollisionAnnotation, RootPolicyCollisionCondition, collision,
+	)
+}
@@ -0,0 +1,266 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/syncerrors"
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+func getTestHubPolicy() *policiesv1.Policy {
+	return &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "policy-test",
+			Namespace:       "hub-cluster-ns",
+			ResourceVersion: "10",
+			Labels: map[string]string{
+				common.ClusterNamespaceLabel: "hub-cluster-ns",
+				common.RootPolicyLabel:       "policies.policy-test",
+			},
+			Annotations: map[string]string{"policy.open-cluster-management.io/standards": "NIST SP 800-53"},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "policy.open-cluster-management.io/v1", Kind: "Policy", Name: "policy-test"},
+			},
+		},
+		Spec: policiesv1.PolicySpec{RemediationAction: policiesv1.Inform},
+		Status: policiesv1.PolicyStatus{
+			ComplianceState: policiesv1.Compliant,
+			Details: []*policiesv1.DetailsPerTemplate{
+				{TemplateMeta: metav1.ObjectMeta{Name: "template"}, ComplianceState: policiesv1.Compliant},
+			},
+		},
+	}
+}
+
+func TestManagedPolicyFromHub(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := getTestHubPolicy()
+	managedPlc := ManagedPolicyFromHub(hubPlc, "managed-cluster-ns", nil)
+
+	Expect(managedPlc.Namespace).To(Equal("managed-cluster-ns"))
+	Expect(managedPlc.ResourceVersion).To(BeEmpty())
+	Expect(managedPlc.OwnerReferences).To(BeNil())
+	Expect(managedPlc.Labels[common.ClusterNamespaceLabel]).To(Equal("managed-cluster-ns"))
+	Expect(managedPlc.Labels[common.RootPolicyLabel]).To(Equal("policies.policy-test"))
+	Expect(managedPlc.Annotations).To(Equal(hubPlc.Annotations))
+
+	// Verify the Hub policy was not mutated
+	Expect(hubPlc.Labels[common.ClusterNamespaceLabel]).To(Equal("hub-cluster-ns"))
+}
+
+func TestSyncFromHub(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := getTestHubPolicy()
+	hubPlc.Spec.PolicyTemplates = []*policiesv1.PolicyTemplate{
+		{ObjectDefinition: runtime.RawExtension{Raw: []byte(`{"kind":"ConfigurationPolicy"}`)}},
+	}
+	cached := hubPlc.DeepCopy()
+
+	// The updates of the spec sync and the status sync don't share the fields of the cached Hub policy
+	for _, managedPlc := range []*policiesv1.Policy{{}, ManagedPolicyFromHub(hubPlc, "managed-cluster-ns", nil)} {
+		SyncFromHub(hubPlc, managedPlc, nil)
+		Expect(managedPlc.Spec).To(Equal(hubPlc.Spec))
+		Expect(managedPlc.Annotations).To(Equal(hubPlc.Annotations))
+
+		// Such as when the update decodes the response into the managed policy
+		managedPlc.Spec.RemediationAction = policiesv1.Enforce
+		managedPlc.Spec.PolicyTemplates[0].ObjectDefinition.Raw[2] = 'x'
+		managedPlc.Spec.PolicyTemplates = append(managedPlc.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{})
+		managedPlc.Annotations["policy.open-cluster-management.io/standards"] = "altered"
+		if managedPlc.Labels != nil {
+			managedPlc.Labels[common.RootPolicyLabel] = "altered"
+		}
+
+		Expect(hubPlc).To(Equal(cached))
+	}
+}
+
+func TestCreateManagedPolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	managedClient := testutils.NewApplyClient(fake.NewClientBuilder().WithScheme(scheme).Build())
+	hubPlc := getTestHubPolicy()
+
+	_, err := CreateManagedPolicy(context.TODO(), managedClient, hubPlc, "managed-cluster-ns", nil)
+	Expect(err).To(BeNil())
+
+	managedPlc := &policiesv1.Policy{}
+	err = managedClient.Get(
+		context.TODO(), types.NamespacedName{Namespace: "managed-cluster-ns", Name: "policy-test"}, managedPlc,
+	)
+	Expect(err).To(BeNil())
+	Expect(managedPlc.Status.ComplianceState).To(Equal(policiesv1.Compliant))
+	Expect(managedPlc.Status.Details).To(HaveLen(1))
+
+	// The status is applied so that the Status Sync controller owns its fields
+	Expect(*managedClient.Patches).To(HaveLen(1))
+	Expect((*managedClient.Patches)[0].FieldManager).To(Equal(StatusFieldOwner))
+
+	// A second creation, such as from a different controller, should return the existing policy
+	existingPlc, err := CreateManagedPolicy(context.TODO(), managedClient, hubPlc, "managed-cluster-ns", nil)
+	Expect(err).To(BeNil())
+	Expect(existingPlc.UID).To(Equal(managedPlc.UID))
+}
+
+// failingStatusClient fails the first status writes.
+type failingStatusClient struct {
+	testutils.ApplyClient
+	failures *int
+}
+
+func (c failingStatusClient) Status() client.StatusWriter {
+	return failingStatusWriter{StatusWriter: c.ApplyClient.Status(), failures: c.failures}
+}
+
+type failingStatusWriter struct {
+	client.StatusWriter
+	failures *int
+}
+
+func (w failingStatusWriter) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	if *w.failures > 0 {
+		*w.failures--
+
+		return errors.New("the status write failed")
+	}
+
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func TestCreateManagedPolicyStatusRetry(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	failures := 1
+	managedClient := failingStatusClient{
+		ApplyClient: testutils.NewApplyClient(fake.NewClientBuilder().WithScheme(scheme).Build()),
+		failures:    &failures,
+	}
+	hubPlc := getTestHubPolicy()
+
+	// The policy is created but its status isn't restored
+	_, err := CreateManagedPolicy(context.TODO(), managedClient, hubPlc, "managed-cluster-ns", nil)
+	Expect(err).ToNot(BeNil())
+
+	key := types.NamespacedName{Namespace: "managed-cluster-ns", Name: "policy-test"}
+	managedPlc := &policiesv1.Policy{}
+	Expect(managedClient.Get(context.TODO(), key, managedPlc)).To(Succeed())
+	Expect(managedPlc.Status.Details).To(BeEmpty())
+
+	// The retry restores the status on the existing policy
+	existingPlc, err := CreateManagedPolicy(context.TODO(), managedClient, hubPlc, "managed-cluster-ns", nil)
+	Expect(err).To(BeNil())
+	Expect(existingPlc.Status.Details).To(HaveLen(1))
+
+	Expect(managedClient.Get(context.TODO(), key, managedPlc)).To(Succeed())
+	Expect(managedPlc.Status.ComplianceState).To(Equal(policiesv1.Compliant))
+	Expect(managedPlc.Status.Details).To(HaveLen(1))
+
+	// A status set meanwhile isn't overwritten
+	hubPlc.Status.ComplianceState = policiesv1.NonCompliant
+	existingPlc, err = CreateManagedPolicy(context.TODO(), managedClient, hubPlc, "managed-cluster-ns", nil)
+	Expect(err).To(BeNil())
+	Expect(existingPlc.Status.ComplianceState).To(Equal(policiesv1.Compliant))
+	Expect(*managedClient.Patches).To(HaveLen(1))
+}
+
+func TestRootPolicyCollision(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:   "policies.policy",
+		Labels: map[string]string{common.RootPolicyLabel: "policies.policy"},
+	}}
+	managedPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:      "policies.policy",
+		Namespace: "managed",
+		Labels:    map[string]string{common.RootPolicyLabel: "policies.policy"},
+	}}
+
+	Expect(RootPolicyCollision(hubPlc, managedPlc)).To(Succeed())
+
+	managedPlc.Labels[common.RootPolicyLabel] = "other.policy"
+
+	err := RootPolicyCollision(hubPlc, managedPlc)
+	Expect(errors.Is(err, syncerrors.ErrRootPolicyCollision)).To(BeTrue())
+	Expect(err.Error()).To(ContainSubstring("other.policy"))
+	Expect(err.Error()).To(ContainSubstring("root policy policies.policy"))
+
+	// Policies without the root policy label don't collide
+	delete(managedPlc.Labels, common.RootPolicyLabel)
+	Expect(RootPolicyCollision(hubPlc, managedPlc)).To(Succeed())
+}
+
+func TestReportRootPolicyCollision(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	managedPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:      "policies.policy",
+		Namespace: "managed",
+		Labels:    map[string]string{common.RootPolicyLabel: "other.policy"},
+	}}
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(managedPlc).Build()
+	ctx := context.TODO()
+
+	Expect(managedClient.Get(ctx, types.NamespacedName{Namespace: "managed", Name: "policies.policy"}, managedPlc)).
+		To(Succeed())
+
+	hubPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:   "policies.policy",
+		Labels: map[string]string{common.RootPolicyLabel: "policies.policy"},
+	}}
+
+	collision := RootPolicyCollision(hubPlc, managedPlc)
+	Expect(ReportRootPolicyCollision(ctx, managedClient, managedPlc, collision)).To(Succeed())
+
+	stored := &policiesv1.Policy{}
+	Expect(managedClient.Get(ctx, types.NamespacedName{Namespace: "managed", Name: "policies.policy"}, stored)).
+		To(Succeed())
+
+	condition := ConditionAnnotation(stored, RootPolicyCollisionAnnotation)
+	Expect(condition).ToNot(BeNil())
+	Expect(condition.Type).To(Equal(RootPolicyCollisionCondition))
+	Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	Expect(condition.Message).To(Equal(collision.Error()))
+
+	// The condition is removed once the collision is resolved
+	Expect(ReportRootPolicyCollision(ctx, managedClient, managedPlc, nil)).To(Succeed())
+	Expect(managedClient.Get(ctx, types.NamespacedName{Namespace: "managed", Name: "policies.policy"}, stored)).
+		To(Succeed())
+	Expect(stored.GetAnnotations()).ToNot(HaveKey(RootPolicyCollisionAnnotation))
+}
+
+func TestReplaceCollidingPolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	managedPlc := &policiesv1.Policy{}
+	Expect(ReplaceCollidingPolicy(managedPlc)).To(BeFalse())
+
+	managedPlc.SetAnnotations(map[string]string{ReplaceCollidingPolicyAnnotation: "True"})
+	Expect(ReplaceCollidingPolicy(managedPlc)).To(BeTrue())
+}
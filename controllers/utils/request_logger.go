@@ -0,0 +1,67 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"math"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// RequestLogger is an HTTP round tripper that logs the method, path, latency, and status code of API requests. To
+// bound the log volume, only a sample of the successful requests are logged, but failed requests are always logged.
+type RequestLogger struct {
+	next    http.RoundTripper
+	log     logr.Logger
+	every   uint64
+	counter uint64
+}
+
+// NewRequestLoggerWrapper returns a function that wraps a round tripper in a RequestLogger, to be passed to
+// rest.Config.Wrap. The client name identifies the client in the logs, and the sample rate is the fraction of
+// successful requests to log between 0 and 1.
+func NewRequestLoggerWrapper(clientName string, sampleRate float64) func(http.RoundTripper) http.RoundTripper {
+	every := uint64(1)
+	if sampleRate > 0 && sampleRate < 1 {
+		every = uint64(math.Round(1 / sampleRate))
+	} else if sampleRate <= 0 {
+		// Only log failed requests
+		every = 0
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &RequestLogger{
+			next:  next,
+			log:   ctrl.Log.WithName("api-requests").WithValues("client", clientName),
+			every: every,
+		}
+	}
+}
+
+// RoundTrip performs the request and logs it if it failed or is sampled.
+func (l *RequestLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := l.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	if err != nil {
+		l.log.Info("API request failed", "method", req.Method, "path", req.URL.Path, "latency", latency.String(),
+			"error", err.Error())
+
+		return resp, err
+	}
+
+	failed := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+	sampled := l.every != 0 && atomic.AddUint64(&l.counter, 1)%l.every == 0
+
+	if failed || sampled {
+		l.log.Info("API request", "method", req.Method, "path", req.URL.Path, "latency", latency.String(),
+			"statusCode", resp.StatusCode)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,43 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeRoundTripper struct {
+	statusCode int
+	requests   int
+}
+
+func (f *fakeRoundTripper) RoundTrip(_ *http.Request) (*http.Response, error) {
+	f.requests++
+
+	return &http.Response{StatusCode: f.statusCode}, nil
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	RegisterTestingT(t)
+
+	next := &fakeRoundTripper{statusCode: http.StatusOK}
+
+	for sampleRate, expectedEvery := range map[float64]uint64{1: 1, 2: 1, 0.1: 10, 0.3: 3, 0: 0, -1: 0} {
+		logger, ok := NewRequestLoggerWrapper("hub", sampleRate)(next).(*RequestLogger)
+		Expect(ok).To(BeTrue())
+		Expect(logger.every).To(Equal(expectedEvery))
+	}
+
+	logger := NewRequestLoggerWrapper("hub", 0.5)(next)
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "https://hub:6443/api/v1/namespaces", nil)
+	Expect(err).To(BeNil())
+
+	resp, err := logger.RoundTrip(req)
+	Expect(err).To(BeNil())
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Expect(next.requests).To(Equal(1))
+}
@@ -0,0 +1,54 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TriggerUpdateAnnotation can be set on a replicated policy on the Hub or on the managed cluster to a new value, such
+// as the current timestamp, to force a full resync of only that policy. It is always synced to the managed cluster,
+// even when the replicated policies are compacted.
+const TriggerUpdateAnnotation = "policy.open-cluster-management.io/trigger-update"
+
+// ResyncTracker records the last TriggerUpdateAnnotation value seen by a controller for each policy, so that each new
+// value forces a single resync. The zero value is ready to use.
+type ResyncTracker struct {
+	seen map[types.NamespacedName]string
+	lock sync.Mutex
+}
+
+// Requested returns true if the TriggerUpdateAnnotation annotation of the input policy changed to a new value since the
+// policy was last seen. The first time a policy is seen, such as after a restart, its value is only recorded since the
+// policy is then fully synced anyway.
+func (t *ResyncTracker) Requested(plc client.Object) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.seen == nil {
+		t.seen = map[types.NamespacedName]string{}
+	}
+
+	key := types.NamespacedName{Namespace: plc.GetNamespace(), Name: plc.GetName()}
+	value, found := plc.GetAnnotations()[TriggerUpdateAnnotation]
+	previous, seen := t.seen[key]
+
+	if found {
+		t.seen[key] = value
+	} else if !seen {
+		t.seen[key] = ""
+	}
+
+	return seen && found && value != "" && value != previous
+}
+
+// Forget stops tracking the input policy, such as when it is deleted.
+func (t *ResyncTracker) Forget(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.seen, key)
+}
@@ -0,0 +1,40 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestResyncTracker(t *testing.T) {
+	RegisterTestingT(t)
+
+	tracker := ResyncTracker{}
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "cluster-ns"}}
+
+	// The first time a policy is seen, it is fully synced anyway
+	Expect(tracker.Requested(plc)).To(BeFalse())
+
+	plc.SetAnnotations(map[string]string{TriggerUpdateAnnotation: "2024-01-01T00:00:00Z"})
+	Expect(tracker.Requested(plc)).To(BeTrue())
+	Expect(tracker.Requested(plc)).To(BeFalse())
+
+	// Removing the annotation, such as when it was only set on the managed policy, doesn't request a resync
+	plc.SetAnnotations(nil)
+	Expect(tracker.Requested(plc)).To(BeFalse())
+
+	plc.SetAnnotations(map[string]string{TriggerUpdateAnnotation: "2024-01-02T00:00:00Z"})
+	Expect(tracker.Requested(plc)).To(BeTrue())
+
+	tracker.Forget(types.NamespacedName{Namespace: "cluster-ns", Name: "policy"})
+	Expect(tracker.Requested(plc)).To(BeFalse())
+
+	// The annotation is retained regardless of the compaction allow-list
+	compaction := &PolicyCompaction{AllowList: []string{"example.com/*"}}
+	Expect(compaction.allowed(TriggerUpdateAnnotation)).To(BeTrue())
+}
@@ -0,0 +1,132 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+)
+
+const (
+	// RootPlacementBindingsAnnotation is set on the replicated policy on the managed cluster to the comma-separated
+	// names of the placement bindings of its root policy on the Hub which select the cluster.
+	RootPlacementBindingsAnnotation = "policy.open-cluster-management.io/root-policy-placement-bindings"
+	// RootPlacementsAnnotation is set on the replicated policy on the managed cluster to the comma-separated kinds and
+	// names of the placements of its root policy on the Hub which select the cluster, such as Placement/my-placement
+	// or PlacementRule/my-rule.
+	RootPlacementsAnnotation = "policy.open-cluster-management.io/root-policy-placements"
+)
+
+// rootPlacementAnnotations are the annotations set from the placement of the root policy.
+var rootPlacementAnnotations = []string{RootPlacementBindingsAnnotation, RootPlacementsAnnotation}
+
+// RootPolicyKey returns the namespace and name of the root policy of the input replicated policy, from its root policy
+// label, and false if it has none.
+func RootPolicyKey(plc *policiesv1.Policy) (types.NamespacedName, bool) {
+	// The namespace can't contain a dot, but the name can
+	namespace, name, found := strings.Cut(plc.GetLabels()[common.RootPolicyLabel], ".")
+	if !found || namespace == "" || name == "" {
+		return types.NamespacedName{}, false
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: name}, true
+}
+
+// RootPlacementAnnotations returns the RootPlacementBindingsAnnotation and RootPlacementsAnnotation annotations for
+// the placements in the status of the input root policy whose decisions include the input cluster namespace on the
+// Hub. If no placement has decisions for the cluster, such as when the decisions are not reported in the status, all
+// the placements are listed. The annotations are empty when the root policy has no placement.
+func RootPlacementAnnotations(rootPlc *policiesv1.Policy, clusterNamespace string) map[string]string {
+	selecting := []*policiesv1.Placement{}
+
+	for _, placement := range rootPlc.Status.Placement {
+		if placement == nil {
+			continue
+		}
+
+		for _, decision := range placement.Decisions {
+			if decision.ClusterNamespace == clusterNamespace || decision.ClusterName == clusterNamespace {
+				selecting = append(selecting, placement)
+
+				break
+			}
+		}
+	}
+
+	if len(selecting) == 0 {
+		selecting = rootPlc.Status.Placement
+	}
+
+	bindings := map[string]bool{}
+	placements := map[string]bool{}
+
+	for _, placement := range selecting {
+		if placement == nil {
+			continue
+		}
+
+		if placement.PlacementBinding != "" {
+			bindings[placement.PlacementBinding] = true
+		}
+
+		if placement.Placement != "" {
+			placements["Placement/"+placement.Placement] = true
+		}
+
+		if placement.PlacementRule != "" {
+			placements["PlacementRule/"+placement.PlacementRule] = true
+		}
+	}
+
+	annotations := map[string]string{}
+
+	if len(bindings) != 0 {
+		annotations[RootPlacementBindingsAnnotation] = sortedKeys(bindings)
+	}
+
+	if len(placements) != 0 {
+		annotations[RootPlacementsAnnotation] = sortedKeys(placements)
+	}
+
+	return annotations
+}
+
+// sortedKeys returns the sorted keys of the input set separated by commas.
+func sortedKeys(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return strings.Join(keys, ",")
+}
+
+// RootPlacementPatch returns the values of the root policy placement annotations of the input policy to change so
+// that they match the input annotations, where a nil value removes the annotation, or nil if they already match.
+func RootPlacementPatch(plc *policiesv1.Policy, annotations map[string]string) map[string]interface{} {
+	patch := map[string]interface{}{}
+
+	for _, key := range rootPlacementAnnotations {
+		current, set := plc.GetAnnotations()[key]
+		desired, wanted := annotations[key]
+
+		switch {
+		case wanted && (!set || current != desired):
+			patch[key] = desired
+		case !wanted && set:
+			patch[key] = nil
+		}
+	}
+
+	if len(patch) == 0 {
+		return nil
+	}
+
+	return patch
+}
@@ -0,0 +1,100 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	appsv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+)
+
+func TestRootPolicyKey(t *testing.T) {
+	t.Parallel()
+	RegisterTestingT(t)
+
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{common.RootPolicyLabel: "policies.my.policy"},
+	}}
+
+	key, ok := RootPolicyKey(plc)
+	Expect(ok).To(BeTrue())
+	Expect(key).To(Equal(types.NamespacedName{Namespace: "policies", Name: "my.policy"}))
+
+	plc.Labels[common.RootPolicyLabel] = "policy"
+
+	_, ok = RootPolicyKey(plc)
+	Expect(ok).To(BeFalse())
+
+	_, ok = RootPolicyKey(&policiesv1.Policy{})
+	Expect(ok).To(BeFalse())
+}
+
+func TestRootPlacementAnnotations(t *testing.T) {
+	t.Parallel()
+	RegisterTestingT(t)
+
+	rootPlc := &policiesv1.Policy{Status: policiesv1.PolicyStatus{Placement: []*policiesv1.Placement{
+		{
+			PlacementBinding: "binding-b",
+			Placement:        "placement",
+			Decisions:        []appsv1.PlacementDecision{{ClusterName: "cluster1", ClusterNamespace: "cluster1"}},
+		},
+		{
+			PlacementBinding: "binding-a",
+			PlacementRule:    "rule",
+			Decisions:        []appsv1.PlacementDecision{{ClusterName: "cluster1", ClusterNamespace: "cluster1"}},
+		},
+		{
+			PlacementBinding: "binding-c",
+			Placement:        "other",
+			Decisions:        []appsv1.PlacementDecision{{ClusterName: "cluster2", ClusterNamespace: "cluster2"}},
+		},
+	}}}
+
+	Expect(RootPlacementAnnotations(rootPlc, "cluster1")).To(Equal(map[string]string{
+		RootPlacementBindingsAnnotation: "binding-a,binding-b",
+		RootPlacementsAnnotation:        "Placement/placement,PlacementRule/rule",
+	}))
+
+	// Without decisions for the cluster, all the placements are listed
+	Expect(RootPlacementAnnotations(rootPlc, "cluster3")).To(Equal(map[string]string{
+		RootPlacementBindingsAnnotation: "binding-a,binding-b,binding-c",
+		RootPlacementsAnnotation:        "Placement/other,Placement/placement,PlacementRule/rule",
+	}))
+
+	Expect(RootPlacementAnnotations(&policiesv1.Policy{}, "cluster1")).To(BeEmpty())
+}
+
+func TestRootPlacementPatch(t *testing.T) {
+	t.Parallel()
+	RegisterTestingT(t)
+
+	plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{
+			RootPlacementBindingsAnnotation: "binding",
+			RootPlacementsAnnotation:        "Placement/placement",
+		},
+	}}
+
+	Expect(RootPlacementPatch(plc, map[string]string{
+		RootPlacementBindingsAnnotation: "binding",
+		RootPlacementsAnnotation:        "Placement/placement",
+	})).To(BeNil())
+
+	Expect(RootPlacementPatch(plc, map[string]string{
+		RootPlacementBindingsAnnotation: "binding",
+		RootPlacementsAnnotation:        "PlacementRule/rule",
+	})).To(Equal(map[string]interface{}{RootPlacementsAnnotation: "PlacementRule/rule"}))
+
+	Expect(RootPlacementPatch(plc, map[string]string{})).To(Equal(map[string]interface{}{
+		RootPlacementBindingsAnnotation: nil,
+		RootPlacementsAnnotation:        nil,
+	}))
+
+	Expect(RootPlacementPatch(&policiesv1.Policy{}, map[string]string{})).To(BeNil())
+}
@@ -0,0 +1,60 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SelfManagedHubMode determines when the replicated policy on the Hub and the policy on the managed cluster are
+// treated as the same object, such as when the Hub manages itself and the cluster namespace is the same on both. The
+// status of such a policy is then only written once, and the events are only emitted once.
+type SelfManagedHubMode string
+
+const (
+	// SelfManagedHubAuto treats the policies as the same object when the ON_MULTICLUSTERHUB environment variable is
+	// set to true or when they have the same UID.
+	SelfManagedHubAuto SelfManagedHubMode = "auto"
+	// SelfManagedHubEnabled always treats the policies as the same object.
+	SelfManagedHubEnabled SelfManagedHubMode = "true"
+	// SelfManagedHubDisabled never treats the policies as the same object, even when they have the same UID.
+	SelfManagedHubDisabled SelfManagedHubMode = "false"
+)
+
+// ParseSelfManagedHubMode parses the input mode, such as from the command line. An empty mode is SelfManagedHubAuto.
+func ParseSelfManagedHubMode(mode string) (SelfManagedHubMode, error) {
+	switch SelfManagedHubMode(mode) {
+	case "", SelfManagedHubAuto:
+		return SelfManagedHubAuto, nil
+	case SelfManagedHubEnabled, SelfManagedHubDisabled:
+		return SelfManagedHubMode(mode), nil
+	default:
+		return "", fmt.Errorf("invalid self-managed Hub mode %q, must be auto, true, or false", mode)
+	}
+}
+
+// Always returns true if all the policies are treated as the same object on the Hub and the managed cluster, without
+// comparing them.
+func (m SelfManagedHubMode) Always() bool {
+	return m == SelfManagedHubEnabled ||
+		(m != SelfManagedHubDisabled && os.Getenv("ON_MULTICLUSTERHUB") == "true")
+}
+
+// SamePolicy returns true if the input replicated policy on the Hub and policy on the managed cluster are treated as
+// the same object.
+func (m SelfManagedHubMode) SamePolicy(hubPlc client.Object, managedPlc client.Object) bool {
+	if m.Always() {
+		return true
+	}
+
+	return m != SelfManagedHubDisabled && SameObject(hubPlc, managedPlc)
+}
+
+// SameObject returns true if the input objects have the same UID, such as the replicated policy on a Hub which manages
+// itself and the policy on the managed cluster when the cluster namespace is the same on both.
+func SameObject(hubObj client.Object, managedObj client.Object) bool {
+	return hubObj.GetUID() != "" && hubObj.GetUID() == managedObj.GetUID()
+}
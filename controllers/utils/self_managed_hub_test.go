@@ -0,0 +1,43 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestSelfManagedHubMode(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "local-cluster", UID: "1"}}
+	samePlc := hubPlc.DeepCopy()
+	otherPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "local-cluster", UID: "2"}}
+
+	mode, err := ParseSelfManagedHubMode("")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(mode).To(Equal(SelfManagedHubAuto))
+
+	_, err = ParseSelfManagedHubMode("yes")
+	Expect(err).To(HaveOccurred())
+
+	t.Setenv("ON_MULTICLUSTERHUB", "")
+
+	Expect(SelfManagedHubAuto.Always()).To(BeFalse())
+	Expect(SelfManagedHubAuto.SamePolicy(hubPlc, samePlc)).To(BeTrue())
+	Expect(SelfManagedHubAuto.SamePolicy(hubPlc, otherPlc)).To(BeFalse())
+	Expect(SelfManagedHubEnabled.SamePolicy(hubPlc, otherPlc)).To(BeTrue())
+	Expect(SelfManagedHubDisabled.SamePolicy(hubPlc, samePlc)).To(BeFalse())
+
+	// Policies without a UID, such as in unit tests, aren't the same object
+	Expect(SameObject(&policiesv1.Policy{}, &policiesv1.Policy{})).To(BeFalse())
+
+	t.Setenv("ON_MULTICLUSTERHUB", "true")
+
+	Expect(SelfManagedHubAuto.Always()).To(BeTrue())
+	Expect(SelfManagedHubAuto.SamePolicy(hubPlc, otherPlc)).To(BeTrue())
+	Expect(SelfManagedHubDisabled.Always()).To(BeFalse())
+}
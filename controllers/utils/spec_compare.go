@@ -0,0 +1,83 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// EquivalentSpecs returns true if the input policy specs are equal, regardless of the order of their policy templates.
+// Re-ordering the policy templates on the Hub doesn't change any policy template, so it doesn't require updating the
+// policy on the managed cluster, which would otherwise also re-order the policy status. When the policy is updated for
+// another reason, such as an annotation change, the order of the Hub is then synced.
+func EquivalentSpecs(hubSpec policiesv1.PolicySpec, managedSpec policiesv1.PolicySpec) bool {
+	if len(hubSpec.PolicyTemplates) != len(managedSpec.PolicyTemplates) {
+		return false
+	}
+
+	hubTemplates := sortedTemplates(hubSpec.PolicyTemplates)
+	managedTemplates := sortedTemplates(managedSpec.PolicyTemplates)
+
+	hubSpec.PolicyTemplates = nil
+	managedSpec.PolicyTemplates = nil
+
+	return equality.Semantic.DeepEqual(hubSpec, managedSpec) &&
+		equality.Semantic.DeepEqual(hubTemplates, managedTemplates)
+}
+
+// templateSortKey returns the kind and name of the input policy template, which identify it in the policy. A policy
+// template which can't be parsed has an empty key.
+func templateSortKey(policyT *policiesv1.PolicyTemplate) string {
+	if policyT == nil {
+		return ""
+	}
+
+	object := struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}{}
+
+	if err := json.Unmarshal(policyT.ObjectDefinition.Raw, &object); err != nil {
+		return ""
+	}
+
+	return object.Kind + "/" + object.Metadata.Name
+}
+
+// sortedTemplates returns a copy of the input policy templates sorted by kind and name, and then by content for the
+// policy templates with the same key.
+func sortedTemplates(templates []*policiesv1.PolicyTemplate) []*policiesv1.PolicyTemplate {
+	sorted := make([]*policiesv1.PolicyTemplate, len(templates))
+	keys := make(map[*policiesv1.PolicyTemplate]string, len(templates))
+
+	copy(sorted, templates)
+
+	for _, policyT := range sorted {
+		keys[policyT] = templateSortKey(policyT)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if keys[sorted[i]] != keys[sorted[j]] {
+			return keys[sorted[i]] < keys[sorted[j]]
+		}
+
+		return string(rawTemplate(sorted[i])) < string(rawTemplate(sorted[j]))
+	})
+
+	return sorted
+}
+
+// rawTemplate returns the object definition of the input policy template, which is nil for a nil policy template.
+func rawTemplate(policyT *policiesv1.PolicyTemplate) []byte {
+	if policyT == nil {
+		return nil
+	}
+
+	return policyT.ObjectDefinition.Raw
+}
@@ -0,0 +1,57 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func policyTemplate(kind string, name string, remediationAction string) *policiesv1.PolicyTemplate {
+	return &policiesv1.PolicyTemplate{ObjectDefinition: runtime.RawExtension{Raw: []byte(
+		`{"kind":"` + kind + `","metadata":{"name":"` + name + `"},"spec":{"remediationAction":"` +
+			remediationAction + `"}}`,
+	)}}
+}
+
+func TestEquivalentSpecs(t *testing.T) {
+	RegisterTestingT(t)
+
+	hubPlc := &policiesv1.Policy{Spec: policiesv1.PolicySpec{
+		RemediationAction: policiesv1.Inform,
+		PolicyTemplates: []*policiesv1.PolicyTemplate{
+			policyTemplate("ConfigurationPolicy", "a", "inform"),
+			policyTemplate("ConfigurationPolicy", "b", "inform"),
+			policyTemplate("CertificatePolicy", "a", "inform"),
+		},
+	}}
+	managedPlc := hubPlc.DeepCopy()
+	managedPlc.Spec.PolicyTemplates = []*policiesv1.PolicyTemplate{
+		hubPlc.Spec.PolicyTemplates[2], hubPlc.Spec.PolicyTemplates[0], hubPlc.Spec.PolicyTemplates[1],
+	}
+
+	// A pure re-ordering doesn't change the managed policy
+	Expect(EquivalentSpecs(hubPlc.Spec, managedPlc.Spec)).To(BeTrue())
+	Expect(CompareSpecAndAnnotation(hubPlc, managedPlc, nil)).To(BeTrue())
+
+	// The input specs aren't sorted
+	Expect(templateSortKey(hubPlc.Spec.PolicyTemplates[0])).To(Equal("ConfigurationPolicy/a"))
+	Expect(templateSortKey(managedPlc.Spec.PolicyTemplates[0])).To(Equal("CertificatePolicy/a"))
+
+	// A changed policy template is still detected when re-ordered
+	hubPlc.Spec.PolicyTemplates[1] = policyTemplate("ConfigurationPolicy", "b", "enforce")
+	Expect(EquivalentSpecs(hubPlc.Spec, managedPlc.Spec)).To(BeFalse())
+
+	managedPlc.Spec = *hubPlc.Spec.DeepCopy()
+	Expect(EquivalentSpecs(hubPlc.Spec, managedPlc.Spec)).To(BeTrue())
+
+	managedPlc.Spec.RemediationAction = policiesv1.Enforce
+	Expect(EquivalentSpecs(hubPlc.Spec, managedPlc.Spec)).To(BeFalse())
+
+	managedPlc.Spec.RemediationAction = policiesv1.Inform
+	managedPlc.Spec.PolicyTemplates = managedPlc.Spec.PolicyTemplates[1:]
+	Expect(EquivalentSpecs(hubPlc.Spec, managedPlc.Spec)).To(BeFalse())
+}
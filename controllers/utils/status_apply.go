@@ -0,0 +1,173 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// StatusFieldOwner is the field manager of the policy statuses written by the addon with server-side apply.
+const StatusFieldOwner = "governance-policy-framework-addon-status-sync"
+
+// appliedStatusFields are the fields of the policy status written by the addon, whose ownership is taken over from the
+// field managers which updated them.
+var appliedStatusFields = map[string]bool{"compliant": true, "details": true}
+
+// ApplyPolicyStatus writes the input status of the policy with server-side apply. Since the apply request doesn't set
+// the resource version of the policy, the status write doesn't conflict with the concurrent writes of the policy, such
+// as the annotations patched by the controllers, and the fields of the status set by other field managers are kept.
+// The fields of the status previously applied by the addon which are not in the input status are removed. On success,
+// the input policy reflects the updated policy.
+func ApplyPolicyStatus(
+	ctx context.Context, c client.Client, policy *policiesv1.Policy, status policiesv1.PolicyStatus,
+) error {
+	if err := migrateStatusOwnership(ctx, c, policy); err != nil {
+		return fmt.Errorf("failed to take over the ownership of the policy status fields: %w", err)
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status.DeepCopy())
+	if err != nil {
+		return fmt.Errorf("failed to convert the policy status: %w", err)
+	}
+
+	applied := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": policiesv1.GroupVersion.String(),
+		"kind":       policiesv1.Kind,
+		"metadata": map[string]interface{}{
+			"name":      policy.GetName(),
+			"namespace": policy.GetNamespace(),
+		},
+		"status": content,
+	}}
+
+	err = c.Status().Patch(ctx, applied, client.Apply, client.FieldOwner(StatusFieldOwner), client.ForceOwnership)
+	if err != nil {
+		return err
+	}
+
+	updated := &policiesv1.Policy{}
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(applied.Object, updated)
+	if err != nil {
+		return fmt.Errorf("failed to convert the updated policy: %w", err)
+	}
+
+	*policy = *updated
+
+	return nil
+}
+
+// migrateStatusOwnership moves the ownership of the status fields written by the addon from the field managers which
+// updated them, such as the addon versions which updated the policy statuses instead of applying them, to
+// StatusFieldOwner. Otherwise, a field still owned by such a manager wouldn't be removed when it is left out of an
+// applied status, such as a cleared compliance state. Since the field managers no longer own these fields afterwards,
+// the policy is only patched once, after an upgrade. The patch fails if the input policy is outdated.
+func migrateStatusOwnership(ctx context.Context, c client.Client, policy *policiesv1.Policy) error {
+	managedFields := policy.GetManagedFields()
+	migrated := make([]metav1.ManagedFieldsEntry, 0, len(managedFields)+1)
+	taken := &fieldpath.Set{}
+	owner := -1
+
+	for _, entry := range managedFields {
+		if entry.Manager == StatusFieldOwner && entry.Operation == metav1.ManagedFieldsOperationApply &&
+			entry.Subresource == "status" {
+			owner = len(migrated)
+		}
+
+		if entry.Operation != metav1.ManagedFieldsOperationUpdate || entry.FieldsV1 == nil {
+			migrated = append(migrated, entry)
+
+			continue
+		}
+
+		fields := &fieldpath.Set{}
+		if err := fields.FromJSON(bytes.NewReader(entry.FieldsV1.Raw)); err != nil {
+			return err
+		}
+
+		statusFields := &fieldpath.Set{}
+
+		fields.Iterate(func(path fieldpath.Path) {
+			if len(path) > 1 && path[0].FieldName != nil && *path[0].FieldName == "status" &&
+				path[1].FieldName != nil && appliedStatusFields[*path[1].FieldName] {
+				statusFields.Insert(path)
+			}
+		})
+
+		if statusFields.Empty() {
+			migrated = append(migrated, entry)
+
+			continue
+		}
+
+		taken = taken.Union(statusFields)
+
+		remaining := fields.Difference(statusFields)
+		if remaining.Empty() {
+			continue
+		}
+
+		raw, err := remaining.ToJSON()
+		if err != nil {
+			return err
+		}
+
+		entry.FieldsV1 = &metav1.FieldsV1{Raw: raw}
+		migrated = append(migrated, entry)
+	}
+
+	if taken.Empty() {
+		return nil
+	}
+
+	if owner == -1 {
+		now := metav1.NewTime(time.Now())
+
+		migrated = append(migrated, metav1.ManagedFieldsEntry{
+			Manager:     StatusFieldOwner,
+			Operation:   metav1.ManagedFieldsOperationApply,
+			APIVersion:  policiesv1.GroupVersion.String(),
+			Time:        &now,
+			FieldsType:  "FieldsV1",
+			Subresource: "status",
+		})
+		owner = len(migrated) - 1
+	} else if migrated[owner].FieldsV1 != nil {
+		owned := &fieldpath.Set{}
+		if err := owned.FromJSON(bytes.NewReader(migrated[owner].FieldsV1.Raw)); err != nil {
+			return err
+		}
+
+		taken = taken.Union(owned)
+	}
+
+	raw, err := taken.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	migrated[owner].FieldsV1 = &metav1.FieldsV1{Raw: raw}
+
+	// The test operation fails the patch if the policy changed meanwhile, so that no ownership change is lost
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": policy.GetResourceVersion()},
+		{"op": "replace", "path": "/metadata/managedFields", "value": migrated},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Patch(ctx, policy, client.RawPatch(types.JSONPatchType, patch))
+}
@@ -0,0 +1,144 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+func TestApplyPolicyStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"}}
+	c := testutils.NewApplyClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).Build())
+
+	stale := &policiesv1.Policy{}
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), stale)).To(Succeed())
+
+	// Change the policy so that the stale copy has an outdated resource version
+	latest := stale.DeepCopy()
+	latest.Labels = map[string]string{"updated": "true"}
+	Expect(c.Update(context.TODO(), latest)).To(Succeed())
+
+	status := policiesv1.PolicyStatus{ComplianceState: policiesv1.NonCompliant}
+	Expect(ApplyPolicyStatus(context.TODO(), c, stale, status)).To(Succeed())
+	Expect(stale.Labels).To(HaveKeyWithValue("updated", "true"))
+	Expect(stale.Status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+
+	// Only the status is applied, without a resource version, and the controller owns its fields
+	Expect(*c.Patches).To(HaveLen(1))
+	Expect((*c.Patches)[0].FieldManager).To(Equal(StatusFieldOwner))
+	Expect(*(*c.Patches)[0].Force).To(BeTrue())
+	Expect((*c.Data)[0]).To(Equal(map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "Policy",
+		"metadata":   map[string]interface{}{"name": "policy", "namespace": "managed"},
+		"status":     map[string]interface{}{"compliant": "NonCompliant"},
+	}))
+
+	updated := &policiesv1.Policy{}
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), updated)).To(Succeed())
+	Expect(updated.Status.ComplianceState).To(Equal(policiesv1.NonCompliant))
+}
+
+// managedFieldsOf returns the fields owned by the input field manager with the input operation in the input managed
+// fields, or nil if there is no such entry.
+func managedFieldsOf(
+	entries []metav1.ManagedFieldsEntry, manager string, operation metav1.ManagedFieldsOperationType,
+) *fieldpath.Set {
+	for _, entry := range entries {
+		if entry.Manager != manager || entry.Operation != operation {
+			continue
+		}
+
+		fields := &fieldpath.Set{}
+		Expect(fields.FromJSON(bytes.NewReader(entry.FieldsV1.Raw))).To(Succeed())
+
+		return fields
+	}
+
+	return nil
+}
+
+func TestApplyPolicyStatusMigratesOwnership(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+
+	// The compliance state and the details were updated by an earlier addon version, which co-owns them with the
+	// other fields it updated. Since a field co-owned by another manager isn't removed when it is left out of an
+	// applied status, clearing the compliance state wouldn't clear it.
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:      "policy",
+		Namespace: "managed",
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{
+				Manager:    "governance-policy-framework-addon",
+				Operation:  metav1.ManagedFieldsOperationUpdate,
+				APIVersion: "policy.open-cluster-management.io/v1",
+				FieldsType: "FieldsV1",
+				FieldsV1: &metav1.FieldsV1{
+					Raw: []byte(`{"f:metadata":{"f:labels":{"f:app":{}}},"f:status":{"f:compliant":{},` +
+						`"f:details":{},"f:placement":{}}}`),
+				},
+			},
+			{
+				Manager:     "governance-policy-framework-addon",
+				Operation:   metav1.ManagedFieldsOperationUpdate,
+				APIVersion:  "policy.open-cluster-management.io/v1",
+				FieldsType:  "FieldsV1",
+				FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:status":{"f:details":{}}}`)},
+				Subresource: "status",
+			},
+		},
+	}}
+	c := testutils.NewApplyClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).Build())
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+
+	original := policy.DeepCopy()
+
+	Expect(ApplyPolicyStatus(context.TODO(), c, policy, policiesv1.PolicyStatus{})).To(Succeed())
+
+	updated := &policiesv1.Policy{}
+	Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(policy), updated)).To(Succeed())
+
+	// The status fields written by the addon are now only owned by the applied status, and the other fields are kept
+	// by their manager
+	Expect(updated.GetManagedFields()).To(HaveLen(2))
+
+	legacy := managedFieldsOf(updated.GetManagedFields(), "governance-policy-framework-addon",
+		metav1.ManagedFieldsOperationUpdate)
+	Expect(legacy).ToNot(BeNil())
+	Expect(legacy.Has(fieldpath.MakePathOrDie("metadata", "labels", "app"))).To(BeTrue())
+	Expect(legacy.Has(fieldpath.MakePathOrDie("status", "placement"))).To(BeTrue())
+	Expect(legacy.Has(fieldpath.MakePathOrDie("status", "compliant"))).To(BeFalse())
+	Expect(legacy.Has(fieldpath.MakePathOrDie("status", "details"))).To(BeFalse())
+
+	owned := managedFieldsOf(updated.GetManagedFields(), StatusFieldOwner, metav1.ManagedFieldsOperationApply)
+	Expect(owned).ToNot(BeNil())
+	Expect(owned.Has(fieldpath.MakePathOrDie("status", "compliant"))).To(BeTrue())
+	Expect(owned.Has(fieldpath.MakePathOrDie("status", "details"))).To(BeTrue())
+
+	// The ownership is only migrated once, so an outdated policy doesn't fail the migration patch
+	updated.SetResourceVersion("1")
+	Expect(migrateStatusOwnership(context.TODO(), c, updated)).To(Succeed())
+
+	// The migration of an outdated policy fails instead of discarding the ownership changes made meanwhile
+	Expect(migrateStatusOwnership(context.TODO(), c, original)).ToNot(Succeed())
+}
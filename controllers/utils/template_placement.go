@@ -0,0 +1,168 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// TemplatePlacementsKey is the key in the template placement ConfigMap that contains the list of placements.
+	TemplatePlacementsKey = "placements"
+	// PlacedByPolicyLabel is set on the objects of policy templates placed outside the namespace of the policy to the
+	// name of the policy. Along with PlacedByPolicyNamespaceLabel, it replaces the owner reference, which can't refer
+	// to an object in another namespace.
+	PlacedByPolicyLabel = "policy.open-cluster-management.io/placed-by-policy"
+	// PlacedByPolicyNamespaceLabel is set on the objects of policy templates placed outside the namespace of the policy
+	// to the namespace of the policy.
+	PlacedByPolicyNamespaceLabel = "policy.open-cluster-management.io/placed-by-policy-namespace"
+)
+
+var placementLog = ctrl.Log.WithName("template-placements")
+
+// TemplatePlacement places the objects of the policy templates of the input kind in Namespace instead of the cluster
+// namespace.
+type TemplatePlacement struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+}
+
+// TemplatePlacements determines the namespace of the objects of namespaced policy templates from the per-kind
+// placements in the configured ConfigMap, so that engine-specific objects can live in the conventional namespace of
+// their engine (e.g. gatekeeper-system). The ConfigMap is watched so that changes take effect without a restart.
+type TemplatePlacements struct {
+	Client     kubernetes.Interface
+	Namespace  string
+	Name       string
+	placements map[schema.GroupKind]string
+	lock       sync.RWMutex
+}
+
+// Start watches the template placement ConfigMap until the input context is closed.
+func (p *TemplatePlacements) Start(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		p.Client,
+		0,
+		informers.WithNamespace(p.Namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", p.Name).String()
+		}),
+	)
+
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if configMap, ok := obj.(*corev1.ConfigMap); ok {
+				p.load(configMap)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if configMap, ok := newObj.(*corev1.ConfigMap); ok {
+				p.load(configMap)
+			}
+		},
+		DeleteFunc: func(_ interface{}) {
+			placementLog.Info("The template placement ConfigMap was deleted", "namespace", p.Namespace, "name", p.Name)
+
+			p.lock.Lock()
+			p.placements = nil
+			p.lock.Unlock()
+		},
+	})
+
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+
+	return nil
+}
+
+// load replaces the placements with the content of the input ConfigMap. Invalid placements are logged and skipped.
+func (p *TemplatePlacements) load(configMap *corev1.ConfigMap) {
+	placements := []TemplatePlacement{}
+
+	err := yaml.Unmarshal([]byte(configMap.Data[TemplatePlacementsKey]), &placements)
+	if err != nil {
+		placementLog.Error(err, "Failed to parse the template placement ConfigMap, ignoring it",
+			"namespace", p.Namespace, "name", p.Name)
+
+		placements = nil
+	}
+
+	placementsByKind := make(map[schema.GroupKind]string, len(placements))
+
+	for _, placement := range placements {
+		if placement.Kind == "" || placement.Namespace == "" {
+			placementLog.Info("Skipping the template placement without a kind or namespace",
+				"group", placement.Group, "kind", placement.Kind, "namespace", placement.Namespace)
+
+			continue
+		}
+
+		placementsByKind[schema.GroupKind{Group: placement.Group, Kind: placement.Kind}] = placement.Namespace
+	}
+
+	placementLog.Info("Loaded the template placements",
+		"namespace", p.Namespace, "name", p.Name, "count", len(placementsByKind))
+
+	p.lock.Lock()
+	p.placements = placementsByKind
+	p.lock.Unlock()
+}
+
+// TemplateNamespace returns the namespace of the objects of the namespaced policy templates of the input kind, which
+// is the input default namespace if the kind is not placed. A nil TemplatePlacements always returns the default.
+func (p *TemplatePlacements) TemplateNamespace(groupKind schema.GroupKind, defaultNamespace string) string {
+	if p == nil {
+		return defaultNamespace
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if namespace, ok := p.placements[groupKind]; ok {
+		return namespace
+	}
+
+	return defaultNamespace
+}
+
+// Placements returns the current placements sorted by kind.
+func (p *TemplatePlacements) Placements() []TemplatePlacement {
+	if p == nil {
+		return nil
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	placements := make([]TemplatePlacement, 0, len(p.placements))
+
+	for groupKind, namespace := range p.placements {
+		placements = append(placements, TemplatePlacement{
+			Group: groupKind.Group, Kind: groupKind.Kind, Namespace: namespace,
+		})
+	}
+
+	sort.Slice(placements, func(i, j int) bool {
+		if placements[i].Group != placements[j].Group {
+			return placements[i].Group < placements[j].Group
+		}
+
+		return placements[i].Kind < placements[j].Kind
+	})
+
+	return placements
+}
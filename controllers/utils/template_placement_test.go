@@ -0,0 +1,44 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestTemplatePlacements(t *testing.T) {
+	RegisterTestingT(t)
+
+	assignKind := schema.GroupKind{Group: "mutations.gatekeeper.sh", Kind: "Assign"}
+
+	var nilPlacements *TemplatePlacements
+	Expect(nilPlacements.TemplateNamespace(assignKind, "managed")).To(Equal("managed"))
+	Expect(nilPlacements.Placements()).To(BeEmpty())
+
+	placements := &TemplatePlacements{Namespace: "managed", Name: "placements"}
+	placements.load(&corev1.ConfigMap{Data: map[string]string{
+		TemplatePlacementsKey: `
+- group: mutations.gatekeeper.sh
+  kind: Assign
+  namespace: gatekeeper-system
+- group: config.gatekeeper.sh
+  kind: Config
+  namespace: gatekeeper-system
+- kind: ConfigMap
+`,
+	}})
+
+	Expect(placements.TemplateNamespace(assignKind, "managed")).To(Equal("gatekeeper-system"))
+	Expect(placements.TemplateNamespace(schema.GroupKind{Kind: "ConfigMap"}, "managed")).To(Equal("managed"))
+	Expect(placements.Placements()).To(Equal([]TemplatePlacement{
+		{Group: "config.gatekeeper.sh", Kind: "Config", Namespace: "gatekeeper-system"},
+		{Group: "mutations.gatekeeper.sh", Kind: "Assign", Namespace: "gatekeeper-system"},
+	}))
+
+	placements.load(&corev1.ConfigMap{Data: map[string]string{TemplatePlacementsKey: "not a list"}})
+	Expect(placements.TemplateNamespace(assignKind, "managed")).To(Equal("managed"))
+}
@@ -0,0 +1,29 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"time"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// LastTransitionTimeAnnotation is set on the template metadata in the policy status by the status sync to the time
+// since which the policy template has its current compliance state.
+const LastTransitionTimeAnnotation = "policy.open-cluster-management.io/last-transition-time"
+
+// TemplateTimestamp returns the time in the input annotation of the template metadata of the input policy template
+// details, or the zero time if it isn't set or is invalid.
+func TemplateTimestamp(dpt *policiesv1.DetailsPerTemplate, annotation string) time.Time {
+	value, ok := dpt.TemplateMeta.Annotations[annotation]
+	if !ok {
+		return time.Time{}
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return timestamp
+}
@@ -0,0 +1,26 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestTemplateTimestamp(t *testing.T) {
+	RegisterTestingT(t)
+
+	dpt := &policiesv1.DetailsPerTemplate{}
+	Expect(TemplateTimestamp(dpt, LastTransitionTimeAnnotation)).To(BeZero())
+
+	dpt.TemplateMeta.Annotations = map[string]string{LastTransitionTimeAnnotation: "2024-01-01T10:00:00Z"}
+	Expect(TemplateTimestamp(dpt, LastTransitionTimeAnnotation)).To(
+		BeTemporally("==", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+	)
+
+	dpt.TemplateMeta.Annotations[LastTransitionTimeAnnotation] = "yesterday"
+	Expect(TemplateTimestamp(dpt, LastTransitionTimeAnnotation)).To(BeZero())
+}
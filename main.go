@@ -12,9 +12,12 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/zapr"
 	"github.com/spf13/pflag"
@@ -22,14 +25,19 @@ import (
 
 	// to ensure that exec-entrypoint and run can make use of them.
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/metadata"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
@@ -39,14 +47,20 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/yaml"
 
+	policyv1alpha1 "open-cluster-management.io/governance-policy-framework-addon/api/v1alpha1"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/engines/gatekeeper"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/specsync"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/statussync"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/templatesync"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 	"open-cluster-management.io/governance-policy-framework-addon/tool"
 	"open-cluster-management.io/governance-policy-framework-addon/version"
 )
@@ -61,9 +75,11 @@ func printVersion() {
 	log.Info(
 		"Using",
 		"OperatorVersion", version.Version,
+		"Revision", version.Revision(),
 		"GoVersion", runtime.Version(),
 		"GOOS", runtime.GOOS,
 		"GOARCH", runtime.GOARCH,
+		"FeatureGates", tool.EnabledFeatures(),
 	)
 }
 
@@ -73,6 +89,7 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 	utilruntime.Must(policiesv1.AddToScheme(scheme))
 	utilruntime.Must(policiesv1.AddToScheme(eventsScheme))
+	utilruntime.Must(policyv1alpha1.AddToScheme(scheme))
 }
 
 func main() {
@@ -107,6 +124,10 @@ func main() {
 
 	printVersion()
 
+	if tool.Options.ReplayPolicy != "" {
+		os.Exit(replayEvents())
+	}
+
 	if tool.Options.ClusterNamespace == "" {
 		log.Info("The --cluster-namespace flag must be provided")
 		os.Exit(1)
@@ -158,9 +179,77 @@ func main() {
 		}
 	}
 
+	// Count the API requests by verb and controller to quantify the footprint of the addon on each API server
+	hubCfg.Wrap(utils.NewAPIRequestCounterWrapper("hub"))
+	managedCfg.Wrap(utils.NewAPIRequestCounterWrapper("managed"))
+
+	for _, clientName := range tool.Options.APIRequestLogging {
+		wrapper := utils.NewRequestLoggerWrapper(clientName, tool.Options.APIRequestLoggingSampleRate)
+
+		switch clientName {
+		case "hub":
+			hubCfg.Wrap(wrapper)
+		case "managed":
+			managedCfg.Wrap(wrapper)
+		default:
+			log.Info("Ignoring the unknown client for API request logging", "client", clientName)
+		}
+	}
+
+	if tool.Options.GenerateTemplateRBAC {
+		os.Exit(generateTemplateRBAC(managedCfg))
+	}
+
+	switch {
+	case tool.Options.ReadOnly:
+		log.Info("Running in read-only mode, the writes to the Hub and managed clusters are only logged")
+
+		hubCfg.Wrap(utils.NewReadOnlyWrapper("hub"))
+		managedCfg.Wrap(utils.NewReadOnlyWrapper("managed"))
+	case tool.Options.DryRun:
+		log.Info("Running in dry run mode, the changes to the Hub and managed clusters are only logged")
+
+		hubCfg.Wrap(utils.NewDryRunWrapper("hub"))
+		managedCfg.Wrap(utils.NewDryRunWrapper("managed"))
+	}
+
+	controllers, err := tool.ParseControllerSet(tool.Options.Controllers)
+	if err != nil {
+		log.Error(err, "Invalid --controllers value")
+		os.Exit(1)
+	}
+
+	for flagName, concurrency := range map[string]int{
+		"spec-sync-concurrency":     tool.Options.SpecSyncConcurrency,
+		"status-sync-concurrency":   tool.Options.StatusSyncConcurrency,
+		"template-sync-concurrency": tool.Options.TemplateSyncConcurrency,
+	} {
+		if concurrency < 1 {
+			err := fmt.Errorf("the concurrency must be at least 1, got %d", concurrency)
+			log.Error(err, "Invalid --"+flagName+" value")
+			os.Exit(1)
+		}
+	}
+
+	for _, bindAddress := range []struct {
+		flag     string
+		address  string
+		disabled string
+	}{
+		{"health-probe-bind-address", tool.Options.ProbeAddr, ""},
+		{"metrics-bind-address", tool.Options.MetricsAddr, "0"},
+		{"compliance-api-bind-address", tool.Options.ComplianceAPIAddr, ""},
+	} {
+		if err := tool.ValidateBindAddress(bindAddress.address, bindAddress.disabled); err != nil {
+			log.Error(err, "Invalid --"+bindAddress.flag+" value")
+			os.Exit(1)
+		}
+	}
+
 	mgrOptionsBase := manager.Options{
 		LeaderElection: tool.Options.EnableLeaderElection,
-		// Disable the metrics endpoint
+		// Disable the metrics endpoint by default. It is only enabled on the managed cluster manager since both
+		// managers share the same metrics registry.
 		MetricsBindAddress: "0",
 		Scheme:             scheme,
 		// Override the EventBroadcaster so that the spam filter will not ignore events for the policy but with
@@ -201,9 +290,19 @@ func main() {
 		mgrOptionsBase.LeaderElectionResourceLock = "leases"
 	}
 
+	if tool.Options.DryRun || tool.Options.ReadOnly {
+		// Nothing is changed in these modes, so they can run alongside the addon without holding its leader lease
+		mgrOptionsBase.LeaderElection = false
+	}
+
+	// Keeps track of the last successful interactions with the Hub to report in the lease. The reads are recorded from
+	// the responses of the Hub API server, since the reads of the controllers may be served by the cache.
+	heartbeat := &utils.Heartbeat{}
+	hubCfg.Wrap(utils.NewHubSyncWrapper(heartbeat))
+
 	// This lease is not related to leader election. This is to report the status of the controller
 	// to the addon framework. This can be seen in the "status" section of the ManagedClusterAddOn
-	// resource objects.
+	// resource objects. It is reported by the managed cluster controllers, which sync the policy statuses.
 	if tool.Options.EnableLease {
 		ctx := context.TODO()
 
@@ -216,34 +315,119 @@ func main() {
 				os.Exit(1)
 			}
 		} else {
-			log.Info("Starting lease controller to report status")
 			generatedClient := kubernetes.NewForConfigOrDie(managedCfg)
-			leaseUpdater := lease.NewLeaseUpdater(
-				generatedClient, "governance-policy-framework", operatorNs,
-			).WithHubLeaseConfig(hubCfg, tool.Options.ClusterNamespaceOnHub)
-			go leaseUpdater.Start(ctx)
+
+			if controllers.RunsManaged() {
+				log.Info("Starting lease controller to report status")
+				leaseUpdater := lease.NewLeaseUpdater(
+					generatedClient, "governance-policy-framework", operatorNs,
+				).WithHubLeaseConfig(hubCfg, tool.Options.ClusterNamespaceOnHub)
+				go leaseUpdater.Start(ctx)
+			} else {
+				// The Hub sync heartbeat is recorded by the spec sync controller, so it is annotated by this
+				// deployment on the lease renewed by the other one
+				log.Info("Status reporting is left to the deployment running the managed cluster controllers")
+			}
+
+			leaseAnnotator := &utils.LeaseAnnotator{
+				Heartbeat:         heartbeat,
+				Client:            generatedClient,
+				LeaseName:         "governance-policy-framework",
+				LeaseNamespace:    operatorNs,
+				HubClient:         kubernetes.NewForConfigOrDie(hubCfg),
+				HubLeaseNamespace: tool.Options.ClusterNamespaceOnHub,
+				Interval:          time.Minute,
+			}
+			go leaseAnnotator.Start(ctx)
 		}
 	} else {
 		log.Info("Status reporting is not enabled")
 	}
 
-	mgrHealthAddr, err := getFreeLocalAddr()
+	var compaction *utils.PolicyCompaction
+
+	if tool.Options.CompactReplicatedPolicies {
+		compaction = &utils.PolicyCompaction{AllowList: tool.Options.CompactionAllowList}
+	}
+
+	policySelector, err := utils.ParsePolicySelector(tool.Options.PolicyLabelSelector)
 	if err != nil {
-		log.Error(err, "Failed to get a free port for the health endpoint")
+		log.Error(err, "Invalid --policy-label-selector value")
 		os.Exit(1)
 	}
 
-	mgr := getManager(mgrOptionsBase, mgrHealthAddr, hubCfg, managedCfg)
+	// Shared by the controllers since the CPU and memory limits are those of the whole container
+	var concurrency *utils.AdaptiveConcurrency
 
-	hubMgrHealthAddr, err := getFreeLocalAddr()
-	if err != nil {
-		log.Error(err, "Failed to get a free port for the health endpoint")
-		os.Exit(1)
+	if tool.Options.AdaptiveConcurrencyMax > 0 {
+		concurrency = &utils.AdaptiveConcurrency{
+			Max:      tool.Options.AdaptiveConcurrencyMax,
+			Interval: tool.Options.AdaptiveConcurrencyInterval,
+		}
 	}
 
-	hubMgr := getHubManager(mgrOptionsBase, hubMgrHealthAddr, hubCfg, managedCfg)
+	// Shared by the spec sync and status sync controllers since either can delete a policy on the managed cluster
+	var historyCarryOver *utils.HistoryCarryOver
+
+	if tool.Options.HistoryCarryOverAnnotation != "" {
+		// The history is read from the API server so that it isn't restored twice
+		carryOverClient, err := client.New(managedCfg, client.Options{Scheme: scheme})
+		if err != nil {
+			log.Error(err, "Failed to create the managed cluster client for the compliance history carry-over")
+			os.Exit(1)
+		}
 
-	log.Info("Starting the controller managers")
+		historyCarryOver = &utils.HistoryCarryOver{
+			Client:     carryOverClient,
+			Annotation: tool.Options.HistoryCarryOverAnnotation,
+		}
+	}
+
+	// Pauses the reconciles while the cluster namespace is being deleted or is missing
+	namespaceGuard := &utils.NamespaceGuard{
+		Client:    kubernetes.NewForConfigOrDie(managedCfg),
+		Namespace: tool.Options.ClusterNamespace,
+		Recreate:  tool.Options.RecreateClusterNamespace,
+	}
+
+	managers := map[string]manager.Manager{}
+	healthAddrs := []string{}
+
+	if controllers.RunsManaged() {
+		mgrHealthAddr, err := getFreeLocalAddr()
+		if err != nil {
+			log.Error(err, "Failed to get a free port for the health endpoint")
+			os.Exit(1)
+		}
+
+		managers["manager"] = getManager(
+			mgrOptionsBase, mgrHealthAddr, hubCfg, managedCfg, heartbeat, namespaceGuard, compaction,
+			historyCarryOver, policySelector, concurrency,
+		)
+		healthAddrs = append(healthAddrs, mgrHealthAddr)
+	}
+
+	if controllers.RunsHub() {
+		hubMgrHealthAddr, err := getFreeLocalAddr()
+		if err != nil {
+			log.Error(err, "Failed to get a free port for the health endpoint")
+			os.Exit(1)
+		}
+
+		hubMgrOptions := mgrOptionsBase
+		// The metrics endpoint is served by the managed cluster manager when it runs in the same process
+		if !controllers.RunsManaged() {
+			hubMgrOptions.MetricsBindAddress = tool.Options.MetricsAddr
+		}
+
+		managers["hub manager"] = getHubManager(
+			hubMgrOptions, hubMgrHealthAddr, hubCfg, managedCfg, namespaceGuard, compaction,
+			historyCarryOver, policySelector, concurrency,
+		)
+		healthAddrs = append(healthAddrs, hubMgrHealthAddr)
+	}
+
+	log.Info("Starting the controller managers", "controllers", controllers)
 
 	mainCtx := ctrl.SetupSignalHandler()
 	mgrCtx, mgrCtxCancel := context.WithCancel(mainCtx)
@@ -253,7 +437,7 @@ func main() {
 	wg.Add(1)
 
 	go func() {
-		err := startHealthProxy(mgrCtx, &wg, mgrHealthAddr, hubMgrHealthAddr)
+		err := startHealthProxy(mgrCtx, &wg, healthAddrs...)
 		if err != nil {
 			log.Error(err, "failed to start the health endpoint proxy")
 
@@ -264,35 +448,22 @@ func main() {
 
 	var errorExit bool
 
-	wg.Add(1)
-
-	go func() {
-		if err := mgr.Start(mgrCtx); err != nil {
-			log.Error(err, "problem running manager")
-
-			// On errors, the parent context (mainCtx) may not have closed, so cancel the child context.
-			mgrCtxCancel()
-
-			errorExit = true
-		}
+	for name, mgr := range managers {
+		wg.Add(1)
 
-		wg.Done()
-	}()
+		go func(name string, mgr manager.Manager) {
+			if err := mgr.Start(mgrCtx); err != nil {
+				log.Error(err, "problem running "+name)
 
-	wg.Add(1)
+				// On errors, the parent context (mainCtx) may not have closed, so cancel the child context.
+				mgrCtxCancel()
 
-	go func() {
-		if err := hubMgr.Start(mgrCtx); err != nil {
-			log.Error(err, "problem running hub manager")
-
-			// On errors, the parent context (mainCtx) may not have closed, so cancel the child context.
-			mgrCtxCancel()
-
-			errorExit = true
-		}
+				errorExit = true
+			}
 
-		wg.Done()
-	}()
+			wg.Done()
+		}(name, mgr)
+	}
 
 	wg.Wait()
 
@@ -303,9 +474,25 @@ func main() {
 
 // getManager return a controller Manager object that watches on the managed cluster and has the controllers registered.
 func getManager(
-	options manager.Options, healthAddr string, hubCfg *rest.Config, managedCfg *rest.Config,
+	options manager.Options,
+	healthAddr string,
+	hubCfg *rest.Config,
+	managedCfg *rest.Config,
+	heartbeat *utils.Heartbeat,
+	namespaceGuard *utils.NamespaceGuard,
+	compaction *utils.PolicyCompaction,
+	historyCarryOver *utils.HistoryCarryOver,
+	policySelector labels.Selector,
+	concurrency *utils.AdaptiveConcurrency,
 ) manager.Manager {
-	hubClient, err := client.New(hubCfg, client.Options{Scheme: scheme})
+	// Discover the Hub API lazily so that the managed cluster controllers can start while the Hub is unavailable
+	hubMapper, err := apiutil.NewDynamicRESTMapper(hubCfg, apiutil.WithLazyDiscovery)
+	if err != nil {
+		log.Error(err, "Failed to generate the REST mapper of the hub cluster")
+		os.Exit(1)
+	}
+
+	hubClient, err := client.New(hubCfg, client.Options{Scheme: scheme, Mapper: hubMapper})
 	if err != nil {
 		log.Error(err, "Failed to generate client to the hub cluster")
 		os.Exit(1)
@@ -318,10 +505,21 @@ func getManager(
 		&corev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events(tool.Options.ClusterNamespaceOnHub)},
 	)
 
-	hubRecorder := eventBroadcaster.NewRecorder(eventsScheme, v1.EventSource{Component: statussync.ControllerName})
+	hubRecorder := eventRecorder(utils.CorrelatedRecorder(
+		eventBroadcaster.NewRecorder(eventsScheme, v1.EventSource{Component: statussync.ControllerName}),
+	))
 
 	options.LeaderElectionID = "governance-policy-framework-addon.open-cluster-management.io"
 	options.HealthProbeBindAddress = healthAddr
+	options.MetricsBindAddress = tool.Options.MetricsAddr
+	// Only cache the events involving policies since the cluster namespace may be shared with chatty workloads
+	options.NewCache = cache.BuilderWithOptions(
+		cache.Options{
+			SelectorsByObject: cache.SelectorsByObject{
+				&v1.Event{}: statussync.EventCacheSelector(),
+			},
+		},
+	)
 
 	mgr, err := ctrl.NewManager(managedCfg, options)
 	if err != nil {
@@ -329,23 +527,430 @@ func getManager(
 		os.Exit(1)
 	}
 
+	var messageParser *statussync.ComplianceMessageParser
+
+	if tool.Options.ComplianceMappingConfigMap != "" {
+		messageParser = &statussync.ComplianceMessageParser{
+			Client:    kubernetes.NewForConfigOrDie(managedCfg),
+			Namespace: tool.Options.ClusterNamespace,
+			Name:      tool.Options.ComplianceMappingConfigMap,
+		}
+
+		if err := mgr.Add(messageParser); err != nil {
+			log.Error(err, "Unable to watch the compliance mapping ConfigMap")
+			os.Exit(1)
+		}
+	}
+
+	var templatePlacements *utils.TemplatePlacements
+
+	if tool.Options.TemplatePlacementConfigMap != "" {
+		templatePlacements = &utils.TemplatePlacements{
+			Client:    kubernetes.NewForConfigOrDie(managedCfg),
+			Namespace: tool.Options.ClusterNamespace,
+			Name:      tool.Options.TemplatePlacementConfigMap,
+		}
+
+		if err := mgr.Add(templatePlacements); err != nil {
+			log.Error(err, "Unable to watch the template placement ConfigMap")
+			os.Exit(1)
+		}
+	}
+
+	if concurrency != nil {
+		if err := mgr.Add(concurrency); err != nil {
+			log.Error(err, "Unable to start the adaptive concurrency")
+			os.Exit(1)
+		}
+	}
+
+	var engineRegistry *engines.Registry
+
+	if tool.FeatureGates.Enabled(tool.PolicyEngineAdapters) {
+		engineRegistry = engines.NewRegistry(&gatekeeper.Adapter{})
+		installedEngines := engineRegistry.Discover(
+			context.TODO(), kubernetes.NewForConfigOrDie(managedCfg).Discovery(),
+		)
+		log.Info("Discovered the policy engines on the managed cluster", "engines", installedEngines)
+	}
+
+	messageNormalizer, err := statussync.ParseMessageNormalizer(
+		tool.Options.NormalizeMessageKinds, tool.Options.MessageJSONKeys,
+	)
+	if err != nil {
+		log.Error(err, "Invalid --status-message-json-keys value")
+		os.Exit(1)
+	}
+
+	trustedEventSources := newEventSourceTrust()
+
+	selfManagedHub, err := utils.ParseSelfManagedHubMode(tool.Options.SelfManagedHub)
+	if err != nil {
+		log.Error(err, "Invalid --self-managed-hub value")
+		os.Exit(1)
+	}
+
+	var templateWatcher *utils.DynamicWatcher
+
+	if tool.Options.WatchTemplateObjects {
+		templateWatcher = &utils.DynamicWatcher{
+			Client:    metadata.NewForConfigOrDie(managedCfg),
+			Namespace: tool.Options.ClusterNamespace,
+		}
+
+		if err := mgr.Add(templateWatcher); err != nil {
+			log.Error(err, "Unable to watch the policy template objects")
+			os.Exit(1)
+		}
+	}
+
+	var searchExporter *statussync.SearchExporter
+
+	if tool.Options.SearchExportEndpoint != "" {
+		searchExporter, err = statussync.NewSearchExporter(
+			tool.Options.SearchExportEndpoint, tool.Options.ClusterNamespaceOnHub,
+		)
+		if err != nil {
+			log.Error(err, "Invalid --search-export-endpoint value")
+			os.Exit(1)
+		}
+	}
+
+	var complianceNotifier *statussync.ComplianceNotifier
+
+	if tool.Options.ComplianceNotificationURL != "" || len(tool.Options.NotificationAllowedHosts) != 0 {
+		complianceNotifier, err = statussync.NewComplianceNotifier(
+			tool.Options.ComplianceNotificationURL, tool.Options.ClusterNamespaceOnHub,
+			tool.Options.NotificationAllowedHosts,
+		)
+		if err != nil {
+			log.Error(err, "Invalid --compliance-notification-url value")
+			os.Exit(1)
+		}
+
+		if err := mgr.Add(complianceNotifier); err != nil {
+			log.Error(err, "Unable to start the compliance notifier")
+			os.Exit(1)
+		}
+	}
+
+	var historyStore *statussync.HistoryStore
+
+	if tool.Options.PersistComplianceHistory {
+		historyStore = &statussync.HistoryStore{Client: mgr.GetClient()}
+	}
+
+	var hubSync *utils.HubSyncMonitor
+
+	if tool.Options.HubSyncDegradedThreshold > 0 {
+		hubSync = &utils.HubSyncMonitor{Threshold: tool.Options.HubSyncDegradedThreshold}
+	}
+
+	var complianceSummarizer *statussync.ComplianceSummarizer
+
+	if tool.Options.EnableComplianceSummary {
+		complianceSummarizer = &statussync.ComplianceSummarizer{
+			Client:          mgr.GetClient(),
+			Namespace:       tool.Options.ClusterNamespace,
+			CategoryWeights: tool.Options.ComplianceScoreWeights,
+			HubSync:         hubSync,
+		}
+
+		if err := mgr.Add(complianceSummarizer); err != nil {
+			log.Error(err, "Unable to maintain the compliance summary")
+			os.Exit(1)
+		}
+	}
+
+	var complianceTimeline *statussync.ComplianceTimeline
+
+	if tool.Options.EnableComplianceTimeline {
+		complianceTimeline = &statussync.ComplianceTimeline{
+			Client:    mgr.GetClient(),
+			Namespace: tool.Options.ClusterNamespace,
+		}
+
+		if err := mgr.Add(complianceTimeline); err != nil {
+			log.Error(err, "Unable to maintain the compliance timeline")
+			os.Exit(1)
+		}
+	}
+
+	if tool.Options.ComplianceAPIAddr != "" {
+		token, err := os.ReadFile(tool.Options.ComplianceAPITokenFile)
+		if err != nil || strings.TrimSpace(string(token)) == "" {
+			log.Error(err, "The compliance API requires a token in --compliance-api-token-file")
+			os.Exit(1)
+		}
+
+		complianceAPI := &statussync.ComplianceAPI{
+			Client:      mgr.GetClient(),
+			Namespace:   tool.Options.ClusterNamespace,
+			BindAddress: tool.Options.ComplianceAPIAddr,
+			Token:       strings.TrimSpace(string(token)),
+		}
+
+		// The bearer token must not be sent in clear text outside of the pod
+		if tool.Options.ComplianceAPICertDir != "" {
+			complianceAPI.CertFile = filepath.Join(tool.Options.ComplianceAPICertDir, "tls.crt")
+			complianceAPI.KeyFile = filepath.Join(tool.Options.ComplianceAPICertDir, "tls.key")
+		} else if !tool.IsLoopbackBindAddress(tool.Options.ComplianceAPIAddr) {
+			log.Info("The compliance API must be served over TLS with --compliance-api-cert-dir unless "+
+				"--compliance-api-bind-address is a loopback address", "address", tool.Options.ComplianceAPIAddr)
+			os.Exit(1)
+		}
+
+		if err := mgr.Add(complianceAPI); err != nil {
+			log.Error(err, "Unable to serve the compliance API")
+			os.Exit(1)
+		}
+	}
+
+	var statusAuditor *statussync.StatusAuditor
+
+	if tool.Options.HubStatusWriteAudit {
+		statusAuditor = &statussync.StatusAuditor{InstanceID: addonInstanceID()}
+
+		log.Info("Stamping the policy status writes on the hub", "instance", statusAuditor.InstanceID)
+	}
+
+	var historySigner *statussync.HistorySigner
+
+	if tool.Options.SignComplianceHistory {
+		historySigner = &statussync.HistorySigner{
+			Client:    kubernetes.NewForConfigOrDie(managedCfg),
+			Namespace: tool.Options.ClusterNamespace,
+		}
+
+		if tool.Options.ComplianceSigningKeyFile != "" {
+			historySigner.Key, err = os.ReadFile(tool.Options.ComplianceSigningKeyFile)
+			if err != nil || len(historySigner.Key) == 0 {
+				log.Error(err, "Unable to read the key in --compliance-signing-key-file")
+				os.Exit(1)
+			}
+		}
+	} else if tool.Options.ComplianceSigningKeyFile != "" {
+		log.Info("Ignoring --compliance-signing-key-file since --sign-compliance-history is not set")
+	}
+
+	var managedStatusWriter, hubStatusWriter *statussync.StatusWriter
+
+	// Write the policy statuses to each cluster from a single goroutine so that the writes of a policy are ordered
+	if tool.FeatureGates.Enabled(tool.StatusWriters) {
+		managedStatusWriter = &statussync.StatusWriter{
+			Client:          mgr.GetClient(),
+			Target:          "managed",
+			WritesPerSecond: tool.Options.StatusWritesPerSecond,
+		}
+		hubStatusWriter = &statussync.StatusWriter{
+			Client:          hubClient,
+			Target:          "hub",
+			WritesPerSecond: tool.Options.StatusWritesPerSecond,
+			Interval:        tool.Options.StatusSyncInterval,
+			BufferOnOutage:  tool.Options.HubOutageBuffer,
+			// The policies written to the Hub are reconciled again in the cluster namespace on the managed cluster
+			RequeueNamespace: tool.Options.ClusterNamespace,
+		}
+
+		for _, writer := range []*statussync.StatusWriter{managedStatusWriter, hubStatusWriter} {
+			if err := mgr.Add(writer); err != nil {
+				log.Error(err, "Unable to start the policy status writer", "target", writer.Target)
+				os.Exit(1)
+			}
+		}
+	} else {
+		if tool.Options.StatusWritesPerSecond > 0 {
+			log.Info("Ignoring --status-writes-per-second since the StatusWriters feature gate is disabled")
+		}
+
+		if tool.Options.StatusSyncInterval > 0 {
+			log.Info("Ignoring --status-sync-interval since the StatusWriters feature gate is disabled")
+		}
+
+		if tool.Options.HubOutageBuffer {
+			log.Info("Ignoring --hub-outage-buffer since the StatusWriters feature gate is disabled")
+		}
+	}
+
+	var clockSkew *utils.ClockSkew
+
+	if tool.Options.ClockSkewThreshold > 0 && !selfManagedHub.Always() {
+		clockSkew = &utils.ClockSkew{
+			HubConfig:     hubCfg,
+			ManagedConfig: managedCfg,
+			Threshold:     tool.Options.ClockSkewThreshold,
+		}
+
+		if err := mgr.Add(clockSkew); err != nil {
+			log.Error(err, "Unable to start the clock skew detection")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&statussync.PolicyReconciler{
+		ManagedAPIReader:      mgr.GetAPIReader(),
 		ClusterNamespaceOnHub: tool.Options.ClusterNamespaceOnHub,
-		HubClient:             hubClient,
-		HubRecorder:           hubRecorder,
-		ManagedClient:         mgr.GetClient(),
-		ManagedRecorder:       mgr.GetEventRecorderFor(statussync.ControllerName),
-		Scheme:                mgr.GetScheme(),
+		ClockSkew:             clockSkew,
+		ManagedStatusWriter:   managedStatusWriter,
+		HubStatusWriter:       hubStatusWriter,
+		Compaction:            compaction,
+		DeletionGuard:         newDeletionGuard(),
+		HistoryCarryOver:      historyCarryOver,
+		ComplianceSummarizer:  complianceSummarizer,
+		ComplianceTimeline:    complianceTimeline,
+		SearchExporter:        searchExporter,
+		StatusAuditor:         statusAuditor,
+		HistorySigner:         historySigner,
+		PolicySelector:        policySelector,
+		Concurrency:           concurrency,
+		ConcurrentReconciles:  tool.Options.StatusSyncConcurrency,
+		Hysteresis: &statussync.ComplianceHysteresis{
+			Count:  tool.Options.ComplianceHysteresisCount,
+			Window: tool.Options.ComplianceHysteresisWindow,
+		},
+		HubClient:      hubClient,
+		HubRecorder:    hubRecorder,
+		Heartbeat:      heartbeat,
+		NamespaceGuard: namespaceGuard,
+		ManagedClient:  mgr.GetClient(),
+		ManagedRecorder: eventRecorder(
+			utils.CorrelatedRecorder(mgr.GetEventRecorderFor(statussync.ControllerName)),
+		),
+		MessageParser:          messageParser,
+		MessageNormalizer:      messageNormalizer,
+		ReadinessGates:         tool.Options.TemplateReadinessGates,
+		Engines:                engineRegistry,
+		TemplatePlacements:     templatePlacements,
+		Scheme:                 mgr.GetScheme(),
+		TemplateWatcher:        templateWatcher,
+		TrustedEventSources:    trustedEventSources,
+		SelfManagedHub:         selfManagedHub,
+		EngineStatus:           tool.Options.EngineStatus,
+		DeleteEventsOnDeletion: tool.Options.DeletePolicyEvents,
+		HubSync:                hubSync,
+		InternHistoryMessages:  tool.Options.InternHistoryMessages,
+		MessageSizeLimit:       tool.Options.HistoryMessageSizeLimit,
+		StatusSizeBudget:       tool.Options.StatusSizeBudget,
+		HistoryLimit:           tool.Options.ComplianceHistoryLimit,
+		HistoryStore:           historyStore,
+		ComplianceNotifier:     complianceNotifier,
 	}).SetupWithManager(mgr); err != nil {
 		log.Error(err, "unable to create controller", "controller", "Policy")
 		os.Exit(1)
 	}
 
+	if tool.Options.StatusVerifyInterval > 0 {
+		if selfManagedHub.Always() {
+			log.Info("Ignoring --status-verify-interval since the Hub and the managed cluster are the same cluster")
+		} else if err := mgr.Add(&statussync.StatusVerifier{
+			HubClient:             hubClient,
+			ManagedClient:         mgr.GetClient(),
+			ClusterNamespace:      tool.Options.ClusterNamespace,
+			ClusterNamespaceOnHub: tool.Options.ClusterNamespaceOnHub,
+			Interval:              tool.Options.StatusVerifyInterval,
+			SampleSize:            tool.Options.StatusVerifySampleSize,
+			Repair:                tool.Options.StatusVerifyRepair,
+			HubStatusWriter:       hubStatusWriter,
+		}); err != nil {
+			log.Error(err, "Unable to verify the policy statuses")
+			os.Exit(1)
+		}
+	}
+
+	var ociFetcher *templatesync.OCIFetcher
+
+	if tool.Options.EnableOCITemplates {
+		ociFetcher, err = templatesync.NewOCIFetcher(tool.Options.OCISignaturePublicKey)
+		if err != nil {
+			log.Error(err, "Failed to initialize the OCI artifact fetcher")
+			os.Exit(1)
+		}
+	}
+
+	var policyVerifier templatesync.PolicyVerifier
+
+	if tool.Options.PolicySignaturePublicKey != "" {
+		policyVerifier, err = templatesync.NewPolicySignatureVerifier(tool.Options.PolicySignaturePublicKey)
+		if err != nil {
+			log.Error(err, "Failed to initialize the policy signature verifier")
+			os.Exit(1)
+		}
+	}
+
+	var configMapResolver *templatesync.HubConfigMapResolver
+
+	if tool.Options.EnableHubConfigMapTemplates {
+		configMapResolver = &templatesync.HubConfigMapResolver{
+			Client:    kubernetes.NewForConfigOrDie(hubCfg),
+			Namespace: tool.Options.ClusterNamespaceOnHub,
+		}
+
+		if err := mgr.Add(configMapResolver); err != nil {
+			log.Error(err, "Unable to watch the ConfigMaps on the Hub")
+			os.Exit(1)
+		}
+	}
+
+	applyTimeoutsByKind, err := templatesync.ParseApplyTimeouts(tool.Options.TemplateApplyTimeoutsByKind)
+	if err != nil {
+		log.Error(err, "Invalid --template-apply-timeouts-by-kind value")
+		os.Exit(1)
+	}
+
+	configPolicyDefaults, err := templatesync.ParseConfigurationPolicyDefaults(tool.Options.ConfigurationPolicyDefaults)
+	if err != nil {
+		log.Error(err, "Invalid --configuration-policy-defaults value")
+		os.Exit(1)
+	}
+
+	var execHooks *templatesync.ExecHooks
+
+	if tool.Options.EnableTemplateExecHooks {
+		execHooks, err = templatesync.NewExecHooks(tool.Options.TemplateExecHooks)
+		if err != nil {
+			log.Error(err, "Invalid --template-exec-hooks value")
+			os.Exit(1)
+		}
+	} else if len(tool.Options.TemplateExecHooks) != 0 {
+		log.Info("Ignoring --template-exec-hooks since --enable-template-exec-hooks is not set")
+	}
+
 	if err := (&templatesync.PolicyReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Config:   mgr.GetConfig(),
-		Recorder: mgr.GetEventRecorderFor(templatesync.ControllerName),
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Config: mgr.GetConfig(),
+		Recorder: eventRecorder(
+			utils.CorrelatedRecorder(mgr.GetEventRecorderFor(templatesync.ControllerName)),
+		),
+		OCIFetcher:                ociFetcher,
+		RBACReport:                tool.Options.TemplateRBACReport,
+		ConfigMapResolver:         configMapResolver,
+		NamespaceGuard:            namespaceGuard,
+		RecreateOnImmutableChange: tool.Options.RecreateOnImmutableChange,
+		RecreateOnChangeKinds:     tool.Options.RecreateOnChangeKinds,
+		CreateOnlyTemplates:       tool.FeatureGates.Enabled(tool.CreateOnlyTemplates),
+		TemplateWatcher:           templateWatcher,
+		TemplatePlacements:        templatePlacements,
+		Verifier:                  policyVerifier,
+		CopyPolicyMetadata:        tool.Options.CopyPolicyMetadata,
+		ApplyTimeouts: &templatesync.ApplyTimeouts{
+			Default: tool.Options.TemplateApplyTimeout,
+			ByKind:  applyTimeoutsByKind,
+		},
+		ExecHooks:                   execHooks,
+		EnforceSoakTime:             tool.Options.EnforceSoakTime,
+		ApplyPreview:                &templatesync.ApplyPreview{Delay: tool.Options.EnforcePreviewDelay},
+		ApplySpread:                 &templatesync.ApplySpread{Window: tool.Options.EnforceApplySpread},
+		StrictTemplateValidation:    tool.Options.StrictTemplateValidation,
+		Engines:                     engineRegistry,
+		TemplateKinds:               templatesync.NewTemplateKindRegistry(templatesync.DefaultTemplateKinds()...),
+		Concurrency:                 concurrency,
+		ConcurrentReconciles:        tool.Options.TemplateSyncConcurrency,
+		ConfigurationPolicyDefaults: configPolicyDefaults,
+		ClusterIdentity: &templatesync.ClusterIdentity{
+			Client:      dynamic.NewForConfigOrDie(managedCfg),
+			ClusterName: tool.Options.ClusterNamespaceOnHub,
+		},
 	}).SetupWithManager(mgr); err != nil {
 		log.Error(err, "Unable to create the controller", "controller", templatesync.ControllerName)
 		os.Exit(1)
@@ -371,12 +976,32 @@ func getManager(
 		os.Exit(1)
 	}
 
+	namespaceGuard.Recorder = eventRecorder(mgr.GetEventRecorderFor("namespace-guard"))
+
+	if err := mgr.Add(namespaceGuard); err != nil {
+		log.Error(err, "Unable to watch the cluster namespace")
+		os.Exit(1)
+	}
+
+	if err := mgr.AddReadyzCheck("cluster-namespace", namespaceGuard.Check); err != nil {
+		log.Error(err, "unable to set up the cluster namespace ready check")
+		os.Exit(1)
+	}
+
 	return mgr
 }
 
 // getHubManager return a controller Manager object that watches on the Hub and has the controllers registered.
 func getHubManager(
-	options manager.Options, healthAddr string, hubCfg *rest.Config, managedCfg *rest.Config,
+	options manager.Options,
+	healthAddr string,
+	hubCfg *rest.Config,
+	managedCfg *rest.Config,
+	namespaceGuard *utils.NamespaceGuard,
+	compaction *utils.PolicyCompaction,
+	historyCarryOver *utils.HistoryCarryOver,
+	policySelector labels.Selector,
+	concurrency *utils.AdaptiveConcurrency,
 ) manager.Manager {
 	managedClient, err := client.New(managedCfg, client.Options{Scheme: scheme})
 	if err != nil {
@@ -391,7 +1016,9 @@ func getHubManager(
 		&corev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events(tool.Options.ClusterNamespace)},
 	)
 
-	managedRecorder := eventBroadcaster.NewRecorder(eventsScheme, v1.EventSource{Component: specsync.ControllerName})
+	managedRecorder := eventRecorder(utils.CorrelatedRecorder(
+		eventBroadcaster.NewRecorder(eventsScheme, v1.EventSource{Component: specsync.ControllerName}),
+	))
 
 	// Set a field selector so that a watch on secrets will be limited to just the secret with the policy template
 	// encryption key.
@@ -410,6 +1037,10 @@ func getHubManager(
 	options.LeaderElectionID = "governance-policy-framework-addon2.open-cluster-management.io"
 	options.LeaderElectionConfig = managedCfg
 	options.NewCache = newCacheFunc
+	// Discover the Hub API lazily so that a temporarily unavailable Hub doesn't prevent the start
+	options.MapperProvider = func(c *rest.Config) (meta.RESTMapper, error) {
+		return apiutil.NewDynamicRESTMapper(c, apiutil.WithLazyDiscovery)
+	}
 
 	// Create a new manager to provide shared dependencies and start components
 	mgr, err := ctrl.NewManager(hubCfg, options)
@@ -418,14 +1049,54 @@ func getHubManager(
 		os.Exit(1)
 	}
 
-	// Setup all Controllers
-	if err = (&specsync.PolicyReconciler{
-		HubClient:       mgr.GetClient(),
-		ManagedClient:   managedClient,
-		ManagedRecorder: managedRecorder,
-		Scheme:          mgr.GetScheme(),
-		TargetNamespace: tool.Options.ClusterNamespace,
-	}).SetupWithManager(mgr); err != nil {
+	// The Policy CRD on the Hub may be briefly unavailable, such as during an upgrade of the Hub. Rather than failing
+	// to start, the spec sync controller is started once the Policy API is available and the other controllers are
+	// started right away.
+	policyAPIWaiter := &utils.APIResourceWaiter{
+		Client:   kubernetes.NewForConfigOrDie(hubCfg).Discovery(),
+		Resource: policiesv1.GroupVersion.WithResource("policies"),
+		MaxDelay: time.Minute,
+	}
+
+	var rootPlacements *specsync.RootPlacementCache
+
+	if tool.Options.RootPlacementAnnotations {
+		rootPlacements = &specsync.RootPlacementCache{
+			Reader:           mgr.GetAPIReader(),
+			ClusterNamespace: tool.Options.ClusterNamespaceOnHub,
+		}
+
+		if err := mgr.Add(rootPlacements); err != nil {
+			log.Error(err, "Unable to start the root policy placement cache")
+			os.Exit(1)
+		}
+	}
+
+	err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if err := policyAPIWaiter.Wait(ctx); err != nil {
+			// The manager is stopping
+			return nil
+		}
+
+		return (&specsync.PolicyReconciler{
+			Compaction:           compaction,
+			DeletionGuard:        newDeletionGuard(),
+			HistoryCarryOver:     historyCarryOver,
+			HubAPIReader:         mgr.GetAPIReader(),
+			MetadataOnly:         tool.Options.HubPolicyMetadataCache,
+			NamespaceGuard:       namespaceGuard,
+			HubClient:            mgr.GetClient(),
+			ManagedClient:        managedClient,
+			ManagedRecorder:      managedRecorder,
+			Scheme:               mgr.GetScheme(),
+			TargetNamespace:      tool.Options.ClusterNamespace,
+			PolicySelector:       policySelector,
+			Concurrency:          concurrency,
+			ConcurrentReconciles: tool.Options.SpecSyncConcurrency,
+			RootPlacements:       rootPlacements,
+		}).SetupWithManager(mgr)
+	}))
+	if err != nil {
 		log.Error(err, "Unable to create the controller", "controller", specsync.ControllerName)
 		os.Exit(1)
 	}
@@ -433,6 +1104,7 @@ func getHubManager(
 	if err = (&secretsync.SecretReconciler{
 		Client:          mgr.GetClient(),
 		ManagedClient:   managedClient,
+		NamespaceGuard:  namespaceGuard,
 		Scheme:          mgr.GetScheme(),
 		TargetNamespace: tool.Options.ClusterNamespace,
 	}).SetupWithManager(mgr); err != nil {
@@ -460,9 +1132,164 @@ func getHubManager(
 		os.Exit(1)
 	}
 
+	if err := mgr.AddReadyzCheck("hub-policy-api", policyAPIWaiter.Check); err != nil {
+		log.Error(err, "unable to set up the Hub policy API ready check")
+		os.Exit(1)
+	}
+
 	return mgr
 }
 
+// eventRecorder returns the input event recorder, which marks its events as coming from a dry run in dry run mode.
+func eventRecorder(recorder record.EventRecorder) record.EventRecorder {
+	// The events are not sent at all in read-only mode
+	if tool.Options.DryRun && !tool.Options.ReadOnly {
+		return utils.DryRunRecorder(recorder)
+	}
+
+	return recorder
+}
+
+// newDeletionGuard returns a DeletionGuard with the configured grace period, or nil if there is no grace period.
+func newDeletionGuard() *utils.DeletionGuard {
+	if tool.Options.PolicyDeletionGracePeriod <= 0 {
+		return nil
+	}
+
+	return &utils.DeletionGuard{GracePeriod: tool.Options.PolicyDeletionGracePeriod}
+}
+
+// generateTemplateRBAC prints the minimal ClusterRole needed to manage the policy templates of the policies in the
+// cluster namespace on the managed cluster. The return value is the exit code.
+func generateTemplateRBAC(managedCfg *rest.Config) int {
+	managedClient, err := client.New(managedCfg, client.Options{Scheme: scheme})
+	if err != nil {
+		log.Error(err, "Failed to generate client to the managed cluster")
+
+		return 1
+	}
+
+	policies := &policiesv1.PolicyList{}
+
+	err = managedClient.List(context.TODO(), policies, client.InNamespace(tool.Options.ClusterNamespace))
+	if err != nil {
+		log.Error(err, "Failed to list the policies on the managed cluster")
+
+		return 1
+	}
+
+	apiGroups, err := restmapper.GetAPIGroupResources(kubernetes.NewForConfigOrDie(managedCfg).Discovery())
+	if err != nil {
+		log.Error(err, "Failed to discover the API resources on the managed cluster")
+
+		return 1
+	}
+
+	resources, errs := templatesync.TemplateResources(policies.Items, restmapper.NewDiscoveryRESTMapper(apiGroups))
+	for _, err := range errs {
+		log.Error(err, "Skipping a policy template when generating the ClusterRole")
+	}
+
+	clusterRoleYAML, err := templatesync.MinimalClusterRoleYAML("governance-policy-framework-addon", resources)
+	if err != nil {
+		log.Error(err, "Failed to generate the ClusterRole")
+
+		return 1
+	}
+
+	fmt.Print(clusterRoleYAML)
+
+	return 0
+}
+
+// newEventSourceTrust returns the EventSourceTrust of the configured trusted event sources, or nil if all the event
+// sources are trusted.
+func newEventSourceTrust() utils.EventSourceTrust {
+	if len(tool.Options.TrustedEventSources) == 0 {
+		return nil
+	}
+
+	// The template sync reports template errors as compliance events, so it is always trusted
+	return utils.NewEventSourceTrust(append(tool.Options.TrustedEventSources, templatesync.ControllerName)...)
+}
+
+// replayEvents prints the status of the policy in the --replay-policy file after merging the events in the
+// --replay-events file with the status sync logic, without a cluster. The return value is the exit code.
+func replayEvents() int {
+	policyYAML, err := os.ReadFile(tool.Options.ReplayPolicy)
+	if err != nil {
+		log.Error(err, "Failed to read the policy to replay the events on")
+
+		return 1
+	}
+
+	policy := &policiesv1.Policy{}
+
+	if err := yaml.Unmarshal(policyYAML, policy); err != nil {
+		log.Error(err, "Failed to parse the policy to replay the events on")
+
+		return 1
+	}
+
+	// This also accepts the output of `kubectl get events -o yaml` since a List has the same items field
+	events := &v1.EventList{}
+
+	if tool.Options.ReplayEvents != "" {
+		eventsYAML, err := os.ReadFile(tool.Options.ReplayEvents)
+		if err != nil {
+			log.Error(err, "Failed to read the events to replay")
+
+			return 1
+		}
+
+		if err := yaml.Unmarshal(eventsYAML, events); err != nil {
+			log.Error(err, "Failed to parse the events to replay")
+
+			return 1
+		}
+	}
+
+	messageNormalizer, err := statussync.ParseMessageNormalizer(
+		tool.Options.NormalizeMessageKinds, tool.Options.MessageJSONKeys,
+	)
+	if err != nil {
+		log.Error(err, "Invalid --status-message-json-keys value")
+
+		return 1
+	}
+
+	reconciler := &statussync.PolicyReconciler{
+		Hysteresis: &statussync.ComplianceHysteresis{
+			Count:  tool.Options.ComplianceHysteresisCount,
+			Window: tool.Options.ComplianceHysteresisWindow,
+		},
+		MessageNormalizer:     messageNormalizer,
+		TrustedEventSources:   newEventSourceTrust(),
+		MessageSizeLimit:      tool.Options.HistoryMessageSizeLimit,
+		StatusSizeBudget:      tool.Options.StatusSizeBudget,
+		InternHistoryMessages: tool.Options.InternHistoryMessages,
+		HistoryLimit:          tool.Options.ComplianceHistoryLimit,
+	}
+
+	status, err := reconciler.ReplayStatus(policy, events.Items)
+	if err != nil {
+		log.Error(err, "Failed to replay the events")
+
+		return 1
+	}
+
+	statusYAML, err := yaml.Marshal(status)
+	if err != nil {
+		log.Error(err, "Failed to encode the replayed status")
+
+		return 1
+	}
+
+	fmt.Print(string(statusYAML))
+
+	return 0
+}
+
 // startHealthProxy responds to /healthz and /readyz HTTP requests and combines the status together of the input
 // addresses representing the managers. The HTTP server gracefully shutsdown when the input context is closed.
 // The wg.Done() is only called after the HTTP server fails to start or after graceful shutdown of the HTTP server.
@@ -541,7 +1368,8 @@ func startHealthProxy(ctx context.Context, wg *sync.WaitGroup, addresses ...stri
 	return nil
 }
 
-// getFreeLocalAddr returns an address on the localhost interface with a random free port assigned.
+// getFreeLocalAddr returns an address on the localhost interface with a random free port assigned. The address is in
+// the IP family localhost resolves to, so that it can be bound on a single-stack IPv6 host.
 func getFreeLocalAddr() (string, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -555,5 +1383,21 @@ func getFreeLocalAddr() (string, error) {
 
 	defer l.Close()
 
-	return fmt.Sprintf("127.0.0.1:%d", l.Addr().(*net.TCPAddr).Port), nil
+	localAddr := l.Addr().(*net.TCPAddr)
+
+	return net.JoinHostPort(localAddr.IP.String(), strconv.Itoa(localAddr.Port)), nil
+}
+
+// addonInstanceID returns the name of the pod of the addon, or the hostname when not running in a pod.
+func addonInstanceID() string {
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		return podName
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+
+	return hostname
 }
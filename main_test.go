@@ -32,5 +32,19 @@ func TestRunMain(t *testing.T) {
 		fmt.Sprintf("--cluster-namespace-on-hub=%s", clusterNsHub),
 	)
 
+	if probeAddr := os.Getenv("E2E_HEALTH_PROBE_BIND_ADDRESS"); probeAddr != "" {
+		os.Args = append(os.Args, fmt.Sprintf("--health-probe-bind-address=%s", probeAddr))
+	}
+
+	// Concurrent reconciles of the same controller, such as in the race detector mode
+	if concurrency := os.Getenv("E2E_CONCURRENCY"); concurrency != "" {
+		os.Args = append(
+			os.Args,
+			fmt.Sprintf("--spec-sync-concurrency=%s", concurrency),
+			fmt.Sprintf("--status-sync-concurrency=%s", concurrency),
+			fmt.Sprintf("--template-sync-concurrency=%s", concurrency),
+		)
+	}
+
 	main()
 }
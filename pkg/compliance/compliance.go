@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package compliance provides typed helpers to read the compliance of the policies on a managed cluster, as reported
+// by the governance policy framework addon, so that other components don't need to reimplement the parsing of the
+// policy status.
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyv1alpha1 "open-cluster-management.io/governance-policy-framework-addon/api/v1alpha1"
+)
+
+// SummaryName is the name of the ComplianceSummary in the cluster namespace on the managed cluster.
+const SummaryName = "compliance-summary"
+
+// internedMessageRegex matches the message of a compliance history entry replaced with a reference to a newer entry
+// with the same message. The text before the reference is the compliance state prefix of the original message.
+var internedMessageRegex = regexp.MustCompile(`^(.*); \(same message as history entry (\d+)\)$`)
+
+// HistoryEntry is a compliance history entry of a policy template with its compliance state.
+type HistoryEntry struct {
+	Timestamp time.Time
+	State     policiesv1.ComplianceState
+	Message   string
+	EventName string
+}
+
+// TemplateCompliance is the compliance of a policy template. The history is sorted from the newest entry, and the
+// annotations are those set on the template metadata in the policy status, such as the compliance timestamps.
+type TemplateCompliance struct {
+	Name        string
+	State       policiesv1.ComplianceState
+	History     []HistoryEntry
+	Annotations map[string]string
+}
+
+// PolicyCompliance is the compliance of a policy and of its policy templates.
+type PolicyCompliance struct {
+	Namespace string
+	Name      string
+	State     policiesv1.ComplianceState
+	Templates []TemplateCompliance
+}
+
+// GetSummary returns the status of the ComplianceSummary in the input cluster namespace. The ComplianceSummary is only
+// maintained when the addon runs with --enable-compliance-summary, and the scheme of the input client must include
+// the policy v1alpha1 API group.
+func GetSummary(
+	ctx context.Context, c client.Reader, namespace string,
+) (*policyv1alpha1.ComplianceSummaryStatus, error) {
+	summary := &policyv1alpha1.ComplianceSummary{}
+
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: SummaryName}, summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the compliance summary in the namespace %s: %w", namespace, err)
+	}
+
+	return &summary.Status, nil
+}
+
+// GetPolicy returns the compliance of the input policy in the input cluster namespace.
+func GetPolicy(ctx context.Context, c client.Reader, namespace string, name string) (*PolicyCompliance, error) {
+	plc := &policiesv1.Policy{}
+
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, plc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the policy %s/%s: %w", namespace, name, err)
+	}
+
+	compliance := FromPolicy(plc)
+
+	return &compliance, nil
+}
+
+// ListPolicies returns the compliance of the policies in the input cluster namespace.
+func ListPolicies(ctx context.Context, c client.Reader, namespace string) ([]PolicyCompliance, error) {
+	policies := &policiesv1.PolicyList{}
+
+	err := c.List(ctx, policies, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the policies in the namespace %s: %w", namespace, err)
+	}
+
+	compliance := make([]PolicyCompliance, 0, len(policies.Items))
+
+	for i := range policies.Items {
+		compliance = append(compliance, FromPolicy(&policies.Items[i]))
+	}
+
+	return compliance, nil
+}
+
+// FromPolicy returns the compliance of the input policy from its status. The interned history messages are expanded.
+func FromPolicy(plc *policiesv1.Policy) PolicyCompliance {
+	compliance := PolicyCompliance{
+		Namespace: plc.GetNamespace(),
+		Name:      plc.GetName(),
+		State:     plc.Status.ComplianceState,
+	}
+
+	for _, dpt := range plc.Status.Details {
+		if dpt == nil {
+			continue
+		}
+
+		history := make([]policiesv1.ComplianceHistory, len(dpt.History))
+		copy(history, dpt.History)
+		ExpandHistory(history)
+
+		template := TemplateCompliance{
+			Name:        dpt.TemplateMeta.Name,
+			State:       dpt.ComplianceState,
+			History:     make([]HistoryEntry, 0, len(history)),
+			Annotations: dpt.TemplateMeta.Annotations,
+		}
+
+		for _, entry := range history {
+			template.History = append(template.History, HistoryEntry{
+				Timestamp: entry.LastTimestamp.Time,
+				State:     MessageState(entry.Message),
+				Message:   entry.Message,
+				EventName: entry.EventName,
+			})
+		}
+
+		compliance.Templates = append(compliance.Templates, template)
+	}
+
+	return compliance
+}
+
+// MessageState returns the compliance state of the input compliance message, which is Compliant if it starts with
+// "Compliant" and NonCompliant otherwise. The addon may be configured with other mappings of the messages, in which
+// case the compliance state of the policy templates should be used instead.
+func MessageState(message string) policiesv1.ComplianceState {
+	message = strings.TrimSpace(strings.TrimPrefix(message, "(combined from similar events):"))
+
+	if strings.HasPrefix(strings.ToLower(message), "compliant") {
+		return policiesv1.Compliant
+	}
+
+	return policiesv1.NonCompliant
+}
+
+// InternedMessage returns the reference to the input index of a newer history entry which replaces the input message
+// when the addon runs with --intern-history-messages.
+func InternedMessage(message string, index int) string {
+	prefix := message
+	if i := strings.Index(message, ";"); i != -1 {
+		prefix = message[:i]
+	}
+
+	return fmt.Sprintf("%s; (same message as history entry %d)", prefix, index)
+}
+
+// ExpandHistory restores the messages of the input compliance history entries which were replaced with a reference to
+// a newer entry with the same message. A reference which doesn't point to a newer entry is left as is.
+func ExpandHistory(history []policiesv1.ComplianceHistory) {
+	for i := range history {
+		match := internedMessageRegex.FindStringSubmatch(history[i].Message)
+		if match == nil {
+			continue
+		}
+
+		index, err := strconv.Atoi(match[2])
+		if err != nil || index >= i {
+			continue
+		}
+
+		// The referenced entry is newer, so it was already expanded
+		history[i].Message = history[index].Message
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package compliance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyv1alpha1 "open-cluster-management.io/governance-policy-framework-addon/api/v1alpha1"
+)
+
+func TestReadCompliance(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(policiesv1.AddToScheme(scheme)).To(Succeed())
+	Expect(policyv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	violation := "NonCompliant; violation - the namespace prod is missing"
+	newest := metav1.NewTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	plc := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "default.policy", Namespace: "cluster1"},
+		Status: policiesv1.PolicyStatus{
+			ComplianceState: policiesv1.NonCompliant,
+			Details: []*policiesv1.DetailsPerTemplate{{
+				TemplateMeta:    metav1.ObjectMeta{Name: "config-policy"},
+				ComplianceState: policiesv1.NonCompliant,
+				History: []policiesv1.ComplianceHistory{
+					{LastTimestamp: newest, Message: violation, EventName: "policy.1"},
+					{Message: "Compliant; notification - the namespace prod exists", EventName: "policy.2"},
+					{Message: InternedMessage(violation, 0), EventName: "policy.3"},
+				},
+			}},
+		},
+	}
+	summary := &policyv1alpha1.ComplianceSummary{
+		ObjectMeta: metav1.ObjectMeta{Name: SummaryName, Namespace: "cluster1"},
+		Status:     policyv1alpha1.ComplianceSummaryStatus{Total: 1, NonCompliant: 1},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(plc, summary).Build()
+
+	summaryStatus, err := GetSummary(context.TODO(), c, "cluster1")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(summaryStatus.NonCompliant).To(Equal(1))
+
+	_, err = GetSummary(context.TODO(), c, "cluster2")
+	Expect(err).To(HaveOccurred())
+
+	compliance, err := GetPolicy(context.TODO(), c, "cluster1", "default.policy")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(compliance.State).To(Equal(policiesv1.NonCompliant))
+	Expect(compliance.Templates).To(HaveLen(1))
+
+	history := compliance.Templates[0].History
+	Expect(history).To(HaveLen(3))
+	Expect(history[0].Timestamp).To(BeTemporally("==", newest.Time))
+	Expect(history[1].State).To(Equal(policiesv1.Compliant))
+	// The interned message is expanded without changing the policy
+	Expect(history[2].Message).To(Equal(violation))
+	Expect(history[2].State).To(Equal(policiesv1.NonCompliant))
+	Expect(plc.Status.Details[0].History[2].Message).To(Equal(InternedMessage(violation, 0)))
+
+	policies, err := ListPolicies(context.TODO(), c, "cluster1")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(policies).To(HaveLen(1))
+	Expect(policies[0].Name).To(Equal("default.policy"))
+}
+
+func TestMessageState(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(MessageState("Compliant; notification")).To(Equal(policiesv1.Compliant))
+	Expect(MessageState("(combined from similar events): compliant; notification")).To(Equal(policiesv1.Compliant))
+	Expect(MessageState("NonCompliant; violation")).To(Equal(policiesv1.NonCompliant))
+}
@@ -0,0 +1,77 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	"errors"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"open-cluster-management.io/governance-policy-propagator/test/utils"
+)
+
+const (
+	case11PolicyName          string = "case11-test-policy"
+	case11PolicyYaml          string = "../resources/case11_skip_remediation_override/case11-test-policy.yaml"
+	case11InformConfigPolicy  string = "case11-config-policy-inform"
+	case11EnforceConfigPolicy string = "case11-config-policy-enforce"
+)
+
+var _ = Describe("Test skipping the remediationAction override", func() {
+	BeforeEach(func() {
+		By("Creating a policy on the hub in ns:" + clusterNamespaceOnHub)
+		_, err := kubectlHub("apply", "-f", case11PolicyYaml, "-n", clusterNamespaceOnHub)
+		Expect(err).Should(BeNil())
+		plc := utils.GetWithTimeout(clientManagedDynamic, gvrPolicy, case11PolicyName, clusterNamespace, true,
+			defaultTimeoutSeconds)
+		Expect(plc).NotTo(BeNil())
+	})
+	AfterEach(func() {
+		By("Deleting a policy on the hub in ns:" + clusterNamespaceOnHub)
+		_, err := kubectlHub("delete", "-f", case11PolicyYaml, "-n", clusterNamespaceOnHub)
+		var e *exec.ExitError
+		if !errors.As(err, &e) {
+			Expect(err).Should(BeNil())
+		}
+		opt := metav1.ListOptions{}
+		utils.ListWithTimeout(clientManagedDynamic, gvrPolicy, opt, 0, true, defaultTimeoutSeconds)
+	})
+	It("should retain the remediationAction of each template", func() {
+		By("Checking the inform configpolicy CR")
+		yamlInformPlc := utils.ParseYaml(
+			"../resources/case11_skip_remediation_override/case11-config-policy-inform.yaml")
+		Eventually(func() interface{} {
+			trustedPlc := utils.GetWithTimeout(clientManagedDynamic, gvrConfigurationPolicy,
+				case11InformConfigPolicy, clusterNamespace, true, defaultTimeoutSeconds)
+
+			return trustedPlc.Object["spec"]
+		}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlInformPlc.Object["spec"]))
+		By("Checking the enforce configpolicy CR")
+		yamlEnforcePlc := utils.ParseYaml(
+			"../resources/case11_skip_remediation_override/case11-config-policy-enforce.yaml")
+		Eventually(func() interface{} {
+			trustedPlc := utils.GetWithTimeout(clientManagedDynamic, gvrConfigurationPolicy,
+				case11EnforceConfigPolicy, clusterNamespace, true, defaultTimeoutSeconds)
+
+			return trustedPlc.Object["spec"]
+		}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlEnforcePlc.Object["spec"]))
+	})
+	It("should override remediationAction in spec when the annotation is removed", func() {
+		By("Removing the annotation from the policy")
+		_, err := kubectlHub("apply", "-f",
+			"../resources/case11_skip_remediation_override/case11-test-policy-override.yaml",
+			"-n", clusterNamespaceOnHub)
+		Expect(err).Should(BeNil())
+		By("Checking the inform configpolicy CR remediationAction")
+		yamlTrustedPlc := utils.ParseYaml(
+			"../resources/case11_skip_remediation_override/case11-config-policy-inform-overridden.yaml")
+		Eventually(func() interface{} {
+			trustedPlc := utils.GetWithTimeout(clientManagedDynamic, gvrConfigurationPolicy,
+				case11InformConfigPolicy, clusterNamespace, true, defaultTimeoutSeconds)
+
+			return trustedPlc.Object["spec"]
+		}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlTrustedPlc.Object["spec"]))
+	})
+})
@@ -0,0 +1,158 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	"context"
+	"net/url"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"open-cluster-management.io/governance-policy-propagator/test/utils"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+const (
+	case12PolicyName string = "case12-test-policy"
+	case12PolicyYaml string = "../resources/case12_hub_outage/case12-test-policy.yaml"
+	// case12OutageSeconds is how long the Hub is unavailable to the controller in each test
+	case12OutageSeconds = 10
+)
+
+// These tests require the controller to reach the Hub through the proxy at E2E_HUB_PROXY_ADDRESS, as set up by
+// `make e2e-test-hub-outage`.
+var _ = Describe("Test the recovery from a Hub outage", Ordered, Label("hub-outage"), func() {
+	var proxy *testutils.HubProxy
+
+	BeforeAll(func() {
+		proxyAddress := os.Getenv("E2E_HUB_PROXY_ADDRESS")
+		if proxyAddress == "" {
+			Skip("E2E_HUB_PROXY_ADDRESS is not set")
+		}
+
+		By("Starting the Hub proxy on " + proxyAddress)
+		hubConfig, err := LoadConfig("", kubeconfigHub, "")
+		Expect(err).Should(BeNil())
+
+		hubURL, err := url.Parse(hubConfig.Host)
+		Expect(err).Should(BeNil())
+
+		proxy, err = testutils.StartHubProxy(proxyAddress, hubURL.Host)
+		Expect(err).Should(BeNil())
+
+		By("Creating a policy on hub cluster in ns:" + clusterNamespaceOnHub)
+		_, err = kubectlHub("apply", "-f", case12PolicyYaml, "-n", clusterNamespaceOnHub)
+		Expect(err).Should(BeNil())
+		plc := utils.GetWithTimeout(
+			clientManagedDynamic, gvrPolicy, case12PolicyName, clusterNamespace, true, defaultTimeoutSeconds*2,
+		)
+		Expect(plc).NotTo(BeNil())
+	})
+
+	AfterAll(func() {
+		if proxy == nil {
+			return
+		}
+
+		proxy.Resume()
+
+		By("Deleting a policy on hub cluster in ns:" + clusterNamespaceOnHub)
+		_, _ = kubectlHub("delete", "-f", case12PolicyYaml, "-n", clusterNamespaceOnHub)
+		opt := metav1.ListOptions{}
+		utils.ListWithTimeout(clientHubDynamic, gvrPolicy, opt, 0, true, defaultTimeoutSeconds)
+		utils.ListWithTimeout(clientManagedDynamic, gvrPolicy, opt, 0, true, defaultTimeoutSeconds*2)
+
+		Expect(proxy.Stop()).To(Succeed())
+	})
+
+	It("should sync the spec changes made on the Hub during the outage", func() {
+		By("Simulating a Hub outage")
+		proxy.Pause()
+
+		By("Patching the policy on the Hub with spec.remediationAction = enforce")
+		_, err := clientHubDynamic.Resource(gvrPolicy).Namespace(clusterNamespaceOnHub).Patch(
+			context.TODO(),
+			case12PolicyName,
+			types.MergePatchType,
+			[]byte(`{"spec":{"remediationAction":"enforce"}}`),
+			metav1.PatchOptions{},
+		)
+		Expect(err).Should(BeNil())
+
+		By("Checking that the policy on the managed cluster is unchanged during the outage")
+		Consistently(func() interface{} {
+			managedPlc := utils.GetWithTimeout(
+				clientManagedDynamic, gvrPolicy, case12PolicyName, clusterNamespace, true, defaultTimeoutSeconds,
+			)
+
+			return managedPlc.Object["spec"].(map[string]interface{})["remediationAction"]
+		}, case12OutageSeconds, 1).Should(Equal("inform"))
+
+		By("Ending the Hub outage")
+		proxy.Resume()
+
+		By("Checking that the policy on the managed cluster is synced")
+		Eventually(func() interface{} {
+			managedPlc := utils.GetWithTimeout(
+				clientManagedDynamic, gvrPolicy, case12PolicyName, clusterNamespace, true, defaultTimeoutSeconds,
+			)
+
+			return managedPlc.Object["spec"].(map[string]interface{})["remediationAction"]
+		}, defaultTimeoutSeconds*2, 1).Should(Equal("enforce"))
+	})
+
+	It("should sync the status changes made on the managed cluster during the outage", func() {
+		By("Simulating a Hub outage")
+		proxy.Pause()
+
+		By("Generating a compliant event on the policy")
+		managedPlc := utils.GetWithTimeout(
+			clientManagedDynamic, gvrPolicy, case12PolicyName, clusterNamespace, true, defaultTimeoutSeconds,
+		)
+		Expect(managedPlc).NotTo(BeNil())
+		managedRecorder.Event(
+			managedPlc,
+			"Normal",
+			"policy: managed/case12-test-policy-configurationpolicy",
+			"Compliant; No violation detected",
+		)
+
+		By("Checking that the policy on the managed cluster is compliant")
+		Eventually(func() interface{} {
+			managedPlc = utils.GetWithTimeout(
+				clientManagedDynamic, gvrPolicy, case12PolicyName, clusterNamespace, true, defaultTimeoutSeconds,
+			)
+
+			return getCompliant(managedPlc)
+		}, defaultTimeoutSeconds, 1).Should(Equal("Compliant"))
+
+		By("Checking that the policy status on the Hub is unchanged during the outage")
+		Consistently(func() interface{} {
+			hubPlc := utils.GetWithTimeout(
+				clientHubDynamic, gvrPolicy, case12PolicyName, clusterNamespaceOnHub, true, defaultTimeoutSeconds,
+			)
+
+			return getCompliant(hubPlc)
+		}, case12OutageSeconds, 1).ShouldNot(Equal("Compliant"))
+
+		By("Ending the Hub outage")
+		proxy.Resume()
+
+		By("Checking that the policy status on the Hub is synced")
+		Eventually(func() interface{} {
+			hubPlc := utils.GetWithTimeout(
+				clientHubDynamic, gvrPolicy, case12PolicyName, clusterNamespaceOnHub, true, defaultTimeoutSeconds,
+			)
+
+			return hubPlc.Object["status"]
+		}, defaultTimeoutSeconds*2, 1).Should(Equal(managedPlc.Object["status"]))
+
+		By("Cleaning up the events")
+		_, err := kubectlManaged("delete", "events", "-n", clusterNamespace, "--all")
+		Expect(err).Should(BeNil())
+	})
+})
@@ -0,0 +1,112 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"open-cluster-management.io/governance-policy-propagator/test/utils"
+)
+
+const (
+	case13PolicyName string = "case13-test-policy"
+	case13PolicyYaml string = "../resources/case13_ipv6/case13-test-policy.yaml"
+)
+
+// These tests require single-stack IPv6 clusters and the controller health probe to be bound to the IPv6 address at
+// E2E_HEALTH_PROBE_BIND_ADDRESS, as set up by `make kind-bootstrap-cluster-dev KIND_IP_FAMILY=ipv6` and
+// `make e2e-test-ipv6`.
+var _ = Describe("Test the sync with IPv6 Hub endpoints", Ordered, Label("ipv6"), func() {
+	var probeAddress string
+
+	BeforeAll(func() {
+		probeAddress = os.Getenv("E2E_HEALTH_PROBE_BIND_ADDRESS")
+		if probeAddress == "" {
+			Skip("E2E_HEALTH_PROBE_BIND_ADDRESS is not set")
+		}
+
+		By("Checking that the Hub API server has an IPv6 address")
+		hubConfig, err := LoadConfig("", kubeconfigHub, "")
+		Expect(err).Should(BeNil())
+
+		hubURL, err := url.Parse(hubConfig.Host)
+		Expect(err).Should(BeNil())
+
+		hubIP := net.ParseIP(hubURL.Hostname())
+		Expect(hubIP).NotTo(BeNil(), "the Hub API server host %s is not an IP address", hubURL.Hostname())
+		Expect(hubIP.To4()).To(BeNil(), "the Hub API server address %s is not an IPv6 address", hubIP)
+	})
+
+	AfterAll(func() {
+		if probeAddress == "" {
+			return
+		}
+
+		By("Deleting a policy on hub cluster in ns:" + clusterNamespaceOnHub)
+		_, _ = kubectlHub("delete", "-f", case13PolicyYaml, "-n", clusterNamespaceOnHub)
+		opt := metav1.ListOptions{}
+		utils.ListWithTimeout(clientHubDynamic, gvrPolicy, opt, 0, true, defaultTimeoutSeconds)
+		utils.ListWithTimeout(clientManagedDynamic, gvrPolicy, opt, 0, true, defaultTimeoutSeconds*2)
+	})
+
+	It("should serve the health probes on the IPv6 bind address", func() {
+		for _, endpoint := range []string{"/healthz", "/readyz"} {
+			Eventually(func() int {
+				req, err := http.NewRequestWithContext(
+					context.TODO(), http.MethodGet, fmt.Sprintf("http://%s%s", probeAddress, endpoint), nil,
+				)
+				if err != nil {
+					return 0
+				}
+
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					return 0
+				}
+
+				defer resp.Body.Close()
+
+				return resp.StatusCode
+			}, defaultTimeoutSeconds, 1).Should(Equal(http.StatusOK))
+		}
+	})
+
+	It("should sync the policy and its status with the IPv6 Hub", func() {
+		By("Creating a policy on hub cluster in ns:" + clusterNamespaceOnHub)
+		_, err := kubectlHub("apply", "-f", case13PolicyYaml, "-n", clusterNamespaceOnHub)
+		Expect(err).Should(BeNil())
+		managedPlc := utils.GetWithTimeout(
+			clientManagedDynamic, gvrPolicy, case13PolicyName, clusterNamespace, true, defaultTimeoutSeconds,
+		)
+		Expect(managedPlc).NotTo(BeNil())
+
+		By("Generating a compliant event on the policy")
+		managedRecorder.Event(
+			managedPlc,
+			"Normal",
+			"policy: managed/case13-test-policy-configurationpolicy",
+			"Compliant; No violation detected",
+		)
+
+		By("Checking that the policy status on the Hub is synced")
+		Eventually(func() interface{} {
+			hubPlc := utils.GetWithTimeout(
+				clientHubDynamic, gvrPolicy, case13PolicyName, clusterNamespaceOnHub, true, defaultTimeoutSeconds,
+			)
+
+			return getCompliant(hubPlc)
+		}, defaultTimeoutSeconds, 1).Should(Equal("Compliant"))
+
+		By("Cleaning up the events")
+		_, err = kubectlManaged("delete", "events", "-n", clusterNamespace, "--all")
+		Expect(err).Should(BeNil())
+	})
+})
@@ -207,4 +207,35 @@ var _ = Describe("Test mutation recovery", func() {
 		_, err := kubectlManaged("delete", "events", "-n", clusterNamespace, "--all")
 		Expect(err).Should(BeNil())
 	})
+	It("Should clear a compliance state set by another field manager", func() {
+		By("Updating the status on managed to NonCompliant as an addon version which didn't apply the statuses")
+		Eventually(
+			func() interface{} {
+				managedPlc := utils.GetWithTimeout(
+					clientManagedDynamic, gvrPolicy, case1PolicyName, clusterNamespace, true, defaultTimeoutSeconds,
+				)
+				managedPlc.Object["status"] = map[string]interface{}{"compliant": "NonCompliant"}
+				nsPolicy := clientManagedDynamic.Resource(gvrPolicy).Namespace(clusterNamespace)
+				_, err := nsPolicy.UpdateStatus(
+					context.TODO(), managedPlc, metav1.UpdateOptions{FieldManager: "governance-policy-framework-addon"},
+				)
+
+				return err
+			},
+			defaultTimeoutSeconds,
+			1,
+		).Should(BeNil())
+		By("Checking if the compliance state was cleared since no policy template reported its compliance")
+		Eventually(func() interface{} {
+			managedPlc := utils.GetWithTimeout(
+				clientManagedDynamic,
+				gvrPolicy,
+				case1PolicyName,
+				clusterNamespace,
+				true,
+				defaultTimeoutSeconds)
+
+			return getCompliant(managedPlc)
+		}, defaultTimeoutSeconds, 1).Should(BeEmpty())
+	})
 })
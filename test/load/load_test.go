@@ -0,0 +1,378 @@
+//go:build load
+// +build load
+
+// Copyright Contributors to the Open Cluster Management project
+
+package load
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const loadTestLabel = "policy.open-cluster-management.io/load-test"
+
+var (
+	kubeconfigHub     string
+	kubeconfigManaged string
+	policyCount       int
+	templateCount     int
+	eventCount        int
+	timeout           time.Duration
+
+	gvrPolicy = schema.GroupVersionResource{
+		Group: "policy.open-cluster-management.io", Version: "v1", Resource: "policies",
+	}
+	gvrConfigurationPolicy = schema.GroupVersionResource{
+		Group: "policy.open-cluster-management.io", Version: "v1", Resource: "configurationpolicies",
+	}
+)
+
+func init() {
+	flag.StringVar(&kubeconfigHub, "kubeconfig_hub", "../../kubeconfig_hub", "Location of the Hub kubeconfig")
+	flag.StringVar(
+		&kubeconfigManaged, "kubeconfig_managed", "../../kubeconfig_managed", "Location of the managed kubeconfig",
+	)
+	flag.IntVar(&policyCount, "policies", 100, "The number of synthetic policies to create on the Hub")
+	flag.IntVar(&templateCount, "templates", 2, "The number of policy templates in each synthetic policy")
+	flag.IntVar(&eventCount, "events", 5, "The number of compliance events to emit for each policy template")
+	flag.DurationVar(&timeout, "timeout", 10*time.Minute, "The maximum time to wait for each phase to complete")
+}
+
+type loadClients struct {
+	hub              dynamic.Interface
+	managed          dynamic.Interface
+	managedClient    kubernetes.Interface
+	hubNamespace     string
+	managedNamespace string
+}
+
+// TestLoad generates synthetic policies on the Hub and reports the throughput and latency percentiles of the spec
+// sync, template sync, and status sync controllers. The controllers must already be running against the clusters.
+func TestLoad(t *testing.T) {
+	clients := newLoadClients(t)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	defer cancel()
+	defer cleanup(t, clients)
+
+	createTimes := map[string]time.Time{}
+	policyNames := make([]string, 0, policyCount)
+
+	for i := 0; i < policyCount; i++ {
+		policyNames = append(policyNames, fmt.Sprintf("load-policy-%d", i))
+	}
+
+	// Start the watches before creating the policies so that no events are missed
+	policyWatch := startWatch(ctx, t, clients.managed, gvrPolicy, clients.managedNamespace)
+	templateWatch := startWatch(ctx, t, clients.managed, gvrConfigurationPolicy, clients.managedNamespace)
+
+	t.Logf("Creating %d policies with %d templates each on the Hub", policyCount, templateCount)
+
+	start := time.Now()
+
+	for _, name := range policyNames {
+		createTimes[name] = time.Now()
+
+		_, err := clients.hub.Resource(gvrPolicy).Namespace(clients.hubNamespace).Create(
+			ctx, syntheticPolicy(name, clients.hubNamespace), metav1.CreateOptions{},
+		)
+		if err != nil {
+			t.Fatalf("Failed to create the policy %s: %v", name, err)
+		}
+	}
+
+	specSyncTimes := policyWatch.waitFor(t, policyCount)
+	report(t, "policy-spec-sync", start, createTimes, specSyncTimes)
+
+	templateSyncTimes := templateWatch.waitFor(t, policyCount*templateCount)
+	templateCreateTimes := map[string]time.Time{}
+
+	for _, name := range policyNames {
+		for j := 0; j < templateCount; j++ {
+			templateCreateTimes[templateName(name, j)] = createTimes[name]
+		}
+	}
+
+	report(t, "policy-template-sync", start, templateCreateTimes, templateSyncTimes)
+
+	t.Logf("Emitting %d compliance events for each of the %d policy templates", eventCount, policyCount*templateCount)
+
+	statusWatch := startWatch(ctx, t, clients.hub, gvrPolicy, clients.hubNamespace)
+	statusWatch.match = func(obj *unstructured.Unstructured) bool {
+		return lastEventSynced(obj)
+	}
+
+	start = time.Now()
+	eventTimes := emitEventStorm(ctx, t, clients, policyNames)
+	statusSyncTimes := statusWatch.waitFor(t, policyCount)
+
+	report(t, "policy-status-sync", start, eventTimes, statusSyncTimes)
+}
+
+func newLoadClients(t *testing.T) *loadClients {
+	t.Helper()
+
+	hubCfg, err := clientcmd.BuildConfigFromFlags("", kubeconfigHub)
+	if err != nil {
+		t.Fatalf("Failed to load the Hub kubeconfig: %v", err)
+	}
+
+	managedCfg, err := clientcmd.BuildConfigFromFlags("", kubeconfigManaged)
+	if err != nil {
+		t.Fatalf("Failed to load the managed kubeconfig: %v", err)
+	}
+
+	// Raise the client side rate limits so that the harness isn't the bottleneck
+	hubCfg.QPS, hubCfg.Burst = 200, 400
+	managedCfg.QPS, managedCfg.Burst = 200, 400
+
+	clients := &loadClients{
+		hub:              dynamic.NewForConfigOrDie(hubCfg),
+		managed:          dynamic.NewForConfigOrDie(managedCfg),
+		managedClient:    kubernetes.NewForConfigOrDie(managedCfg),
+		hubNamespace:     os.Getenv("E2E_CLUSTER_NAMESPACE_ON_HUB"),
+		managedNamespace: os.Getenv("E2E_CLUSTER_NAMESPACE"),
+	}
+
+	if clients.managedNamespace == "" {
+		clients.managedNamespace = "managed"
+	}
+
+	if clients.hubNamespace == "" {
+		clients.hubNamespace = clients.managedNamespace
+	}
+
+	return clients
+}
+
+func templateName(policyName string, index int) string {
+	return fmt.Sprintf("%s-template-%d", policyName, index)
+}
+
+func syntheticPolicy(name string, namespace string) *unstructured.Unstructured {
+	templates := make([]interface{}, 0, templateCount)
+
+	for j := 0; j < templateCount; j++ {
+		templates = append(templates, map[string]interface{}{
+			"objectDefinition": map[string]interface{}{
+				"apiVersion": "policy.open-cluster-management.io/v1",
+				"kind":       "ConfigurationPolicy",
+				"metadata":   map[string]interface{}{"name": templateName(name, j)},
+				"spec": map[string]interface{}{
+					"remediationAction": "inform",
+					"object-templates": []interface{}{
+						map[string]interface{}{
+							"complianceType": "musthave",
+							"objectDefinition": map[string]interface{}{
+								"apiVersion": "v1",
+								"kind":       "Namespace",
+								"metadata":   map[string]interface{}{"name": "default"},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "Policy",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels": map[string]interface{}{
+				loadTestLabel: "true",
+				"policy.open-cluster-management.io/cluster-name":      namespace,
+				"policy.open-cluster-management.io/cluster-namespace": namespace,
+				"policy.open-cluster-management.io/root-policy":       "load." + name,
+			},
+		},
+		"spec": map[string]interface{}{
+			"remediationAction": "inform",
+			"disabled":          false,
+			"policy-templates":  templates,
+		},
+	}}
+
+	return policy
+}
+
+func lastEventSynced(obj *unstructured.Unstructured) bool {
+	details, _, _ := unstructured.NestedSlice(obj.Object, "status", "details")
+	if len(details) != templateCount {
+		return false
+	}
+
+	for _, detail := range details {
+		history, _, _ := unstructured.NestedSlice(detail.(map[string]interface{}), "history")
+		if len(history) == 0 {
+			return false
+		}
+
+		message, _, _ := unstructured.NestedString(history[0].(map[string]interface{}), "message")
+		if message != eventMessage(eventCount-1) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func eventMessage(index int) string {
+	return fmt.Sprintf("Compliant; load test event %d", index)
+}
+
+// emitEventStorm emits the compliance events for all the policy templates and returns the time the last event was
+// emitted for each policy.
+func emitEventStorm(
+	ctx context.Context, t *testing.T, clients *loadClients, policyNames []string,
+) map[string]time.Time {
+	t.Helper()
+
+	eventTimes := map[string]time.Time{}
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for _, name := range policyNames {
+		managedPlc, err := clients.managed.Resource(gvrPolicy).Namespace(clients.managedNamespace).Get(
+			ctx, name, metav1.GetOptions{},
+		)
+		if err != nil {
+			t.Fatalf("Failed to get the managed policy %s: %v", name, err)
+		}
+
+		wg.Add(1)
+
+		go func(managedPlc *unstructured.Unstructured) {
+			defer wg.Done()
+
+			for k := 0; k < eventCount; k++ {
+				for j := 0; j < templateCount; j++ {
+					now := metav1.Now()
+					event := &corev1.Event{
+						ObjectMeta: metav1.ObjectMeta{
+							GenerateName: managedPlc.GetName() + ".",
+							Namespace:    clients.managedNamespace,
+						},
+						InvolvedObject: corev1.ObjectReference{
+							Kind:       "Policy",
+							Namespace:  clients.managedNamespace,
+							Name:       managedPlc.GetName(),
+							UID:        managedPlc.GetUID(),
+							APIVersion: "policy.open-cluster-management.io/v1",
+						},
+						Reason: fmt.Sprintf(
+							"policy: %s/%s", clients.managedNamespace, templateName(managedPlc.GetName(), j),
+						),
+						Message:        eventMessage(k),
+						Type:           "Normal",
+						FirstTimestamp: now,
+						LastTimestamp:  metav1.NewTime(now.Add(time.Duration(k) * time.Second)),
+						Count:          1,
+					}
+
+					_, err := clients.managedClient.CoreV1().Events(clients.managedNamespace).Create(
+						ctx, event, metav1.CreateOptions{},
+					)
+					if err != nil {
+						t.Errorf("Failed to create the event for the policy %s: %v", managedPlc.GetName(), err)
+					}
+				}
+			}
+
+			lock.Lock()
+			eventTimes[managedPlc.GetName()] = time.Now()
+			lock.Unlock()
+		}(managedPlc)
+	}
+
+	wg.Wait()
+
+	return eventTimes
+}
+
+func cleanup(t *testing.T, clients *loadClients) {
+	t.Helper()
+
+	err := clients.hub.Resource(gvrPolicy).Namespace(clients.hubNamespace).DeleteCollection(
+		context.TODO(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: loadTestLabel + "=true"},
+	)
+	if err != nil && !errors.IsNotFound(err) {
+		t.Logf("Failed to clean up the load test policies: %v", err)
+	}
+}
+
+// objectWatch records the first time each object matching the optional match function is observed.
+type objectWatch struct {
+	watcher watch.Interface
+	match   func(obj *unstructured.Unstructured) bool
+	seen    map[string]time.Time
+}
+
+func startWatch(
+	ctx context.Context, t *testing.T, client dynamic.Interface, gvr schema.GroupVersionResource, namespace string,
+) *objectWatch {
+	t.Helper()
+
+	watcher, err := client.Resource(gvr).Namespace(namespace).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to watch %s: %v", gvr.Resource, err)
+	}
+
+	return &objectWatch{watcher: watcher, seen: map[string]time.Time{}}
+}
+
+// waitFor blocks until count matching objects were observed or the watch ends, and returns the observation times.
+func (w *objectWatch) waitFor(t *testing.T, count int) map[string]time.Time {
+	t.Helper()
+
+	defer w.watcher.Stop()
+
+	for event := range w.watcher.ResultChan() {
+		obj, ok := event.Object.(*unstructured.Unstructured)
+		if !ok || event.Type == watch.Deleted {
+			continue
+		}
+
+		if _, seen := w.seen[obj.GetName()]; seen {
+			continue
+		}
+
+		if w.match != nil && !w.match(obj) {
+			continue
+		}
+
+		w.seen[obj.GetName()] = time.Now()
+
+		if len(w.seen) >= count {
+			return w.seen
+		}
+	}
+
+	t.Errorf("Timed out after observing %d of %d objects", len(w.seen), count)
+
+	return w.seen
+}
+
+func report(t *testing.T, controller string, phaseStart time.Time, startTimes, observedTimes map[string]time.Time) {
+	t.Helper()
+
+	t.Log(Summarize(controller, phaseStart, startTimes, observedTimes).String())
+}
@@ -0,0 +1,79 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package load contains a load test harness that generates synthetic policies on a Hub and reports the throughput and
+// latency percentiles of each controller. Run it with `make load-test` against clusters set up for the e2e tests.
+package load
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Result is the summary of the latencies observed for a controller.
+type Result struct {
+	Controller string
+	Count      int
+	Throughput float64
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf(
+		"%s: count=%d throughput=%.2f/s p50=%s p90=%s p99=%s max=%s",
+		r.Controller, r.Count, r.Throughput, r.P50, r.P90, r.P99, r.Max,
+	)
+}
+
+// Summarize calculates the latency percentiles between the start times and the observed times of the objects, and the
+// throughput since the start of the phase. Objects without a start time are ignored.
+func Summarize(controller string, phaseStart time.Time, startTimes, observedTimes map[string]time.Time) Result {
+	latencies := make([]time.Duration, 0, len(observedTimes))
+
+	var lastObserved time.Time
+
+	for name, observed := range observedTimes {
+		start, ok := startTimes[name]
+		if !ok {
+			continue
+		}
+
+		latencies = append(latencies, observed.Sub(start))
+
+		if observed.After(lastObserved) {
+			lastObserved = observed
+		}
+	}
+
+	result := Result{Controller: controller, Count: len(latencies)}
+
+	if len(latencies) == 0 {
+		return result
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	result.P50 = percentile(latencies, 50)
+	result.P90 = percentile(latencies, 90)
+	result.P99 = percentile(latencies, 99)
+	result.Max = latencies[len(latencies)-1]
+
+	if elapsed := lastObserved.Sub(phaseStart).Seconds(); elapsed > 0 {
+		result.Throughput = float64(len(latencies)) / elapsed
+	}
+
+	return result
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
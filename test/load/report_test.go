@@ -0,0 +1,38 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package load
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	phaseStart := time.Now()
+	startTimes := map[string]time.Time{}
+	observedTimes := map[string]time.Time{}
+
+	for i := 1; i <= 100; i++ {
+		name := fmt.Sprintf("policy-%d", i)
+		startTimes[name] = phaseStart
+		observedTimes[name] = phaseStart.Add(time.Duration(i) * time.Second)
+	}
+
+	// An object without a start time must be ignored
+	observedTimes["unknown"] = phaseStart.Add(time.Hour)
+
+	result := Summarize("test", phaseStart, startTimes, observedTimes)
+
+	if result.Count != 100 {
+		t.Fatalf("Expected 100 latencies but got %d", result.Count)
+	}
+
+	if result.P50 != 50*time.Second || result.P90 != 90*time.Second || result.P99 != 99*time.Second {
+		t.Fatalf("Unexpected percentiles: %s", result)
+	}
+
+	if result.Max != 100*time.Second || result.Throughput != 1 {
+		t.Fatalf("Unexpected max or throughput: %s", result)
+	}
+}
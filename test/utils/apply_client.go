@@ -0,0 +1,61 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ApplyClient records the server-side apply patches of the statuses and sends them as merge patches since the fake
+// client doesn't support server-side apply.
+type ApplyClient struct {
+	client.Client
+	// Patches are the options of the recorded apply patches, in order
+	Patches *[]client.PatchOptions
+	// Data are the contents of the recorded apply patches, in order
+	Data *[]map[string]interface{}
+}
+
+// NewApplyClient returns an ApplyClient wrapping the input client, such as a fake client.
+func NewApplyClient(c client.Client) ApplyClient {
+	return ApplyClient{Client: c, Patches: &[]client.PatchOptions{}, Data: &[]map[string]interface{}{}}
+}
+
+func (c ApplyClient) Status() client.StatusWriter {
+	return applyStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type applyStatusWriter struct {
+	client.StatusWriter
+	client ApplyClient
+}
+
+func (w applyStatusWriter) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	if patch.Type() != types.ApplyPatchType {
+		return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+	}
+
+	options := client.PatchOptions{}
+	options.ApplyOptions(opts)
+	*w.client.Patches = append(*w.client.Patches, options)
+
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	content := map[string]interface{}{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return err
+	}
+
+	*w.client.Data = append(*w.client.Data, content)
+
+	return w.StatusWriter.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
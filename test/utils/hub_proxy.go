@@ -0,0 +1,156 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// HubProxy forwards the TCP connections of the controller to the Hub API server so that the e2e tests can simulate a
+// Hub outage. The controller must use a kubeconfig whose server is the address of the proxy.
+type HubProxy struct {
+	listener net.Listener
+	target   string
+	paused   bool
+	conns    map[net.Conn]bool
+	lock     sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// StartHubProxy listens on the input address and forwards the connections to the input Hub API server address until
+// Stop is called.
+func StartHubProxy(address string, target string) (*HubProxy, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := &HubProxy{listener: listener, target: target, conns: map[net.Conn]bool{}}
+
+	proxy.wg.Add(1)
+
+	go proxy.accept()
+
+	return proxy, nil
+}
+
+// accept forwards the accepted connections until the listener is closed. The connections accepted while the proxy
+// is paused are closed right away.
+func (p *HubProxy) accept() {
+	defer p.wg.Done()
+
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			continue
+		}
+
+		if !p.track(conn) {
+			_ = conn.Close()
+
+			continue
+		}
+
+		go p.forward(conn)
+	}
+}
+
+// track records the input connection so that it is closed when the proxy is paused. It returns false if the proxy is
+// paused.
+func (p *HubProxy) track(conn net.Conn) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.paused {
+		return false
+	}
+
+	p.conns[conn] = true
+
+	return true
+}
+
+// forward copies the data between the input connection and a new connection to the Hub API server until either is
+// closed.
+func (p *HubProxy) forward(conn net.Conn) {
+	upstream, err := net.Dial("tcp", p.target)
+	if err != nil {
+		p.close(conn)
+
+		return
+	}
+
+	if !p.track(upstream) {
+		_ = upstream.Close()
+
+		p.close(conn)
+
+		return
+	}
+
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = io.Copy(upstream, conn)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(conn, upstream)
+		done <- struct{}{}
+	}()
+
+	<-done
+
+	p.close(conn)
+	p.close(upstream)
+}
+
+// close closes the input connection and stops tracking it.
+func (p *HubProxy) close(conn net.Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.conns, conn)
+
+	_ = conn.Close()
+}
+
+// Pause simulates a Hub outage by closing the open connections and refusing new ones until Resume is called.
+func (p *HubProxy) Pause() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.paused = true
+
+	for conn := range p.conns {
+		_ = conn.Close()
+
+		delete(p.conns, conn)
+	}
+}
+
+// Resume ends the simulated Hub outage so that new connections are forwarded again.
+func (p *HubProxy) Resume() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.paused = false
+}
+
+// Stop closes the listener and the open connections of the proxy.
+func (p *HubProxy) Stop() error {
+	err := p.listener.Close()
+
+	p.Pause()
+	p.wg.Wait()
+
+	return err
+}
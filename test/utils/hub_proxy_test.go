@@ -0,0 +1,95 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// startEchoServer returns the address of a TCP server which echoes the lines it receives.
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// echo sends a line through the input address and returns the response, or an error if the connection fails.
+func echo(address string) (string, error) {
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		return "", err
+	}
+
+	return bufio.NewReader(conn).ReadString('\n')
+}
+
+func TestHubProxy(t *testing.T) {
+	RegisterTestingT(t)
+
+	proxy, err := StartHubProxy("127.0.0.1:0", startEchoServer(t))
+	Expect(err).ToNot(HaveOccurred())
+
+	address := proxy.listener.Addr().String()
+	Expect(echo(address)).To(Equal("ping\n"))
+
+	// An open connection is closed when the proxy is paused
+	conn, err := net.Dial("tcp", address)
+	Expect(err).ToNot(HaveOccurred())
+
+	defer conn.Close()
+
+	Eventually(func() int {
+		proxy.lock.Lock()
+		defer proxy.lock.Unlock()
+
+		return len(proxy.conns)
+	}).Should(Equal(2))
+
+	proxy.Pause()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	Expect(err).To(MatchError(io.EOF))
+
+	// New connections fail while the proxy is paused
+	_, err = echo(address)
+	Expect(err).To(HaveOccurred())
+
+	proxy.Resume()
+	Expect(echo(address)).To(Equal("ping\n"))
+
+	Expect(proxy.Stop()).To(Succeed())
+}
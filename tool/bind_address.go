@@ -0,0 +1,58 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package tool
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ValidateBindAddress checks that the input listener address, such as from the command line, is a host and port which
+// can be bound. The host may be empty to bind all the addresses of both IP families, and an IPv6 literal must be
+// enclosed in brackets, such as [::]:8080 or [::1]:8080. The input disabled value, such as 0 for the metrics endpoint,
+// is also accepted.
+func ValidateBindAddress(address string, disabled string) error {
+	if address == disabled {
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		if strings.Count(address, ":") > 1 && !strings.HasPrefix(address, "[") {
+			return fmt.Errorf(
+				"invalid bind address %q, an IPv6 address must be enclosed in brackets (e.g. [::1]:8080)", address,
+			)
+		}
+
+		return fmt.Errorf("invalid bind address %q: %w", address, err)
+	}
+
+	if _, err := net.LookupPort("tcp", port); err != nil {
+		return fmt.Errorf("invalid bind address %q: %w", address, err)
+	}
+
+	// A host which isn't an IP address is resolved when binding, such as localhost
+	if strings.Contains(host, ":") && net.ParseIP(host) == nil {
+		return fmt.Errorf("invalid bind address %q, %q is not a valid IPv6 address", address, host)
+	}
+
+	return nil
+}
+
+// IsLoopbackBindAddress returns true if the input valid listener address only binds a loopback address, such as
+// localhost:8080 or [::1]:8080, so that the listener can't be reached from outside the pod.
+func IsLoopbackBindAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
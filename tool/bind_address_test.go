@@ -0,0 +1,37 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package tool
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateBindAddress(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, address := range []string{":8080", "0.0.0.0:8080", "[::]:8080", "[::1]:8080", "localhost:8080", "0"} {
+		Expect(ValidateBindAddress(address, "0")).To(Succeed(), address)
+	}
+
+	Expect(ValidateBindAddress("", "")).To(Succeed())
+
+	Expect(ValidateBindAddress("::1:8080", "")).To(MatchError(ContainSubstring("must be enclosed in brackets")))
+	Expect(ValidateBindAddress("[fd00::zz]:8080", "")).To(MatchError(ContainSubstring("not a valid IPv6 address")))
+	Expect(ValidateBindAddress("localhost", "")).ToNot(Succeed())
+	Expect(ValidateBindAddress(":http-metrics", "")).ToNot(Succeed())
+	Expect(ValidateBindAddress("0", "")).ToNot(Succeed())
+}
+
+func TestIsLoopbackBindAddress(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, address := range []string{"localhost:8080", "127.0.0.1:8080", "[::1]:8080"} {
+		Expect(IsLoopbackBindAddress(address)).To(BeTrue(), address)
+	}
+
+	for _, address := range []string{":8080", "0.0.0.0:8080", "[::]:8080", "10.0.0.1:8080", "localhost"} {
+		Expect(IsLoopbackBindAddress(address)).To(BeFalse(), address)
+	}
+}
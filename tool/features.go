@@ -0,0 +1,66 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package tool
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// CreateOnlyTemplates honors the create-only annotation of the policy templates, whose objects are then created
+	// if they are missing but never updated.
+	CreateOnlyTemplates featuregate.Feature = "CreateOnlyTemplates"
+	// PolicyEngineAdapters delegates the readiness, translation, and cleanup of the template objects of the policy
+	// engines, such as Gatekeeper, to their adapters.
+	PolicyEngineAdapters featuregate.Feature = "PolicyEngineAdapters"
+	// StatusWriters writes the policy statuses from a single goroutine per cluster rather than from the reconciles.
+	StatusWriters featuregate.Feature = "StatusWriters"
+)
+
+// defaultFeatureGates are the features of the addon which can be toggled with --feature-gates. The Alpha features are
+// disabled by default and the Beta features are enabled by default.
+var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	CreateOnlyTemplates:  {Default: false, PreRelease: featuregate.Alpha},
+	PolicyEngineAdapters: {Default: true, PreRelease: featuregate.Beta},
+	StatusWriters:        {Default: true, PreRelease: featuregate.Beta},
+}
+
+// FeatureGates are the feature gates of the addon, set with --feature-gates.
+var FeatureGates = featuregate.NewFeatureGate()
+
+var featureGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "policy_framework_feature_enabled",
+		Help: "Whether a feature gate of the addon is enabled (1) or disabled (0), labeled with its stage.",
+	},
+	[]string{"feature", "stage"},
+)
+
+func init() {
+	utilruntime.Must(FeatureGates.Add(defaultFeatureGates))
+
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(featureGauge)
+}
+
+// EnabledFeatures returns whether each feature gate is enabled and records it in the policy_framework_feature_enabled
+// metric. It must be called after the flags are parsed.
+func EnabledFeatures() map[string]bool {
+	enabled := make(map[string]bool, len(defaultFeatureGates))
+
+	for feature, spec := range defaultFeatureGates {
+		enabled[string(feature)] = FeatureGates.Enabled(feature)
+
+		value := 0.0
+		if enabled[string(feature)] {
+			value = 1
+		}
+
+		featureGauge.WithLabelValues(string(feature), string(spec.PreRelease)).Set(value)
+	}
+
+	return enabled
+}
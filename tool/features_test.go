@@ -0,0 +1,29 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package tool
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEnabledFeatures(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(EnabledFeatures()).To(Equal(map[string]bool{
+		"CreateOnlyTemplates":  false,
+		"PolicyEngineAdapters": true,
+		"StatusWriters":        true,
+	}))
+
+	defer func() { Expect(FeatureGates.Set("CreateOnlyTemplates=false,StatusWriters=true")).To(Succeed()) }()
+
+	Expect(FeatureGates.Set("CreateOnlyTemplates=true,StatusWriters=false")).To(Succeed())
+	Expect(EnabledFeatures()).To(HaveKeyWithValue("CreateOnlyTemplates", true))
+	Expect(testutil.ToFloat64(featureGauge.WithLabelValues("CreateOnlyTemplates", "ALPHA"))).To(Equal(1.0))
+	Expect(testutil.ToFloat64(featureGauge.WithLabelValues("StatusWriters", "BETA"))).To(Equal(0.0))
+
+	Expect(FeatureGates.Set("UnknownFeature=true")).ToNot(Succeed())
+}
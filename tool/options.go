@@ -4,6 +4,8 @@
 package tool
 
 import (
+	"time"
+
 	"github.com/spf13/pflag"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -12,13 +14,89 @@ var log = ctrl.Log.WithName("cmd")
 
 // PolicySpecSyncOptions for command line flag parsing
 type SyncerOptions struct {
-	ClusterNamespaceOnHub     string
-	HubConfigFilePathName     string
-	ManagedConfigFilePathName string
-	EnableLease               bool
-	EnableLeaderElection      bool
-	LegacyLeaderElection      bool
-	ProbeAddr                 string
+	ClusterNamespaceOnHub       string
+	HubConfigFilePathName       string
+	ManagedConfigFilePathName   string
+	EnableLease                 bool
+	EnableLeaderElection        bool
+	LegacyLeaderElection        bool
+	ProbeAddr                   string
+	EnableOCITemplates          bool
+	OCISignaturePublicKey       string
+	PolicySignaturePublicKey    string
+	ComplianceMappingConfigMap  string
+	TemplateReadinessGates      bool
+	GenerateTemplateRBAC        bool
+	TemplateRBACReport          bool
+	RecreateOnImmutableChange   bool
+	RecreateOnChangeKinds       []string
+	EnableComplianceSummary     bool
+	ComplianceScoreWeights      map[string]int
+	WatchTemplateObjects        bool
+	CompactReplicatedPolicies   bool
+	CompactionAllowList         []string
+	PolicyDeletionGracePeriod   time.Duration
+	HistoryCarryOverAnnotation  string
+	TemplateApplyTimeout        time.Duration
+	TemplateApplyTimeoutsByKind map[string]string
+	NormalizeMessageKinds       []string
+	MessageJSONKeys             []string
+	ReplayPolicy                string
+	ReplayEvents                string
+	CopyPolicyMetadata          bool
+	TrustedEventSources         []string
+	MetricsAddr                 string
+	RecreateClusterNamespace    bool
+	EnableHubConfigMapTemplates bool
+	APIRequestLogging           []string
+	APIRequestLoggingSampleRate float64
+	ComplianceHysteresisCount   int
+	ComplianceHysteresisWindow  time.Duration
+	TemplatePlacementConfigMap  string
+	SearchExportEndpoint        string
+	ComplianceNotificationURL   string
+	NotificationAllowedHosts    []string
+	EnableTemplateExecHooks     bool
+	TemplateExecHooks           map[string]string
+	HubStatusWriteAudit         bool
+	EnforceSoakTime             time.Duration
+	EnforcePreviewDelay         time.Duration
+	EnforceApplySpread          time.Duration
+	ComplianceAPIAddr           string
+	ComplianceAPITokenFile      string
+	ComplianceAPICertDir        string
+	StatusVerifyInterval        time.Duration
+	StatusVerifySampleSize      int
+	StatusVerifyRepair          bool
+	SignComplianceHistory       bool
+	ComplianceSigningKeyFile    string
+	PolicyLabelSelector         string
+	AdaptiveConcurrencyMax      int
+	AdaptiveConcurrencyInterval time.Duration
+	SpecSyncConcurrency         int
+	StatusSyncConcurrency       int
+	TemplateSyncConcurrency     int
+	ConfigurationPolicyDefaults map[string]string
+	StatusWritesPerSecond       float64
+	StrictTemplateValidation    bool
+	RootPlacementAnnotations    bool
+	StatusSyncInterval          time.Duration
+	HubOutageBuffer             bool
+	EnableComplianceTimeline    bool
+	HubPolicyMetadataCache      bool
+	Controllers                 string
+	DeletePolicyEvents          bool
+	EngineStatus                bool
+	PersistComplianceHistory    bool
+	ComplianceHistoryLimit      int
+	HistoryMessageSizeLimit     int
+	StatusSizeBudget            int
+	InternHistoryMessages       bool
+	HubSyncDegradedThreshold    time.Duration
+	SelfManagedHub              string
+	ClockSkewThreshold          time.Duration
+	DryRun                      bool
+	ReadOnly                    bool
 	// The namespace that the replicated policies should be synced to. This defaults to the same namespace as on the
 	// Hub.
 	ClusterNamespace string
@@ -87,4 +165,637 @@ func ProcessFlags() {
 		":8080",
 		"The address the first probe endpoint binds to.",
 	)
+
+	flag.BoolVar(
+		&Options.EnableOCITemplates,
+		"enable-oci-templates",
+		false,
+		"If enabled, policy templates with the policy.open-cluster-management.io/oci-artifact annotation will have "+
+			"their object definition fetched from the referenced OCI artifact.",
+	)
+
+	flag.StringVar(
+		&Options.OCISignaturePublicKey,
+		"oci-signature-public-key",
+		"",
+		"Path to a PEM encoded ECDSA public key used to verify the cosign signature of policy templates delivered as "+
+			"OCI artifacts. If not set, signatures are not verified.",
+	)
+
+	flag.StringVar(
+		&Options.PolicySignaturePublicKey,
+		"policy-signature-public-key",
+		"",
+		"Path to a PEM encoded ECDSA public key used to verify the cosign signature in the "+
+			"policy.open-cluster-management.io/signature annotation of a policy before its enforce mode policy "+
+			"templates are applied. If not set, policies are not verified.",
+	)
+
+	flag.StringVar(
+		&Options.ComplianceMappingConfigMap,
+		"compliance-mapping-configmap",
+		"",
+		"The name of a ConfigMap in the cluster namespace on the managed cluster which maps compliance event messages "+
+			"to compliance states using regular expressions. This is consulted before the built-in check.",
+	)
+
+	flag.BoolVar(
+		&Options.TemplateReadinessGates,
+		"template-readiness-gates",
+		false,
+		"If enabled, a policy template is reported as Pending instead of Compliant until its object on the managed "+
+			"cluster is ready (e.g. a Gatekeeper constraint is enforced or a CRD is established).",
+	)
+
+	flag.BoolVar(
+		&Options.GenerateTemplateRBAC,
+		"generate-template-rbac",
+		false,
+		"Print the minimal ClusterRole needed to manage the policy templates currently in the cluster namespace on "+
+			"the managed cluster and exit.",
+	)
+
+	flag.BoolVar(
+		&Options.TemplateRBACReport,
+		"template-rbac-report",
+		false,
+		"If enabled, the minimal ClusterRole needed to manage the policy templates in use is logged whenever it "+
+			"changes.",
+	)
+
+	flag.BoolVar(
+		&Options.RecreateOnImmutableChange,
+		"recreate-on-immutable-change",
+		false,
+		"If enabled, a policy template object whose update is rejected because an immutable field changed is deleted "+
+			"and recreated. This can also be enabled per policy template with the "+
+			"policy.open-cluster-management.io/recreate-on-immutable-change annotation.",
+	)
+
+	flag.StringSliceVar(
+		&Options.RecreateOnChangeKinds,
+		"recreate-on-change-kinds",
+		nil,
+		"The policy template kinds whose objects are deleted and recreated instead of updated when their spec "+
+			"changes, for policy engines which only evaluate objects when they are created. This can also be enabled "+
+			"per policy template with the policy.open-cluster-management.io/recreate-on-change annotation.",
+	)
+
+	flag.BoolVar(
+		&Options.EnableComplianceSummary,
+		"enable-compliance-summary",
+		false,
+		"If enabled, a ComplianceSummary summarizing the compliance of the policies in the cluster namespace is "+
+			"maintained on the managed cluster. This requires the ComplianceSummary CRD to be installed.",
+	)
+
+	flag.StringSliceVar(
+		&Options.TrustedEventSources,
+		"trusted-event-sources",
+		nil,
+		"A comma separated list of event source components whose compliance events are used for the policy status. "+
+			"Compliance events from other sources are ignored. The event source is set by the creator of the event, "+
+			"so this is a filter rather than a protection against spoofed events. If not set, all event sources are "+
+			"trusted.",
+	)
+
+	flag.StringVar(
+		&Options.MetricsAddr,
+		"metrics-bind-address",
+		"0",
+		"The address the metrics endpoint binds to. Set to 0 to disable the metrics endpoint.",
+	)
+
+	flag.BoolVar(
+		&Options.RecreateClusterNamespace,
+		"recreate-cluster-namespace",
+		false,
+		"If enabled, the cluster namespace on the managed cluster is recreated after it is deleted. Otherwise, the "+
+			"policy synchronization is paused until it is recreated.",
+	)
+
+	flag.BoolVar(
+		&Options.EnableHubConfigMapTemplates,
+		"enable-hub-configmap-templates",
+		false,
+		"If enabled, policy templates with the policy.open-cluster-management.io/object-definition-from annotation "+
+			"will have their object definition read from the referenced ConfigMap key in the cluster namespace on "+
+			"the Hub.",
+	)
+
+	flag.StringSliceVar(
+		&Options.APIRequestLogging,
+		"api-request-logging",
+		nil,
+		"A comma separated list of the clients (hub, managed) for which to log the method, path, latency, and "+
+			"status code of API requests. This is meant for debugging.",
+	)
+
+	flag.Float64Var(
+		&Options.APIRequestLoggingSampleRate,
+		"api-request-logging-sample-rate",
+		1,
+		"The fraction of successful API requests to log between 0 and 1 when --api-request-logging is set. Failed "+
+			"requests are always logged.",
+	)
+
+	flag.IntVar(
+		&Options.ComplianceHysteresisCount,
+		"compliance-hysteresis-count",
+		1,
+		"The number of consecutive evaluations with a new compliance state required before the reported compliance "+
+			"state of a policy template changes. The default of 1 reports changes right away.",
+	)
+
+	flag.DurationVar(
+		&Options.ComplianceHysteresisWindow,
+		"compliance-hysteresis-window",
+		10*time.Minute,
+		"The time window in which the consecutive evaluations set by --compliance-hysteresis-count must occur.",
+	)
+
+	flag.StringToIntVar(
+		&Options.ComplianceScoreWeights,
+		"compliance-score-category-weights",
+		nil,
+		"A comma separated list of policy category and weight pairs (e.g. \"CM Configuration Management=3\") used "+
+			"for the compliance score of the ComplianceSummary. Categories without a weight have a weight of 1.",
+	)
+
+	flag.BoolVar(
+		&Options.WatchTemplateObjects,
+		"watch-template-objects",
+		false,
+		"If enabled, the policy template objects are watched so that changes to them are reverted and template "+
+			"objects pending readiness are checked as soon as they change. A watch is only kept while a policy uses "+
+			"the kind.",
+	)
+
+	flag.BoolVar(
+		&Options.CompactReplicatedPolicies,
+		"compact-replicated-policies",
+		false,
+		"If enabled, the annotations of the replicated policies on the Hub that aren't in the "+
+			"--compaction-annotation-allow-list are not synced to the managed cluster.",
+	)
+
+	flag.StringSliceVar(
+		&Options.CompactionAllowList,
+		"compaction-annotation-allow-list",
+		[]string{"policy.open-cluster-management.io/*"},
+		"A comma separated list of the annotation keys synced to the managed cluster when "+
+			"--compact-replicated-policies is enabled. An entry ending with * matches all the keys with that prefix.",
+	)
+
+	flag.DurationVar(
+		&Options.PolicyDeletionGracePeriod,
+		"policy-deletion-grace-period",
+		0,
+		"How long a replicated policy must be missing on the Hub before its policy on the managed cluster is deleted. "+
+			"This protects against transient inconsistencies on the Hub. Set to 0 to delete the policy right away.",
+	)
+
+	flag.StringVar(
+		&Options.HistoryCarryOverAnnotation,
+		"compliance-history-carry-over-annotation",
+		"",
+		"The policy annotation with a stable identifier of the policy, such as the root policy UID. When set, the "+
+			"compliance history of a deleted policy continues on the next policy with the same identifier, such as "+
+			"when a policy is renamed on the Hub.",
+	)
+
+	flag.DurationVar(
+		&Options.TemplateApplyTimeout,
+		"template-apply-timeout",
+		time.Minute,
+		"The maximum time to wait for a create, update, or delete request of a policy template object, such as when "+
+			"an admission webhook on the managed cluster hangs. Set to 0 to not bound the requests.",
+	)
+
+	flag.StringToStringVar(
+		&Options.TemplateApplyTimeoutsByKind,
+		"template-apply-timeouts-by-kind",
+		map[string]string{},
+		"Overrides of --template-apply-timeout for kinds of policy templates (e.g. ConfigurationPolicy=30s).",
+	)
+
+	flag.StringSliceVar(
+		&Options.NormalizeMessageKinds,
+		"normalize-status-messages",
+		[]string{},
+		"The comma separated policy template kinds whose multi-line compliance messages are collapsed to a single "+
+			"line in the policy status. Use * for all the kinds.",
+	)
+
+	flag.StringArrayVar(
+		&Options.MessageJSONKeys,
+		"status-message-json-keys",
+		[]string{},
+		"The keys to extract from the JSON formatted compliance messages of a policy template kind in the format of "+
+			"<kind>=<key>[,<key>...]. The extracted values replace the JSON in the policy status. Can be repeated.",
+	)
+
+	flag.StringVar(
+		&Options.ReplayPolicy,
+		"replay-policy",
+		"",
+		"Path to the YAML of a policy, such as from a support bundle. When set, the events in --replay-events are "+
+			"replayed through the status sync logic without a cluster, and the resulting policy status is printed.",
+	)
+
+	flag.StringVar(
+		&Options.ReplayEvents,
+		"replay-events",
+		"",
+		"Path to the YAML of the list of events in the cluster namespace to replay with --replay-policy.",
+	)
+
+	flag.BoolVar(
+		&Options.CopyPolicyMetadata,
+		"copy-policy-metadata",
+		false,
+		"If enabled, the standards, categories, and controls annotations of a policy are copied onto the objects of "+
+			"its policy templates. The policy.open-cluster-management.io/copy-policy-metadata annotation on a policy "+
+			"overrides this.",
+	)
+
+	flag.StringVar(
+		&Options.TemplatePlacementConfigMap,
+		"template-placement-configmap",
+		"",
+		"The name of a ConfigMap in the cluster namespace on the managed cluster which places the objects of "+
+			"namespaced policy templates of some kinds in another namespace than the cluster namespace.",
+	)
+
+	flag.StringVar(
+		&Options.SearchExportEndpoint,
+		"search-export-endpoint",
+		"",
+		"An http, https, or file URL to export the compliance of the policies to in the schema consumed by the OCM "+
+			"search indexer. An http or https URL receives each change in a POST request, and a file URL is a "+
+			"directory with a document per policy.",
+	)
+
+	flag.StringVar(
+		&Options.ComplianceNotificationURL,
+		"compliance-notification-url",
+		"",
+		"An http or https URL to send a notification to in a POST request when the compliance of a policy changes. "+
+			"A policy on the Hub can route its notifications to a channel identifier or another URL with the "+
+			"policy.open-cluster-management.io/compliance-notification-target annotation.",
+	)
+
+	flag.StringSliceVar(
+		&Options.NotificationAllowedHosts,
+		"compliance-notification-allowed-hosts",
+		nil,
+		"The hosts of the URLs the policies on the Hub may route their compliance notifications to. The notifications "+
+			"are sent if this or --compliance-notification-url is set.",
+	)
+
+	flag.BoolVar(
+		&Options.EnableTemplateExecHooks,
+		"enable-template-exec-hooks",
+		false,
+		"If enabled, the policy templates of the kinds in --template-exec-hooks are passed to their exec hook before "+
+			"they are applied.",
+	)
+
+	flag.StringToStringVar(
+		&Options.TemplateExecHooks,
+		"template-exec-hooks",
+		map[string]string{},
+		"The absolute paths of the binaries validating or transforming kinds of policy templates before they are "+
+			"applied (e.g. ConfigurationPolicy=/hooks/check). Requires --enable-template-exec-hooks.",
+	)
+
+	flag.BoolVar(
+		&Options.HubStatusWriteAudit,
+		"hub-status-write-audit",
+		false,
+		"If enabled, each status update of a replicated policy on the Hub is stamped in its "+
+			"policy.open-cluster-management.io/status-writer annotation with the addon instance and its number of "+
+			"status writes, so the status writes of the addon can be told apart from those of other actors.",
+	)
+
+	flag.DurationVar(
+		&Options.EnforceSoakTime,
+		"enforce-soak-time",
+		0,
+		"If set, the updates of the enforce mode policy templates are held as Pending until the previous version of "+
+			"the policy template was Compliant for this long (e.g. 1h), so that a broken policy change doesn't roll "+
+			"out to the whole fleet at once. Defaults to 0, which applies the updates right away.",
+	)
+
+	flag.DurationVar(
+		&Options.EnforcePreviewDelay,
+		"enforce-preview-delay",
+		0,
+		"If set, the updates of the enforce mode policy templates from the Hub are delayed by this long (e.g. 30s) "+
+			"after a PolicyTemplatePreview event summarizing the changed fields. Defaults to 0, which applies the "+
+			"updates right away.",
+	)
+
+	flag.DurationVar(
+		&Options.EnforceApplySpread,
+		"enforce-apply-spread",
+		0,
+		"If set, the updates of the enforce mode policy templates from the Hub are spread over a window of this "+
+			"long (e.g. 10m), each update being delayed by a jitter within the window. Defaults to 0, which applies "+
+			"the updates right away.",
+	)
+
+	flag.StringVar(
+		&Options.ComplianceAPIAddr,
+		"compliance-api-bind-address",
+		"",
+		"The address the read-only compliance API binds to (e.g. localhost:8385). Requires "+
+			"--compliance-api-token-file, and --compliance-api-cert-dir unless the address is a loopback address. "+
+			"Defaults to an empty string, which disables the API.",
+	)
+
+	flag.StringVar(
+		&Options.ComplianceAPITokenFile,
+		"compliance-api-token-file",
+		"",
+		"The path to a file containing the bearer token that the requests to the compliance API must have.",
+	)
+
+	flag.StringVar(
+		&Options.ComplianceAPICertDir,
+		"compliance-api-cert-dir",
+		"",
+		"The directory containing the tls.crt and tls.key files the compliance API is served over TLS with. "+
+			"Defaults to an empty string, which serves the API over plain HTTP, so it may only bind to a loopback "+
+			"address.",
+	)
+
+	flag.DurationVar(
+		&Options.StatusVerifyInterval,
+		"status-verify-interval",
+		0,
+		"If set, a sample of the policies has its status on the Hub compared with its status on the managed "+
+			"cluster on this interval (e.g. 10m), and the divergences are logged and counted. Defaults to 0, which "+
+			"disables the verification.",
+	)
+
+	flag.IntVar(
+		&Options.StatusVerifySampleSize,
+		"status-verify-sample-size",
+		10,
+		"The maximum number of policies whose status is verified on each --status-verify-interval.",
+	)
+
+	flag.BoolVar(
+		&Options.StatusVerifyRepair,
+		"status-verify-repair",
+		false,
+		"If enabled, the policy statuses on the Hub that diverged from the managed cluster are repaired by the "+
+			"status verification.",
+	)
+
+	flag.BoolVar(
+		&Options.SignComplianceHistory,
+		"sign-compliance-history",
+		false,
+		"If enabled, the compliance history entries in the policy status are signed with an HMAC so that auditors "+
+			"on the Hub can verify that they weren't altered.",
+	)
+
+	flag.StringVar(
+		&Options.ComplianceSigningKeyFile,
+		"compliance-signing-key-file",
+		"",
+		"The path to a file containing a dedicated key to sign the compliance history with. Defaults to an empty "+
+			"string, which uses the policy encryption key synced from the Hub.",
+	)
+
+	flag.StringVar(
+		&Options.PolicyLabelSelector,
+		"policy-label-selector",
+		"",
+		"A label selector (e.g. tier=critical) of the replicated policies on the Hub to sync. The other policies are "+
+			"skipped so that several addon instances can share a Hub namespace. Defaults to an empty string, which "+
+			"syncs all the policies.",
+	)
+
+	flag.IntVar(
+		&Options.AdaptiveConcurrencyMax,
+		"adaptive-concurrency-max",
+		0,
+		"The highest number of concurrent reconciles of the spec sync, status sync, and template sync controllers. "+
+			"The concurrency is adapted to the CPU and memory usage of the container relative to its cgroup limits. "+
+			"Defaults to 0, which uses the concurrency of each controller. Takes precedence over the concurrency of "+
+			"each controller.",
+	)
+
+	flag.IntVar(
+		&Options.SpecSyncConcurrency,
+		"spec-sync-concurrency",
+		1,
+		"The number of policies the spec sync controller reconciles concurrently.",
+	)
+
+	flag.IntVar(
+		&Options.StatusSyncConcurrency,
+		"status-sync-concurrency",
+		1,
+		"The number of policies the status sync controller reconciles concurrently.",
+	)
+
+	flag.IntVar(
+		&Options.TemplateSyncConcurrency,
+		"template-sync-concurrency",
+		1,
+		"The number of policies the template sync controller reconciles concurrently.",
+	)
+
+	flag.DurationVar(
+		&Options.AdaptiveConcurrencyInterval,
+		"adaptive-concurrency-interval",
+		10*time.Second,
+		"How often the CPU and memory usage of the container is sampled to adapt the concurrent reconciles when "+
+			"--adaptive-concurrency-max is set.",
+	)
+
+	flag.StringToStringVar(
+		&Options.ConfigurationPolicyDefaults,
+		"configuration-policy-defaults",
+		map[string]string{},
+		"Default values of the spec fields of the ConfigurationPolicy templates which don't set them, by the "+
+			"dot-separated path of the field (e.g. pruneObjectBehavior=DeleteIfCreated,"+
+			"evaluationInterval.compliant=10m).",
+	)
+
+	flag.Float64Var(
+		&Options.StatusWritesPerSecond,
+		"status-writes-per-second",
+		0,
+		"The highest rate of the policy status writes to each of the Hub and the managed cluster. Defaults to 0, "+
+			"which doesn't limit the rate.",
+	)
+
+	flag.DurationVar(
+		&Options.StatusSyncInterval,
+		"status-sync-interval",
+		0,
+		"The shortest time between two status writes of the same policy to the Hub. The statuses of a policy "+
+			"computed within the interval are coalesced into a single write. Defaults to 0, which writes the "+
+			"statuses right away.",
+	)
+
+	flag.BoolVar(
+		&Options.HubOutageBuffer,
+		"hub-outage-buffer",
+		false,
+		"Buffer the policy statuses while the Hub can't be reached instead of failing and requeuing their sync, and "+
+			"write them in order once the Hub can be reached again. Requires the StatusWriters feature gate.",
+	)
+
+	flag.DurationVar(
+		&Options.ClockSkewThreshold,
+		"clock-skew-threshold",
+		0,
+		"The skew between the clocks of the Hub and the managed cluster API servers above which a warning is "+
+			"reported and the compliance event timestamps are shifted to the Hub clock, such as 30s. The clock skew "+
+			"detection is disabled when it is 0, which is the default.",
+	)
+
+	flag.BoolVar(
+		&Options.EnableComplianceTimeline,
+		"enable-compliance-timeline",
+		false,
+		"If enabled, a ConfigMap with an hourly timeline of the compliance states of the policies in the cluster "+
+			"namespace over the last 24 hours is maintained on the managed cluster.",
+	)
+
+	flag.StringVar(
+		&Options.SelfManagedHub,
+		"self-managed-hub",
+		"auto",
+		"Whether the replicated policies on the Hub and the policies on the managed cluster are the same objects, "+
+			"such as when the Hub manages itself, so that their status is only written once. With auto, they are "+
+			"when the ON_MULTICLUSTERHUB environment variable is true or when they have the same UID. Set to true "+
+			"or false to override the detection.",
+	)
+
+	flag.BoolVar(
+		&Options.HubPolicyMetadataCache,
+		"hub-policy-metadata-cache",
+		false,
+		"If enabled, only the metadata of the replicated policies on the Hub is cached, which reduces the memory "+
+			"usage with large cluster namespaces on the Hub. Each spec sync then reads the policy from the Hub API "+
+			"server.",
+	)
+
+	flag.BoolVar(
+		&Options.EngineStatus,
+		"engine-status-passthrough",
+		false,
+		"If enabled, the structured engine status published by the template objects in their status.engineStatus "+
+			"field or policy.open-cluster-management.io/engine-status annotation is copied to the policy status.",
+	)
+
+	flag.BoolVar(
+		&Options.DeletePolicyEvents,
+		"delete-policy-events",
+		false,
+		"If enabled, the events involving a policy on the managed cluster, such as its compliance events, are "+
+			"deleted when the policy is deleted instead of being kept until they expire.",
+	)
+
+	flag.StringVar(
+		&Options.Controllers,
+		"controllers",
+		"all",
+		"The controllers to run: all, hub for the controllers watching the Hub (spec sync and secret sync), or "+
+			"managed for the controllers watching the managed cluster, so that they can run in separate deployments.",
+	)
+
+	flag.DurationVar(
+		&Options.HubSyncDegradedThreshold,
+		"hub-sync-degraded-threshold",
+		0,
+		"How long the status of a policy must fail to be synced to the Hub before a HubSyncDegraded condition is "+
+			"reported on the policy on the managed cluster and on the compliance summary. Set to 0 to disable it.",
+	)
+
+	flag.BoolVar(
+		&Options.InternHistoryMessages,
+		"intern-history-messages",
+		false,
+		"Replace the messages of the compliance history entries repeating the message of a newer entry of the same "+
+			"policy template with a reference to it, so that the status of flapping policies is smaller.",
+	)
+
+	flag.IntVar(
+		&Options.HistoryMessageSizeLimit,
+		"history-message-size-limit",
+		16*1024,
+		"The size in bytes above which the compliance messages are truncated before they are added to the compliance "+
+			"history. Set to 0 to disable it.",
+	)
+
+	flag.IntVar(
+		&Options.StatusSizeBudget,
+		"status-size-budget",
+		512*1024,
+		"The size in bytes of the policy template details in a policy status above which the oldest compliance "+
+			"history entries are removed, so that the status update isn't rejected for exceeding the etcd object "+
+			"size limit. Set to 0 to disable it.",
+	)
+
+	flag.IntVar(
+		&Options.ComplianceHistoryLimit,
+		"compliance-history-limit",
+		10,
+		"The number of compliance history entries kept for each policy template in the policy status. A policy can "+
+			"override it with the policy.open-cluster-management.io/compliance-history-limit annotation.",
+	)
+
+	flag.BoolVar(
+		&Options.PersistComplianceHistory,
+		"persist-compliance-history",
+		false,
+		"If enabled, the compliance history of each policy is persisted in a ConfigMap in the cluster namespace on "+
+			"the managed cluster, so that it outlives the compliance events and is restored on a policy without a "+
+			"status.",
+	)
+
+	flag.BoolVar(
+		&Options.StrictTemplateValidation,
+		"strict-template-validation",
+		false,
+		"Reject the policy template objects with fields unknown to the schema of their kind, such as a misspelled "+
+			"remediationAction, with a template error rather than letting the API server drop the fields.",
+	)
+
+	flag.BoolVar(
+		&Options.RootPlacementAnnotations,
+		"root-policy-placement-annotations",
+		false,
+		"Set annotations naming the placement bindings and placements of the root policy on the Hub which select the "+
+			"cluster on the policies on the managed cluster. The Hub credentials must be allowed to get the root "+
+			"policies.",
+	)
+
+	flag.BoolVar(
+		&Options.DryRun,
+		"dry-run",
+		false,
+		"Preview the changes of the controllers without making them: the requests modifying objects on the Hub and "+
+			"managed clusters are sent as server-side dry runs and logged, and the events are prefixed with "+
+			"\"Dry run: \". Leader election is disabled.",
+	)
+
+	flag.BoolVar(
+		&Options.ReadOnly,
+		"read-only",
+		false,
+		"Observe what the controllers would do without side effects, such as during an incident investigation: the "+
+			"requests modifying objects on the Hub and managed clusters, including events, are logged instead of "+
+			"being sent. Leader election is disabled. Takes precedence over --dry-run.",
+	)
+
+	FeatureGates.AddFlag(flag)
 }
@@ -0,0 +1,43 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package tool
+
+import "fmt"
+
+// ControllerSet determines which controllers the addon runs. On very large clusters, the controllers watching the Hub
+// and those watching the managed cluster can run in separate deployments, so that each can be scaled and restarted
+// independently. Each set of controllers has its own leader election lease, and they only share state through the
+// policies on the managed cluster, such as the template inventory annotation.
+type ControllerSet string
+
+const (
+	// AllControllers runs all the controllers in the same process.
+	AllControllers ControllerSet = "all"
+	// HubControllers only runs the controllers watching the Hub, which are the spec sync and secret sync controllers.
+	HubControllers ControllerSet = "hub"
+	// ManagedControllers only runs the controllers watching the managed cluster, such as the status sync and template
+	// sync controllers. It also reports the status of the addon in its lease.
+	ManagedControllers ControllerSet = "managed"
+)
+
+// ParseControllerSet parses the input set of controllers, such as from the command line.
+func ParseControllerSet(controllers string) (ControllerSet, error) {
+	switch ControllerSet(controllers) {
+	case "", AllControllers:
+		return AllControllers, nil
+	case HubControllers, ManagedControllers:
+		return ControllerSet(controllers), nil
+	default:
+		return "", fmt.Errorf("invalid set of controllers %q, must be all, hub, or managed", controllers)
+	}
+}
+
+// RunsHub returns true if the controllers watching the Hub are run.
+func (c ControllerSet) RunsHub() bool {
+	return c != ManagedControllers
+}
+
+// RunsManaged returns true if the controllers watching the managed cluster are run.
+func (c ControllerSet) RunsManaged() bool {
+	return c != HubControllers
+}
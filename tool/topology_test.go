@@ -0,0 +1,31 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package tool
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseControllerSet(t *testing.T) {
+	RegisterTestingT(t)
+
+	controllers, err := ParseControllerSet("")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(controllers.RunsHub()).To(BeTrue())
+	Expect(controllers.RunsManaged()).To(BeTrue())
+
+	controllers, err = ParseControllerSet("hub")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(controllers.RunsHub()).To(BeTrue())
+	Expect(controllers.RunsManaged()).To(BeFalse())
+
+	controllers, err = ParseControllerSet("managed")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(controllers.RunsHub()).To(BeFalse())
+	Expect(controllers.RunsManaged()).To(BeTrue())
+
+	_, err = ParseControllerSet("status")
+	Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,53 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var buildInfoGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "policy_framework_build_info",
+		Help: "Always 1, labeled with the version, the VCS revision, and the Go version of the addon binary.",
+	},
+	[]string{"version", "revision", "go_version"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	metrics.Registry.MustRegister(buildInfoGauge)
+
+	buildInfoGauge.WithLabelValues(Version, Revision(), runtime.Version()).Set(1)
+}
+
+// Revision returns the VCS revision the binary was built from, with a "-dirty" suffix if the working tree had local
+// changes, or "unknown" if the binary wasn't built with the VCS information.
+func Revision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	revision := "unknown"
+	modified := false
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	if modified && revision != "unknown" {
+		revision += "-dirty"
+	}
+
+	return revision
+}